@@ -1,23 +1,37 @@
 package cmd
 
 import (
+	"context"
+	goerrors "errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/wellmaintained/yak-box/internal/errors"
 	"github.com/wellmaintained/yak-box/internal/runtime"
 	"github.com/wellmaintained/yak-box/internal/sessions"
+	"github.com/wellmaintained/yak-box/internal/tasks"
 	"github.com/wellmaintained/yak-box/internal/ui"
 )
 
 var (
-	checkBlocked bool
-	checkWIP     bool
-	checkPrefix  string
+	checkBlocked     bool
+	checkWIP         bool
+	checkPrefix      string
+	checkPrefixStrip bool
+	checkSort        string
+	checkCount       bool
+	checkStatus      string
+	checkSummaryOnly bool
+	checkWatch       bool
+	checkInterval    string
 )
 
 var checkCmd = &cobra.Command{
@@ -30,7 +44,10 @@ The check command displays:
 2. Running workers with container name, status, and uptime
 3. Live cost information from OpenCode for each running container
 
-Filters can be applied to show only specific task states or prefixes.`,
+Filters can be applied to show only specific task states or prefixes.
+The task list ends with a tally of tasks per state.
+
+With --watch, the output is redrawn every --interval until Ctrl-C.`,
 	Example: `  # Check all workers and tasks
   yak-box check
 
@@ -40,9 +57,27 @@ Filters can be applied to show only specific task states or prefixes.`,
   # Show only work-in-progress tasks
   yak-box check --wip
 
+  # Show only finished tasks
+  yak-box check --status done
+
   # Filter tasks by prefix
   yak-box check --prefix auth/api
 
+  # Show task names relative to the prefix
+  yak-box check --prefix auth/api --prefix-strip
+
+  # Print only counts, for scripts
+  yak-box check --count
+
+  # Print a one-line human summary of task states and running workers
+  yak-box check --summary
+
+  # Refresh the report every 5 seconds until Ctrl-C
+  yak-box check --watch --interval 5s
+
+  # Sort tasks by status
+  yak-box check --sort status
+
   # Combine filters
   yak-box check --wip --prefix backend`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
@@ -52,6 +87,28 @@ Filters can be applied to show only specific task states or prefixes.`,
 		if checkBlocked && checkWIP {
 			errs = append(errs, fmt.Errorf("--blocked and --wip are mutually exclusive (cannot filter for both states simultaneously)"))
 		}
+		if checkStatus != "" {
+			if checkBlocked || checkWIP {
+				errs = append(errs, fmt.Errorf("--status cannot be combined with --blocked or --wip"))
+			}
+			if !slices.Contains(tasks.States, strings.ToLower(checkStatus)) {
+				errs = append(errs, fmt.Errorf("--status must be one of %s (got %q)", strings.Join(tasks.States, ", "), checkStatus))
+			}
+		}
+		if checkCount && checkSummaryOnly {
+			errs = append(errs, fmt.Errorf("--count and --summary are mutually exclusive"))
+		}
+		if checkSort != "" && checkSort != "name" && checkSort != "status" {
+			errs = append(errs, fmt.Errorf("--sort must be 'name' or 'status' (got %q)", checkSort))
+		}
+		if d, err := time.ParseDuration(checkInterval); err != nil {
+			errs = append(errs, fmt.Errorf("--interval has invalid format: %v (use '2s', '1m', etc.)", err))
+		} else if d <= 0 {
+			errs = append(errs, fmt.Errorf("--interval must be positive, got '%s'", checkInterval))
+		}
+		if cmd.Flags().Changed("interval") && !fromConfig(cmd, "interval") && !checkWatch {
+			errs = append(errs, fmt.Errorf("--interval requires --watch"))
+		}
 
 		// Return all errors at once
 		if len(errs) > 0 {
@@ -64,14 +121,55 @@ Filters can be applied to show only specific task states or prefixes.`,
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := runCheck(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(errors.GetExitCode(err))
+		if err := runCheck(cmd.Context()); err != nil {
+			exitWithError(err)
 		}
 	},
 }
 
-func runCheck() error {
+func runCheck(ctx context.Context) error {
+	if !checkWatch {
+		return renderCheck()
+	}
+	interval, err := time.ParseDuration(checkInterval)
+	if err != nil {
+		return errors.NewValidationError("invalid --interval. Use a valid duration like '2s' or '1m'", err)
+	}
+	return watchCheck(ctx, interval, os.Stdout, renderCheck)
+}
+
+// watchCheck clears the screen and calls render every interval until ctx is
+// cancelled, which for the CLI is the first Ctrl-C. A render error stops the
+// loop.
+func watchCheck(ctx context.Context, interval time.Duration, out io.Writer, render func() error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ui.ClearScreen(out)
+		fmt.Fprintf(out, "Every %s: yak-box check (%s, Ctrl-C to stop)\n\n", interval, time.Now().Format("15:04:05"))
+		if err := render(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// renderCheck prints one check report: the counts for --count or --summary,
+// otherwise the full sessions, homes, tasks and workers listing.
+func renderCheck() error {
+	if checkCount {
+		return runCheckCount()
+	}
+	if checkSummaryOnly {
+		return runCheckSummary()
+	}
+
 	fmt.Println("=== Active Sessions ===")
 	activeSessions, err := sessions.List()
 	if err != nil {
@@ -79,12 +177,13 @@ func runCheck() error {
 	} else if len(activeSessions) == 0 {
 		fmt.Println("No active sessions.")
 	} else {
-		headers := []string{"Session", "Worker", "Runtime", "Task"}
+		headers := []string{"Session", "Worker", "Runtime", "Task", "Expires"}
 		var rows [][]string
+		now := time.Now()
 		for id, session := range activeSessions {
-			rows = append(rows, []string{id, session.Worker, session.Runtime, session.Task})
+			rows = append(rows, []string{id, session.Worker, session.Runtime, session.Task, expiryLabel(session, now)})
 		}
-		ui.PrintTable(os.Stdout, headers, rows)
+		ui.PrintTableOpts(os.Stdout, headers, rows, sessionTableOptions)
 	}
 
 	fmt.Println("\n=== Worker Homes ===")
@@ -95,19 +194,13 @@ func runCheck() error {
 		fmt.Println("No persistent worker homes.")
 	} else {
 		for _, home := range homes {
-			homePath, _ := sessions.GetHomeDir(home)
-			var size int64
-			filepath.Walk(homePath, func(_ string, info os.FileInfo, err error) error {
-				if err == nil && info != nil && !info.IsDir() {
-					size += info.Size()
-				}
-				return nil
-			})
+			size, _ := sessions.HomeDirSize(home)
 			fmt.Printf("  %s (~%.1f MB)\n", home, float64(size)/1024/1024)
 		}
 	}
 
-	yakPath := ".yaks"
+	yakRoot := ".yaks"
+	yakPath := yakRoot
 	if prefix := checkPrefix; prefix != "" {
 		yakPath = filepath.Join(yakPath, prefix)
 	}
@@ -115,107 +208,377 @@ func runCheck() error {
 	if _, err := os.Stat(yakPath); os.IsNotExist(err) {
 		fmt.Printf("No tasks found under %s\n", yakPath)
 	} else {
-		err := filepath.Walk(yakPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if info.Name() == "agent-status" {
-				taskDir := filepath.Dir(path)
-				taskName := strings.TrimPrefix(taskDir, ".yaks/")
-				taskName = strings.TrimPrefix(taskName, ".yaks\\")
-
-				status, err := os.ReadFile(path)
-				if err != nil {
-					return nil
-				}
-
-				statusStr := strings.TrimSpace(string(status))
-				if checkBlocked && !strings.HasPrefix(statusStr, "blocked") {
-					return nil
-				}
-				if checkWIP && !strings.HasPrefix(statusStr, "wip") {
-					return nil
-				}
-
-				// Color-code the status output
-				if strings.HasPrefix(statusStr, "wip") {
-					ui.Info("%-50s %s\n", taskName, statusStr)
-				} else if strings.HasPrefix(statusStr, "blocked") {
-					ui.Warning("%-50s %s\n", taskName, statusStr)
-				} else {
-					fmt.Printf("%-50s %s\n", taskName, statusStr)
-				}
-			}
-			return nil
-		})
+		taskStatuses, err := collectTaskStatuses(yakRoot, yakPath)
 		if err != nil {
 			fmt.Printf("Warning: Error walking task directory: %v\n", err)
 		}
+		sortTaskStatuses(taskStatuses, checkSort)
+		for _, task := range taskStatuses {
+			// Color-code the status output
+			if strings.HasPrefix(task.Status, "wip") {
+				ui.Info("%-50s %s\n", task.Name, task.Status)
+			} else if strings.HasPrefix(task.Status, "blocked") {
+				ui.Warning("%-50s %s\n", task.Name, task.Status)
+			} else {
+				fmt.Printf("%-50s %s\n", task.Name, task.Status)
+			}
+		}
+		if len(taskStatuses) > 0 {
+			fmt.Printf("\nTally: %s\n", tallyTaskStates(taskStatuses))
+		}
 	}
 
 	fmt.Println("\n=== Running Workers (Docker) ===")
-	containers, err := runtime.ListRunningContainers()
+	running, err := runtime.ListWorkerContainers(false)
 	if err != nil {
-		fmt.Println("No running worker containers.")
-	} else if len(containers) == 0 {
+		printContainerListError(err)
+	} else if len(running) == 0 {
 		fmt.Println("No running worker containers.")
 	} else {
-		cmd := exec.Command("docker", "ps", "--filter", "name=yak-worker-", "--format", "{{.Names}}\t{{.Status}}\t{{.RunningFor}}")
-		output, _ := cmd.Output()
-		if strings.TrimSpace(string(output)) != "" {
-			headers := []string{"Container Name", "Status", "Running For"}
-			var rows [][]string
-			for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
-				if strings.TrimSpace(line) != "" {
-					parts := strings.Split(line, "\t")
-					if len(parts) >= 3 {
-						rows = append(rows, []string{parts[0], parts[1], parts[2]})
-					}
-				}
-			}
-			ui.PrintTable(os.Stdout, headers, rows)
+		headers := []string{"Container Name", "Status", "Running For"}
+		var rows [][]string
+		for _, row := range running {
+			rows = append(rows, []string{row.Name, row.Status, row.RunningFor})
 		}
+		ui.PrintTableOpts(os.Stdout, headers, rows, containerTableOptions)
 
 		fmt.Println("\nLive Cost:")
-		for _, container := range containers {
-			cmd := exec.Command("docker", "exec", container, "opencode", "stats")
-			output, _ := cmd.Output()
-			for _, line := range strings.Split(string(output), "\n") {
-				if strings.Contains(line, "Total Cost") {
-					parts := strings.Fields(line)
-					if len(parts) > 0 {
-						fmt.Printf("  %-30s %s\n", container, parts[len(parts)-1])
-					}
-				}
+		for _, name := range costContainers(running, activeSessions) {
+			if cost, ok := containerCost(name); ok {
+				fmt.Printf("  %-30s %s\n", name, cost)
 			}
 		}
 	}
 
 	fmt.Println("\n=== Stopped Workers (Docker) ===")
-	cmd := exec.Command("docker", "ps", "-a", "--filter", "name=yak-worker-", "--filter", "status=exited", "--format", "{{.Names}}\t{{.Status}}")
-	output, _ := cmd.Output()
-	if strings.TrimSpace(string(output)) == "" {
+	stopped, err := runtime.ListWorkerContainers(true, "status=exited")
+	if err != nil {
+		printContainerListError(err)
+	} else if len(stopped) == 0 {
 		fmt.Println("No stopped worker containers.")
 	} else {
 		headers := []string{"Container Name", "Status"}
 		var rows [][]string
-		for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
-			if strings.TrimSpace(line) != "" {
-				parts := strings.Split(line, "\t")
-				if len(parts) >= 2 {
-					rows = append(rows, []string{parts[0], parts[1]})
-				}
-			}
+		for _, row := range stopped {
+			rows = append(rows, []string{row.Name, row.Status})
 		}
-		ui.PrintTable(os.Stdout, headers, rows)
+		ui.PrintTableOpts(os.Stdout, headers, rows, containerTableOptions)
 		fmt.Println("\nRun 'yak-box stop --name <worker>' to clean up stopped containers.")
 	}
 
 	return nil
 }
 
+// checkSummary holds the counts printed by check --count.
+type checkSummary struct {
+	Sessions int
+	Running  int
+	Stopped  int
+	WIP      int
+	Blocked  int
+	Done     int
+}
+
+// String renders the summary as space-separated key=value pairs. Done is
+// left out to keep the --count format stable for existing scripts.
+func (s checkSummary) String() string {
+	return fmt.Sprintf("sessions=%d running=%d stopped=%d wip=%d blocked=%d",
+		s.Sessions, s.Running, s.Stopped, s.WIP, s.Blocked)
+}
+
+// summarizeCheck counts the sessions, containers and task states check reports.
+func summarizeCheck(activeSessions sessions.Sessions, running, stopped []runtime.ContainerRow, taskList []taskStatus) checkSummary {
+	summary := checkSummary{
+		Sessions: len(activeSessions),
+		Running:  len(running),
+		Stopped:  len(stopped),
+	}
+	for _, task := range taskList {
+		switch tasks.State(task.Status) {
+		case "wip":
+			summary.WIP++
+		case "blocked":
+			summary.Blocked++
+		case "done":
+			summary.Done++
+		}
+	}
+	return summary
+}
+
+// Human renders the summary printed by check --summary.
+func (s checkSummary) Human() string {
+	return fmt.Sprintf("%d wip, %d blocked, %d done, %d running workers", s.WIP, s.Blocked, s.Done, s.Running)
+}
+
+// tallyTaskStates counts tasks per state, known states first and in order,
+// e.g. "2 wip, 1 blocked, 0 done, 1 other". Statuses that do not start with
+// a known state are counted as other, which is omitted when zero.
+func tallyTaskStates(taskList []taskStatus) string {
+	counts := make(map[string]int, len(tasks.States))
+	other := 0
+	for _, task := range taskList {
+		state := tasks.State(task.Status)
+		if slices.Contains(tasks.States, state) {
+			counts[state]++
+		} else {
+			other++
+		}
+	}
+
+	parts := make([]string, 0, len(tasks.States)+1)
+	for _, state := range tasks.States {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[state], state))
+	}
+	if other > 0 {
+		parts = append(parts, fmt.Sprintf("%d other", other))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// runCheckCount prints a single summary line. Unlike the detailed report it
+// fails instead of printing warnings, so scripts never mistake an unreadable
+// source for a zero count.
+func runCheckCount() error {
+	summary, _, err := collectCheckSummary()
+	if err != nil {
+		return err
+	}
+	fmt.Println(summary)
+	return nil
+}
+
+// runCheckSummary prints task state and running worker counts in words.
+// Like --count it fails rather than reporting a partial count.
+func runCheckSummary() error {
+	summary, _, err := collectCheckSummary()
+	if err != nil {
+		return err
+	}
+	fmt.Println(summary.Human())
+	return nil
+}
+
+// collectCheckSummary gathers the counts behind check --count and
+// yak-box metrics, also returning the running containers it counted.
+func collectCheckSummary() (checkSummary, []runtime.ContainerRow, error) {
+	activeSessions, err := sessions.List()
+	if err != nil {
+		return checkSummary{}, nil, fmt.Errorf("failed to load sessions: %w", err)
+	}
+	running, err := runtime.ListWorkerContainers(false)
+	if err != nil {
+		return checkSummary{}, nil, errors.NewRuntimeError("failed to list running worker containers", err)
+	}
+	stopped, err := runtime.ListWorkerContainers(true, "status=exited")
+	if err != nil {
+		return checkSummary{}, nil, errors.NewRuntimeError("failed to list stopped worker containers", err)
+	}
+
+	yakRoot := ".yaks"
+	yakPath := filepath.Join(yakRoot, checkPrefix)
+	var tasks []taskStatus
+	if _, err := os.Stat(yakPath); err == nil {
+		tasks, err = collectTaskStatuses(yakRoot, yakPath)
+		if err != nil {
+			return checkSummary{}, nil, fmt.Errorf("failed to walk task directory: %w", err)
+		}
+	}
+
+	return summarizeCheck(activeSessions, running, stopped, tasks), running, nil
+}
+
+// costContainers returns the running containers whose cost can be read with
+// `opencode stats`, skipping those whose session records a different tool.
+func costContainers(running []runtime.ContainerRow, active sessions.Sessions) []string {
+	owners := make(map[string]sessions.Session, len(active))
+	for _, session := range active {
+		if session.Container != "" {
+			owners[session.Container] = session
+		}
+	}
+
+	var names []string
+	for _, row := range running {
+		if session, ok := owners[row.Name]; ok && !session.UsesOpenCode() {
+			continue
+		}
+		names = append(names, row.Name)
+	}
+	return names
+}
+
+// containerCost returns the total cost OpenCode reports inside a running
+// worker container, or false when it cannot be read.
+func containerCost(containerName string) (string, bool) {
+	output, _ := exec.Command("docker", "exec", containerName, "opencode", "stats").Output()
+	return parseTotalCost(string(output))
+}
+
+// parseTotalCost extracts the last field of the "Total Cost" line from
+// `opencode stats` output.
+func parseTotalCost(output string) (string, bool) {
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "Total Cost") {
+			parts := strings.Fields(line)
+			if len(parts) > 0 {
+				return parts[len(parts)-1], true
+			}
+		}
+	}
+	return "", false
+}
+
+// taskStatus is a task line shown by check.
+type taskStatus struct {
+	Name   string
+	Status string
+}
+
+// collectTaskStatuses walks yakPath and returns the tasks that pass the
+// --status/--blocked/--wip filter, in walk order. Tasks walked before an error are
+// still returned alongside it.
+func collectTaskStatuses(yakRoot, yakPath string) ([]taskStatus, error) {
+	var found []taskStatus
+	ignore := loadYakIgnore(yakRoot)
+	err := filepath.Walk(yakPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if isYakIgnored(ignore, yakRoot, path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() != "agent-status" {
+			return nil
+		}
+
+		status, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+
+		statusStr := strings.TrimSpace(string(status))
+		if !matchesStateFilter(statusStr) {
+			return nil
+		}
+
+		found = append(found, taskStatus{
+			Name:   displayTaskName(yakRoot, filepath.Dir(path), checkPrefix, checkPrefixStrip),
+			Status: statusStr,
+		})
+		return nil
+	})
+	return found, err
+}
+
+// matchesStateFilter reports whether a task with status passes the --status,
+// --blocked or --wip filter. --status compares the state word, so "done-ish"
+// is not done; --blocked and --wip keep matching any status that starts with
+// their state, in any case, such as "Blocked-on-review".
+func matchesStateFilter(status string) bool {
+	switch {
+	case checkStatus != "":
+		return tasks.State(status) == strings.ToLower(checkStatus)
+	case checkBlocked:
+		return strings.HasPrefix(strings.ToLower(status), "blocked")
+	case checkWIP:
+		return strings.HasPrefix(strings.ToLower(status), "wip")
+	}
+	return true
+}
+
+// sortTaskStatuses orders tasks by the --sort key. "status" groups tasks by
+// status and orders each group by name; an empty key keeps walk order.
+func sortTaskStatuses(tasks []taskStatus, key string) {
+	switch key {
+	case "name":
+		sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+	case "status":
+		sort.SliceStable(tasks, func(i, j int) bool {
+			if tasks[i].Status != tasks[j].Status {
+				return tasks[i].Status < tasks[j].Status
+			}
+			return tasks[i].Name < tasks[j].Name
+		})
+	}
+}
+
+// expiryWarningWindow is how close to its TTL a session must be for check to flag it.
+const expiryWarningWindow = time.Hour
+
+// expiryLabel describes when a session expires, flagging sessions that are
+// past or close to their TTL. Sessions without a TTL get an empty label.
+func expiryLabel(session sessions.Session, now time.Time) string {
+	if session.ExpiresAt.IsZero() {
+		return ""
+	}
+	if session.IsExpired(now) {
+		return "⚠️  expired (run 'yak-box reap')"
+	}
+	remaining := session.ExpiresAt.Sub(now).Round(time.Minute)
+	if remaining < expiryWarningWindow {
+		return fmt.Sprintf("⚠️  in %s", remaining)
+	}
+	return fmt.Sprintf("in %s", remaining)
+}
+
+// sessionTableOptions keeps long session IDs and task paths from wrapping the
+// Active Sessions table; task paths keep their leaf task name.
+var sessionTableOptions = ui.TableOptions{Columns: []ui.Column{
+	{MaxWidth: 30},
+	{MaxWidth: 20},
+	{},
+	{MaxWidth: 40, Truncate: ui.TruncateMiddle},
+}}
+
+// containerTableOptions caps the container name column of the Docker tables.
+var containerTableOptions = ui.TableOptions{Columns: []ui.Column{{MaxWidth: 40}}}
+
+// printContainerListError reports why worker containers could not be listed,
+// separating an unreachable Docker daemon from other docker failures.
+func printContainerListError(err error) {
+	if goerrors.Is(err, runtime.ErrDaemonUnavailable) {
+		fmt.Printf("Docker daemon not reachable (%v).\n", err)
+		return
+	}
+	fmt.Printf("Warning: failed to list worker containers: %v\n", err)
+}
+
+// displayTaskName returns the name check shows for taskDir: its slash-separated
+// path under yakRoot, made relative to prefix when strip is set. The prefix
+// task itself is shown as ".".
+func displayTaskName(yakRoot, taskDir, prefix string, strip bool) string {
+	name, err := filepath.Rel(yakRoot, taskDir)
+	if err != nil {
+		name = taskDir
+	}
+	name = filepath.ToSlash(name)
+
+	prefix = strings.Trim(filepath.ToSlash(prefix), "/")
+	if !strip || prefix == "" {
+		return name
+	}
+	if name == prefix {
+		return "."
+	}
+	if rest, ok := strings.CutPrefix(name, prefix+"/"); ok {
+		return rest
+	}
+	return name
+}
+
 func init() {
 	checkCmd.Flags().BoolVar(&checkBlocked, "blocked", false, "Show only blocked tasks")
 	checkCmd.Flags().BoolVar(&checkWIP, "wip", false, "Show only work-in-progress tasks")
 	checkCmd.Flags().StringVar(&checkPrefix, "prefix", "", "Filter tasks by prefix (e.g., 'auth/api')")
+	checkCmd.Flags().BoolVar(&checkPrefixStrip, "prefix-strip", false, "Show task names relative to --prefix")
+	checkCmd.Flags().StringVar(&checkSort, "sort", "", "Sort tasks by 'name' or 'status' (default: directory order)")
+	checkCmd.Flags().BoolVar(&checkCount, "count", false, "Print only counts (sessions, running, stopped, wip, blocked) on one line")
+	checkCmd.Flags().StringVar(&checkStatus, "status", "", "Show only tasks in this state (wip, blocked or done)")
+	checkCmd.Flags().BoolVar(&checkSummaryOnly, "summary", false, "Print only task state and running worker counts")
+	checkCmd.Flags().BoolVar(&checkWatch, "watch", false, "Redraw the output every --interval until Ctrl-C")
+	checkCmd.Flags().StringVar(&checkInterval, "interval", "2s", "Refresh interval for --watch (e.g., '2s', '1m')")
 }
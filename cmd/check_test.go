@@ -1,17 +1,33 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	goerrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/runtime"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+	"github.com/wellmaintained/yak-box/internal/ui"
 )
 
 func TestCheckFlags(t *testing.T) {
 	assert.NotNil(t, checkCmd.Flags().Lookup("blocked"))
 	assert.NotNil(t, checkCmd.Flags().Lookup("wip"))
 	assert.NotNil(t, checkCmd.Flags().Lookup("prefix"))
+	assert.NotNil(t, checkCmd.Flags().Lookup("prefix-strip"))
+	assert.NotNil(t, checkCmd.Flags().Lookup("sort"))
+	assert.NotNil(t, checkCmd.Flags().Lookup("count"))
+	assert.NotNil(t, checkCmd.Flags().Lookup("status"))
+	assert.NotNil(t, checkCmd.Flags().Lookup("summary"))
 
 	blocked, _ := checkCmd.Flags().GetBool("blocked")
 	assert.False(t, blocked)
@@ -272,3 +288,331 @@ func TestCheckFilterCombinations(t *testing.T) {
 		})
 	}
 }
+
+func TestDisplayTaskName(t *testing.T) {
+	tests := []struct {
+		name    string
+		taskDir string
+		prefix  string
+		strip   bool
+		want    string
+	}{
+		{name: "no prefix", taskDir: ".yaks/auth/api/login", want: "auth/api/login"},
+		{name: "prefix kept without strip", taskDir: ".yaks/auth/api/login", prefix: "auth/api", want: "auth/api/login"},
+		{name: "prefix stripped", taskDir: ".yaks/auth/api/login", prefix: "auth/api", strip: true, want: "login"},
+		{name: "nested task under prefix", taskDir: ".yaks/auth/api/login/oauth", prefix: "auth/api", strip: true, want: "login/oauth"},
+		{name: "prefix task itself", taskDir: ".yaks/auth/api", prefix: "auth/api", strip: true, want: "."},
+		{name: "trailing slash in prefix", taskDir: ".yaks/auth/api/login", prefix: "auth/api/", strip: true, want: "login"},
+		{name: "sibling sharing a name prefix", taskDir: ".yaks/auth/apiv2", prefix: "auth/api", strip: true, want: "auth/apiv2"},
+		{name: "strip without prefix", taskDir: ".yaks/auth/api", strip: true, want: "auth/api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, displayTaskName(".yaks", tt.taskDir, tt.prefix, tt.strip))
+		})
+	}
+}
+
+func TestCheckSortValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(checkCmd.Flags())
+	checkBlocked, checkWIP = false, false
+	t.Cleanup(func() { checkSort = "" })
+
+	checkSort = "age"
+	err := checkCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--sort must be 'name' or 'status'")
+
+	for _, key := range []string{"", "name", "status"} {
+		checkSort = key
+		assert.NoError(t, checkCmd.PreRunE(cmd, []string{}), key)
+	}
+}
+
+func TestSortTaskStatuses(t *testing.T) {
+	walked := func() []taskStatus {
+		return []taskStatus{
+			{Name: "frontend/ui", Status: "wip: styling"},
+			{Name: "auth/login", Status: "done"},
+			{Name: "backend/db", Status: "blocked: waiting on schema"},
+			{Name: "auth/api", Status: "wip: endpoints"},
+			{Name: "backend/api", Status: "done"},
+		}
+	}
+	names := func(tasks []taskStatus) []string {
+		var out []string
+		for _, task := range tasks {
+			out = append(out, task.Name)
+		}
+		return out
+	}
+
+	t.Run("by name", func(t *testing.T) {
+		tasks := walked()
+		sortTaskStatuses(tasks, "name")
+		assert.Equal(t, []string{"auth/api", "auth/login", "backend/api", "backend/db", "frontend/ui"}, names(tasks))
+	})
+
+	t.Run("by status then name", func(t *testing.T) {
+		tasks := walked()
+		sortTaskStatuses(tasks, "status")
+		assert.Equal(t, []string{"backend/db", "auth/login", "backend/api", "auth/api", "frontend/ui"}, names(tasks))
+	})
+
+	t.Run("no key keeps walk order", func(t *testing.T) {
+		tasks := walked()
+		sortTaskStatuses(tasks, "")
+		assert.Equal(t, names(walked()), names(tasks))
+	})
+}
+
+func TestCollectTaskStatuses(t *testing.T) {
+	yakRoot := filepath.Join(t.TempDir(), ".yaks")
+	for task, status := range map[string]string{
+		"zeta":       "done",
+		"alpha":      "wip: started",
+		"mid/nested": "blocked: needs review",
+	} {
+		dir := filepath.Join(yakRoot, task)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "agent-status"), []byte(status+"\n"), 0644))
+	}
+	checkBlocked, checkWIP, checkPrefix, checkPrefixStrip = false, false, "", false
+
+	tasks, err := collectTaskStatuses(yakRoot, yakRoot)
+	require.NoError(t, err)
+	sortTaskStatuses(tasks, "name")
+	assert.Equal(t, []taskStatus{
+		{Name: "alpha", Status: "wip: started"},
+		{Name: "mid/nested", Status: "blocked: needs review"},
+		{Name: "zeta", Status: "done"},
+	}, tasks)
+
+	checkBlocked = true
+	t.Cleanup(func() { checkBlocked = false })
+	tasks, err = collectTaskStatuses(yakRoot, yakRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []taskStatus{{Name: "mid/nested", Status: "blocked: needs review"}}, tasks)
+}
+
+func TestCollectTaskStatusesBlockedAndWIPMatchPrefix(t *testing.T) {
+	yakRoot := filepath.Join(t.TempDir(), ".yaks")
+	for task, status := range map[string]string{
+		"a": "blocked: waiting on X",
+		"b": "Blocked-on-review",
+		"c": "BLOCKED",
+		"d": "WIP (paused)",
+		"e": "wip: started",
+		"f": "done",
+	} {
+		dir := filepath.Join(yakRoot, task)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "agent-status"), []byte(status+"\n"), 0644))
+	}
+	checkBlocked, checkWIP, checkStatus, checkPrefix, checkPrefixStrip = true, false, "", "", false
+	t.Cleanup(func() { checkBlocked, checkWIP = false, false })
+
+	found, err := collectTaskStatuses(yakRoot, yakRoot)
+	require.NoError(t, err)
+	sortTaskStatuses(found, "name")
+	assert.Equal(t, []taskStatus{
+		{Name: "a", Status: "blocked: waiting on X"},
+		{Name: "b", Status: "Blocked-on-review"},
+		{Name: "c", Status: "BLOCKED"},
+	}, found)
+
+	checkBlocked, checkWIP = false, true
+	found, err = collectTaskStatuses(yakRoot, yakRoot)
+	require.NoError(t, err)
+	sortTaskStatuses(found, "name")
+	assert.Equal(t, []taskStatus{
+		{Name: "d", Status: "WIP (paused)"},
+		{Name: "e", Status: "wip: started"},
+	}, found)
+}
+
+func TestSummarizeCheck(t *testing.T) {
+	activeSessions := sessions.Sessions{
+		"s1": {Worker: "Yakov"},
+		"s2": {Worker: "Yakira"},
+		"s3": {Worker: "Yakriel"},
+	}
+	running := []runtime.ContainerRow{{Name: "yak-worker-a"}, {Name: "yak-worker-b"}}
+	stopped := []runtime.ContainerRow{{Name: "yak-worker-c"}}
+	tasks := []taskStatus{
+		{Name: "a", Status: "wip: one"},
+		{Name: "b", Status: "wip"},
+		{Name: "c", Status: "blocked: two"},
+		{Name: "d", Status: "done"},
+		{Name: "e", Status: "wip: three"},
+		{Name: "f", Status: "wip: four"},
+	}
+
+	summary := summarizeCheck(activeSessions, running, stopped, tasks)
+	assert.Equal(t, checkSummary{Sessions: 3, Running: 2, Stopped: 1, WIP: 4, Blocked: 1, Done: 1}, summary)
+	assert.Equal(t, "sessions=3 running=2 stopped=1 wip=4 blocked=1", summary.String())
+	assert.Equal(t, "4 wip, 1 blocked, 1 done, 2 running workers", summary.Human())
+
+	assert.Equal(t, "sessions=0 running=0 stopped=0 wip=0 blocked=0", summarizeCheck(nil, nil, nil, nil).String())
+}
+
+func TestTallyTaskStates(t *testing.T) {
+	assert.Equal(t, "2 wip, 1 blocked, 0 done", tallyTaskStates([]taskStatus{
+		{Name: "a", Status: "wip: one"},
+		{Name: "b", Status: "Blocked: needs review"},
+		{Name: "c", Status: "wip"},
+	}))
+	assert.Equal(t, "0 wip, 0 blocked, 1 done, 2 other", tallyTaskStates([]taskStatus{
+		{Name: "a", Status: "done"},
+		{Name: "b", Status: "wipe the cache"},
+		{Name: "c", Status: "paused"},
+	}))
+}
+
+func TestCollectTaskStatusesStatusFilter(t *testing.T) {
+	yakRoot := filepath.Join(t.TempDir(), ".yaks")
+	for task, status := range map[string]string{
+		"a": "done",
+		"b": "Done: shipped",
+		"c": "done-ish",
+		"d": "wip: started",
+	} {
+		dir := filepath.Join(yakRoot, task)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "agent-status"), []byte(status+"\n"), 0644))
+	}
+	checkBlocked, checkWIP, checkPrefix, checkPrefixStrip = false, false, "", false
+	checkStatus = "done"
+	t.Cleanup(func() { checkStatus = "" })
+
+	found, err := collectTaskStatuses(yakRoot, yakRoot)
+	require.NoError(t, err)
+	sortTaskStatuses(found, "name")
+	assert.Equal(t, []taskStatus{
+		{Name: "a", Status: "done"},
+		{Name: "b", Status: "Done: shipped"},
+	}, found)
+}
+
+func TestCheckStatusValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	checkBlocked, checkWIP, checkSort, checkCount, checkSummaryOnly = false, false, "", false, false
+	t.Cleanup(func() { checkStatus, checkBlocked, checkCount, checkSummaryOnly = "", false, false, false })
+
+	checkStatus = "blocked"
+	assert.NoError(t, checkCmd.PreRunE(cmd, nil))
+
+	checkStatus = "paused"
+	err := checkCmd.PreRunE(cmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--status must be one of wip, blocked, done")
+
+	checkStatus, checkBlocked = "wip", true
+	err = checkCmd.PreRunE(cmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--status cannot be combined")
+
+	checkStatus, checkBlocked = "", false
+	checkCount, checkSummaryOnly = true, true
+	err = checkCmd.PreRunE(cmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--count and --summary are mutually exclusive")
+}
+
+func TestCostContainers(t *testing.T) {
+	running := []runtime.ContainerRow{
+		{Name: "yak-worker-oc"},
+		{Name: "yak-worker-cursor"},
+		{Name: "yak-worker-legacy"},
+		{Name: "yak-worker-orphan"},
+	}
+	active := sessions.Sessions{
+		"oc":     {Container: "yak-worker-oc", Tool: "opencode"},
+		"cursor": {Container: "yak-worker-cursor", Tool: "cursor"},
+		"legacy": {Container: "yak-worker-legacy"},
+		"native": {Runtime: "native", Tool: "claude"},
+	}
+
+	assert.Equal(t,
+		[]string{"yak-worker-oc", "yak-worker-legacy", "yak-worker-orphan"},
+		costContainers(running, active),
+		"only containers whose session records a non-opencode tool are skipped")
+	assert.Len(t, costContainers(running, nil), 4)
+}
+
+func TestRenderCheckRepeatedly(t *testing.T) {
+	tmpRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpRoot, ".yaks", "auth"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpRoot, ".yaks", "auth", "agent-status"), []byte("wip\n"), 0644))
+	orig, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(tmpRoot))
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+	checkBlocked, checkWIP, checkStatus, checkPrefix, checkCount, checkSummaryOnly = false, false, "", "", false, false
+
+	for i := 0; i < 3; i++ {
+		var renderErr error
+		out := captureStdout(t, func() { renderErr = renderCheck() })
+		require.NoError(t, renderErr, "render %d", i)
+		assert.Contains(t, out, "Tally: 1 wip, 0 blocked, 0 done", "render %d", i)
+	}
+}
+
+func TestWatchCheck(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	renders := 0
+	render := func() error {
+		renders++
+		if renders == 3 {
+			cancel()
+		}
+		return nil
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, watchCheck(ctx, time.Millisecond, &out, render))
+	assert.Equal(t, 3, renders, "watch should stop once the context is cancelled")
+	assert.Equal(t, 3, strings.Count(out.String(), "Every 1ms: yak-box check"))
+
+	boom := goerrors.New("boom")
+	err := watchCheck(context.Background(), time.Millisecond, &out, func() error { return boom })
+	assert.ErrorIs(t, err, boom)
+}
+
+func TestCheckWatchValidation(t *testing.T) {
+	// A fresh flag so that marking it changed does not leak into checkCmd
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVar(&checkInterval, "interval", "2s", "")
+	checkBlocked, checkWIP, checkStatus, checkSort, checkCount, checkSummaryOnly = false, false, "", "", false, false
+	t.Cleanup(func() { checkWatch, checkInterval = false, "2s" })
+
+	require.NoError(t, cmd.Flags().Set("interval", "0s"))
+	checkWatch = true
+	err := checkCmd.PreRunE(cmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--interval must be positive")
+
+	require.NoError(t, cmd.Flags().Set("interval", "5s"))
+	assert.NoError(t, checkCmd.PreRunE(cmd, nil))
+
+	checkWatch = false
+	err = checkCmd.PreRunE(cmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--interval requires --watch")
+
+	// An interval from config.json only applies when --watch is given
+	require.NoError(t, cmd.Flags().SetAnnotation("interval", configDefaultAnnotation, []string{"true"}))
+	assert.NoError(t, checkCmd.PreRunE(cmd, nil))
+}
+
+func TestSessionTableTruncatesLongTaskPaths(t *testing.T) {
+	var out bytes.Buffer
+	task := "platform/auth/api/very-long-subtask-name/login-endpoint"
+	require.NoError(t, ui.PrintTableOpts(&out, []string{"Session", "Worker", "Runtime", "Task", "Expires"},
+		[][]string{{"api-auth", "Yakov", "sandboxed", task, "never"}}, sessionTableOptions))
+
+	assert.NotContains(t, out.String(), task)
+	assert.Contains(t, out.String(), "platform/auth/api/v…-name/login-endpoint")
+}
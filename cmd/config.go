@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wellmaintained/yak-box/internal/config"
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/ui"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show flag defaults from .yak-boxes/config.json",
+	Long: `Show the flag defaults read from .yak-boxes/config.json.
+
+The file maps a command to the flags it should default, for example:
+
+  {
+    "spawn": {"runtime": "native", "tool": "claude", "resources": "heavy"},
+    "worktree remove": {"delete-branch": true}
+  }
+
+A flag given on the command line always wins over the config file, which in
+turn wins over the built-in default. A default that cannot be combined with a
+flag given on the command line, such as spawn --pin-persona with --count, is
+skipped, and a default is not required to come with the flags it needs, such
+as check --interval with --watch. Repeatable flags take a JSON array.`,
+	Example: `  # Show the effective flag defaults
+  yak-box config`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runConfig(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func runConfig() error {
+	path, err := config.DefaultsPath()
+	if err != nil {
+		return fmt.Errorf("failed to locate config file: %w", err)
+	}
+	defaults, err := config.LoadDefaults(path)
+	if err != nil {
+		return err
+	}
+
+	if len(defaults) == 0 {
+		fmt.Printf("No flag defaults configured (%s).\n", path)
+		return nil
+	}
+	fmt.Printf("Config file: %s\n\n", path)
+
+	commands := make([]string, 0, len(defaults))
+	for command := range defaults {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	var rows [][]string
+	for _, command := range commands {
+		target, _, err := rootCmd.Find(strings.Fields(command))
+		if err != nil || target == rootCmd {
+			fmt.Fprintf(os.Stderr, "Warning: %s: unknown command %q\n", config.DefaultsFile, command)
+			continue
+		}
+		for _, name := range sortedFlagNames(defaults[command]) {
+			builtin := "(unknown flag)"
+			if flag := target.Flags().Lookup(name); flag != nil {
+				builtin = flag.DefValue
+			}
+			rows = append(rows, []string{command, "--" + name, strings.Join(defaults[command][name], ","), builtin})
+		}
+	}
+	return ui.PrintTable(os.Stdout, []string{"Command", "Flag", "Value", "Built-in default"}, rows)
+}
+
+// configDefaultAnnotation marks a flag whose value came from config.json.
+const configDefaultAnnotation = "yak-box/config-default"
+
+// configConflicts lists, per command, pairs of flags that cannot be combined.
+// A config default for one of a pair is skipped when the other is given on
+// the command line, so the defaults never clash with an explicit flag.
+var configConflicts = map[string][][2]string{
+	"check": {{"blocked", "wip"}, {"status", "blocked"}, {"status", "wip"}, {"count", "summary"}},
+	"diff":  {{"name", "all"}, {"stat", "name-only"}},
+	"spawn": {
+		{"count", "follow"}, {"count", "home-dir"}, {"count", "scripts-dir"}, {"count", "pin-persona"}, {"count", "from-task"},
+		{"strict-security", "allow-unsafe-security"}, {"strict-security", "no-cap-drop"},
+	},
+	"stop": {{"name", "all"}, {"name", "container"}, {"name", "tab"}, {"all", "container"}, {"all", "tab"}, {"container", "tab"}},
+	"wait": {{"name", "task"}},
+}
+
+// applyConfigDefaults sets each flag of cmd that defaults configures, unless
+// it was already given on the command line or conflicts with a flag that was.
+// Applied flags count as Changed, so commands treat them like explicit flags;
+// fromConfig tells them apart where a flag requires another.
+func applyConfigDefaults(cmd *cobra.Command, defaults config.Defaults) error {
+	command := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	values := defaults[command]
+	for _, name := range sortedFlagNames(values) {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			return errors.NewValidationError(fmt.Sprintf("%s: %s has no flag --%s", config.DefaultsFile, command, name), nil)
+		}
+		if flag.Changed || conflictsWithCommandLine(cmd, command, name) {
+			continue
+		}
+		for _, value := range values[name] {
+			if err := cmd.Flags().Set(name, value); err != nil {
+				return errors.NewValidationError(fmt.Sprintf("%s: invalid value %q for %s --%s", config.DefaultsFile, value, command, name), err)
+			}
+		}
+		if err := cmd.Flags().SetAnnotation(name, configDefaultAnnotation, []string{"true"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conflictsWithCommandLine reports whether the flag name of command cannot be
+// combined with a flag given on the command line.
+func conflictsWithCommandLine(cmd *cobra.Command, command, name string) bool {
+	for _, pair := range configConflicts[command] {
+		other := ""
+		switch name {
+		case pair[0]:
+			other = pair[1]
+		case pair[1]:
+			other = pair[0]
+		default:
+			continue
+		}
+		if cmd.Flags().Changed(other) && !fromConfig(cmd, other) {
+			return true
+		}
+	}
+	return false
+}
+
+// fromConfig reports whether the flag name of cmd got its value from
+// config.json rather than the command line.
+func fromConfig(cmd *cobra.Command, name string) bool {
+	flag := cmd.Flags().Lookup(name)
+	return flag != nil && len(flag.Annotations[configDefaultAnnotation]) > 0
+}
+
+// loadConfigDefaults reads the workspace's flag defaults. Outside a
+// workspace there is no config file, so nothing is applied.
+func loadConfigDefaults() (config.Defaults, error) {
+	path, err := config.DefaultsPath()
+	if err != nil {
+		return config.Defaults{}, nil
+	}
+	return config.LoadDefaults(path)
+}
+
+func sortedFlagNames(values map[string]config.FlagValue) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wellmaintained/yak-box/internal/config"
+	"github.com/wellmaintained/yak-box/internal/errors"
+)
+
+// newConfigProbe returns a "probe" subcommand of a fresh root with a few
+// flags of the kinds spawn uses.
+func newConfigProbe() *cobra.Command {
+	root := &cobra.Command{Use: "yak-box"}
+	probe := &cobra.Command{Use: "probe", Run: func(cmd *cobra.Command, args []string) {}}
+	probe.Flags().String("runtime", "auto", "")
+	probe.Flags().String("tool", "opencode", "")
+	probe.Flags().Int("pids", 512, "")
+	probe.Flags().StringSlice("env-file", nil, "")
+	root.AddCommand(probe)
+	return probe
+}
+
+func TestApplyConfigDefaultsPrecedence(t *testing.T) {
+	defaults := config.Defaults{
+		"probe": {
+			"runtime":  {"native"},
+			"tool":     {"claude"},
+			"env-file": {"a.env", "b.env"},
+		},
+	}
+
+	probe := newConfigProbe()
+	require.NoError(t, probe.Flags().Parse([]string{"--tool", "cursor"}))
+	require.NoError(t, applyConfigDefaults(probe, defaults))
+
+	tool, _ := probe.Flags().GetString("tool")
+	assert.Equal(t, "cursor", tool, "CLI flag wins over config file")
+
+	runtimeName, _ := probe.Flags().GetString("runtime")
+	assert.Equal(t, "native", runtimeName, "config file wins over built-in default")
+	assert.True(t, probe.Flags().Changed("runtime"), "config values count as explicitly set")
+
+	pids, _ := probe.Flags().GetInt("pids")
+	assert.Equal(t, 512, pids, "built-in default applies when neither sets the flag")
+
+	envFiles, _ := probe.Flags().GetStringSlice("env-file")
+	assert.Equal(t, []string{"a.env", "b.env"}, envFiles)
+}
+
+func TestApplyConfigDefaultsNestedCommand(t *testing.T) {
+	root := &cobra.Command{Use: "yak-box"}
+	parent := &cobra.Command{Use: "worktree"}
+	child := &cobra.Command{Use: "remove", Run: func(cmd *cobra.Command, args []string) {}}
+	child.Flags().Bool("delete-branch", false, "")
+	parent.AddCommand(child)
+	root.AddCommand(parent)
+
+	require.NoError(t, applyConfigDefaults(child, config.Defaults{"worktree remove": {"delete-branch": {"true"}}}))
+	deleteBranch, _ := child.Flags().GetBool("delete-branch")
+	assert.True(t, deleteBranch)
+}
+
+func TestApplyConfigDefaultsErrors(t *testing.T) {
+	t.Run("unknown flag", func(t *testing.T) {
+		err := applyConfigDefaults(newConfigProbe(), config.Defaults{"probe": {"no-such-flag": {"x"}}})
+		require.Error(t, err)
+		assert.Equal(t, 2, errors.GetExitCode(err))
+		assert.Contains(t, err.Error(), "probe has no flag --no-such-flag")
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		err := applyConfigDefaults(newConfigProbe(), config.Defaults{"probe": {"pids": {"lots"}}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid value "lots" for probe --pids`)
+	})
+
+	t.Run("other commands are ignored", func(t *testing.T) {
+		assert.NoError(t, applyConfigDefaults(newConfigProbe(), config.Defaults{"spawn": {"no-such-flag": {"x"}}}))
+	})
+}
+
+func TestApplyConfigDefaultsSkipsConflicts(t *testing.T) {
+	root := &cobra.Command{Use: "yak-box"}
+	spawn := &cobra.Command{Use: "spawn", Run: func(cmd *cobra.Command, args []string) {}}
+	spawn.Flags().Int("count", 1, "")
+	spawn.Flags().Bool("pin-persona", false, "")
+	spawn.Flags().Bool("follow", false, "")
+	spawn.Flags().String("tool", "opencode", "")
+	root.AddCommand(spawn)
+
+	require.NoError(t, spawn.Flags().Parse([]string{"--count", "3"}))
+	require.NoError(t, applyConfigDefaults(spawn, config.Defaults{
+		"spawn": {"pin-persona": {"true"}, "follow": {"true"}, "tool": {"claude"}},
+	}))
+
+	pin, _ := spawn.Flags().GetBool("pin-persona")
+	follow, _ := spawn.Flags().GetBool("follow")
+	assert.False(t, pin, "a default that conflicts with --count is skipped")
+	assert.False(t, follow, "a default that conflicts with --count is skipped")
+	assert.False(t, spawn.Flags().Changed("pin-persona"))
+
+	tool, _ := spawn.Flags().GetString("tool")
+	assert.Equal(t, "claude", tool)
+	assert.True(t, fromConfig(spawn, "tool"))
+	assert.False(t, fromConfig(spawn, "count"), "command line flags are not from config")
+}
+
+func TestApplyConfigDefaultsConflictBetweenDefaults(t *testing.T) {
+	root := &cobra.Command{Use: "yak-box"}
+	spawn := &cobra.Command{Use: "spawn", Run: func(cmd *cobra.Command, args []string) {}}
+	spawn.Flags().Int("count", 1, "")
+	spawn.Flags().Bool("follow", false, "")
+	root.AddCommand(spawn)
+
+	// Only a flag given on the command line makes a default yield
+	require.NoError(t, applyConfigDefaults(spawn, config.Defaults{"spawn": {"count": {"3"}, "follow": {"true"}}}))
+	count, _ := spawn.Flags().GetInt("count")
+	follow, _ := spawn.Flags().GetBool("follow")
+	assert.Equal(t, 3, count)
+	assert.True(t, follow)
+}
+
+func TestConfigConflictsNameRealFlags(t *testing.T) {
+	for command, pairs := range configConflicts {
+		target, _, err := rootCmd.Find(strings.Fields(command))
+		require.NoError(t, err)
+		require.NotEqual(t, rootCmd, target, "unknown command %q", command)
+		for _, pair := range pairs {
+			for _, name := range pair {
+				assert.NotNil(t, target.Flags().Lookup(name), "%s has no flag --%s", command, name)
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -11,71 +12,159 @@ import (
 	"github.com/wellmaintained/yak-box/internal/sessions"
 )
 
-var diffName string
+var (
+	diffName     string
+	diffAll      bool
+	diffStat     bool
+	diffNameOnly bool
+	diffBase     string
+)
+
+// diffOptions controls how each repo's diff is rendered.
+type diffOptions struct {
+	// GitFlag is passed to git diff, e.g. "--stat"; empty for a full diff.
+	GitFlag string
+	// Base overrides the detected default branch when set.
+	Base string
+}
 
 var diffCmd = &cobra.Command{
-	Use:   "diff --name <worker>",
+	Use:   "diff (--name <worker> | --all) [flags]",
 	Short: "Show diffs for all repos in a worker's home",
 	Long: `Show git diffs for all repos in a worker's home directory.
 
 A worker's home directory contains flat repo directories (each a git worktree).
-This command loops through them and shows changes against their default branch (main or master).`,
+This command loops through them and shows changes against their default branch (main or master).
+
+With --all, every worker home under .yak-boxes/@home is shown in its own
+section; workers without git repos are skipped.`,
 	Example: `  # Show all diffs for worker Yakira
-  yak-box diff --name Yakira`,
+  yak-box diff --name Yakira
+
+  # Summarise changes across every worker
+  yak-box diff --all --stat
+
+  # List changed files against a release branch
+  yak-box diff --name Yakira --name-only --base release/1.2`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if diffName == "" {
-			return errors.NewValidationError("--name is required (worker name)", nil)
+		if diffName != "" && diffAll {
+			return errors.NewValidationError("--name and --all are mutually exclusive", nil)
+		}
+		if diffName == "" && !diffAll {
+			return errors.NewValidationError("--name is required (worker name) unless --all is set", nil)
+		}
+		if diffStat && diffNameOnly {
+			return errors.NewValidationError("--stat and --name-only are mutually exclusive", nil)
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runDiff(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(errors.GetExitCode(err))
+			exitWithError(err)
 		}
 	},
 }
 
 func runDiff() error {
-	homeDir, err := sessions.GetHomeDir(diffName)
+	opts := diffOptions{Base: diffBase}
+	switch {
+	case diffStat:
+		opts.GitFlag = "--stat"
+	case diffNameOnly:
+		opts.GitFlag = "--name-only"
+	}
+
+	if diffAll {
+		return runDiffAll(os.Stdout, opts)
+	}
+
+	homeDir, repos, err := workerRepos(diffName)
+	if err != nil {
+		return err
+	}
+	if len(repos) == 0 {
+		fmt.Printf("No git repos found in %s\n", homeDir)
+		return nil
+	}
+	writeRepoDiffs(os.Stdout, homeDir, repos, opts)
+	return nil
+}
+
+// runDiffAll writes a section per worker home that contains git repos.
+func runDiffAll(w io.Writer, opts diffOptions) error {
+	homes, err := sessions.ListHomes()
+	if err != nil {
+		return fmt.Errorf("failed to list worker homes: %w", err)
+	}
+
+	shown := 0
+	for _, worker := range homes {
+		homeDir, repos, err := workerRepos(worker)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		if len(repos) == 0 {
+			continue
+		}
+		shown++
+		fmt.Fprintf(w, "\n##### %s #####\n", worker)
+		writeRepoDiffs(w, homeDir, repos, opts)
+	}
+
+	if shown == 0 {
+		fmt.Fprintln(w, "No worker homes with git repos found")
+	}
+	return nil
+}
+
+// workerRepos resolves worker's home directory and returns it with the names
+// of the repos directly inside it, in directory order.
+func workerRepos(worker string) (string, []string, error) {
+	homeDir, err := sessions.GetHomeDir(worker)
 	if err != nil {
-		return fmt.Errorf("could not resolve home for worker %q: %w", diffName, err)
+		return "", nil, fmt.Errorf("could not resolve home for worker %q: %w", worker, err)
 	}
 
 	if _, err := os.Stat(homeDir); os.IsNotExist(err) {
-		return fmt.Errorf("no home directory found for worker %q (expected %s)", diffName, homeDir)
+		return "", nil, fmt.Errorf("no home directory found for worker %q (expected %s)", worker, homeDir)
 	}
 
 	entries, err := os.ReadDir(homeDir)
 	if err != nil {
-		return fmt.Errorf("failed to read home directory %s: %w", homeDir, err)
+		return "", nil, fmt.Errorf("failed to read home directory %s: %w", homeDir, err)
 	}
 
-	found := false
+	var repos []string
 	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
+		if entry.IsDir() && hasOwnGitDir(filepath.Join(homeDir, entry.Name())) {
+			repos = append(repos, entry.Name())
 		}
-		repoPath := filepath.Join(homeDir, entry.Name())
-		if !hasOwnGitDir(repoPath) {
-			continue
+	}
+	return homeDir, repos, nil
+}
+
+// writeRepoDiffs writes each repo's diff against opts.Base, or its default
+// branch when unset, to w.
+func writeRepoDiffs(w io.Writer, homeDir string, repos []string, opts diffOptions) {
+	for _, repo := range repos {
+		repoPath := filepath.Join(homeDir, repo)
+		branch := opts.Base
+		if branch == "" {
+			branch = defaultBranch(repoPath)
+		}
+		fmt.Fprintf(w, "\n=== %s (diff against %s) ===\n", repo, branch)
+		args := []string{"-C", repoPath, "diff"}
+		if opts.GitFlag != "" {
+			args = append(args, opts.GitFlag)
 		}
-		found = true
-		branch := defaultBranch(repoPath)
-		fmt.Printf("\n=== %s (diff against %s) ===\n", entry.Name(), branch)
-		cmd := exec.Command("git", "-C", repoPath, "diff", branch+"...HEAD")
-		cmd.Stdout = os.Stdout
+		cmd := exec.Command("git", append(args, branch+"...HEAD")...)
+		cmd.Stdout = w
 		cmd.Stderr = os.Stderr
 		if err := cmd.Run(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: git diff failed for %s: %v\n", entry.Name(), err)
+			fmt.Fprintf(os.Stderr, "Warning: git diff failed for %s: %v\n", repo, err)
 		}
 	}
-
-	if !found {
-		fmt.Printf("No git repos found in %s\n", homeDir)
-	}
-
-	return nil
 }
 
 // hasOwnGitDir reports whether path directly owns a .git entry (file or directory).
@@ -102,6 +191,9 @@ func defaultBranch(repoPath string) string {
 }
 
 func init() {
-	diffCmd.Flags().StringVar(&diffName, "name", "", "Worker name (required)")
-	diffCmd.MarkFlagRequired("name")
+	diffCmd.Flags().StringVar(&diffName, "name", "", "Worker name (required unless --all)")
+	diffCmd.Flags().BoolVar(&diffAll, "all", false, "Show diffs for every worker home")
+	diffCmd.Flags().BoolVar(&diffStat, "stat", false, "Show only git diff --stat summaries")
+	diffCmd.Flags().BoolVar(&diffNameOnly, "name-only", false, "Show only the names of changed files")
+	diffCmd.Flags().StringVar(&diffBase, "base", "", "Branch or ref to diff against (default: main or master)")
 }
@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"bytes"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -29,6 +31,9 @@ func TestDiffValidation(t *testing.T) {
 	tests := []struct {
 		name     string
 		diffName string
+		diffAll  bool
+		stat     bool
+		nameOnly bool
 		wantErr  bool
 		errMsg   string
 	}{
@@ -43,6 +48,26 @@ func TestDiffValidation(t *testing.T) {
 			diffName: "Yakira",
 			wantErr:  false,
 		},
+		{
+			name:     "stat and name-only",
+			diffName: "Yakira",
+			stat:     true,
+			nameOnly: true,
+			wantErr:  true,
+			errMsg:   "--stat and --name-only are mutually exclusive",
+		},
+		{
+			name:    "all without name",
+			diffAll: true,
+			wantErr: false,
+		},
+		{
+			name:     "name and all",
+			diffName: "Yakira",
+			diffAll:  true,
+			wantErr:  true,
+			errMsg:   "mutually exclusive",
+		},
 	}
 
 	for _, tt := range tests {
@@ -51,6 +76,10 @@ func TestDiffValidation(t *testing.T) {
 			cmd.Flags().AddFlagSet(diffCmd.Flags())
 
 			diffName = tt.diffName
+			diffAll = tt.diffAll
+			diffStat = tt.stat
+			diffNameOnly = tt.nameOnly
+			t.Cleanup(func() { diffAll, diffStat, diffNameOnly = false, false, false })
 
 			err := diffCmd.PreRunE(cmd, []string{})
 
@@ -189,3 +218,91 @@ func TestRunDiffSkipsNonGitDirs(t *testing.T) {
 	// Should succeed (just print "No git repos found")
 	assert.NoError(t, err)
 }
+
+func TestRunDiffAll(t *testing.T) {
+	tmpRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpRoot, ".git"), 0755))
+
+	for _, worker := range []string{"Yakira", "Yakov"} {
+		repo := filepath.Join(tmpRoot, ".yak-boxes", "@home", worker, "my-repo")
+		require.NoError(t, os.MkdirAll(repo, 0755))
+		initGitRepo(t, repo)
+		out, err := exec.Command("git", "-C", repo, "checkout", "-b", "feature").CombinedOutput()
+		require.NoError(t, err, "%s", out)
+		require.NoError(t, os.WriteFile(filepath.Join(repo, worker+".txt"), []byte("hello\n"), 0644))
+		out, err = exec.Command("git", "-C", repo, "add", ".").CombinedOutput()
+		require.NoError(t, err, "%s", out)
+		out, err = exec.Command("git", "-C", repo, "commit", "-m", "add file").CombinedOutput()
+		require.NoError(t, err, "%s", out)
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpRoot, ".yak-boxes", "@home", "Yakueline", "not-a-repo"), 0755))
+
+	orig, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(tmpRoot))
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	t.Run("full diffs per worker", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, runDiffAll(&buf, diffOptions{}))
+		out := buf.String()
+		assert.Contains(t, out, "##### Yakira #####")
+		assert.Contains(t, out, "##### Yakov #####")
+		assert.NotContains(t, out, "Yakueline")
+		assert.Contains(t, out, "+hello")
+		assert.Less(t, strings.Index(out, "Yakira"), strings.Index(out, "Yakov"))
+	})
+
+	t.Run("stat only", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, runDiffAll(&buf, diffOptions{GitFlag: "--stat"}))
+		out := buf.String()
+		assert.Contains(t, out, "Yakira.txt | 1 +")
+		assert.NotContains(t, out, "+hello")
+	})
+}
+
+func TestWriteRepoDiffsModes(t *testing.T) {
+	home := t.TempDir()
+	repo := filepath.Join(home, "my-repo")
+	require.NoError(t, os.MkdirAll(repo, 0755))
+	initGitRepo(t, repo)
+
+	out, err := exec.Command("git", "-C", repo, "checkout", "-b", "release").CombinedOutput()
+	require.NoError(t, err, "%s", out)
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "release.txt"), []byte("v1\n"), 0644))
+	out, err = exec.Command("git", "-C", repo, "add", ".").CombinedOutput()
+	require.NoError(t, err, "%s", out)
+	out, err = exec.Command("git", "-C", repo, "commit", "-m", "release").CombinedOutput()
+	require.NoError(t, err, "%s", out)
+
+	out, err = exec.Command("git", "-C", repo, "checkout", "-b", "feature").CombinedOutput()
+	require.NoError(t, err, "%s", out)
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "changed.txt"), []byte("one\ntwo\n"), 0644))
+	out, err = exec.Command("git", "-C", repo, "add", ".").CombinedOutput()
+	require.NoError(t, err, "%s", out)
+	out, err = exec.Command("git", "-C", repo, "commit", "-m", "change").CombinedOutput()
+	require.NoError(t, err, "%s", out)
+
+	t.Run("stat lists the changed file", func(t *testing.T) {
+		var buf bytes.Buffer
+		writeRepoDiffs(&buf, home, []string{"my-repo"}, diffOptions{GitFlag: "--stat"})
+		assert.Contains(t, buf.String(), "changed.txt | 2 ++")
+		assert.NotContains(t, buf.String(), "+one")
+	})
+
+	t.Run("name-only lists only file names", func(t *testing.T) {
+		var buf bytes.Buffer
+		writeRepoDiffs(&buf, home, []string{"my-repo"}, diffOptions{GitFlag: "--name-only"})
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, []string{"changed.txt", "release.txt"}, lines[1:])
+	})
+
+	t.Run("base overrides the default branch", func(t *testing.T) {
+		var buf bytes.Buffer
+		writeRepoDiffs(&buf, home, []string{"my-repo"}, diffOptions{GitFlag: "--name-only", Base: "release"})
+		assert.Contains(t, buf.String(), "(diff against release)")
+		assert.Contains(t, buf.String(), "changed.txt")
+		assert.NotContains(t, buf.String(), "release.txt")
+	})
+}
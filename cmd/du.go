@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+	"github.com/wellmaintained/yak-box/internal/ui"
+	"github.com/wellmaintained/yak-box/pkg/worktree"
+)
+
+var duFormat string
+
+var duCmd = &cobra.Command{
+	Use:   "du [flags]",
+	Short: "Report disk space used by yak-box state",
+	Long: `Report the disk space used by yak-box state.
+
+The report is broken down into:
+1. Session state (.yak-boxes files outside worker homes)
+2. Each persistent worker home under .yak-boxes/@home
+3. Worker cost exports (.worker-costs)
+4. Worktrees under the worktree root (YAK_BOX_WORKTREE_ROOT or XDG data home)`,
+	Example: `  # Show disk usage
+  yak-box du
+
+  # Machine-readable output
+  yak-box du --format json`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if duFormat != "" && duFormat != "default" && duFormat != "json" {
+			return errors.NewValidationError(fmt.Sprintf("--format must be 'default' or 'json' (got %q)", duFormat), nil)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDu(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+// diskUsage is the per-component breakdown of yak-box disk usage in bytes.
+type diskUsage struct {
+	Sessions    int64            `json:"sessions"`
+	Homes       map[string]int64 `json:"homes"`
+	WorkerCosts int64            `json:"worker_costs"`
+	Worktrees   int64            `json:"worktrees"`
+	Total       int64            `json:"total"`
+}
+
+func runDu() error {
+	worktreeRoot, err := worktree.WorktreeRoot()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not determine worktree root: %v\n", err)
+		worktreeRoot = ""
+	}
+
+	usage, err := computeDiskUsage(worktreeRoot)
+	if err != nil {
+		return err
+	}
+
+	if duFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(usage)
+	}
+
+	names := make([]string, 0, len(usage.Homes))
+	for name := range usage.Homes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := [][]string{{"sessions", formatBytes(usage.Sessions)}}
+	for _, name := range names {
+		rows = append(rows, []string{"home: " + name, formatBytes(usage.Homes[name])})
+	}
+	rows = append(rows,
+		[]string{"worker-costs", formatBytes(usage.WorkerCosts)},
+		[]string{"worktrees", formatBytes(usage.Worktrees)},
+		[]string{"total", formatBytes(usage.Total)},
+	)
+	// Sizes are right-aligned so their units line up
+	return ui.PrintTableOpts(os.Stdout, []string{"Component", "Size"}, rows, ui.TableOptions{
+		Columns: []ui.Column{{}, {Align: ui.AlignRight}},
+	})
+}
+
+// computeDiskUsage measures the .yak-boxes state of the current workspace and
+// the given worktree root (skipped when empty).
+func computeDiskUsage(worktreeRoot string) (*diskUsage, error) {
+	yakBoxes, err := sessions.GetYakBoxesDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate .yak-boxes: %w", err)
+	}
+
+	stateSize, err := sessions.DirSize(yakBoxes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure %s: %w", yakBoxes, err)
+	}
+
+	homes, err := sessions.ListHomes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worker homes: %w", err)
+	}
+
+	usage := &diskUsage{Homes: make(map[string]int64, len(homes))}
+	var homesTotal int64
+	for _, home := range homes {
+		size, err := sessions.HomeDirSize(home)
+		if err != nil {
+			return nil, fmt.Errorf("failed to measure home %s: %w", home, err)
+		}
+		usage.Homes[home] = size
+		homesTotal += size
+	}
+	usage.Sessions = stateSize - homesTotal
+
+	costsDir, err := sessions.GetWorkerCostsDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate worker costs: %w", err)
+	}
+	usage.WorkerCosts, err = sessions.DirSize(costsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure worker costs: %w", err)
+	}
+
+	if worktreeRoot != "" {
+		usage.Worktrees, err = sessions.DirSize(worktreeRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to measure worktrees: %w", err)
+		}
+	}
+
+	usage.Total = usage.Sessions + homesTotal + usage.WorkerCosts + usage.Worktrees
+	return usage, nil
+}
+
+// formatBytes renders a byte count using binary units.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	duCmd.Flags().StringVar(&duFormat, "format", "", "Output format: 'default' or 'json'")
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuFlags(t *testing.T) {
+	assert.NotNil(t, duCmd.Flags().Lookup("format"))
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(duCmd.Flags())
+
+	duFormat = "yaml"
+	err := duCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--format must be")
+
+	duFormat = "json"
+	assert.NoError(t, duCmd.PreRunE(cmd, []string{}))
+	duFormat = ""
+}
+
+func writeSizedFile(t *testing.T, path string, size int) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, make([]byte, size), 0644))
+}
+
+func TestComputeDiskUsage(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", repo).Run())
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(repo))
+
+	writeSizedFile(t, filepath.Join(repo, ".yak-boxes", "sessions.json"), 100)
+	writeSizedFile(t, filepath.Join(repo, ".yak-boxes", "@home", "Yakov", "notes.txt"), 1000)
+	writeSizedFile(t, filepath.Join(repo, ".yak-boxes", "@home", "Yakira", ".cache", "blob"), 2000)
+	writeSizedFile(t, filepath.Join(repo, ".worker-costs", "Yakov.json"), 300)
+
+	worktrees := t.TempDir()
+	writeSizedFile(t, filepath.Join(worktrees, "project", "task", "file.go"), 4000)
+
+	t.Run("breaks down each component", func(t *testing.T) {
+		usage, err := computeDiskUsage(worktrees)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), usage.Sessions)
+		assert.Equal(t, map[string]int64{"Yakov": 1000, "Yakira": 2000}, usage.Homes)
+		assert.Equal(t, int64(300), usage.WorkerCosts)
+		assert.Equal(t, int64(4000), usage.Worktrees)
+		assert.Equal(t, int64(7400), usage.Total)
+	})
+
+	t.Run("skips worktrees when root unknown", func(t *testing.T) {
+		usage, err := computeDiskUsage("")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), usage.Worktrees)
+		assert.Equal(t, int64(3400), usage.Total)
+	})
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.0 KiB", formatBytes(1024))
+	assert.Equal(t, "1.5 MiB", formatBytes(1536*1024))
+	assert.Equal(t, "2.0 GiB", formatBytes(2*1024*1024*1024))
+}
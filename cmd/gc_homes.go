@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+	"github.com/wellmaintained/yak-box/internal/ui"
+)
+
+var (
+	gcHomesOlderThan string
+	gcHomesDryRun    bool
+)
+
+var gcHomesCmd = &cobra.Command{
+	Use:   "gc-homes [flags]",
+	Short: "Remove worker homes that have not been used recently",
+	Long: `Remove persistent worker home directories that have not been used recently.
+
+A home under .yak-boxes/@home/<persona> is removed when:
+1. Nothing inside it has been modified within --older-than
+2. No active session in .yak-boxes/sessions.json uses that persona`,
+	Example: `  # Remove homes untouched for 30 days
+  yak-box gc-homes --older-than 30d
+
+  # Show what would be removed
+  yak-box gc-homes --older-than 7d --dry-run`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := parseAgeDuration(gcHomesOlderThan); err != nil {
+			return errors.NewValidationError(fmt.Sprintf("--older-than has invalid format: %v (use '30d', '12h', etc.)", err), nil)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runGCHomes(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+// homeUsage describes a worker home and when it was last modified.
+type homeUsage struct {
+	Name    string
+	ModTime time.Time
+}
+
+func runGCHomes() error {
+	maxAge, err := parseAgeDuration(gcHomesOlderThan)
+	if err != nil {
+		return errors.NewValidationError("invalid --older-than value", err)
+	}
+
+	names, err := sessions.ListHomes()
+	if err != nil {
+		return fmt.Errorf("failed to list worker homes: %w", err)
+	}
+
+	// Refuse to guess which homes are in use if sessions cannot be read.
+	active, err := sessions.List()
+	if err != nil {
+		return fmt.Errorf("failed to load sessions: %w. Suggestion: Fix or remove .yak-boxes/sessions.json before collecting homes", err)
+	}
+	inUse := make(map[string]bool, len(active))
+	for _, session := range active {
+		inUse[session.Worker] = true
+	}
+
+	var homes []homeUsage
+	for _, name := range names {
+		homePath, err := sessions.GetHomeDir(name)
+		if err != nil {
+			return err
+		}
+		modTime, err := lastModified(homePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to inspect home %s: %v\n", name, err)
+			continue
+		}
+		homes = append(homes, homeUsage{Name: name, ModTime: modTime})
+	}
+
+	stale := selectStaleHomes(homes, inUse, time.Now().Add(-maxAge))
+	if len(stale) == 0 {
+		fmt.Println("No stale worker homes.")
+		return nil
+	}
+
+	for _, name := range stale {
+		if gcHomesDryRun {
+			fmt.Printf("[dry-run] Would remove home: %s\n", name)
+			continue
+		}
+		if err := sessions.CleanHome(name); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove home %s: %v\n", name, err)
+			continue
+		}
+		ui.Success("✅ Removed home: %s\n", name)
+	}
+
+	return nil
+}
+
+// selectStaleHomes returns the names of homes last modified before cutoff that
+// are not used by an active session, sorted by name.
+func selectStaleHomes(homes []homeUsage, inUse map[string]bool, cutoff time.Time) []string {
+	var stale []string
+	for _, home := range homes {
+		if inUse[home.Name] {
+			continue
+		}
+		if home.ModTime.Before(cutoff) {
+			stale = append(stale, home.Name)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// lastModified returns the most recent modification time of path or anything beneath it.
+func lastModified(path string) (time.Time, error) {
+	var latest time.Time
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest, err
+}
+
+// parseAgeDuration parses a duration that may use a "d" (days) suffix in
+// addition to the units supported by time.ParseDuration.
+func parseAgeDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid day count %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration must not be negative")
+	}
+	return d, nil
+}
+
+func init() {
+	gcHomesCmd.Flags().StringVar(&gcHomesOlderThan, "older-than", "30d", "Remove homes not modified within this duration (e.g., '30d', '12h')")
+	gcHomesCmd.Flags().BoolVar(&gcHomesDryRun, "dry-run", false, "Show which homes would be removed without removing them")
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGCHomesFlags(t *testing.T) {
+	assert.NotNil(t, gcHomesCmd.Flags().Lookup("older-than"))
+	assert.NotNil(t, gcHomesCmd.Flags().Lookup("dry-run"))
+
+	olderThan, _ := gcHomesCmd.Flags().GetString("older-than")
+	assert.Equal(t, "30d", olderThan)
+}
+
+func TestGCHomesValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(gcHomesCmd.Flags())
+
+	gcHomesOlderThan = "soon"
+	err := gcHomesCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--older-than has invalid format")
+
+	gcHomesOlderThan = "30d"
+	assert.NoError(t, gcHomesCmd.PreRunE(cmd, []string{}))
+}
+
+func TestParseAgeDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "30d", want: 30 * 24 * time.Hour},
+		{in: "0d", want: 0},
+		{in: "12h", want: 12 * time.Hour},
+		{in: "90m", want: 90 * time.Minute},
+		{in: "xd", wantErr: true},
+		{in: "-1d", wantErr: true},
+		{in: "-5h", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseAgeDuration(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSelectStaleHomes(t *testing.T) {
+	now := time.Now()
+	cutoff := now.Add(-30 * 24 * time.Hour)
+	homes := []homeUsage{
+		{Name: "Yakriel", ModTime: now.Add(-40 * 24 * time.Hour)},
+		{Name: "Yakueline", ModTime: now.Add(-1 * time.Hour)},
+		{Name: "Yakov", ModTime: now.Add(-60 * 24 * time.Hour)},
+		{Name: "Yakira", ModTime: now.Add(-31 * 24 * time.Hour)},
+	}
+
+	t.Run("selects old homes not in use", func(t *testing.T) {
+		got := selectStaleHomes(homes, map[string]bool{"Yakov": true}, cutoff)
+		assert.Equal(t, []string{"Yakira", "Yakriel"}, got)
+	})
+
+	t.Run("keeps recently used homes", func(t *testing.T) {
+		got := selectStaleHomes(homes, nil, cutoff)
+		assert.NotContains(t, got, "Yakueline")
+		assert.Len(t, got, 3)
+	})
+
+	t.Run("nothing stale when all are active", func(t *testing.T) {
+		inUse := map[string]bool{"Yakriel": true, "Yakueline": true, "Yakov": true, "Yakira": true}
+		assert.Empty(t, selectStaleHomes(homes, inUse, cutoff))
+	})
+}
@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	goerrors "errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/wellmaintained/yak-box/internal/errors"
@@ -13,8 +17,12 @@ import (
 )
 
 var (
-	messageFormat  string
-	messageSession string
+	messageFormat   string
+	messageSession  string
+	messageOut      string
+	messageInteract bool
+	messageWait     bool
+	messageTimeout  string
 )
 
 var messageCmd = &cobra.Command{
@@ -27,7 +35,17 @@ The message command:
 2. Discovers its OpenCode session (via docker exec or opencode --dir)
 3. Sends the message via opencode run --session
 
-Works with both sandboxed (Docker) and native workers.`,
+Works with both sandboxed (Docker) and native workers. Workers spawned with
+--tool claude or --tool cursor have no OpenCode session and are rejected.
+
+With --wait, yak-box keeps polling the session after sending (via
+opencode export) until a new assistant message appears, then prints it. This
+is for replies that arrive after opencode run returns. --timeout bounds the
+wait.
+
+With --interactive, the worker name may be omitted (pass only the text), and
+a missing or unknown worker is chosen from a numbered list of active
+sessions.`,
 	Example: `  # Send a message to a worker
   yak-box message api-auth "Add error handling to the login endpoint"
 
@@ -35,16 +53,31 @@ Works with both sandboxed (Docker) and native workers.`,
   yak-box message api-auth "Check test results" --format json
 
   # Send to a specific OpenCode session (skip auto-discovery)
-  yak-box message api-auth "Fix the bug" --session ses_abc123`,
-	Args: cobra.MinimumNArgs(2),
+  yak-box message api-auth "Fix the bug" --session ses_abc123
+
+  # Save the result and exit code as JSON for later inspection
+  yak-box message api-auth "Run the tests" --out result.json
+
+  # Wait up to 10 minutes for the worker's reply
+  yak-box message api-auth "Summarize what you changed" --wait --timeout 10m
+
+  # Choose the worker from a list
+  yak-box message -i "Check test results"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if messageInteract {
+			return cobra.MinimumNArgs(1)(cmd, args)
+		}
+		return cobra.MinimumNArgs(2)(cmd, args)
+	},
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		var errs []error
 
-		if strings.TrimSpace(args[0]) == "" {
+		workerName, text := messageTarget(args)
+		if strings.TrimSpace(workerName) == "" && !messageInteract {
 			errs = append(errs, fmt.Errorf("worker name cannot be empty"))
 		}
 
-		if strings.TrimSpace(strings.Join(args[1:], " ")) == "" {
+		if strings.TrimSpace(text) == "" {
 			errs = append(errs, fmt.Errorf("message text cannot be empty"))
 		}
 
@@ -52,6 +85,12 @@ Works with both sandboxed (Docker) and native workers.`,
 			errs = append(errs, fmt.Errorf("--format must be 'default' or 'json' (got %q)", messageFormat))
 		}
 
+		if d, err := time.ParseDuration(messageTimeout); err != nil {
+			errs = append(errs, fmt.Errorf("--timeout has invalid format: %v (use '30s', '5m', etc.)", err))
+		} else if d <= 0 {
+			errs = append(errs, fmt.Errorf("--timeout must be positive, got '%s'", messageTimeout))
+		}
+
 		if len(errs) > 0 {
 			combined := "Validation errors:\n"
 			for _, err := range errs {
@@ -62,14 +101,30 @@ Works with both sandboxed (Docker) and native workers.`,
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := runMessage(args[0], strings.Join(args[1:], " ")); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(errors.GetExitCode(err))
+		workerName, text := messageTarget(args)
+		if err := runMessage(cmd.Context(), workerName, text); err != nil {
+			exitWithError(err)
 		}
 	},
 }
 
-func runMessage(workerName, text string) error {
+// messageTarget splits args into the worker name and message text. With
+// --interactive a single argument is the text and the worker is picked later.
+func messageTarget(args []string) (workerName, text string) {
+	if messageInteract && len(args) == 1 {
+		return "", args[0]
+	}
+	return args[0], strings.Join(args[1:], " ")
+}
+
+func runMessage(ctx context.Context, workerName, text string) error {
+	if messageInteract {
+		var err error
+		if workerName, err = pickWorker(os.Stderr, os.Stdin, strings.TrimSpace(workerName)); err != nil {
+			return err
+		}
+	}
+
 	session, err := sessions.Get(workerName)
 	if err != nil {
 		workers, listErr := sessions.ListWorkers()
@@ -80,13 +135,20 @@ func runMessage(workerName, text string) error {
 		return errors.NewValidationError(
 			fmt.Sprintf("worker %q not found. Available workers: %s", workerName, strings.Join(workers, ", ")), err)
 	}
+	if !session.UsesOpenCode() {
+		return errors.NewValidationError(
+			fmt.Sprintf("messaging is not supported for %s workers; %q was spawned with --tool %s. Only --tool opencode workers accept messages", session.Tool, workerName, session.Tool), nil)
+	}
 
 	runner := &sessions.ExecRunner{}
 
 	openCodeSessionID := messageSession
 	if openCodeSessionID == "" {
 		ui.Info("🔍 Discovering OpenCode sessions for %s...\n", workerName)
-		ocSessions, err := sessions.DiscoverOpenCodeSessions(runner, session)
+		ocSessions, err := sessions.DiscoverOpenCodeSessions(ctx, runner, session)
+		if goerrors.Is(err, sessions.ErrOpenCodeNotFound) {
+			return openCodeNotFoundError(workerName, err)
+		}
 		if err != nil {
 			return errors.NewRuntimeError(
 				fmt.Sprintf("failed to discover sessions for %q. The worker might still be starting up, or it may have stopped", workerName), err)
@@ -101,43 +163,121 @@ func runMessage(workerName, text string) error {
 		if most == nil {
 			return errors.NewRuntimeError("could not determine most recent session", nil)
 		}
+		if most.Archived {
+			return errors.NewRuntimeError(
+				fmt.Sprintf("worker %q has stopped; its last OpenCode session was %s (from .worker-costs). Suggestion: Respawn the worker to continue that work", workerName, most.ID), nil)
+		}
 		openCodeSessionID = most.ID
 		ui.Info("📡 Using session: %s\n", openCodeSessionID)
 	}
 
+	// Note the last reply before sending so --wait can tell a new one apart
+	var lastReplyID string
+	if messageWait {
+		last, err := sessions.LastAssistantMessage(ctx, runner, session, openCodeSessionID)
+		if err != nil {
+			return errors.NewRuntimeError(fmt.Sprintf("failed to read the session of %q before waiting", workerName), err)
+		}
+		if last != nil {
+			lastReplyID = last.ID
+		}
+	}
+
 	ui.Info("📨 Sending message to %s...\n", workerName)
-	result, err := sessions.SendMessage(runner, session, openCodeSessionID, text, messageFormat)
+	result, err := sessions.SendMessage(ctx, runner, session, openCodeSessionID, text, messageFormat)
+	if goerrors.Is(err, sessions.ErrOpenCodeNotFound) {
+		return openCodeNotFoundError(workerName, err)
+	}
 	if err != nil {
 		return errors.NewRuntimeError(
 			fmt.Sprintf("failed to send message to %q", workerName), err)
 	}
 
-	if messageFormat == "json" {
-		output := struct {
-			Worker    string `json:"worker"`
-			SessionID string `json:"session_id"`
-			ExitCode  int    `json:"exit_code"`
-			Output    string `json:"output"`
-		}{
-			Worker:    workerName,
-			SessionID: openCodeSessionID,
-			ExitCode:  result.ExitCode,
-			Output:    result.Output,
-		}
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		enc.Encode(output)
+	res := messageResult{
+		Worker:    workerName,
+		SessionID: openCodeSessionID,
+		ExitCode:  result.ExitCode,
+		Output:    result.Output,
+	}
+
+	if messageWait {
+		timeout, _ := time.ParseDuration(messageTimeout)
+		ui.Info("⏳ Waiting up to %s for a reply from %s...\n", timeout, workerName)
+		reply, err := sessions.WaitForReply(ctx, runner, session, openCodeSessionID, lastReplyID, timeout)
+		if goerrors.Is(err, sessions.ErrReplyTimeout) {
+			return errors.NewRuntimeError(fmt.Sprintf("message was delivered, but %q did not reply", workerName), err)
+		}
+		if err != nil {
+			return errors.NewRuntimeError(fmt.Sprintf("failed waiting for a reply from %q", workerName), err)
+		}
+		res.Reply = reply.Text
+	}
+	return printMessageResult(os.Stdout, res, messageFormat, messageOut)
+}
+
+// openCodeNotFoundError explains that message only works with OpenCode workers.
+func openCodeNotFoundError(workerName string, err error) error {
+	return errors.NewValidationError(
+		fmt.Sprintf("opencode CLI not found for %q; this worker may use a different tool (message only supports OpenCode workers)", workerName), err)
+}
+
+// messageResult is the JSON form of a delivered message, used by
+// --format json and --out.
+type messageResult struct {
+	Worker    string `json:"worker"`
+	SessionID string `json:"session_id"`
+	ExitCode  int    `json:"exit_code"`
+	Output    string `json:"output"`
+	Reply     string `json:"reply,omitempty"`
+}
+
+// printMessageResult writes res to stdout in the given format and, when out
+// is set, also saves it as JSON to that path. An out of "-" makes the JSON the
+// only thing written to stdout.
+func printMessageResult(stdout io.Writer, res messageResult, format, out string) error {
+	if out == "-" {
+		return writeMessageJSON(stdout, res)
+	}
+
+	if format == "json" {
+		if err := writeMessageJSON(stdout, res); err != nil {
+			return err
+		}
 	} else {
-		if result.Output != "" {
-			fmt.Print(result.Output)
+		if res.Output != "" {
+			fmt.Fprint(stdout, res.Output)
+		}
+		if res.Reply != "" {
+			fmt.Fprintln(stdout, res.Reply)
 		}
-		ui.Success("✅ Message delivered to %s\n", workerName)
+		ui.Success("✅ Message delivered to %s\n", res.Worker)
+	}
+
+	if out == "" {
+		return nil
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("failed to write --out file: %w", err)
+	}
+	if err := writeMessageJSON(f, res); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write --out file: %w", err)
 	}
+	return f.Close()
+}
 
-	return nil
+func writeMessageJSON(w io.Writer, res messageResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
 }
 
 func init() {
 	messageCmd.Flags().StringVar(&messageFormat, "format", "", "Output format: 'default' or 'json'")
 	messageCmd.Flags().StringVar(&messageSession, "session", "", "OpenCode session ID (skip auto-discovery)")
+	messageCmd.Flags().BoolVarP(&messageInteract, "interactive", "i", false, "Choose the worker from a list when it is omitted or not found")
+	messageCmd.Flags().BoolVar(&messageWait, "wait", false, "After sending, poll the session until a new reply arrives and print it")
+	messageCmd.Flags().StringVar(&messageTimeout, "timeout", "5m", "How long --wait polls for a reply")
+	messageCmd.Flags().StringVar(&messageOut, "out", "", "Also write the result (output, exit code, session, worker) as JSON to this file; '-' writes only the JSON to stdout")
 }
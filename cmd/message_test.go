@@ -1,16 +1,25 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/sessions"
 )
 
 func TestMessageFlags(t *testing.T) {
 	assert.NotNil(t, messageCmd.Flags().Lookup("format"))
 	assert.NotNil(t, messageCmd.Flags().Lookup("session"))
+	assert.NotNil(t, messageCmd.Flags().Lookup("out"))
 
 	format, _ := messageCmd.Flags().GetString("format")
 	assert.Equal(t, "", format)
@@ -142,6 +151,28 @@ func TestMessageArgsRequirement(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestMessageInteractiveArgs(t *testing.T) {
+	t.Cleanup(func() { messageInteract, messageFormat = false, "" })
+	messageInteract = true
+	messageFormat = ""
+
+	assert.Error(t, messageCmd.Args(messageCmd, []string{}))
+	assert.NoError(t, messageCmd.Args(messageCmd, []string{"text only"}))
+
+	workerName, text := messageTarget([]string{"text only"})
+	assert.Equal(t, "", workerName)
+	assert.Equal(t, "text only", text)
+
+	workerName, text = messageTarget([]string{"api", "fix", "it"})
+	assert.Equal(t, "api", workerName)
+	assert.Equal(t, "fix it", text)
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(messageCmd.Flags())
+	assert.NoError(t, messageCmd.PreRunE(cmd, []string{"text only"}), "worker name is picked interactively")
+	assert.Error(t, messageCmd.PreRunE(cmd, []string{"  "}))
+}
+
 func TestMessageMultipleValidationErrors(t *testing.T) {
 	cmd := &cobra.Command{}
 	cmd.Flags().AddFlagSet(messageCmd.Flags())
@@ -155,3 +186,88 @@ func TestMessageMultipleValidationErrors(t *testing.T) {
 	assert.Contains(t, err.Error(), "message text cannot be empty")
 	assert.Contains(t, err.Error(), "--format must be 'default' or 'json'")
 }
+
+func TestMessageTimeoutValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	messageFormat = ""
+	t.Cleanup(func() { messageTimeout = "5m" })
+
+	messageTimeout = "soon"
+	err := messageCmd.PreRunE(cmd, []string{"api-auth", "hello"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--timeout has invalid format")
+
+	messageTimeout = "0s"
+	err = messageCmd.PreRunE(cmd, []string{"api-auth", "hello"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--timeout must be positive")
+
+	messageTimeout = "30s"
+	assert.NoError(t, messageCmd.PreRunE(cmd, []string{"api-auth", "hello"}))
+}
+
+func TestPrintMessageResult(t *testing.T) {
+	res := messageResult{Worker: "api-auth", SessionID: "ses_abc123", ExitCode: 3, Output: "tests failed\n"}
+
+	t.Run("writes JSON file and keeps stdout", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "result.json")
+		var stdout bytes.Buffer
+		require.NoError(t, printMessageResult(&stdout, res, "", out))
+		assert.Equal(t, "tests failed\n", stdout.String())
+
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		var saved messageResult
+		require.NoError(t, json.Unmarshal(data, &saved))
+		assert.Equal(t, res, saved)
+	})
+
+	t.Run("dash writes only JSON to stdout", func(t *testing.T) {
+		var stdout bytes.Buffer
+		require.NoError(t, printMessageResult(&stdout, res, "json", "-"))
+
+		var printed messageResult
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &printed))
+		assert.Equal(t, res, printed)
+	})
+
+	t.Run("prints the awaited reply after the output", func(t *testing.T) {
+		var stdout bytes.Buffer
+		withReply := res
+		withReply.Reply = "All green now."
+		require.NoError(t, printMessageResult(&stdout, withReply, "", ""))
+		assert.Equal(t, "tests failed\nAll green now.\n", stdout.String())
+	})
+
+	t.Run("unwritable path", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := printMessageResult(&stdout, res, "", filepath.Join(t.TempDir(), "missing", "result.json"))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to write --out file")
+	})
+}
+
+func TestOpenCodeNotFoundError(t *testing.T) {
+	err := openCodeNotFoundError("api-auth", sessions.ErrOpenCodeNotFound)
+	assert.Equal(t, 2, errors.GetExitCode(err))
+	assert.Contains(t, err.Error(), "opencode CLI not found")
+	assert.Contains(t, err.Error(), "different tool")
+}
+
+func TestRunMessageUnsupportedTool(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", repo).Run())
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(repo))
+
+	for _, tool := range []string{"cursor", "claude"} {
+		require.NoError(t, sessions.Register(tool+"-worker", sessions.Session{Worker: "Yakov", Runtime: "sandboxed", Tool: tool}))
+
+		err := runMessage(context.Background(), tool+"-worker", "hello")
+		require.Error(t, err)
+		assert.Equal(t, 2, errors.GetExitCode(err))
+		assert.Contains(t, err.Error(), "not supported for "+tool+" workers")
+	}
+}
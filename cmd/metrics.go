@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+)
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Print worker and task gauges in Prometheus text format",
+	Long: `Print yak-box gauges in the Prometheus text exposition format.
+
+The output contains:
+1. yakbox_active_workers - sessions in .yak-boxes/sessions.json
+2. yakbox_running_containers - running worker containers
+3. yakbox_tasks{status="wip"|"blocked"} - tasks by agent-status state
+4. yakbox_worker_cost_total - summed OpenCode cost across running containers`,
+	Example: `  # Print metrics
+  yak-box metrics
+
+  # Expose via the node_exporter textfile collector
+  yak-box metrics > /var/lib/node_exporter/textfile/yakbox.prom`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runMetrics(os.Stdout); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func runMetrics(w io.Writer) error {
+	summary, running, err := collectCheckSummary()
+	if err != nil {
+		return err
+	}
+
+	// A failed load only means no container is skipped for its tool
+	active, _ := sessions.List()
+
+	var totalCost float64
+	for _, name := range costContainers(running, active) {
+		if cost, ok := containerCost(name); ok {
+			if value, ok := parseCostValue(cost); ok {
+				totalCost += value
+			}
+		}
+	}
+
+	return writeMetrics(w, summary, totalCost)
+}
+
+// writeMetrics renders summary and totalCost as Prometheus gauges.
+func writeMetrics(w io.Writer, summary checkSummary, totalCost float64) error {
+	var b strings.Builder
+	gauge := func(name, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+
+	gauge("yakbox_active_workers", "Number of active yak-box sessions.")
+	fmt.Fprintf(&b, "yakbox_active_workers %d\n", summary.Sessions)
+
+	gauge("yakbox_running_containers", "Number of running worker containers.")
+	fmt.Fprintf(&b, "yakbox_running_containers %d\n", summary.Running)
+
+	gauge("yakbox_tasks", "Number of tasks by agent-status state.")
+	fmt.Fprintf(&b, "yakbox_tasks{status=%q} %d\n", "wip", summary.WIP)
+	fmt.Fprintf(&b, "yakbox_tasks{status=%q} %d\n", "blocked", summary.Blocked)
+
+	gauge("yakbox_worker_cost_total", "Total OpenCode cost reported by running worker containers.")
+	fmt.Fprintf(&b, "yakbox_worker_cost_total %s\n", strconv.FormatFloat(totalCost, 'f', -1, 64))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// parseCostValue converts an OpenCode cost such as "$1,234.56" to a number.
+func parseCostValue(cost string) (float64, bool) {
+	cost = strings.ReplaceAll(strings.TrimPrefix(cost, "$"), ",", "")
+	value, err := strconv.ParseFloat(cost, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	summary := checkSummary{Sessions: 3, Running: 2, Stopped: 1, WIP: 4, Blocked: 1}
+	require.NoError(t, writeMetrics(&buf, summary, 1.75))
+
+	out := buf.String()
+	assert.Contains(t, out, "yakbox_active_workers 3\n")
+	assert.Contains(t, out, "yakbox_running_containers 2\n")
+	assert.Contains(t, out, `yakbox_tasks{status="wip"} 4`+"\n")
+	assert.Contains(t, out, `yakbox_tasks{status="blocked"} 1`+"\n")
+	assert.Contains(t, out, "yakbox_worker_cost_total 1.75\n")
+	assert.Contains(t, out, "# TYPE yakbox_tasks gauge\n")
+
+	sample := regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*(\{[a-zA-Z_][a-zA-Z0-9_]*="[^"]*"(,[a-zA-Z_][a-zA-Z0-9_]*="[^"]*")*\})? -?[0-9.e+]+$`)
+	comment := regexp.MustCompile(`^# (HELP|TYPE) [a-zA-Z_:][a-zA-Z0-9_:]* .+$`)
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		assert.True(t, sample.MatchString(line) || comment.MatchString(line), "malformed metric line: %q", line)
+	}
+}
+
+func TestParseTotalCost(t *testing.T) {
+	output := "Sessions   4\nTotal Cost        $1.23\nTokens   1000\n"
+	cost, ok := parseTotalCost(output)
+	assert.True(t, ok)
+	assert.Equal(t, "$1.23", cost)
+
+	_, ok = parseTotalCost("no stats here")
+	assert.False(t, ok)
+}
+
+func TestParseCostValue(t *testing.T) {
+	value, ok := parseCostValue("$1,234.50")
+	assert.True(t, ok)
+	assert.Equal(t, 1234.5, value)
+
+	value, ok = parseCostValue("0.42")
+	assert.True(t, ok)
+	assert.Equal(t, 0.42, value)
+
+	_, ok = parseCostValue("│")
+	assert.False(t, ok)
+}
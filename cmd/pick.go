@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	goerrors "errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+	"github.com/wellmaintained/yak-box/internal/ui"
+)
+
+// pickWorker returns name if it is a registered session. Otherwise, which
+// includes an empty name, it lists the active sessions on w and reads the
+// user's choice from r.
+func pickWorker(w io.Writer, r io.Reader, name string) (string, error) {
+	if name != "" {
+		_, err := sessions.Get(name)
+		if err == nil {
+			return name, nil
+		}
+		if !goerrors.Is(err, sessions.ErrSessionNotFound) {
+			return "", fmt.Errorf("failed to load sessions: %w", err)
+		}
+		fmt.Fprintf(w, "Worker %q not found.\n", name)
+	}
+
+	all, err := sessions.List()
+	if err != nil {
+		return "", fmt.Errorf("failed to load sessions: %w", err)
+	}
+	if len(all) == 0 {
+		return "", errors.NewValidationError("no active workers registered", nil)
+	}
+
+	names := make([]string, 0, len(all))
+	for id := range all {
+		names = append(names, id)
+	}
+	sort.Strings(names)
+
+	labels := make([]string, len(names))
+	for i, id := range names {
+		session := all[id]
+		labels[i] = fmt.Sprintf("%s (%s)", id, session.Worker)
+		if session.Task != "" {
+			labels[i] = fmt.Sprintf("%s (%s, %s)", id, session.Worker, session.Task)
+		}
+	}
+
+	fmt.Fprintln(w, "Active workers:")
+	idx, err := ui.SelectFromList(w, r, labels)
+	if err != nil {
+		return "", errors.NewValidationError("no worker selected", err)
+	}
+	return names[idx], nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+)
+
+func TestPickWorker(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", repo).Run())
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(repo))
+
+	t.Run("no sessions", func(t *testing.T) {
+		_, err := pickWorker(&bytes.Buffer{}, strings.NewReader("1\n"), "")
+		require.Error(t, err)
+		assert.Equal(t, 2, errors.GetExitCode(err))
+		assert.Contains(t, err.Error(), "no active workers registered")
+	})
+
+	require.NoError(t, sessions.Register("docs", sessions.Session{Worker: "Yakira", Runtime: "sandboxed"}))
+	require.NoError(t, sessions.Register("api-auth", sessions.Session{Worker: "Yakov", Task: "auth/api", Runtime: "native"}))
+
+	t.Run("registered name skips the prompt", func(t *testing.T) {
+		var out bytes.Buffer
+		got, err := pickWorker(&out, strings.NewReader(""), "docs")
+		require.NoError(t, err)
+		assert.Equal(t, "docs", got)
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("omitted name prompts", func(t *testing.T) {
+		var out bytes.Buffer
+		got, err := pickWorker(&out, strings.NewReader("2\n"), "")
+		require.NoError(t, err)
+		assert.Equal(t, "docs", got)
+		assert.Contains(t, out.String(), "  1) api-auth (Yakov, auth/api)\n")
+		assert.Contains(t, out.String(), "  2) docs (Yakira)\n")
+	})
+
+	t.Run("unknown name prompts", func(t *testing.T) {
+		var out bytes.Buffer
+		got, err := pickWorker(&out, strings.NewReader("1\n"), "api")
+		require.NoError(t, err)
+		assert.Equal(t, "api-auth", got)
+		assert.Contains(t, out.String(), `Worker "api" not found.`)
+	})
+
+	t.Run("no selection", func(t *testing.T) {
+		_, err := pickWorker(&bytes.Buffer{}, strings.NewReader(""), "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no worker selected")
+	})
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/runtime"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+	"github.com/wellmaintained/yak-box/internal/ui"
+)
+
+var (
+	pruneSessions bool
+	pruneDryRun   bool
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune --sessions [flags]",
+	Short: "Remove stale yak-box state",
+	Long: `Remove stale yak-box state.
+
+With --sessions, entries in .yak-boxes/sessions.json are removed when:
+1. A sandboxed worker's container no longer exists (e.g. removed with 'docker rm')
+2. A native worker's PID is no longer running (e.g. after a reboot)
+
+Sessions whose liveness cannot be verified (for example when Docker is
+unreachable) are kept.`,
+	Example: `  # Remove sessions for workers that are gone
+  yak-box prune --sessions
+
+  # Show which sessions would be removed
+  yak-box prune --sessions --dry-run`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if !pruneSessions {
+			return errors.NewValidationError("nothing to prune. Suggestion: Pass --sessions to remove sessions whose workers are gone", nil)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runPrune(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func runPrune() error {
+	alive := runtime.NewLivenessChecker()
+
+	if pruneDryRun {
+		all, err := sessions.List()
+		if err != nil {
+			return fmt.Errorf("failed to load sessions: %w", err)
+		}
+		stale := staleSessions(all, alive)
+		if len(stale) == 0 {
+			fmt.Println("No stale sessions.")
+			return nil
+		}
+		for _, id := range stale {
+			fmt.Printf("[dry-run] Would remove session: %s\n", id)
+		}
+		return nil
+	}
+
+	removed, err := sessions.Prune(alive)
+	if err != nil {
+		return fmt.Errorf("failed to prune sessions: %w", err)
+	}
+	if len(removed) == 0 {
+		fmt.Println("No stale sessions.")
+		return nil
+	}
+	for _, id := range removed {
+		ui.Success("✅ Removed session: %s\n", id)
+	}
+	return nil
+}
+
+// staleSessions returns the sorted IDs of sessions that fail the liveness check.
+func staleSessions(all sessions.Sessions, alive func(sessions.Session) bool) []string {
+	var stale []string
+	for id, session := range all {
+		if !alive(session) {
+			stale = append(stale, id)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+func init() {
+	pruneCmd.Flags().BoolVar(&pruneSessions, "sessions", false, "Remove sessions whose container or process no longer exists")
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Show what would be removed without removing it")
+}
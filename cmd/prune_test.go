@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+)
+
+func TestPruneValidation(t *testing.T) {
+	assert.NotNil(t, pruneCmd.Flags().Lookup("sessions"))
+	assert.NotNil(t, pruneCmd.Flags().Lookup("dry-run"))
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(pruneCmd.Flags())
+	t.Cleanup(func() { pruneSessions = false })
+
+	pruneSessions = false
+	err := pruneCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--sessions")
+
+	pruneSessions = true
+	assert.NoError(t, pruneCmd.PreRunE(cmd, []string{}))
+}
+
+func TestStaleSessions(t *testing.T) {
+	all := sessions.Sessions{
+		"b": {Container: "yak-worker-b"},
+		"a": {Container: "yak-worker-a"},
+		"c": {Container: "yak-worker-c"},
+	}
+	stale := staleSessions(all, func(s sessions.Session) bool { return s.Container == "yak-worker-b" })
+	assert.Equal(t, []string{"a", "c"}, stale)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+)
+
+var (
+	reapTimeout string
+	reapForce   bool
+	reapDryRun  bool
+)
+
+var reapCmd = &cobra.Command{
+	Use:   "reap [flags]",
+	Short: "Stop workers whose session TTL has expired",
+	Long: `Stop every worker whose session has passed its expiry time.
+
+Sessions get an expiry when spawned with --ttl. Sessions without a TTL are
+never reaped. Each expired worker is stopped as with 'yak-box stop'.`,
+	Example: `  # Stop all expired workers
+  yak-box reap
+
+  # Show which workers would be stopped
+  yak-box reap --dry-run`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := time.ParseDuration(reapTimeout); err != nil {
+			return errors.NewValidationError(fmt.Sprintf("--timeout has invalid format: %v (use '30s', '1m', '5m30s', etc.)", err), nil)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runReap(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func runReap() error {
+	timeout, err := time.ParseDuration(reapTimeout)
+	if err != nil {
+		return errors.NewValidationError("invalid timeout format. Use a valid duration like '30s', '1m', or '5m30s'", err)
+	}
+
+	expired, err := sessions.Find(sessions.Expired(time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to load sessions: %w", err)
+	}
+	if len(expired) == 0 {
+		fmt.Println("No expired sessions.")
+		return nil
+	}
+
+	if reapDryRun {
+		for _, name := range expired.IDs() {
+			fmt.Printf("[dry-run] Would stop expired worker: %s (expired %s)\n", name, expired[name].ExpiresAt.Format(time.RFC3339))
+		}
+		return nil
+	}
+
+	return stopWorkers(expired.IDs(), stopOptions{Timeout: timeout, Force: reapForce}, stopAllConcurrency)
+}
+
+func init() {
+	reapCmd.Flags().StringVar(&reapTimeout, "timeout", "30s", "Docker stop timeout (e.g., '30s', '1m')")
+	reapCmd.Flags().BoolVarP(&reapForce, "force", "f", false, "Skip task cleanup and stop immediately")
+	reapCmd.Flags().BoolVar(&reapDryRun, "dry-run", false, "Show which workers would be stopped without stopping them")
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+)
+
+func TestSessionExpiry(t *testing.T) {
+	spawnedAt := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	assert.True(t, sessionExpiry(spawnedAt, 0).IsZero())
+	assert.Equal(t, spawnedAt.Add(8*time.Hour), sessionExpiry(spawnedAt, 8*time.Hour))
+
+	ttl, err := parseAgeDuration("2d")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC), sessionExpiry(spawnedAt, ttl))
+}
+
+func TestExpiryLabel(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "", expiryLabel(sessions.Session{}, now))
+	assert.Contains(t, expiryLabel(sessions.Session{ExpiresAt: now.Add(-time.Minute)}, now), "expired")
+	assert.Equal(t, "⚠️  in 30m0s", expiryLabel(sessions.Session{ExpiresAt: now.Add(30 * time.Minute)}, now))
+	assert.Equal(t, "in 5h0m0s", expiryLabel(sessions.Session{ExpiresAt: now.Add(5 * time.Hour)}, now))
+}
+
+func TestRunReapStopsOnlyExpired(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", repo).Run())
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(repo))
+
+	now := time.Now()
+	require.NoError(t, sessions.Save(sessions.Sessions{
+		"forgotten": {Worker: "Yakov", ExpiresAt: now.Add(-time.Hour)},
+		"fresh":     {Worker: "Yakira", ExpiresAt: now.Add(time.Hour)},
+		"no-ttl":    {Worker: "Yakriel"},
+	}))
+
+	var (
+		mu      sync.Mutex
+		stopped []string
+	)
+	orig := stopWorkerFn
+	t.Cleanup(func() { stopWorkerFn = orig })
+	stopWorkerFn = func(name string, opts stopOptions) error {
+		mu.Lock()
+		stopped = append(stopped, name)
+		mu.Unlock()
+		return nil
+	}
+
+	reapTimeout, reapDryRun = "30s", false
+	require.NoError(t, runReap())
+	assert.Equal(t, []string{"forgotten"}, stopped)
+}
@@ -2,20 +2,100 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/spf13/cobra"
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/runtime"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+	"github.com/wellmaintained/yak-box/internal/ui"
+	"github.com/wellmaintained/yak-box/internal/workspace"
 )
 
-var version string
+var (
+	version string
+	quiet   bool
+	verbose bool
+	rootDir string
+	// jsonOutput selects machine-readable output, including the JSON error
+	// envelope written by exitWithError.
+	jsonOutput bool
+	noRecover  bool
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "yak-box",
 	Short: "Docker-based worker orchestration CLI",
 	Long:  "yak-box is a CLI tool for managing sandboxed and native workers",
+	// Errors are rendered by Execute so that --json applies to them too
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// The root locates .yak-boxes/config.json, so it is applied first
+		workspace.SetRootOverride(rootDir)
+		sessions.SetRecoverCorrupt(!noRecover)
+
+		if jsonOutput {
+			cmd.SilenceUsage = true
+		}
+
+		defaults, err := loadConfigDefaults()
+		if err != nil {
+			return errors.NewValidationError("failed to load flag defaults", err)
+		}
+		if err := applyConfigDefaults(cmd, defaults); err != nil {
+			return err
+		}
+
+		if quiet {
+			ui.SetLevel(ui.Quiet)
+		} else {
+			ui.SetLevel(ui.Normal)
+		}
+		runtime.SetVerbose(verbose)
+		return nil
+	},
 }
 
-// Execute runs the root CLI command.
+// Execute runs the root CLI command with a context that is cancelled on
+// SIGINT or SIGTERM, so child processes started with it are killed. An
+// error is rendered to stderr before it is returned; exit with
+// errors.GetExitCode(err).
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := newSignalContext(context.Background())
+	defer stop()
+	err := ExecuteContext(ctx)
+	if err != nil {
+		errors.Render(err, jsonOutput, os.Stderr)
+	}
+	return err
+}
+
+// exitWithError renders err to stderr, as JSON with --json, and exits with
+// its exit code. Commands call it from Run instead of returning errors.
+func exitWithError(err error) {
+	errors.Render(err, jsonOutput, os.Stderr)
+	os.Exit(errors.GetExitCode(err))
+}
+
+// ExecuteContext runs the root CLI command with ctx as the Context() of
+// every subcommand.
+func ExecuteContext(ctx context.Context) error {
+	return rootCmd.ExecuteContext(ctx)
+}
+
+// newSignalContext returns a copy of parent that is cancelled on the first
+// SIGINT or SIGTERM. Once it is cancelled, default signal handling is
+// restored so that a second interrupt terminates the process immediately.
+func newSignalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
 }
 
 // SetVersion sets the version string for the CLI.
@@ -25,8 +105,25 @@ func SetVersion(v string) {
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress progress and success messages (warnings and errors are still shown)")
+	rootCmd.PersistentFlags().StringVar(&rootDir, "root", "", "Workspace root holding .yak-boxes, instead of searching for .yak-boxes or .git (env: YAK_BOX_ROOT)")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Machine-readable output; errors are printed to stderr as {\"error\", \"code\", \"type\"} JSON")
+	rootCmd.PersistentFlags().BoolVar(&noRecover, "no-recover", false, "Fail if .yak-boxes/sessions.json is corrupt instead of backing it up and starting empty")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Print each docker/zellij command to stderr before running it")
+
 	rootCmd.AddCommand(spawnCmd)
 	rootCmd.AddCommand(stopCmd)
 	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(messageCmd)
 	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(gcHomesCmd)
+	rootCmd.AddCommand(duCmd)
+	rootCmd.AddCommand(pruneCmd)
+	rootCmd.AddCommand(waitCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(reapCmd)
+	rootCmd.AddCommand(worktreeCmd)
+	rootCmd.AddCommand(metricsCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(versionCmd)
 }
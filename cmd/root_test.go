@@ -1,9 +1,17 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wellmaintained/yak-box/internal/ui"
+	"github.com/wellmaintained/yak-box/internal/workspace"
 )
 
 func TestRootCommand(t *testing.T) {
@@ -23,6 +31,8 @@ func TestRootCommandHasSubcommands(t *testing.T) {
 	assert.Contains(t, names, "spawn")
 	assert.Contains(t, names, "stop")
 	assert.Contains(t, names, "check")
+	assert.Contains(t, names, "message")
+	assert.Contains(t, names, "metrics")
 }
 
 func TestSetVersion(t *testing.T) {
@@ -70,3 +80,83 @@ func TestRootCommandExecution(t *testing.T) {
 		})
 	}
 }
+
+func TestExecuteContextPropagatesCancellation(t *testing.T) {
+	var got error
+	probe := &cobra.Command{
+		Use: "context-probe",
+		Run: func(cmd *cobra.Command, args []string) {
+			got = cmd.Context().Err()
+		},
+	}
+	rootCmd.AddCommand(probe)
+	t.Cleanup(func() { rootCmd.RemoveCommand(probe) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rootCmd.SetArgs([]string{"context-probe"})
+	t.Cleanup(func() { rootCmd.SetArgs(nil) })
+
+	require.NoError(t, ExecuteContext(ctx))
+	assert.ErrorIs(t, got, context.Canceled)
+}
+
+func TestNewSignalContextCancelledBySignal(t *testing.T) {
+	ctx, stop := newSignalContext(context.Background())
+	defer stop()
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGINT))
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context was not cancelled by SIGINT")
+	}
+}
+
+func TestQuietFlagSetsUILevel(t *testing.T) {
+	var got ui.Level
+	probe := &cobra.Command{
+		Use: "level-probe",
+		Run: func(cmd *cobra.Command, args []string) {
+			got = ui.GetLevel()
+		},
+	}
+	rootCmd.AddCommand(probe)
+	t.Cleanup(func() {
+		rootCmd.RemoveCommand(probe)
+		rootCmd.SetArgs(nil)
+		quiet = false
+		ui.SetLevel(ui.Normal)
+	})
+
+	rootCmd.SetArgs([]string{"level-probe", "--quiet"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, ui.Quiet, got)
+
+	quiet = false
+	rootCmd.SetArgs([]string{"level-probe"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, ui.Normal, got)
+}
+
+func TestRootFlagOverridesWorkspaceRoot(t *testing.T) {
+	root := t.TempDir()
+	var got string
+	probe := &cobra.Command{
+		Use: "root-probe",
+		Run: func(cmd *cobra.Command, args []string) {
+			got, _ = workspace.FindRoot()
+		},
+	}
+	rootCmd.AddCommand(probe)
+	t.Cleanup(func() {
+		rootCmd.RemoveCommand(probe)
+		rootCmd.SetArgs(nil)
+		rootDir = ""
+		workspace.SetRootOverride("")
+	})
+
+	rootCmd.SetArgs([]string{"root-probe", "--root", root})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, root, got)
+}
@@ -2,20 +2,28 @@ package cmd
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
 	"io"
+	"maps"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/spf13/cobra"
+	"github.com/wellmaintained/yak-box/internal/env"
 	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/pathutil"
 	"github.com/wellmaintained/yak-box/internal/prompt"
 	"github.com/wellmaintained/yak-box/internal/runtime"
 	"github.com/wellmaintained/yak-box/internal/sessions"
+	"github.com/wellmaintained/yak-box/internal/tasks"
 	"github.com/wellmaintained/yak-box/internal/ui"
 	"github.com/wellmaintained/yak-box/pkg/devcontainer"
 	"github.com/wellmaintained/yak-box/pkg/types"
@@ -35,7 +43,43 @@ var (
 	spawnModel        string
 	spawnClean        bool
 	spawnAutoWorktree bool
+	spawnWorktreeBase string
 	spawnSkills       []string
+	spawnInit         bool
+	spawnAllowUnsafe  bool
+	spawnNoCapDrop    bool
+	spawnAllowMounts  []string
+	spawnEnvFiles     []string
+	spawnSetEnv       []string
+	spawnEnvSensitive bool
+	spawnNoAuthMount  bool
+	spawnCredFile     string
+	spawnHomeDir      string
+	spawnPinPersona   bool
+	spawnReadyTimeout string
+	spawnStopTimeout  string
+	spawnStrictSec    bool
+	spawnTTL          string
+	spawnVerifyLock   bool
+	spawnFromTask     string
+	spawnForce        bool
+	spawnImage        string
+	spawnCPUs         string
+	spawnMemory       string
+	spawnSwap         string
+	spawnPIDs         int
+	spawnContainerWS  string
+	spawnUID          string
+	spawnGID          string
+	spawnReplace      bool
+	spawnTmpfsSize    string
+	spawnScriptsDir   string
+	spawnNoZellij     bool
+	spawnFollow       bool
+	spawnPromptParts  []promptSegment
+	spawnCount        int
+	spawnMaxDepth     int
+	spawnLabels       []string
 )
 
 const (
@@ -57,23 +101,87 @@ Native mode: Runs the AI tool directly on the host with full system access.
 Tool selection:
   --tool claude (default): Uses Claude Code with --print mode and agent prompts.
   --tool opencode: Uses OpenCode with --agent build mode.
-  --tool cursor: Uses Cursor agent CLI with --force mode.`,
+  --tool cursor: Uses Cursor agent CLI with --force mode.
+
+Spawning onto a --name that is already registered fails unless --replace is
+set, which stops the existing worker (container, tab and session) just
+before the new one launches; a spawn that fails before then leaves it running.
+
+--no-zellij starts the sandboxed container detached (docker run -dit) instead of
+in a Zellij tab, for headless servers and CI. The worker is still registered,
+so stop, check and message work as usual.
+
+The prompt is the positional argument followed by each --prompt-part and
+--prompt-file in the order given, joined by blank lines. Without any of them
+the worker is told to work on its assigned tasks.
+
+--count N spawns N workers on the same tasks, named <name>-1 to <name>-N.
+Each gets its own persona and home, and with --auto-worktree its own branch
+(<task>-1 and so on). All N are attempted and the failures reported together.
+A --from-task task is assigned to one worker, so it cannot be combined
+with --count.
+
+--follow streams the worker's output after it is spawned: docker logs for a
+sandboxed container, the worker.log for a native worker. Ctrl-C detaches and
+leaves the worker running.
+
+A devcontainer waitFor runs its lifecycle commands inside the container
+before the tool starts. Spawn waits for them to finish; if one fails the
+worker exits and spawn fails with exit code 1, pointing at the worker.log
+that holds the command's output.
+
+Exit codes:
+  1  other runtime failure
+  2  invalid flags or configuration
+  3  no runtime available (docker or zellij)
+  4  devcontainer image build failed
+  5  git worktree could not be created`,
 	Example: `  # Spawn a worker for API authentication tasks
   yak-box spawn --cwd ./api --name api-auth --yaks auth/api/login --yaks auth/api/logout
 
   # Spawn with automatic worktree creation
   yak-box spawn --cwd ./api --name api-auth --yaks auth/api --auto-worktree
 
+  # Branch the worktree off main rather than the current branch
+  yak-box spawn --cwd ./api --name api-auth --yaks auth/api --auto-worktree --worktree-base main
+
   # Spawn with heavy resources and native runtime
   yak-box spawn --cwd ./backend --name backend-worker --resources heavy --runtime native
 
+  # Default profile with more memory
+  yak-box spawn --cwd ./backend --name backend-worker --memory 3g --pids 1024
+
   # Spawn in plan mode with custom yak path
-  yak-box spawn --cwd ./frontend --name ui-worker --mode plan --yak-path .tasks`,
+  yak-box spawn --cwd ./frontend --name ui-worker --mode plan --yak-path .tasks
+
+  # Reuse the same persona (and home) whenever this task is respawned
+  yak-box spawn --cwd ./api --name api-auth --task auth/api --pin-persona
+
+  # Take the name, task and prompt from .yaks/auth/api (task.md or prompt.txt)
+  yak-box spawn --cwd ./api --from-task auth/api
+
+  # Stop the running api-auth worker and spawn a fresh one in its place
+  yak-box spawn --cwd ./api --name api-auth --task auth/api --replace
+
+  # Build the prompt from a shared file and task-specific parts
+  yak-box spawn --cwd ./api --name api-auth --task auth/api --prompt-file ./prompts/conventions.md --prompt-part "Start with the login handler."
+
+  # Explore a task with three workers at once (api-auth-1..api-auth-3)
+  yak-box spawn --cwd ./api --name api-auth --task auth/api --auto-worktree --count 3
+
+  # Spawn and watch the worker's output until Ctrl-C
+  yak-box spawn --cwd ./api --name api-auth --task auth/api --follow
+
+  # Run a sandboxed worker in the background on a host without Zellij
+  yak-box spawn --cwd ./api --name api-auth --task auth/api --runtime sandboxed --no-zellij`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		var errs []error
 
-		if spawnName == "" {
-			errs = append(errs, fmt.Errorf("--name is required (worker name used in logs and metadata)"))
+		if spawnName == "" && spawnFromTask == "" {
+			errs = append(errs, fmt.Errorf("--name is required (worker name used in logs and metadata), unless --from-task is given"))
+		}
+		if spawnForce && spawnFromTask == "" && !fromConfig(cmd, "force") {
+			errs = append(errs, fmt.Errorf("--force requires --from-task"))
 		}
 
 		if spawnMode != "plan" && spawnMode != "build" {
@@ -88,10 +196,120 @@ Tool selection:
 			errs = append(errs, fmt.Errorf("--runtime must be 'auto', 'sandboxed', or 'native', got '%s'", spawnRuntime))
 		}
 
+		if spawnMaxDepth < 1 {
+			errs = append(errs, fmt.Errorf("--max-depth must be at least 1, got %d", spawnMaxDepth))
+		}
+
+		if spawnCount < 1 {
+			errs = append(errs, fmt.Errorf("--count must be at least 1, got %d", spawnCount))
+		} else if spawnCount > 1 {
+			if spawnFollow {
+				errs = append(errs, fmt.Errorf("--follow can only stream one worker; it cannot be combined with --count"))
+			}
+			if spawnHomeDir != "" || spawnScriptsDir != "" {
+				errs = append(errs, fmt.Errorf("--home-dir and --scripts-dir cannot be combined with --count; each worker gets its own"))
+			}
+			if spawnPinPersona {
+				errs = append(errs, fmt.Errorf("--pin-persona cannot be combined with --count; each worker gets its own persona"))
+			}
+			if spawnFromTask != "" {
+				errs = append(errs, fmt.Errorf("--from-task cannot be combined with --count; a task is assigned to one worker"))
+			}
+		}
+
+		if spawnNoZellij && spawnRuntime == "native" {
+			errs = append(errs, fmt.Errorf("--no-zellij requires the sandboxed runtime; native workers run in a Zellij tab"))
+		}
+
 		if spawnTool != "opencode" && spawnTool != "claude" && spawnTool != "cursor" {
 			errs = append(errs, fmt.Errorf("--tool must be 'opencode', 'claude', or 'cursor', got '%s'", spawnTool))
 		}
 
+		if d, err := time.ParseDuration(spawnReadyTimeout); err != nil {
+			errs = append(errs, fmt.Errorf("--ready-timeout has invalid format: %v (use '30s', '2m', etc.)", err))
+		} else if d <= 0 {
+			errs = append(errs, fmt.Errorf("--ready-timeout must be positive, got '%s'", spawnReadyTimeout))
+		}
+
+		if d, err := time.ParseDuration(spawnStopTimeout); err != nil {
+			errs = append(errs, fmt.Errorf("--container-stop-timeout has invalid format: %v (use '300s', '10m', etc.)", err))
+		} else if d <= 0 {
+			errs = append(errs, fmt.Errorf("--container-stop-timeout must be positive, got '%s'", spawnStopTimeout))
+		}
+
+		if err := validateSpawnModel(spawnTool, spawnModel); err != nil {
+			errs = append(errs, err)
+		}
+
+		if spawnTTL != "" {
+			if _, err := parseAgeDuration(spawnTTL); err != nil {
+				errs = append(errs, fmt.Errorf("--ttl has invalid format: %v (use '8h', '2d', etc.)", err))
+			}
+		}
+
+		if _, err := parseLabels(spawnLabels); err != nil {
+			errs = append(errs, fmt.Errorf("--%w", err))
+		}
+
+		if spawnStrictSec && spawnAllowUnsafe {
+			errs = append(errs, fmt.Errorf("--strict-security and --allow-unsafe-security are mutually exclusive"))
+		}
+		if spawnStrictSec && spawnNoCapDrop {
+			errs = append(errs, fmt.Errorf("--strict-security and --no-cap-drop are mutually exclusive"))
+		}
+
+		if _, err := containerIDOverride("--uid", spawnUID, runtime.ContainerUIDEnv); err != nil {
+			errs = append(errs, err)
+		}
+		if _, err := containerIDOverride("--gid", spawnGID, runtime.ContainerGIDEnv); err != nil {
+			errs = append(errs, err)
+		}
+
+		if spawnContainerWS != "" && !strings.HasPrefix(spawnContainerWS, "/") {
+			errs = append(errs, fmt.Errorf("--container-workspace %q must be an absolute container path like /workspace", spawnContainerWS))
+		}
+
+		for _, err := range spawnResourceOverrides().Validate() {
+			errs = append(errs, fmt.Errorf("--%w", err))
+		}
+
+		if spawnPinPersona && len(spawnYaks) == 0 && !fromConfig(cmd, "pin-persona") {
+			errs = append(errs, fmt.Errorf("--pin-persona requires --task (the task to pin the persona to)"))
+		}
+
+		if spawnHomeDir != "" {
+			if strings.TrimSpace(spawnHomeDir) == "" {
+				errs = append(errs, fmt.Errorf("--home-dir must not be blank"))
+			} else if info, err := os.Stat(spawnHomeDir); err == nil && !info.IsDir() {
+				errs = append(errs, fmt.Errorf("--home-dir %q: must be a directory", spawnHomeDir))
+			}
+		}
+
+		if spawnScriptsDir != "" {
+			if info, err := os.Stat(spawnScriptsDir); err == nil && !info.IsDir() {
+				errs = append(errs, fmt.Errorf("--scripts-dir %q: must be a directory", spawnScriptsDir))
+			}
+		}
+
+		if spawnCredFile != "" {
+			if data, err := os.ReadFile(spawnCredFile); err != nil {
+				errs = append(errs, fmt.Errorf("--credential-file %q: %v", spawnCredFile, err))
+			} else if strings.TrimSpace(string(data)) == "" {
+				errs = append(errs, fmt.Errorf("--credential-file %q is empty", spawnCredFile))
+			}
+		}
+
+		for _, envFile := range spawnEnvFiles {
+			if _, err := env.ParseEnvFile(envFile); err != nil {
+				errs = append(errs, fmt.Errorf("--env-file %q: %v", envFile, err))
+			}
+		}
+		for _, assignment := range spawnSetEnv {
+			if _, _, err := env.ParseAssignment(assignment); err != nil {
+				errs = append(errs, fmt.Errorf("--set-env: %v", err))
+			}
+		}
+
 		for _, skillPath := range spawnSkills {
 			info, err := os.Stat(skillPath)
 			if err != nil {
@@ -119,48 +337,108 @@ Tool selection:
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runSpawn(cmd, cmd.Context(), args); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(errors.GetExitCode(err))
+			exitWithError(err)
 		}
 	},
 }
 
-const lastPersonaFile = ".last-persona"
-
 // pickWorkerName selects the next worker name in round-robin order so consecutive
-// spawns get different personas. State is stored in .yak-boxes/.last-persona.
-// Falls back to random if the state file cannot be read or written (e.g. not in a git repo).
+// spawns get different personas. Names come from .yak-boxes/personas.txt when
+// present and state is stored in .yak-boxes/.last-persona.
+// Falls back to random if the state file cannot be read or written (e.g. not in a git repo),
+// with a warning when personas.txt exists but cannot be loaded.
 func pickWorkerName() string {
-	n := len(types.WorkerNames)
-	if n == 0 {
-		return ""
+	name, err := sessions.NextPersona()
+	if name != "" {
+		return name
 	}
+	if err != nil && !personasFileMissing() {
+		ui.Warning("⚠️  Failed to load personas, using a random built-in persona: %v\n", err)
+	}
+	if err != nil && len(types.WorkerNames) > 0 {
+		return types.WorkerNames[rand.Intn(len(types.WorkerNames))]
+	}
+	return ""
+}
+
+// personasFileMissing reports whether there is no .yak-boxes/personas.txt,
+// including when there is no .yak-boxes directory to hold one.
+func personasFileMissing() bool {
 	dir, err := sessions.GetYakBoxesDir()
 	if err != nil {
-		return types.WorkerNames[rand.Intn(n)]
+		return true
 	}
-	path := filepath.Join(dir, lastPersonaFile)
-	data, err := os.ReadFile(path)
-	idx := 0
-	if err == nil {
-		idx, _ = strconv.Atoi(strings.TrimSpace(string(data)))
-		if idx < 0 || idx >= n {
-			idx = 0
+	_, err = os.Stat(filepath.Join(dir, sessions.PersonasFile))
+	return os.IsNotExist(err)
+}
+
+// pickWorkerNameForTask returns the persona pinned to task, if any, so respawns
+// for a long-lived task reuse the same home. Unbound tasks use round-robin.
+func pickWorkerNameForTask(task string) string {
+	if task != "" {
+		persona, err := sessions.GetBinding(task)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to read persona bindings: %v\n", err)
+		} else if persona != "" {
+			return persona
 		}
 	}
-	next := (idx + 1) % n
-	_ = os.WriteFile(path, []byte(strconv.Itoa(next)), 0644)
-	return types.WorkerNames[idx]
+	return pickWorkerName()
 }
 
+// formatDisplayName returns the Zellij tab title for a worker, with control
+// characters such as newlines stripped from the spawn name.
 func formatDisplayName(workerName, spawnName string) string {
-	trimmedName := strings.TrimSpace(spawnName)
+	trimmedName := strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, spawnName))
 	if trimmedName == "" {
 		return workerName
 	}
 	return fmt.Sprintf("%s 🪒🦬 %s", workerName, trimmedName)
 }
 
+// claudeModelAliases are the model aliases accepted by the claude CLI.
+var claudeModelAliases = map[string]bool{
+	"default":  true,
+	"sonnet":   true,
+	"opus":     true,
+	"haiku":    true,
+	"opusplan": true,
+}
+
+// validateSpawnModel rejects model names that the chosen tool cannot accept,
+// so a typo fails at spawn time instead of inside the worker.
+func validateSpawnModel(tool, model string) error {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return nil
+	}
+	if strings.ContainsAny(model, " \t\n") {
+		return fmt.Errorf("--model %q must not contain whitespace", model)
+	}
+
+	switch tool {
+	case "opencode":
+		provider, name, found := strings.Cut(model, "/")
+		if !found || provider == "" || name == "" {
+			return fmt.Errorf("--model %q is not valid for opencode: use 'provider/model' (e.g. 'anthropic/claude-sonnet-4-5')", model)
+		}
+	case "claude":
+		if !claudeModelAliases[model] && !strings.HasPrefix(model, "claude-") {
+			return fmt.Errorf("--model %q is not valid for claude: use an alias ('sonnet', 'opus', 'haiku', 'default') or a full name like 'claude-sonnet-4-5'", model)
+		}
+	case "cursor":
+		if strings.Contains(model, "/") {
+			return fmt.Errorf("--model %q is not valid for cursor: use a bare model name (e.g. 'auto', 'sonnet-4')", model)
+		}
+	}
+	return nil
+}
+
 func resolveSpawnModel(tool, model string) string {
 	if strings.TrimSpace(model) != "" {
 		return model
@@ -177,12 +455,91 @@ func resolveSpawnModel(tool, model string) string {
 }
 
 func runSpawn(cmd *cobra.Command, ctx context.Context, args []string) error {
-	runtimeType := spawnRuntime
-	if runtimeType == "auto" {
-		runtimeType = runtime.DetectRuntime()
-		if runtimeType == "unknown" {
-			return fmt.Errorf("no runtime available (docker or zellij). Suggestion: Install Docker and start the daemon, or install Zellij. Force with --runtime=sandboxed or --runtime=native")
+	if err := checkSpawnDepth(spawnMaxDepth); err != nil {
+		return err
+	}
+	if spawnCount > 1 {
+		return spawnWorkers(cmd, ctx, args, spawnCount)
+	}
+	return spawnWorker(cmd, ctx, args, 0)
+}
+
+// checkSpawnDepth refuses to spawn from inside a worker that is already at
+// maxDepth, read from the runtime.DepthEnv its run script sets, so an agent
+// that spawns sub-workers cannot recurse without bound. Inside a worker,
+// maxDepth cannot exceed the runtime.MaxDepthEnv limit it was spawned under.
+func checkSpawnDepth(maxDepth int) error {
+	depth, err := runtime.CurrentDepth()
+	if err != nil {
+		return errors.NewValidationError("cannot determine the worker nesting depth", err)
+	}
+	limit, err := runtime.EffectiveMaxDepth(maxDepth)
+	if err != nil {
+		return errors.NewValidationError("cannot determine the worker nesting limit", err)
+	}
+	if depth >= limit {
+		return errors.NewRuntimeError(fmt.Sprintf("refusing to spawn: already inside a yak-box worker at depth %d, and the maximum depth is %d. Suggestion: Spawn from the host instead, or raise --max-depth on the host spawn if nested workers are intended", depth, limit), nil)
+	}
+	return nil
+}
+
+// spawnWorkers runs spawnWorker count times for spawn --count, numbering the
+// workers from 1. Every worker is attempted; the failures are reported
+// together once all have run.
+func spawnWorkers(cmd *cobra.Command, ctx context.Context, args []string, count int) error {
+	personas, err := sessions.LoadWorkerNames()
+	if err != nil {
+		return fmt.Errorf("failed to load personas: %w", err)
+	}
+	if count > len(personas) {
+		return errors.NewValidationError(fmt.Sprintf("--count %d needs as many personas, but only %d are configured. Suggestion: Add names to .yak-boxes/personas.txt", count, len(personas)), nil)
+	}
+
+	var spawned, failed []string
+	var errs []error
+	for i := 1; i <= count; i++ {
+		err := spawnWorker(cmd, ctx, args, i)
+		name := instanceName(spawnName, i)
+		if err != nil {
+			ui.Error("❌ %s: %v\n", name, err)
+			failed = append(failed, name)
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
 		}
+		spawned = append(spawned, name)
+	}
+
+	fmt.Printf("Spawned %d of %d workers", len(spawned), count)
+	if len(spawned) > 0 {
+		fmt.Printf(": %s", strings.Join(spawned, ", "))
+	}
+	fmt.Println()
+	if len(errs) > 0 {
+		return errors.NewRuntimeError(fmt.Sprintf("failed to spawn %s", strings.Join(failed, ", ")), goerrors.Join(errs...))
+	}
+	return nil
+}
+
+// instanceName suffixes name with the worker's number for spawn --count.
+// Index 0 is a single spawn and leaves name unchanged.
+func instanceName(name string, index int) string {
+	if index == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s-%d", name, index)
+}
+
+// spawnWorker spawns one worker. A non-zero index is its number within
+// spawn --count: it is appended to the worker's name and to its
+// --auto-worktree task branch, and the persona is picked round-robin so
+// each worker gets its own home.
+func spawnWorker(cmd *cobra.Command, ctx context.Context, args []string, index int) error {
+	runtimeType, err := resolveRuntime(spawnRuntime)
+	if err != nil {
+		return err
+	}
+	if spawnNoZellij && runtimeType != "sandboxed" {
+		return errors.NewCodedError(errors.ExitNoRuntime, "--no-zellij needs Docker, but only the native runtime is available. Suggestion: Start the Docker daemon, or drop --no-zellij", nil)
 	}
 
 	startDir := "."
@@ -201,10 +558,44 @@ func runSpawn(cmd *cobra.Command, ctx context.Context, args []string) error {
 			return fmt.Errorf("failed to resolve yak path: %w. Suggestion: Ensure --yak-path exists and is accessible", err)
 		}
 	} else {
-		absYakPath, err = findYakPath(startAbsDir, filepath.Base(spawnYakPath))
+		absYakPath, err = findYakPathWithDepth(startAbsDir, filepath.Base(spawnYakPath), yakPathSearchDepth)
+		if err != nil {
+			return err
+		}
+	}
+
+	if spawnFromTask != "" {
+		fromTask, err := resolveFromTask(absYakPath, spawnFromTask, spawnForce)
 		if err != nil {
-			return fmt.Errorf("No .yaks found above %s. Use --yak-path to specify explicitly", startAbsDir)
+			return err
+		}
+		if spawnName == "" {
+			spawnName = fromTask.Name
+		}
+		if !slices.Contains(spawnYaks, spawnFromTask) {
+			spawnYaks = append([]string{spawnFromTask}, spawnYaks...)
 		}
+		if len(args) == 0 && fromTask.Prompt != "" {
+			args = []string{fromTask.Prompt}
+		}
+	}
+
+	userPrompt, err := assembleUserPrompt(args, spawnPromptParts)
+	if err != nil {
+		return err
+	}
+
+	if err := checkTaskDirsUnambiguous(absYakPath, spawnYaks); err != nil {
+		return err
+	}
+
+	name := instanceName(spawnName, index)
+
+	// The old worker is only stopped once the new one is ready to launch,
+	// so a spawn that fails validation leaves it running
+	replacing, err := checkExistingWorker(name, spawnReplace)
+	if err != nil {
+		return err
 	}
 
 	var (
@@ -217,6 +608,7 @@ func runSpawn(cmd *cobra.Command, ctx context.Context, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to resolve worktrees from yak %q: %w", spawnYaks[0], err)
 		}
+		worktreeBranch = instanceName(worktreeBranch, index)
 	}
 
 	if strings.TrimSpace(spawnCWD) != "" {
@@ -227,34 +619,77 @@ func runSpawn(cmd *cobra.Command, ctx context.Context, args []string) error {
 	} else if len(inheritedWorktrees) == 0 {
 		return fmt.Errorf("--cwd is required unless the assigned yak defines a worktrees field")
 	}
+	if spawnWorktreeBase != "" && !spawnAutoWorktree && len(inheritedWorktrees) == 0 && !fromConfig(cmd, "worktree-base") {
+		return errors.NewValidationError("--worktree-base requires --auto-worktree or a task with a worktrees field", nil)
+	}
 
+	// sessionWorktree and worktreeProject record an --auto-worktree worktree
+	// so that stop --remove-worktree can clean it up.
+	var sessionWorktree, worktreeProject string
 	worktreePath := ""
 	if spawnAutoWorktree && len(spawnYaks) > 0 {
-		taskPath := spawnYaks[0]
+		taskPath := instanceName(spawnYaks[0], index)
 		fmt.Printf("Creating worktree for task: %s\n", taskPath)
 
-		wt, err := worktree.EnsureWorktree(absCWD, taskPath, true)
+		wt, err := worktree.EnsureWorktree(absCWD, taskPath, spawnWorktreeBase, true)
 		if err != nil {
-			return fmt.Errorf("failed to ensure worktree: %w. Suggestion: Ensure you're in a git repository with proper permissions, or disable --auto-worktree", err)
+			return errors.WithExitCode(errors.ExitWorktree, fmt.Errorf("failed to ensure worktree: %w. Suggestion: Ensure you're in a git repository with proper permissions, or disable --auto-worktree", err))
 		}
 
 		worktreePath = wt
+		sessionWorktree, worktreeProject = wt, absCWD
 		absCWD = wt
 		fmt.Printf("Using worktree: %s\n", wt)
 	}
 
-	workerName := pickWorkerName()
+	var ttl time.Duration
+	if spawnTTL != "" {
+		ttl, err = parseAgeDuration(spawnTTL)
+		if err != nil {
+			return errors.NewValidationError("invalid --ttl. Use a duration like '8h' or '2d'", err)
+		}
+	}
+	labels, err := parseLabels(spawnLabels)
+	if err != nil {
+		return errors.NewValidationError("invalid --label", err)
+	}
+
+	primaryTask := ""
+	if len(spawnYaks) > 0 {
+		primaryTask = spawnYaks[0]
+	}
+	var workerName string
+	if index > 0 {
+		// A pinned persona would give every --count worker the same home
+		workerName = pickWorkerName()
+	} else {
+		workerName = pickWorkerNameForTask(primaryTask)
+	}
+	if spawnPinPersona && primaryTask != "" {
+		if err := sessions.Bind(primaryTask, workerName); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to pin %s to %s: %v\n", workerName, primaryTask, err)
+		} else {
+			fmt.Printf("Pinned %s to task: %s\n", workerName, primaryTask)
+		}
+	}
 
-	if spawnClean {
+	if spawnClean && spawnHomeDir == "" {
 		fmt.Printf("Cleaning home directory for %s...\n", workerName)
 		if err := sessions.CleanHome(workerName); err != nil {
 			return fmt.Errorf("failed to clean home: %w. Suggestion: Ensure .yak-boxes directory exists and is writable", err)
 		}
 	}
 
-	homeDir, err := sessions.EnsureHomeDir(workerName)
+	homeDir, err := resolveHomeDir(spawnHomeDir, workerName)
 	if err != nil {
-		return fmt.Errorf("failed to ensure home directory: %w. Suggestion: Check that .yak-boxes directory exists and is writable", err)
+		return err
+	}
+
+	scriptsDir := ""
+	if spawnScriptsDir != "" {
+		if scriptsDir, err = filepath.Abs(spawnScriptsDir); err != nil {
+			return errors.NewValidationError(fmt.Sprintf("invalid --scripts-dir %q", spawnScriptsDir), err)
+		}
 	}
 
 	if len(inheritedWorktrees) > 0 {
@@ -266,9 +701,9 @@ func runSpawn(cmd *cobra.Command, ctx context.Context, args []string) error {
 				return fmt.Errorf("duplicate worktree destination %q for repos %q and %q", repoName, prior, repoPath)
 			}
 
-			wtPath, err := worktree.EnsureWorktreeAtPath(repoPath, destPath, worktreeBranch, true)
+			wtPath, err := worktree.EnsureWorktreeAtPath(repoPath, destPath, worktreeBranch, spawnWorktreeBase, true)
 			if err != nil {
-				return fmt.Errorf("failed to ensure worktree for repo %s: %w", repoPath, err)
+				return errors.WithExitCode(errors.ExitWorktree, fmt.Errorf("failed to ensure worktree for repo %s: %w", repoPath, err))
 			}
 			seenDestinations[repoName] = repoPath
 			fmt.Printf("Using worktree: %s\n", wtPath)
@@ -286,23 +721,46 @@ func runSpawn(cmd *cobra.Command, ctx context.Context, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load devcontainer config: %w. Suggestion: Ensure .devcontainer/devcontainer.json is valid JSON if it exists", err)
 	}
+	if err := checkDevConfigValid(devConfig); err != nil {
+		return err
+	}
 
-	profile := runtime.GetResourceProfile(spawnResources)
+	if spawnStrictSec {
+		if err := checkStrictSecurity(devConfig); err != nil {
+			return err
+		}
+	}
 
-	userPrompt := "Work on the assigned tasks."
-	if len(args) > 0 {
-		userPrompt = args[0]
+	if spawnVerifyLock {
+		lock, err := devcontainer.LoadLockFile(absCWD)
+		if err != nil {
+			return fmt.Errorf("failed to load devcontainer lock file: %w", err)
+		}
+		if err := checkLockPinned(devConfig, lock); err != nil {
+			return err
+		}
 	}
 
+	profile := spawnResourceOverrides().Apply(runtime.GetResourceProfile(spawnResources))
+
 	skillNames := make([]string, 0, len(spawnSkills))
 	for _, s := range spawnSkills {
 		skillNames = append(skillNames, filepath.Base(s))
 	}
-	workerPrompt := prompt.BuildPrompt(spawnMode, spawnYakPath, userPrompt, spawnYaks, workerName, skillNames)
+	// A native tool runs in absCWD, which may be a worktree without the task
+	// state, so it is pointed at the absolute yak path instead
+	promptYakPath := spawnYakPath
+	if runtimeType == "native" {
+		promptYakPath = absYakPath
+	}
+	workerPrompt, err := prompt.BuildPrompt(spawnMode, promptYakPath, userPrompt, spawnYaks, workerName, skillNames)
+	if err != nil {
+		return errors.NewValidationError("failed to build the worker prompt", err)
+	}
 
-	displayName := formatDisplayName(workerName, spawnName)
+	displayName := formatDisplayName(workerName, name)
 
-	sanitizedName := strings.ReplaceAll(spawnName, " ", "-")
+	sanitizedName := strings.ReplaceAll(name, " ", "-")
 	sanitizedName = strings.Map(func(r rune) rune {
 		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
 			return r
@@ -310,13 +768,15 @@ func runSpawn(cmd *cobra.Command, ctx context.Context, args []string) error {
 		return -1
 	}, sanitizedName)
 
-	if spawnTool == "opencode" && spawnModel != "" {
-		ui.Warning("⚠️  --model is currently ignored for --tool opencode\n")
-	}
 	resolvedModel := resolveSpawnModel(spawnTool, spawnModel)
+	sessionName := resolveSessionName(spawnSession)
+	if spawnNoZellij {
+		// No tab is created, so there is no Zellij session to record
+		sessionName = ""
+	}
 
 	worker := &types.Worker{
-		Name:          spawnName,
+		Name:          name,
 		WorkerName:    workerName,
 		DisplayName:   displayName,
 		ContainerName: "yak-worker-" + sanitizedName,
@@ -325,33 +785,113 @@ func runSpawn(cmd *cobra.Command, ctx context.Context, args []string) error {
 		YakPath:       absYakPath,
 		Tasks:         spawnYaks,
 		SpawnedAt:     time.Now(),
-		SessionName:   spawnSession,
+		SessionName:   sessionName,
 		WorktreePath:  worktreePath,
 		Tool:          spawnTool,
 		Model:         resolvedModel,
 	}
 
+	readyTimeout := runtime.DefaultReadyTimeout
 	if runtimeType == "sandboxed" {
-		ui.Info("⏳ Building container...\n")
-		if err := runtime.EnsureDevcontainer(); err != nil {
-			ui.Error("❌ Build failed: %v\n", err)
-			return fmt.Errorf("failed to ensure devcontainer: %w\n\nSuggestion: Install Docker or use native mode.\nTo try native mode instead, run:\n  yak-box spawn --runtime=native [same options]", err)
+		if err := ensureWorkerImage(ctx, spawnImage, devConfig); err != nil {
+			return err
+		}
+
+		readyTimeout, err = time.ParseDuration(spawnReadyTimeout)
+		if err != nil {
+			return errors.NewValidationError("invalid --ready-timeout. Use a valid duration like '30s' or '2m'", err)
 		}
+		stopTimeout, err := time.ParseDuration(spawnStopTimeout)
+		if err != nil {
+			return errors.NewValidationError("invalid --container-stop-timeout. Use a valid duration like '300s' or '10m'", err)
+		}
+
+		envFileVars, err := loadEnvFiles(spawnEnvFiles)
+		if err != nil {
+			return err
+		}
+
+		setEnvVars, err := parseSetEnv(spawnSetEnv, spawnEnvSensitive)
+		if err != nil {
+			return err
+		}
+
+		// Task env files apply first so --env-file, then --set-env, can override them
+		containerEnv := make(map[string]string)
+		if len(spawnYaks) > 0 {
+			if containerEnv, err = resolveInheritedEnv(absYakPath, spawnYaks[0]); err != nil {
+				return errors.NewValidationError(fmt.Sprintf("invalid env file for task %q", spawnYaks[0]), err)
+			}
+		}
+		maps.Copy(containerEnv, envFileVars)
+		maps.Copy(containerEnv, setEnvVars)
 
-		if err := runtime.SpawnSandboxedWorker(ctx,
+		opts := []runtime.SpawnOption{
 			runtime.WithWorker(worker),
 			runtime.WithPrompt(workerPrompt),
 			runtime.WithResourceProfile(profile),
 			runtime.WithHomeDir(homeDir),
 			runtime.WithDevConfig(devConfig),
-		); err != nil {
+			runtime.WithInit(spawnInit),
+			runtime.WithAllowUnsafeSecurity(spawnAllowUnsafe),
+			runtime.WithNoCapDrop(spawnNoCapDrop),
+			runtime.WithAllowedMountRoots(spawnAllowMounts...),
+			runtime.WithEnvVars(containerEnv),
+			runtime.WithNoAuthMount(spawnNoAuthMount),
+			runtime.WithCredentialFile(spawnCredFile),
+			runtime.WithImage(spawnImage),
+			runtime.WithContainerWorkspace(spawnContainerWS),
+			runtime.WithScriptsDir(scriptsDir),
+			runtime.WithNoZellij(spawnNoZellij),
+			runtime.WithMaxDepth(spawnMaxDepth),
+			runtime.WithReadyTimeout(readyTimeout),
+			runtime.WithStopTimeout(stopTimeout),
+			runtime.WithVerbose(verbose),
+			runtime.WithProgress(func(step string) {
+				if verbose {
+					ui.Info("⏳ %s...\n", step)
+				}
+			}),
+		}
+		uid, err := containerIDOverride("--uid", spawnUID, runtime.ContainerUIDEnv)
+		if err != nil {
+			return errors.NewValidationError("invalid container uid", err)
+		}
+		if uid != nil {
+			opts = append(opts, runtime.WithUID(*uid))
+		}
+		gid, err := containerIDOverride("--gid", spawnGID, runtime.ContainerGIDEnv)
+		if err != nil {
+			return errors.NewValidationError("invalid container gid", err)
+		}
+		if gid != nil {
+			opts = append(opts, runtime.WithGID(*gid))
+		}
+
+		if replacing {
+			if err := replaceExistingWorker(name); err != nil {
+				return err
+			}
+		}
+		if err := spawnSandboxedFn(ctx, opts...); err != nil {
 			ui.Error("❌ Failed to spawn sandboxed worker: %v\n", err)
+			// The container started but the worker failed, e.g. a waitFor
+			// lifecycle command; the error carries its own suggestion
+			var runtimeErr *errors.RuntimeError
+			if goerrors.As(err, &runtimeErr) {
+				return err
+			}
 			return fmt.Errorf("failed to spawn sandboxed worker: %w\n\nSuggestion: Check Docker is running and has enough resources.\nTo try native mode instead, run:\n  yak-box spawn --runtime=native [same options]", err)
 		}
 		ui.Success("✅ Container ready\n")
 	} else {
+		if replacing {
+			if err := replaceExistingWorker(name); err != nil {
+				return err
+			}
+		}
 		ui.Info("⏳ Starting native worker...\n")
-		pidFile, err := runtime.SpawnNativeWorker(worker, workerPrompt, homeDir)
+		pidFile, err := spawnNativeFn(worker, workerPrompt, homeDir, scriptsDir, spawnMaxDepth)
 		if err != nil {
 			ui.Error("❌ Failed to spawn native worker: %v\n", err)
 			return fmt.Errorf("failed to spawn native worker: %w. Suggestion: Ensure Zellij is installed and running, or use --runtime=sandboxed instead", err)
@@ -365,7 +905,7 @@ func runSpawn(cmd *cobra.Command, ctx context.Context, args []string) error {
 		taskName = spawnYaks[0]
 	}
 
-	if err := sessions.Register(spawnName, sessions.Session{
+	if err := sessions.Register(name, sessions.Session{
 		Worker:        workerName,
 		Task:          taskName,
 		Container:     worker.ContainerName,
@@ -373,8 +913,14 @@ func runSpawn(cmd *cobra.Command, ctx context.Context, args []string) error {
 		Runtime:       runtimeType,
 		CWD:           absCWD,
 		DisplayName:   displayName,
-		ZellijSession: spawnSession,
+		ZellijSession: sessionName,
 		PidFile:       worker.PidFile,
+		ExpiresAt:     sessionExpiry(worker.SpawnedAt, ttl),
+		WorktreePath:  sessionWorktree,
+		ProjectPath:   worktreeProject,
+		Tool:          spawnTool,
+		Labels:        labels,
+		Detached:      spawnNoZellij,
 	}); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to register session: %v\n", err)
 	}
@@ -387,71 +933,370 @@ func runSpawn(cmd *cobra.Command, ctx context.Context, args []string) error {
 			continue
 		}
 
-		taskFile := filepath.Join(taskDir, "assigned-to")
-		if err := os.WriteFile(taskFile, []byte(workerName), 0644); err != nil {
+		if err := assignTask(absYakPath, taskDir, workerName, worktreePath); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to assign task %s: %v\n", task, err)
 		}
+	}
 
-		if worktreePath != "" {
-			worktreeFile := filepath.Join(taskDir, "worktree-path")
-			if err := os.WriteFile(worktreeFile, []byte(worktreePath), 0644); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to write worktree path for task %s: %v\n", task, err)
-			}
+	fmt.Printf("Spawned %s (%s) in %s\n", workerName, name, runtimeType)
+
+	if spawnFollow {
+		ui.Info("📜 Following %s (Ctrl-C to detach; the worker keeps running)\n", displayName)
+		if err := followWorkerFn(ctx, worker, runtime.WorkerLogPath(scriptsDir, homeDir), readyTimeout); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// Spawn and follow hooks; tests replace them to run spawn without docker or zellij.
+var (
+	spawnSandboxedFn = runtime.SpawnSandboxedWorker
+	spawnNativeFn    = runtime.SpawnNativeWorker
+	followWorkerFn   = followWorker
+)
+
+// followWorker streams a spawned worker's output to stdout until it exits or
+// ctx is cancelled by Ctrl-C, which leaves the worker running. Sandboxed
+// workers are followed with docker logs, native ones through their log file.
+func followWorker(ctx context.Context, worker *types.Worker, logPath string, readyTimeout time.Duration) error {
+	if worker.Runtime == "sandboxed" {
+		return runtime.FollowContainerLogs(ctx, worker.ContainerName, readyTimeout, os.Stdout)
+	}
+	return runtime.FollowLogFile(ctx, logPath, os.Stdout)
+}
+
+// checkExistingWorker reports whether a session is already registered under
+// name. That is an error unless replace is set, in which case the caller
+// replaces the old worker with replaceExistingWorker just before launching.
+func checkExistingWorker(name string, replace bool) (bool, error) {
+	if _, err := sessions.Get(name); err != nil {
+		if !goerrors.Is(err, sessions.ErrSessionNotFound) {
+			fmt.Fprintf(os.Stderr, "Warning: could not check for an existing %s session: %v\n", name, err)
+		}
+		return false, nil
+	}
+
+	if !replace {
+		return false, errors.NewValidationError(fmt.Sprintf("worker %q is already registered. Suggestion: Use --replace to stop it and spawn a new one, or run 'yak-box stop --name %s' first", name, name), nil)
+	}
+	return true, nil
+}
+
+// replaceExistingWorker stops and unregisters the worker named name so its
+// container or tab is not left running without a session.
+func replaceExistingWorker(name string) error {
+	ui.Info("⏳ Replacing existing worker: %s\n", name)
+	if err := stopWorkerFn(name, stopOptions{Timeout: 30 * time.Second}); err != nil {
+		return fmt.Errorf("failed to stop existing worker %s: %w", name, err)
+	}
+	return nil
+}
+
+// detectRuntimesFn picks the runtime for --runtime=auto; tests replace it.
+var detectRuntimesFn = runtime.DetectRuntimes
+
+// resolveRuntime returns the runtime to spawn with, detecting one for "auto".
+func resolveRuntime(requested string) (string, error) {
+	if requested != "auto" {
+		return requested, nil
+	}
+	report := detectRuntimesFn()
+	if report.Runtime == "unknown" {
+		return "", errors.NewCodedError(errors.ExitNoRuntime, fmt.Sprintf("no runtime available (%s). Suggestion: %s. Force with --runtime=sandboxed or --runtime=native", report.Diagnosis(), noRuntimeSuggestion(report)), nil)
+	}
+	return report.Runtime, nil
+}
+
+// noRuntimeSuggestion says what to fix when neither runtime is usable
+func noRuntimeSuggestion(report runtime.RuntimeReport) string {
+	if report.DockerInstalled {
+		return "Start the Docker daemon, or install Zellij"
+	}
+	return "Install Docker and start the daemon, or install Zellij"
+}
+
+// spawnResourceOverrides collects the --cpus, --memory, --swap and --pids flags.
+func spawnResourceOverrides() runtime.ResourceOverrides {
+	return runtime.ResourceOverrides{CPUs: spawnCPUs, Memory: spawnMemory, Swap: spawnSwap, PIDs: spawnPIDs, TmpfsSize: spawnTmpfsSize}
+}
+
+// ensureDevcontainerFn builds the worker image; replaced in tests.
+var ensureDevcontainerFn = runtime.EnsureDevcontainer
+
+// ensureWorkerImage builds the worker image unless image names a prebuilt
+// --image override, which SpawnSandboxedWorker pulls if it is not local.
+// The image must pass the allowlist first, so a rejected spawn never builds.
+func ensureWorkerImage(ctx context.Context, image string, devConfig *devcontainer.Config) error {
+	if err := runtime.CheckAllowedImage(image, devConfig); err != nil {
+		return err
+	}
+	if image != "" {
+		ui.Info("🐳 Using image %s\n", image)
+		return nil
+	}
+	ui.Info("⏳ Building container...\n")
+	if err := ensureDevcontainerFn(ctx); err != nil {
+		if goerrors.Is(err, runtime.ErrBuildCancelled) {
+			ui.Warning("⚠️  Build cancelled\n")
+			return fmt.Errorf("spawn aborted: %w", err)
 		}
+		ui.Error("❌ Build failed: %v\n", err)
+		return errors.WithExitCode(errors.ExitBuildFailed, fmt.Errorf("failed to ensure devcontainer: %w\n\nSuggestion: Install Docker or use native mode.\nTo try native mode instead, run:\n  yak-box spawn --runtime=native [same options]", err))
 	}
+	return nil
+}
 
-	fmt.Printf("Spawned %s (%s) in %s\n", workerName, spawnName, runtimeType)
+// defaultUserPrompt is the user prompt when none is given.
+const defaultUserPrompt = "Work on the assigned tasks."
+
+// promptSegment is one --prompt-part or --prompt-file value. Both flags
+// append to the same list so their relative order on the command line is kept.
+type promptSegment struct {
+	Text string // literal text from --prompt-part
+	File string // path from --prompt-file, read when the prompt is assembled
+}
+
+// promptSegmentFlag is the pflag.Value behind --prompt-part and
+// --prompt-file, appending each value to segments.
+type promptSegmentFlag struct {
+	segments *[]promptSegment
+	file     bool
+}
+
+func (f promptSegmentFlag) Set(value string) error {
+	if f.file {
+		*f.segments = append(*f.segments, promptSegment{File: value})
+	} else {
+		*f.segments = append(*f.segments, promptSegment{Text: value})
+	}
 	return nil
 }
 
+func (f promptSegmentFlag) String() string { return "" }
+
+func (f promptSegmentFlag) Type() string {
+	if f.file {
+		return "path"
+	}
+	return "text"
+}
+
+// assembleUserPrompt joins the positional prompt and then each segment in
+// order, separated by blank lines. Without any of them the default prompt
+// is used; given ones that are all blank are a validation error.
+func assembleUserPrompt(args []string, segments []promptSegment) (string, error) {
+	if len(args) == 0 && len(segments) == 0 {
+		return defaultUserPrompt, nil
+	}
+
+	var parts []string
+	if len(args) > 0 {
+		parts = append(parts, strings.TrimSpace(args[0]))
+	}
+	for _, segment := range segments {
+		text := segment.Text
+		if segment.File != "" {
+			data, err := os.ReadFile(segment.File)
+			if err != nil {
+				return "", errors.NewValidationError(fmt.Sprintf("failed to read --prompt-file %s", segment.File), err)
+			}
+			text = string(data)
+		}
+		parts = append(parts, strings.TrimSpace(text))
+	}
+	parts = slices.DeleteFunc(parts, func(part string) bool { return part == "" })
+	if len(parts) == 0 {
+		return "", errors.NewValidationError("the prompt is empty: give a non-blank prompt argument, --prompt-part or --prompt-file, or none of them to use the default", nil)
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// fromTaskPromptFiles are the files, in order of preference, whose body
+// --from-task uses as the prompt.
+var fromTaskPromptFiles = []string{"task.md", "prompt.txt"}
+
+// fromTask is what spawn --from-task derives from a task directory.
+type fromTask struct {
+	Name   string // worker name: the task directory's leaf name
+	Prompt string // body of the task's prompt file, empty if it has none
+}
+
+// resolveFromTask locates taskPath under yakPath and reads its prompt file.
+// A task that is already assigned is rejected unless force is set.
+func resolveFromTask(yakPath, taskPath string, force bool) (*fromTask, error) {
+	taskDir, err := findTaskDir(yakPath, types.SlugifyTaskPath(taskPath))
+	if err != nil {
+		return nil, err
+	}
+
+	if assignee, err := tasks.ReadAssignee(taskDir); err == nil && assignee != "" {
+		if !force {
+			return nil, errors.NewValidationError(
+				fmt.Sprintf("task %q is already assigned to %s. Suggestion: Stop that worker first, or pass --force to reassign it", taskPath, assignee), nil)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: reassigning task %s from %s\n", taskPath, assignee)
+	}
+
+	result := &fromTask{Name: filepath.Base(taskDir)}
+	for _, name := range fromTaskPromptFiles {
+		data, err := os.ReadFile(filepath.Join(taskDir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s for task %s: %w", name, taskPath, err)
+		}
+		result.Prompt = strings.TrimSpace(string(data))
+		break
+	}
+	if result.Prompt == "" {
+		fmt.Fprintf(os.Stderr, "Warning: task %s has no %s; using the default prompt\n", taskPath, strings.Join(fromTaskPromptFiles, " or "))
+	}
+	return result, nil
+}
+
+// assignTask assigns the task found at taskDir by findTaskDir to worker
+func assignTask(yakPath, taskDir, worker, worktreePath string) error {
+	rel, err := filepath.Rel(yakPath, taskDir)
+	if err != nil {
+		return err
+	}
+	return tasks.Assign(yakPath, rel, worker, worktreePath)
+}
+
+// unassignTask clears the assignee of the task found at taskDir by findTaskDir
+func unassignTask(yakPath, taskDir string) error {
+	rel, err := filepath.Rel(yakPath, taskDir)
+	if err != nil {
+		return err
+	}
+	return tasks.Unassign(yakPath, rel)
+}
+
 // findTaskDir searches the .yaks/ tree for a directory matching the task slug.
 // Tasks can be nested (e.g., "release-yakthang/yak-box/missing-tab-emoji"),
 // so we walk the tree looking for a directory whose base name matches the slug.
+// When several directories share the leaf name it returns a validation error
+// listing them rather than guessing.
 func findTaskDir(yakPath, taskSlug string) (string, error) {
-	// If the slug contains path separators, try the direct path first.
+	matches, err := findTaskDirs(yakPath, taskSlug)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) > 1 {
+		var b strings.Builder
+		fmt.Fprintf(&b, "task %q matches %d directories under %s; use a fuller path:", taskSlug, len(matches), yakPath)
+		for _, match := range matches {
+			rel, relErr := filepath.Rel(yakPath, match)
+			if relErr != nil {
+				rel = match
+			}
+			fmt.Fprintf(&b, "\n  - %s", filepath.ToSlash(rel))
+		}
+		return "", errors.NewValidationError(b.String(), nil)
+	}
+	return matches[0], nil
+}
+
+// findTaskDirs returns every directory the task slug could refer to. A slug
+// that names an existing directory relative to yakPath is returned alone;
+// otherwise all directories with the same leaf name are returned, sorted.
+func findTaskDirs(yakPath, taskSlug string) ([]string, error) {
 	directPath := filepath.Join(yakPath, taskSlug)
 	if info, err := os.Stat(directPath); err == nil && info.IsDir() {
-		return directPath, nil
+		return []string{directPath}, nil
 	}
 
-	// Otherwise, search for a directory with a matching leaf name.
 	leafName := filepath.Base(taskSlug)
+	ignore := loadYakIgnore(yakPath)
 	var matches []string
 	filepath.Walk(yakPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
+		if err != nil || path == yakPath || !info.IsDir() {
 			return nil
 		}
-		if info.IsDir() && info.Name() == leafName && path != yakPath {
+		if isYakIgnored(ignore, yakPath, path, true) {
+			return filepath.SkipDir
+		}
+		if info.Name() == leafName {
 			matches = append(matches, path)
 		}
 		return nil
 	})
 
 	if len(matches) == 0 {
-		return "", fmt.Errorf("no directory matching %q found under %s", taskSlug, yakPath)
+		return nil, fmt.Errorf("no directory matching %q found under %s", taskSlug, yakPath)
 	}
-	if len(matches) > 1 {
-		fmt.Fprintf(os.Stderr, "Warning: multiple directories match %q, using first: %s\n", taskSlug, matches[0])
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// checkTaskDirsUnambiguous fails if any task resolves to more than one
+// directory. Tasks that match nothing are left for the caller to warn about.
+func checkTaskDirsUnambiguous(yakPath string, tasks []string) error {
+	for _, task := range tasks {
+		_, err := findTaskDir(yakPath, types.SlugifyTaskPath(task))
+		var validationErr *errors.ValidationError
+		if goerrors.As(err, &validationErr) {
+			return err
+		}
 	}
-	return matches[0], nil
+	return nil
 }
 
+// yakIgnoreFile holds gitignore-style patterns, relative to the .yaks root,
+// for directories that task lookups and status walks should skip.
+const yakIgnoreFile = ".yakignore"
+
+// loadYakIgnore loads the .yakignore file at the root of the .yaks tree.
+// A malformed or unreadable file is reported and treated as empty.
+func loadYakIgnore(yakRoot string) *pathutil.IgnoreMatcher {
+	ignore, err := pathutil.LoadIgnoreFile(filepath.Join(yakRoot, yakIgnoreFile))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read %s: %v\n", yakIgnoreFile, err)
+		return nil
+	}
+	return ignore
+}
+
+// isYakIgnored reports whether path, found while walking yakRoot, matches .yakignore.
+func isYakIgnored(ignore *pathutil.IgnoreMatcher, yakRoot, path string, isDir bool) bool {
+	rel, err := filepath.Rel(yakRoot, path)
+	if err != nil {
+		return false
+	}
+	return ignore.Match(rel, isDir)
+}
+
+// yakPathSearchDepth is how many parent directories findYakPath checks above
+// the start directory, so a deeply nested --cwd cannot pick up an unrelated
+// .yaks far up the tree.
+const yakPathSearchDepth = 20
+
 // findYakPath walks up from startDir looking for a directory named yakDirName,
 // similar to how git finds .git. Returns the full path if found, error if not.
 func findYakPath(startDir string, yakDirName string) (string, error) {
+	return findYakPathWithDepth(startDir, yakDirName, yakPathSearchDepth)
+}
+
+// findYakPathWithDepth is findYakPath limited to startDir and at most maxDepth
+// of its parents. The error lists every directory that was searched.
+func findYakPathWithDepth(startDir, yakDirName string, maxDepth int) (string, error) {
+	var searched []string
 	dir := startDir
-	for {
+	for depth := 0; depth <= maxDepth; depth++ {
+		searched = append(searched, dir)
 		candidate := filepath.Join(dir, yakDirName)
 		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
 			return candidate, nil
 		}
 		parent := filepath.Dir(dir)
 		if parent == dir {
-			break
+			return "", fmt.Errorf("no %s directory found above %s (searched %s) — use --yak-path to specify", yakDirName, startDir, strings.Join(searched, ", "))
 		}
 		dir = parent
 	}
-	return "", fmt.Errorf("no .yaks directory found above %s — use --yak-path to specify", startDir)
+	return "", fmt.Errorf("no %s directory found above %s within %d parent directories (searched %s) — use --yak-path to specify", yakDirName, startDir, maxDepth, strings.Join(searched, ", "))
 }
 
 func resolveInheritedWorktrees(absYakPath, taskPath string) ([]string, string, error) {
@@ -513,6 +1358,205 @@ func resolveInheritedWorktrees(absYakPath, taskPath string) ([]string, string, e
 	return nil, "", nil
 }
 
+// taskEnvFile is the optional dotenv file in a task directory whose
+// variables are injected into the containers of that task and its subtasks.
+const taskEnvFile = "env"
+
+// resolveInheritedEnv merges the env files found in the task's directory and
+// each ancestor up to absYakPath, like resolveInheritedWorktrees. A subtask's
+// value overrides its parent's, and sensitive-looking variables are dropped.
+func resolveInheritedEnv(absYakPath, taskPath string) (map[string]string, error) {
+	taskDir, err := findTaskDir(absYakPath, types.SlugifyTaskPath(taskPath))
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	for dir := taskDir; ; {
+		dirs = append(dirs, dir)
+		parent := filepath.Dir(dir)
+		if dir == absYakPath || parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	merged := make(map[string]string)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		envPath := filepath.Join(dirs[i], taskEnvFile)
+		if info, err := os.Stat(envPath); err != nil || info.IsDir() {
+			continue
+		}
+		vars, err := env.ParseEnvFile(envPath)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(merged, vars)
+	}
+	if len(merged) == 0 {
+		return merged, nil
+	}
+	return env.FilterSensitive(merged), nil
+}
+
+// resolveSessionName returns the Zellij session to spawn into: the --session
+// value when set, otherwise the session yak-box is running in (if any).
+func resolveSessionName(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	return os.Getenv("ZELLIJ_SESSION_NAME")
+}
+
+// resolveHomeDir returns the worker home: the --home-dir override when set
+// (made absolute and created), otherwise the persona home under .yak-boxes/@home.
+func resolveHomeDir(override, workerName string) (string, error) {
+	if override == "" {
+		homeDir, err := sessions.EnsureHomeDir(workerName)
+		if err != nil {
+			return "", fmt.Errorf("failed to ensure home directory: %w. Suggestion: Check that .yak-boxes directory exists and is writable", err)
+		}
+		return homeDir, nil
+	}
+
+	homeDir, err := filepath.Abs(override)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve --home-dir: %w. Suggestion: Ensure --home-dir path is valid", err)
+	}
+	if err := sessions.PrepareHomeDir(homeDir); err != nil {
+		return "", fmt.Errorf("failed to prepare --home-dir: %w. Suggestion: Ensure the path is a writable directory", err)
+	}
+	return homeDir, nil
+}
+
+// parseLabels turns --label KEY=VALUE flags into the session's labels, or nil
+// when there are none. A later label replaces an earlier one with the same key.
+func parseLabels(flags []string) (map[string]string, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		key, value, _ := strings.Cut(flag, "=")
+		if key == "" || strings.ContainsFunc(key, unicode.IsSpace) {
+			return nil, fmt.Errorf("label %q must be KEY=VALUE with a non-empty key and no spaces in it", flag)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// sessionExpiry returns when a session spawned at spawnedAt expires, or the
+// zero time when no TTL was requested.
+func sessionExpiry(spawnedAt time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return spawnedAt.Add(ttl)
+}
+
+// checkStrictSecurity fails with a ValidationError listing every critical
+// security warning in the devcontainer config, for use with --strict-security.
+func checkStrictSecurity(devConfig *devcontainer.Config) error {
+	critical := devcontainer.FilterCritical(devcontainer.ValidateSecurityConfig(devConfig))
+	if len(critical) == 0 {
+		return nil
+	}
+
+	msg := "devcontainer config has critical security warnings (--strict-security):\n"
+	for _, warning := range critical {
+		msg += fmt.Sprintf("  - %s\n", warning.Message)
+	}
+	msg += "Suggestion: Remove the flagged settings from devcontainer.json, or spawn without --strict-security"
+	return errors.NewValidationError(msg, nil)
+}
+
+// containerIDOverride parses the --uid or --gid value, falling back to the
+// env variable when the flag is empty. It returns nil when neither is set,
+// leaving the container on the host user's id.
+func containerIDOverride(flag, value, env string) (*int, error) {
+	source := flag
+	if value == "" {
+		value, source = os.Getenv(env), env
+	}
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative integer, got %q", source, value)
+	}
+	return &id, nil
+}
+
+// loadEnvFiles parses each --env-file in order, so later files override
+// earlier ones, and drops sensitive variables from the merged result.
+func loadEnvFiles(paths []string) (map[string]string, error) {
+	merged := make(map[string]string)
+	for _, path := range paths {
+		vars, err := env.ParseEnvFile(path)
+		if err != nil {
+			return nil, errors.NewValidationError(fmt.Sprintf("--env-file %q is invalid", path), err)
+		}
+		for k, v := range vars {
+			merged[k] = v
+		}
+	}
+	if len(merged) == 0 {
+		return merged, nil
+	}
+	return env.FilterSensitive(merged), nil
+}
+
+// parseSetEnv parses each --set-env KEY=VALUE in order, so a later value for
+// the same key wins. Sensitive variables are dropped unless allowSensitive.
+func parseSetEnv(assignments []string, allowSensitive bool) (map[string]string, error) {
+	vars := make(map[string]string, len(assignments))
+	for _, assignment := range assignments {
+		key, value, err := env.ParseAssignment(assignment)
+		if err != nil {
+			return nil, errors.NewValidationError("invalid --set-env", err)
+		}
+		vars[key] = value
+	}
+	if len(vars) == 0 || allowSensitive {
+		return vars, nil
+	}
+	return env.FilterSensitive(vars), nil
+}
+
+// checkDevConfigValid returns a ValidationError listing every structural
+// problem devcontainer.Validate finds in devConfig.
+func checkDevConfigValid(devConfig *devcontainer.Config) error {
+	problems := devcontainer.Validate(devConfig)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	msg := "invalid devcontainer config:\n"
+	for _, problem := range problems {
+		msg += fmt.Sprintf("  - %s\n", problem)
+	}
+	msg += "Suggestion: Fix the listed fields in .devcontainer/devcontainer.json"
+	return errors.NewValidationError(msg, nil)
+}
+
+// checkLockPinned returns a ValidationError listing every devcontainer feature
+// that is not pinned by digest in devcontainer-lock.json.
+func checkLockPinned(devConfig *devcontainer.Config, lock *devcontainer.LockFile) error {
+	problems := devcontainer.VerifyLock(devConfig, lock)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	msg := "devcontainer features are not pinned by digest (--verify-lock):\n"
+	for _, problem := range problems {
+		msg += fmt.Sprintf("  - %s\n", problem)
+	}
+	msg += "Suggestion: Regenerate .devcontainer/devcontainer-lock.json with digests, or spawn without --verify-lock"
+	return errors.NewValidationError(msg, nil)
+}
+
 // copySkillsToHome copies each skill folder into the tool-appropriate location under homeDir.
 // For Claude: <homeDir>/.claude/skills/<skill-folder-name>/
 func copySkillsToHome(skillPaths []string, homeDir string, tool string) error {
@@ -583,13 +1627,50 @@ func init() {
 
 	spawnCmd.Flags().StringVar(&spawnMode, "mode", "build", "Agent mode: 'plan' or 'build'")
 	spawnCmd.Flags().StringVar(&spawnResources, "resources", "default", "Resource profile: 'light', 'default', 'heavy', or 'ram'")
+	spawnCmd.Flags().StringVar(&spawnCPUs, "cpus", "", "Override the profile's CPU limit (e.g., '1.5')")
+	spawnCmd.Flags().StringVar(&spawnMemory, "memory", "", "Override the profile's memory limit (e.g., '512m', '3g')")
+	spawnCmd.Flags().StringVar(&spawnSwap, "swap", "", "Override the profile's memory+swap limit (e.g., '4g', or -1 for unlimited); a --memory above the profile's limit raises it to match")
+	spawnCmd.Flags().IntVar(&spawnPIDs, "pids", 0, "Override the profile's process limit")
+	spawnCmd.Flags().StringVar(&spawnTmpfsSize, "tmpfs-size", "", "Override the size of the container's /tmp tmpfs (e.g., '4g')")
 	spawnCmd.Flags().StringSliceVar(&spawnYaks, "yaks", []string{}, "Yak paths from .yaks/ to assign (can be repeated)")
 	spawnCmd.Flags().StringSliceVar(&spawnYaks, "task", []string{}, "Alias for --yaks")
+	spawnCmd.Flags().StringVar(&spawnFromTask, "from-task", "", "Task path whose leaf name, assignment and task.md/prompt.txt supply --name, --yaks and the prompt")
+	spawnCmd.Flags().BoolVar(&spawnForce, "force", false, "With --from-task, reassign a task that already has an assigned-to")
+	spawnCmd.Flags().BoolVar(&spawnReplace, "replace", false, "Stop and unregister an existing worker with the same --name before spawning")
 	spawnCmd.Flags().StringVar(&spawnYakPath, "yak-path", ".yaks", "Path to task state directory")
 	spawnCmd.Flags().StringVar(&spawnRuntime, "runtime", "auto", "Runtime: 'auto', 'sandboxed', or 'native'")
 	spawnCmd.Flags().StringVar(&spawnTool, "tool", "claude", "AI tool: 'opencode', 'claude', or 'cursor'")
-	spawnCmd.Flags().StringVar(&spawnModel, "model", "", "Optional model override (defaults: claude='default', cursor='auto')")
-	spawnCmd.Flags().BoolVar(&spawnClean, "clean", false, "Clean worker home directory before spawning")
+	spawnCmd.Flags().StringVar(&spawnModel, "model", "", "Optional model override (defaults: claude='default', cursor='auto'; opencode uses 'provider/model')")
+	spawnCmd.Flags().BoolVar(&spawnClean, "clean", false, "Clean worker home directory before spawning (ignored with --home-dir)")
 	spawnCmd.Flags().BoolVar(&spawnAutoWorktree, "auto-worktree", false, "Automatically create and use git worktree for the task")
+	spawnCmd.Flags().StringVar(&spawnWorktreeBase, "worktree-base", "", "Branch new worktree branches, from --auto-worktree or a task's worktrees field, off this branch instead of the current HEAD (e.g., 'main')")
+	spawnCmd.Flags().Var(promptSegmentFlag{segments: &spawnPromptParts}, "prompt-part", "Text appended to the prompt after a blank line, in command-line order (can be repeated)")
+	spawnCmd.Flags().Var(promptSegmentFlag{segments: &spawnPromptParts, file: true}, "prompt-file", "File whose contents are appended to the prompt like --prompt-part (can be repeated)")
 	spawnCmd.Flags().StringArrayVar(&spawnSkills, "skill", []string{}, "Path to a skill folder to copy into the worker's home (can be repeated)")
+	spawnCmd.Flags().BoolVar(&spawnInit, "init", false, "Run an init process in the container to reap zombies (overrides devcontainer init)")
+	spawnCmd.Flags().BoolVar(&spawnStrictSec, "strict-security", false, "Abort the spawn if the devcontainer config has any critical security warning")
+	spawnCmd.Flags().BoolVar(&spawnVerifyLock, "verify-lock", false, "Abort the spawn unless every devcontainer feature is pinned by digest in devcontainer-lock.json")
+	spawnCmd.Flags().BoolVar(&spawnAllowUnsafe, "allow-unsafe-security", false, "Allow devcontainer capAdd/securityOpt settings flagged as critical security risks")
+	spawnCmd.Flags().BoolVar(&spawnNoCapDrop, "no-cap-drop", false, "Do not pass --cap-drop ALL to the container (advanced; weakens the sandbox)")
+	spawnCmd.Flags().StringArrayVar(&spawnLabels, "label", []string{}, "Record a KEY=VALUE label on the worker's session, for filtering sessions (can be repeated)")
+	spawnCmd.Flags().StringVar(&spawnTTL, "ttl", "", "Expire the session after this duration so 'yak-box reap' stops it (e.g., '8h', '2d')")
+	spawnCmd.Flags().StringVar(&spawnReadyTimeout, "ready-timeout", "30s", "How long the container shell pane waits for the container to start (e.g., '30s', '2m')")
+	spawnCmd.Flags().StringVar(&spawnStopTimeout, "container-stop-timeout", "300s", "How long docker waits for the container to exit after SIGTERM before killing it, when stopped without -t (e.g., '300s', '10m')")
+	spawnCmd.Flags().BoolVar(&spawnPinPersona, "pin-persona", false, "Pin the chosen persona to the first --task so respawns reuse it (stored in .yak-boxes/bindings.json)")
+	spawnCmd.Flags().StringVar(&spawnHomeDir, "home-dir", "", "Use this directory as the worker home instead of .yak-boxes/@home/<persona>")
+	spawnCmd.Flags().IntVar(&spawnMaxDepth, "max-depth", runtime.DefaultMaxDepth, "Refuse to spawn from inside a worker nested this deep (workers set "+runtime.DepthEnv+")")
+	spawnCmd.Flags().IntVar(&spawnCount, "count", 1, "Spawn this many workers on the same tasks, named <name>-1..<name>-N, each with its own persona, home and --auto-worktree branch")
+	spawnCmd.Flags().BoolVar(&spawnFollow, "follow", false, "After spawning, stream the worker's output until Ctrl-C (the worker keeps running)")
+	spawnCmd.Flags().BoolVar(&spawnNoZellij, "no-zellij", false, "Start the sandboxed container detached instead of in a Zellij tab (for hosts without Zellij)")
+	spawnCmd.Flags().StringVar(&spawnScriptsDir, "scripts-dir", "", "Write the worker's prompt, run scripts, layout and log here instead of <home>/scripts")
+	spawnCmd.Flags().StringArrayVar(&spawnAllowMounts, "allow-mount", []string{}, "Additional host directory that devcontainer mounts may bind from (can be repeated)")
+	spawnCmd.Flags().StringArrayVar(&spawnSetEnv, "set-env", []string{}, "Set KEY=VALUE in the sandboxed container, overriding devcontainer containerEnv/remoteEnv and --env-file (can be repeated)")
+	spawnCmd.Flags().BoolVar(&spawnEnvSensitive, "env-allow-sensitive", false, "Keep --set-env variables that look like secrets (e.g. *_TOKEN) instead of dropping them")
+	spawnCmd.Flags().StringArrayVar(&spawnEnvFiles, "env-file", []string{}, "Dotenv file of variables to inject into the sandboxed container; later files override earlier ones (can be repeated)")
+	spawnCmd.Flags().BoolVar(&spawnNoAuthMount, "no-auth-mount", false, "Do not mount the host's OpenCode auth.json into the sandboxed container")
+	spawnCmd.Flags().StringVar(&spawnImage, "image", "", "Prebuilt image to run instead of yak-worker:latest or the devcontainer image (skips the image build)")
+	spawnCmd.Flags().StringVar(&spawnUID, "uid", "", "Run the container as this uid instead of the host user's (env: "+runtime.ContainerUIDEnv+")")
+	spawnCmd.Flags().StringVar(&spawnGID, "gid", "", "Run the container with this gid instead of the host user's (env: "+runtime.ContainerGIDEnv+")")
+	spawnCmd.Flags().StringVar(&spawnContainerWS, "container-workspace", "", "Mount the workspace at this container path (e.g. '/workspace') instead of its host path")
+	spawnCmd.Flags().StringVar(&spawnCredFile, "credential-file", "", "File whose contents are injected as OPENCODE_API_KEY in the sandboxed container (read at start-up, never logged)")
 }
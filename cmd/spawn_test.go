@@ -1,15 +1,26 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/runtime"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+	"github.com/wellmaintained/yak-box/pkg/devcontainer"
 	"github.com/wellmaintained/yak-box/pkg/types"
+	"github.com/wellmaintained/yak-box/pkg/worktree"
 )
 
 func TestSpawnFlags(t *testing.T) {
@@ -57,6 +68,11 @@ func TestFormatDisplayName(t *testing.T) {
 		displayName := formatDisplayName("Yakov", "   ")
 		assert.Equal(t, "Yakov", displayName)
 	})
+
+	t.Run("strips control characters", func(t *testing.T) {
+		displayName := formatDisplayName("Yakov", "api \"v2\"\n\x1b[31m")
+		assert.Equal(t, `Yakov 🪒🦬 api "v2"[31m`, displayName)
+	})
 }
 
 func TestSpawnValidation(t *testing.T) {
@@ -230,6 +246,140 @@ func TestSpawnToolOptions(t *testing.T) {
 	})
 }
 
+func TestValidateSpawnModel(t *testing.T) {
+	tests := []struct {
+		tool    string
+		model   string
+		wantErr bool
+	}{
+		{tool: "opencode", model: "", wantErr: false},
+		{tool: "opencode", model: "anthropic/claude-sonnet-4-5", wantErr: false},
+		{tool: "opencode", model: "sonnet", wantErr: true},
+		{tool: "opencode", model: "/sonnet", wantErr: true},
+		{tool: "claude", model: "haiku", wantErr: false},
+		{tool: "claude", model: "claude-opus-4-1", wantErr: false},
+		{tool: "claude", model: "gpt-4o", wantErr: true},
+		{tool: "claude", model: "anthropic/claude-sonnet-4-5", wantErr: true},
+		{tool: "cursor", model: "auto", wantErr: false},
+		{tool: "cursor", model: "openai/gpt-5", wantErr: true},
+		{tool: "claude", model: "son net", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool+"/"+tt.model, func(t *testing.T) {
+			err := validateSpawnModel(tt.tool, tt.model)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "--model")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckDevConfigValid(t *testing.T) {
+	err := checkDevConfigValid(&devcontainer.Config{
+		ForwardPorts:   []interface{}{float64(3000), 1.5},
+		ShutdownAction: "explode",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 2, errors.GetExitCode(err))
+	assert.Contains(t, err.Error(), "forwardPorts[1]")
+	assert.Contains(t, err.Error(), "shutdownAction")
+
+	assert.NoError(t, checkDevConfigValid(&devcontainer.Config{ForwardPorts: []interface{}{float64(3000)}}))
+	assert.NoError(t, checkDevConfigValid(nil))
+}
+
+func TestCheckLockPinned(t *testing.T) {
+	cfg := &devcontainer.Config{Features: map[string]interface{}{
+		"ghcr.io/devcontainers/features/go:1": map[string]interface{}{},
+	}}
+
+	t.Run("unpinned feature rejected", func(t *testing.T) {
+		lock := &devcontainer.LockFile{Features: map[string]devcontainer.LockedFeature{
+			"ghcr.io/devcontainers/features/go:1": {Version: "1.2.3", Resolved: "ghcr.io/devcontainers/features/go:1.2.3"},
+		}}
+		err := checkLockPinned(cfg, lock)
+		assert.Error(t, err)
+		assert.Equal(t, 2, errors.GetExitCode(err))
+		assert.Contains(t, err.Error(), "features/go:1")
+		assert.Contains(t, err.Error(), "--verify-lock")
+	})
+
+	t.Run("pinned feature allowed", func(t *testing.T) {
+		lock := &devcontainer.LockFile{Features: map[string]devcontainer.LockedFeature{
+			"ghcr.io/devcontainers/features/go:1": {Version: "1.2.3", Resolved: "ghcr.io/devcontainers/features/go@sha256:abc123"},
+		}}
+		assert.NoError(t, checkLockPinned(cfg, lock))
+		assert.NoError(t, checkLockPinned(nil, nil))
+	})
+}
+
+func TestCheckStrictSecurity(t *testing.T) {
+	privileged := true
+
+	t.Run("privileged config rejected", func(t *testing.T) {
+		err := checkStrictSecurity(&devcontainer.Config{Privileged: &privileged, CapAdd: []string{"SYS_ADMIN"}})
+		assert.Error(t, err)
+		assert.Equal(t, 2, errors.GetExitCode(err))
+		assert.Contains(t, err.Error(), "privileged mode")
+		assert.Contains(t, err.Error(), "SYS_ADMIN")
+	})
+
+	t.Run("safe config allowed", func(t *testing.T) {
+		assert.NoError(t, checkStrictSecurity(&devcontainer.Config{Image: "ubuntu:22.04"}))
+		assert.NoError(t, checkStrictSecurity(nil))
+	})
+
+	t.Run("conflicts with allow-unsafe-security", func(t *testing.T) {
+		cmd := &cobra.Command{}
+		cmd.Flags().AddFlagSet(spawnCmd.Flags())
+		spawnName, spawnStrictSec, spawnAllowUnsafe = "test", true, true
+		t.Cleanup(func() { spawnName, spawnStrictSec, spawnAllowUnsafe = "", false, false })
+
+		err := spawnCmd.PreRunE(cmd, []string{})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "mutually exclusive")
+	})
+
+	t.Run("conflicts with no-cap-drop", func(t *testing.T) {
+		cmd := &cobra.Command{}
+		cmd.Flags().AddFlagSet(spawnCmd.Flags())
+		spawnName, spawnStrictSec, spawnNoCapDrop = "test", true, true
+		t.Cleanup(func() { spawnName, spawnStrictSec, spawnNoCapDrop = "", false, false })
+
+		err := spawnCmd.PreRunE(cmd, []string{})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "--no-cap-drop are mutually exclusive")
+	})
+}
+
+func TestSpawnResourceOverrideValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(spawnCmd.Flags())
+	t.Cleanup(func() {
+		spawnName, spawnCPUs, spawnMemory, spawnSwap, spawnPIDs, spawnTmpfsSize = "", "", "", "", 0, ""
+	})
+
+	spawnName, spawnCPUs, spawnMemory, spawnSwap, spawnPIDs, spawnTmpfsSize = "test", "fast", "3gb", "-2", -5, "huge"
+	err := spawnCmd.PreRunE(cmd, []string{})
+	require.Error(t, err)
+	for _, flag := range []string{"--cpus", "--memory", "--swap", "--pids", "--tmpfs-size"} {
+		assert.Contains(t, err.Error(), flag)
+	}
+
+	spawnCPUs, spawnMemory, spawnSwap, spawnPIDs, spawnTmpfsSize = "1.5", "3g", "6g", 256, "4g"
+	assert.NoError(t, spawnCmd.PreRunE(cmd, []string{}))
+	profile := spawnResourceOverrides().Apply(runtime.GetResourceProfile("light"))
+	assert.Equal(t, "1.5", profile.CPUs)
+	assert.Equal(t, "3g", profile.Memory)
+	assert.Equal(t, "6g", profile.Swap)
+	assert.Equal(t, 256, profile.PIDs)
+	assert.Contains(t, profile.Tmpfs["/tmp"], "size=4g,")
+}
+
 func TestResolveSpawnModel(t *testing.T) {
 	t.Run("respects explicit model override", func(t *testing.T) {
 		assert.Equal(t, "haiku", resolveSpawnModel("claude", "haiku"))
@@ -375,6 +525,75 @@ func TestFindTaskDir(t *testing.T) {
 	})
 }
 
+func TestFindTaskDirAmbiguity(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := filepath.Join(tmpDir, "release", "docs")
+	second := filepath.Join(tmpDir, "fixes", "docs")
+	require.NoError(t, os.MkdirAll(first, 0755))
+	require.NoError(t, os.MkdirAll(second, 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "fixes", "unique"), 0755))
+
+	t.Run("no match", func(t *testing.T) {
+		matches, err := findTaskDirs(tmpDir, "missing")
+		assert.Error(t, err)
+		assert.Empty(t, matches)
+	})
+
+	t.Run("single match", func(t *testing.T) {
+		dir, err := findTaskDir(tmpDir, "unique")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(tmpDir, "fixes", "unique"), dir)
+	})
+
+	t.Run("many matches is a validation error listing candidates", func(t *testing.T) {
+		matches, err := findTaskDirs(tmpDir, "docs")
+		require.NoError(t, err)
+		assert.Equal(t, []string{second, first}, matches)
+
+		_, err = findTaskDir(tmpDir, "docs")
+		require.Error(t, err)
+		assert.Equal(t, 2, errors.GetExitCode(err))
+		assert.Contains(t, err.Error(), "fixes/docs")
+		assert.Contains(t, err.Error(), "release/docs")
+	})
+
+	t.Run("full path bypasses leaf search", func(t *testing.T) {
+		dir, err := findTaskDir(tmpDir, "release/docs")
+		require.NoError(t, err)
+		assert.Equal(t, first, dir)
+	})
+
+	t.Run("checkTaskDirsUnambiguous", func(t *testing.T) {
+		assert.NoError(t, checkTaskDirsUnambiguous(tmpDir, []string{"unique", "missing", "release/docs"}))
+		assert.Error(t, checkTaskDirsUnambiguous(tmpDir, []string{"unique", "docs"}))
+	})
+}
+
+func TestFindTaskDirYakIgnore(t *testing.T) {
+	tmpDir := t.TempDir()
+	task := filepath.Join(tmpDir, "release", "config")
+	require.NoError(t, os.MkdirAll(task, 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "release", "node_modules", "pkg", "config"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "archive", "config"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, yakIgnoreFile), []byte("node_modules/\n/archive\n"), 0644))
+
+	oldStderr := os.Stderr
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stderr = w
+	dir, err := findTaskDir(tmpDir, "config")
+	w.Close()
+	os.Stderr = oldStderr
+	stderr, _ := io.ReadAll(r)
+
+	require.NoError(t, err)
+	assert.Equal(t, task, dir)
+	assert.NotContains(t, string(stderr), "multiple directories match")
+
+	_, err = findTaskDir(tmpDir, "pkg")
+	assert.Error(t, err, "directories inside ignored subtrees should not match")
+}
+
 func TestFindYakPath(t *testing.T) {
 	// Create a nested dir structure:
 	// tmpDir/
@@ -408,6 +627,42 @@ func TestFindYakPath(t *testing.T) {
 	})
 }
 
+func TestFindYakPathWithDepth(t *testing.T) {
+	tmpDir := t.TempDir()
+	yakDir := filepath.Join(tmpDir, ".yaks")
+	require.NoError(t, os.MkdirAll(yakDir, 0755))
+	deepDir := filepath.Join(tmpDir, "a", "b", "c")
+	require.NoError(t, os.MkdirAll(deepDir, 0755))
+
+	t.Run("finds .yaks within depth", func(t *testing.T) {
+		got, err := findYakPathWithDepth(deepDir, ".yaks", 3)
+		require.NoError(t, err)
+		assert.Equal(t, yakDir, got)
+	})
+
+	t.Run("fails beyond depth", func(t *testing.T) {
+		_, err := findYakPathWithDepth(deepDir, ".yaks", 2)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "within 2 parent directories")
+	})
+
+	t.Run("error lists the search path", func(t *testing.T) {
+		_, err := findYakPathWithDepth(deepDir, ".yaks", 1)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "searched "+deepDir+", "+filepath.Join(tmpDir, "a", "b")+")")
+		assert.NotContains(t, err.Error(), filepath.Join(tmpDir, "a")+",")
+	})
+
+	t.Run("depth zero checks only the start dir", func(t *testing.T) {
+		got, err := findYakPathWithDepth(tmpDir, ".yaks", 0)
+		require.NoError(t, err)
+		assert.Equal(t, yakDir, got)
+
+		_, err = findYakPathWithDepth(filepath.Join(tmpDir, "a"), ".yaks", 0)
+		assert.Error(t, err)
+	})
+}
+
 func TestResolveInheritedWorktrees(t *testing.T) {
 	workspace := t.TempDir()
 	absYakPath := filepath.Join(workspace, ".yaks")
@@ -468,6 +723,70 @@ func TestResolveInheritedWorktrees(t *testing.T) {
 	})
 }
 
+func TestResolveInheritedEnv(t *testing.T) {
+	workspace := t.TempDir()
+	absYakPath := filepath.Join(workspace, ".yaks")
+	taskDir := filepath.Join(absYakPath, "sc-12345", "child-task")
+	assert.NoError(t, os.MkdirAll(taskDir, 0755))
+	assert.NoError(t, os.WriteFile(
+		filepath.Join(absYakPath, "sc-12345", "env"),
+		[]byte("PROJECT=release\nLOG_LEVEL=info\nDEPLOY_TOKEN=hunter2\n"),
+		0644,
+	))
+
+	t.Run("inherits env from ancestor", func(t *testing.T) {
+		got, err := resolveInheritedEnv(absYakPath, "sc-12345/child-task")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"PROJECT": "release", "LOG_LEVEL": "info"}, got, "sensitive variables are filtered")
+	})
+
+	t.Run("child overrides ancestor", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(filepath.Join(taskDir, "env"), []byte("LOG_LEVEL=debug\n"), 0644))
+		t.Cleanup(func() { os.Remove(filepath.Join(taskDir, "env")) })
+
+		got, err := resolveInheritedEnv(absYakPath, "sc-12345/child-task")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"PROJECT": "release", "LOG_LEVEL": "debug"}, got)
+	})
+
+	t.Run("returns no env when file is absent", func(t *testing.T) {
+		emptyYakPath := filepath.Join(workspace, ".empty-yaks")
+		assert.NoError(t, os.MkdirAll(filepath.Join(emptyYakPath, "sc-54321", "child-task"), 0755))
+
+		got, err := resolveInheritedEnv(emptyYakPath, "sc-54321/child-task")
+		assert.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("returns an error for a malformed env file", func(t *testing.T) {
+		badYakPath := filepath.Join(workspace, ".bad-yaks")
+		assert.NoError(t, os.MkdirAll(filepath.Join(badYakPath, "sc-99999", "child-task"), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(badYakPath, "sc-99999", "env"), []byte("PROJECT=release\nnot an assignment\n"), 0644))
+
+		_, err := resolveInheritedEnv(badYakPath, "sc-99999/child-task")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), ":2:")
+	})
+}
+
+func TestPickWorkerNameInvalidPersonasFile(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", repo).Run())
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(repo))
+
+	assert.True(t, personasFileMissing(), "no personas.txt means a silent fallback")
+
+	yakBoxes := filepath.Join(repo, ".yak-boxes")
+	require.NoError(t, os.MkdirAll(yakBoxes, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(yakBoxes, sessions.PersonasFile), []byte("Yakira\nbad/name\n"), 0644))
+
+	assert.False(t, personasFileMissing(), "a broken personas.txt must be warned about")
+	assert.Contains(t, types.WorkerNames, pickWorkerName(), "falls back to a built-in persona")
+}
+
 func TestPickWorkerNameRoundRobin(t *testing.T) {
 	// Run from a temp git repo so GetYakBoxesDir() succeeds and we use .last-persona
 	tmpDir := t.TempDir()
@@ -485,7 +804,7 @@ func TestPickWorkerNameRoundRobin(t *testing.T) {
 	assert.NoError(t, os.Chdir(gitDir))
 
 	// Remove .last-persona if present so we start from a known state
-	_ = os.Remove(filepath.Join(gitDir, ".yak-boxes", lastPersonaFile))
+	_ = os.Remove(filepath.Join(gitDir, ".yak-boxes", sessions.LastPersonaFile))
 
 	var names []string
 	for i := 0; i < 8; i++ {
@@ -511,6 +830,86 @@ func TestPickWorkerNameRoundRobin(t *testing.T) {
 	for i := 0; i < 4; i++ {
 		assert.Equal(t, names[i], names[i+4], "round-robin should repeat after 4")
 	}
+
+	t.Run("concurrent picks get distinct personas", func(t *testing.T) {
+		n := len(types.WorkerNames)
+		picked := make([]string, n)
+		var start, done sync.WaitGroup
+		start.Add(1)
+		for i := 0; i < n; i++ {
+			done.Add(1)
+			go func(i int) {
+				defer done.Done()
+				start.Wait()
+				picked[i] = pickWorkerName()
+			}(i)
+		}
+		start.Done()
+		done.Wait()
+
+		assert.ElementsMatch(t, types.WorkerNames, picked, "simultaneous spawns within a cycle must not collide")
+	})
+}
+
+func TestPickWorkerNameForTaskBinding(t *testing.T) {
+	tmpDir := t.TempDir()
+	assert.NoError(t, exec.Command("git", "init", tmpDir).Run())
+	origWd, err := os.Getwd()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	assert.NoError(t, os.Chdir(tmpDir))
+
+	assert.NoError(t, sessions.Bind("auth/api", "Yakriel"))
+
+	t.Run("bound task always gets its persona", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, "Yakriel", pickWorkerNameForTask("auth/api"))
+		}
+		assert.Equal(t, "Yakriel", pickWorkerNameForTask("/auth/api/"))
+	})
+
+	t.Run("unbound tasks round-robin", func(t *testing.T) {
+		first := pickWorkerNameForTask("auth/web")
+		second := pickWorkerNameForTask("auth/web")
+		third := pickWorkerNameForTask("")
+		assert.NotEqual(t, first, second)
+		assert.NotEqual(t, second, third)
+	})
+}
+
+func TestResolveHomeDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	gitDir := filepath.Join(tmpDir, "repo")
+	assert.NoError(t, os.MkdirAll(gitDir, 0755))
+	assert.NoError(t, exec.Command("git", "init", gitDir).Run())
+	origWd, err := os.Getwd()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	assert.NoError(t, os.Chdir(gitDir))
+
+	t.Run("persona home by default", func(t *testing.T) {
+		homeDir, err := resolveHomeDir("", "Yakov")
+		assert.NoError(t, err)
+		assert.Equal(t, "Yakov", filepath.Base(homeDir))
+		assert.Equal(t, "@home", filepath.Base(filepath.Dir(homeDir)))
+	})
+
+	t.Run("override is created and preferred", func(t *testing.T) {
+		override := filepath.Join(tmpDir, "custom-home")
+		homeDir, err := resolveHomeDir(override, "Yakov")
+		assert.NoError(t, err)
+		assert.Equal(t, override, homeDir)
+		assert.DirExists(t, filepath.Join(override, ".local", "share", "opencode"))
+		assert.NoDirExists(t, filepath.Join(gitDir, ".yak-boxes", "@home", "Yakira"))
+	})
+
+	t.Run("override that is a file is rejected", func(t *testing.T) {
+		file := filepath.Join(tmpDir, "not-a-dir")
+		assert.NoError(t, os.WriteFile(file, []byte("x"), 0644))
+		_, err := resolveHomeDir(file, "Yakov")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not a directory")
+	})
 }
 
 func TestSpawnRuntimeOptions(t *testing.T) {
@@ -532,3 +931,713 @@ func TestSpawnRuntimeOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestContainerIDOverride(t *testing.T) {
+	t.Setenv(runtime.ContainerUIDEnv, "")
+
+	id, err := containerIDOverride("--uid", "", runtime.ContainerUIDEnv)
+	require.NoError(t, err)
+	assert.Nil(t, id, "no flag and no env keeps the host id")
+
+	t.Setenv(runtime.ContainerUIDEnv, "2000")
+	id, err = containerIDOverride("--uid", "", runtime.ContainerUIDEnv)
+	require.NoError(t, err)
+	require.NotNil(t, id)
+	assert.Equal(t, 2000, *id)
+
+	id, err = containerIDOverride("--uid", "0", runtime.ContainerUIDEnv)
+	require.NoError(t, err)
+	require.NotNil(t, id)
+	assert.Equal(t, 0, *id, "the flag wins over the env")
+
+	_, err = containerIDOverride("--uid", "-5", runtime.ContainerUIDEnv)
+	assert.ErrorContains(t, err, `--uid must be a non-negative integer, got "-5"`)
+
+	t.Setenv(runtime.ContainerUIDEnv, "root")
+	_, err = containerIDOverride("--uid", "", runtime.ContainerUIDEnv)
+	assert.ErrorContains(t, err, runtime.ContainerUIDEnv+` must be a non-negative integer, got "root"`)
+}
+
+func TestParseSetEnv(t *testing.T) {
+	vars, err := parseSetEnv([]string{"NODE_ENV=development", "GITHUB_TOKEN=ghp_x", "NODE_ENV=test", "QUERY=a=b"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"NODE_ENV": "test", "QUERY": "a=b"}, vars, "later values win and sensitive ones are dropped")
+
+	vars, err = parseSetEnv([]string{"GITHUB_TOKEN=ghp_x"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"GITHUB_TOKEN": "ghp_x"}, vars, "--env-allow-sensitive keeps the variable")
+
+	_, err = parseSetEnv([]string{"NODE_ENV"}, false)
+	require.Error(t, err)
+	assert.Equal(t, 2, errors.GetExitCode(err))
+}
+
+func TestLoadEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.env")
+	local := filepath.Join(dir, "local.env")
+	require.NoError(t, os.WriteFile(base, []byte("LOG_LEVEL=info\nREGION=eu-west-1\nDB_PASSWORD=hunter2\n"), 0644))
+	require.NoError(t, os.WriteFile(local, []byte("# overrides\nLOG_LEVEL=debug\n"), 0644))
+
+	vars, err := loadEnvFiles([]string{base, local})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "debug", "REGION": "eu-west-1"}, vars)
+
+	bad := filepath.Join(dir, "bad.env")
+	require.NoError(t, os.WriteFile(bad, []byte("NOT VALID\n"), 0644))
+	_, err = loadEnvFiles([]string{base, bad})
+	assert.Error(t, err)
+	assert.Equal(t, 2, errors.GetExitCode(err))
+	assert.Contains(t, err.Error(), "bad.env")
+
+	vars, err = loadEnvFiles(nil)
+	require.NoError(t, err)
+	assert.Empty(t, vars)
+}
+
+func TestSpawnCredentialFileValidation(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	valid := filepath.Join(dir, "api-key")
+	require.NoError(t, os.WriteFile(empty, []byte("\n"), 0600))
+	require.NoError(t, os.WriteFile(valid, []byte("sk-test\n"), 0600))
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(spawnCmd.Flags())
+	spawnName, spawnNoAuthMount = "test", true
+	t.Cleanup(func() { spawnName, spawnNoAuthMount, spawnCredFile = "", false, "" })
+
+	spawnCredFile = filepath.Join(dir, "missing")
+	err := spawnCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--credential-file")
+
+	spawnCredFile = empty
+	err = spawnCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is empty")
+
+	spawnCredFile = valid
+	err = spawnCmd.PreRunE(cmd, []string{})
+	if err != nil {
+		assert.NotContains(t, err.Error(), "--credential-file")
+	}
+}
+
+func TestSpawnNoZellijValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(spawnCmd.Flags())
+	spawnName, spawnNoZellij = "test", true
+	t.Cleanup(func() { spawnName, spawnNoZellij, spawnRuntime = "", false, "auto" })
+
+	spawnRuntime = "native"
+	err := spawnCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--no-zellij requires the sandboxed runtime")
+
+	for _, rt := range []string{"sandboxed", "auto"} {
+		spawnRuntime = rt
+		if err := spawnCmd.PreRunE(cmd, []string{}); err != nil {
+			assert.NotContains(t, err.Error(), "--no-zellij")
+		}
+	}
+}
+
+func TestResolveFromTask(t *testing.T) {
+	yakPath := t.TempDir()
+	withPrompt := filepath.Join(yakPath, "auth", "api")
+	withoutPrompt := filepath.Join(yakPath, "auth", "login")
+	legacyPrompt := filepath.Join(yakPath, "docs")
+	require.NoError(t, os.MkdirAll(withPrompt, 0755))
+	require.NoError(t, os.MkdirAll(withoutPrompt, 0755))
+	require.NoError(t, os.MkdirAll(legacyPrompt, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(withPrompt, "task.md"), []byte("\nAdd rate limiting to the API.\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(withPrompt, "prompt.txt"), []byte("ignored"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(legacyPrompt, "prompt.txt"), []byte("Update the README."), 0644))
+
+	t.Run("task with task.md", func(t *testing.T) {
+		got, err := resolveFromTask(yakPath, "auth/api", false)
+		require.NoError(t, err)
+		assert.Equal(t, &fromTask{Name: "api", Prompt: "Add rate limiting to the API."}, got)
+	})
+
+	t.Run("task with prompt.txt", func(t *testing.T) {
+		got, err := resolveFromTask(yakPath, "docs", false)
+		require.NoError(t, err)
+		assert.Equal(t, "Update the README.", got.Prompt)
+	})
+
+	t.Run("task without a prompt file", func(t *testing.T) {
+		got, err := resolveFromTask(yakPath, "auth/login", false)
+		require.NoError(t, err)
+		assert.Equal(t, &fromTask{Name: "login"}, got)
+	})
+
+	t.Run("missing task", func(t *testing.T) {
+		_, err := resolveFromTask(yakPath, "auth/missing", false)
+		assert.Error(t, err)
+	})
+
+	t.Run("assigned task requires force", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(withoutPrompt, "assigned-to"), []byte("Yakov\n"), 0644))
+
+		_, err := resolveFromTask(yakPath, "auth/login", false)
+		assert.Error(t, err)
+		assert.Equal(t, 2, errors.GetExitCode(err))
+		assert.Contains(t, err.Error(), "already assigned to Yakov")
+		assert.Contains(t, err.Error(), "--force")
+
+		got, err := resolveFromTask(yakPath, "auth/login", true)
+		require.NoError(t, err)
+		assert.Equal(t, "login", got.Name)
+	})
+}
+
+func TestSpawnFromTaskValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(spawnCmd.Flags())
+	t.Cleanup(func() { spawnName, spawnFromTask, spawnForce = "", "", false })
+
+	spawnName, spawnFromTask = "", "auth/api"
+	err := spawnCmd.PreRunE(cmd, []string{})
+	if err != nil {
+		assert.NotContains(t, err.Error(), "--name is required")
+	}
+
+	spawnFromTask = ""
+	err = spawnCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--name is required")
+
+	spawnName, spawnForce = "worker", true
+	err = spawnCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--force requires --from-task")
+}
+
+func TestEnsureWorkerImage(t *testing.T) {
+	orig := ensureDevcontainerFn
+	t.Cleanup(func() { ensureDevcontainerFn = orig })
+
+	builds := 0
+	ensureDevcontainerFn = func(ctx context.Context) error {
+		builds++
+		return nil
+	}
+
+	require.NoError(t, ensureWorkerImage(context.Background(), "ghcr.io/acme/base:1.2", nil))
+	assert.Equal(t, 0, builds, "--image should skip the devcontainer build")
+
+	require.NoError(t, ensureWorkerImage(context.Background(), "", nil))
+	assert.Equal(t, 1, builds)
+
+	t.Run("failed build exits with the build code", func(t *testing.T) {
+		ensureDevcontainerFn = func(ctx context.Context) error {
+			return fmt.Errorf("docker build exited 1")
+		}
+		err := ensureWorkerImage(context.Background(), "", nil)
+		require.Error(t, err)
+		assert.Equal(t, errors.ExitBuildFailed, errors.GetExitCode(err))
+		assert.Contains(t, err.Error(), "runtime=native")
+	})
+
+	t.Run("cancelled build aborts spawn", func(t *testing.T) {
+		ensureDevcontainerFn = func(ctx context.Context) error {
+			return fmt.Errorf("%w: %v", runtime.ErrBuildCancelled, context.Canceled)
+		}
+		err := ensureWorkerImage(context.Background(), "", nil)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, runtime.ErrBuildCancelled)
+		assert.NotContains(t, err.Error(), "runtime=native")
+	})
+
+	t.Run("disallowed image is not built", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(root, ".yak-boxes"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, ".yak-boxes", runtime.AllowedImagesFile), []byte("myregistry/*\n"), 0644))
+		t.Setenv("YAK_BOX_ROOT", root)
+
+		builds = 0
+		ensureDevcontainerFn = func(ctx context.Context) error {
+			builds++
+			return nil
+		}
+		err := ensureWorkerImage(context.Background(), "", nil)
+		require.Error(t, err)
+		var validationErr *errors.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Contains(t, err.Error(), runtime.AllowedImagesFile)
+		assert.Equal(t, 0, builds, "a rejected image should not be built")
+
+		require.NoError(t, ensureWorkerImage(context.Background(), "myregistry/app:1", nil))
+		err = ensureWorkerImage(context.Background(), "ghcr.io/acme/base:1.2", &devcontainer.Config{Image: "myregistry/app:1"})
+		assert.ErrorAs(t, err, &validationErr, "--image overrides the devcontainer image")
+	})
+}
+
+func TestResolveRuntime(t *testing.T) {
+	orig := detectRuntimesFn
+	t.Cleanup(func() { detectRuntimesFn = orig })
+
+	detectRuntimesFn = func() runtime.RuntimeReport {
+		return runtime.RuntimeReport{DockerInstalled: true, Runtime: "unknown"}
+	}
+	_, err := resolveRuntime("auto")
+	require.Error(t, err)
+	assert.Equal(t, errors.ExitNoRuntime, errors.GetExitCode(err))
+	assert.Contains(t, err.Error(), "no runtime available (Docker installed but daemon not running; Zellij not installed)")
+	assert.Contains(t, err.Error(), "Start the Docker daemon")
+
+	detectRuntimesFn = func() runtime.RuntimeReport { return runtime.RuntimeReport{Runtime: "unknown"} }
+	_, err = resolveRuntime("auto")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Docker not installed")
+
+	got, err := resolveRuntime("native")
+	require.NoError(t, err)
+	assert.Equal(t, "native", got, "an explicit runtime skips detection")
+
+	detectRuntimesFn = func() runtime.RuntimeReport { return runtime.RuntimeReport{Runtime: "sandboxed"} }
+	got, err = resolveRuntime("auto")
+	require.NoError(t, err)
+	assert.Equal(t, "sandboxed", got)
+}
+
+func TestResolveSessionName(t *testing.T) {
+	t.Setenv("ZELLIJ_SESSION_NAME", "yak-shaving")
+	assert.Equal(t, "yak-shaving", resolveSessionName(""), "empty --session uses the current Zellij session")
+	assert.Equal(t, "other", resolveSessionName("other"), "--session wins over the environment")
+
+	t.Setenv("ZELLIJ_SESSION_NAME", "")
+	assert.Equal(t, "", resolveSessionName(""))
+}
+
+func TestRunSpawnWorktreeErrorExitCode(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".yaks", "auth-api"), 0755))
+	project := filepath.Join(dir, "not-a-repo")
+	require.NoError(t, os.MkdirAll(project, 0755))
+
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(dir))
+	t.Setenv(worktree.WorktreeRootEnv, t.TempDir())
+
+	spawnName, spawnRuntime, spawnCWD, spawnYaks, spawnAutoWorktree = "auth-api", "native", project, []string{"auth-api"}, true
+	t.Cleanup(func() {
+		spawnName, spawnRuntime, spawnCWD, spawnYaks, spawnAutoWorktree = "", "auto", "", []string{}, false
+	})
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(spawnCmd.Flags())
+	err = runSpawn(cmd, context.Background(), nil)
+	require.Error(t, err)
+	assert.Equal(t, errors.ExitWorktree, errors.GetExitCode(err))
+	assert.Contains(t, err.Error(), "failed to ensure worktree")
+}
+
+func TestRunSpawnFollow(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", dir).Run())
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".yaks", "api"), 0755))
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(dir))
+
+	spawnName, spawnRuntime, spawnCWD, spawnYaks, spawnFollow = "api", "native", dir, []string{"api"}, true
+	origNative, origFollow := spawnNativeFn, followWorkerFn
+	t.Cleanup(func() {
+		spawnName, spawnRuntime, spawnCWD, spawnYaks, spawnFollow = "", "auto", "", []string{}, false
+		spawnNativeFn, followWorkerFn = origNative, origFollow
+	})
+
+	var followed []string
+	followWorkerFn = func(_ context.Context, worker *types.Worker, logPath string, _ time.Duration) error {
+		followed = append(followed, worker.Name+" "+logPath)
+		return nil
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(spawnCmd.Flags())
+
+	t.Run("failed spawn is not followed", func(t *testing.T) {
+		spawnNativeFn = func(*types.Worker, string, string, string, int) (string, error) {
+			return "", fmt.Errorf("zellij not running")
+		}
+		require.Error(t, runSpawn(cmd, context.Background(), nil))
+		assert.Empty(t, followed)
+	})
+
+	t.Run("successful spawn is followed", func(t *testing.T) {
+		spawnNativeFn = func(*types.Worker, string, string, string, int) (string, error) { return "", nil }
+		require.NoError(t, runSpawn(cmd, context.Background(), nil))
+		require.Len(t, followed, 1)
+		assert.True(t, strings.HasPrefix(followed[0], "api "))
+		assert.True(t, strings.HasSuffix(followed[0], filepath.Join("scripts", "worker.log")))
+	})
+}
+
+func TestRunSpawnReplaceStopsOldWorkerLast(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", dir).Run())
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".yaks", "api"), 0755))
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(dir))
+	require.NoError(t, sessions.Register("api", sessions.Session{Worker: "Yakov", Runtime: "native"}))
+
+	spawnName, spawnRuntime, spawnCWD, spawnYaks, spawnReplace = "api", "native", dir, []string{"api"}, true
+	origNative, origStop := spawnNativeFn, stopWorkerFn
+	t.Cleanup(func() {
+		spawnName, spawnRuntime, spawnCWD, spawnYaks, spawnReplace, spawnTTL = "", "auto", "", []string{}, false, ""
+		spawnNativeFn, stopWorkerFn = origNative, origStop
+	})
+
+	var steps []string
+	stopWorkerFn = func(name string, opts stopOptions) error {
+		steps = append(steps, "stop "+name)
+		return sessions.Unregister(name)
+	}
+	spawnNativeFn = func(*types.Worker, string, string, string, int) (string, error) {
+		steps = append(steps, "spawn")
+		return "", nil
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(spawnCmd.Flags())
+
+	t.Run("failed validation leaves the old worker running", func(t *testing.T) {
+		spawnTTL = "soon"
+		require.Error(t, runSpawn(cmd, context.Background(), nil))
+		assert.Empty(t, steps)
+		_, err := sessions.Get("api")
+		assert.NoError(t, err, "the old session must stay registered")
+	})
+
+	t.Run("old worker is stopped just before launch", func(t *testing.T) {
+		spawnTTL = ""
+		require.NoError(t, runSpawn(cmd, context.Background(), nil))
+		assert.Equal(t, []string{"stop api", "spawn"}, steps)
+	})
+}
+
+func TestReplaceExistingWorker(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", repo).Run())
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(repo))
+
+	require.NoError(t, sessions.Register("api-auth", sessions.Session{Worker: "Yakov", Container: "yak-worker-api-auth"}))
+
+	var stopped []string
+	orig := stopWorkerFn
+	t.Cleanup(func() { stopWorkerFn = orig })
+	stopWorkerFn = func(name string, opts stopOptions) error {
+		stopped = append(stopped, name)
+		return sessions.Unregister(name)
+	}
+
+	t.Run("errors without replace", func(t *testing.T) {
+		_, err := checkExistingWorker("api-auth", false)
+		require.Error(t, err)
+		assert.Equal(t, 2, errors.GetExitCode(err))
+		assert.Contains(t, err.Error(), "already registered")
+		assert.Contains(t, err.Error(), "--replace")
+		assert.Empty(t, stopped, "the existing worker must be left running")
+	})
+
+	t.Run("new name needs no replacing", func(t *testing.T) {
+		replacing, err := checkExistingWorker("web-ui", false)
+		require.NoError(t, err)
+		assert.False(t, replacing)
+	})
+
+	t.Run("replace defers the stop", func(t *testing.T) {
+		replacing, err := checkExistingWorker("api-auth", true)
+		require.NoError(t, err)
+		assert.True(t, replacing)
+		assert.Empty(t, stopped, "checking must not stop the old worker")
+	})
+
+	t.Run("replace stops the old worker", func(t *testing.T) {
+		require.NoError(t, replaceExistingWorker("api-auth"))
+		assert.Equal(t, []string{"api-auth"}, stopped)
+		_, err := sessions.Get("api-auth")
+		assert.ErrorIs(t, err, sessions.ErrSessionNotFound)
+	})
+
+	t.Run("stop failure aborts the spawn", func(t *testing.T) {
+		require.NoError(t, sessions.Register("api-auth", sessions.Session{Worker: "Yakov"}))
+		stopWorkerFn = func(name string, opts stopOptions) error { return fmt.Errorf("docker stop failed") }
+		err := replaceExistingWorker("api-auth")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to stop existing worker api-auth")
+	})
+}
+
+func TestAssembleUserPromptOrder(t *testing.T) {
+	spawnPromptParts = nil
+	t.Cleanup(func() { spawnPromptParts = nil })
+
+	conventions := filepath.Join(t.TempDir(), "conventions.md")
+	require.NoError(t, os.WriteFile(conventions, []byte("Follow the repo conventions.\n"), 0644))
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(spawnCmd.Flags())
+	require.NoError(t, cmd.ParseFlags([]string{
+		"--prompt-part", "Fix the login bug.",
+		"--prompt-file", conventions,
+		"--prompt-part", "Then run the tests.",
+	}))
+
+	got, err := assembleUserPrompt([]string{"You own auth/api."}, spawnPromptParts)
+	require.NoError(t, err)
+	assert.Equal(t, "You own auth/api.\n\nFix the login bug.\n\nFollow the repo conventions.\n\nThen run the tests.", got)
+}
+
+func TestAssembleUserPrompt(t *testing.T) {
+	got, err := assembleUserPrompt(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, defaultUserPrompt, got)
+
+	got, err = assembleUserPrompt(nil, []promptSegment{{Text: "  "}, {Text: "Only part"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Only part", got)
+
+	_, err = assembleUserPrompt([]string{" "}, []promptSegment{{Text: ""}})
+	require.Error(t, err)
+	assert.Equal(t, 2, errors.GetExitCode(err))
+
+	_, err = assembleUserPrompt(nil, []promptSegment{{File: filepath.Join(t.TempDir(), "missing.md")}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--prompt-file")
+}
+
+func TestRunSpawnCount(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", dir).Run())
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".yaks", "api"), 0755))
+	t.Chdir(dir)
+
+	spawnName, spawnRuntime, spawnCWD, spawnYaks = "api", "native", dir, []string{"api"}
+	origNative := spawnNativeFn
+	t.Cleanup(func() {
+		spawnName, spawnRuntime, spawnCWD, spawnYaks = "", "auto", "", []string{}
+		spawnNativeFn = origNative
+	})
+
+	var homes []string
+	spawnNativeFn = func(worker *types.Worker, _ string, homeDir, _ string, _ int) (string, error) {
+		if worker.Name == "api-2" {
+			return "", fmt.Errorf("zellij not running")
+		}
+		homes = append(homes, homeDir)
+		return "", nil
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(spawnCmd.Flags())
+
+	err := spawnWorkers(cmd, context.Background(), nil, 3)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to spawn api-2")
+	assert.NotContains(t, err.Error(), "api-1:")
+
+	registered, err := sessions.List()
+	require.NoError(t, err)
+	require.Len(t, registered, 2)
+	require.Contains(t, registered, "api-1")
+	require.Contains(t, registered, "api-3")
+	assert.NotEqual(t, registered["api-1"].Worker, registered["api-3"].Worker, "each worker should get its own persona")
+	require.Len(t, homes, 2)
+	assert.NotEqual(t, homes[0], homes[1], "each worker should get its own home")
+
+	err = spawnWorkers(cmd, context.Background(), nil, len(types.WorkerNames)+1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "personas")
+}
+
+func TestSpawnCountValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(spawnCmd.Flags())
+	spawnName = "api"
+	t.Cleanup(func() { spawnName, spawnCount, spawnFollow, spawnPinPersona, spawnFromTask = "", 1, false, false, "" })
+
+	spawnCount = 0
+	err := spawnCmd.PreRunE(cmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--count must be at least 1")
+
+	spawnCount, spawnFollow, spawnPinPersona, spawnFromTask = 3, true, true, "auth/api"
+	err = spawnCmd.PreRunE(cmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--follow can only stream one worker")
+	assert.Contains(t, err.Error(), "--pin-persona cannot be combined with --count")
+	assert.Contains(t, err.Error(), "--from-task cannot be combined with --count")
+
+	spawnFollow, spawnPinPersona, spawnFromTask = false, false, ""
+	assert.NoError(t, spawnCmd.PreRunE(cmd, nil))
+}
+
+func TestSpawnWorktreeBaseValidation(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", dir).Run())
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".yaks", "api"), 0755))
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(dir))
+
+	spawnName, spawnRuntime, spawnCWD, spawnYaks, spawnWorktreeBase = "api", "native", dir, []string{"api"}, "main"
+	origNative := spawnNativeFn
+	t.Cleanup(func() {
+		spawnName, spawnRuntime, spawnCWD, spawnYaks, spawnWorktreeBase = "", "auto", "", []string{}, ""
+		spawnNativeFn = origNative
+	})
+	spawnNativeFn = func(*types.Worker, string, string, string, int) (string, error) {
+		t.Fatal("spawn should fail validation")
+		return "", nil
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(spawnCmd.Flags())
+	err = runSpawn(cmd, context.Background(), nil)
+	require.Error(t, err)
+	assert.Equal(t, 2, errors.GetExitCode(err))
+	assert.Contains(t, err.Error(), "--worktree-base requires --auto-worktree or a task with a worktrees field")
+}
+
+func TestRunSpawnInheritedWorktreesUseBase(t *testing.T) {
+	dir := t.TempDir()
+	repo := filepath.Join(dir, "repos", "api")
+	for _, args := range [][]string{
+		{"init", dir},
+		{"init", repo},
+		{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "base"},
+		{"-C", repo, "branch", "base"},
+		{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "later"},
+	} {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+	}
+	baseCommit, err := exec.Command("git", "-C", repo, "rev-parse", "base").Output()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".yaks", "sc-1"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".yaks", "sc-1", "worktrees"), []byte("repos/api\n"), 0644))
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(dir))
+
+	home := filepath.Join(t.TempDir(), "home")
+	spawnName, spawnRuntime, spawnYaks, spawnWorktreeBase, spawnHomeDir = "sc-1", "native", []string{"sc-1"}, "base", home
+	origNative := spawnNativeFn
+	t.Cleanup(func() {
+		spawnName, spawnRuntime, spawnYaks, spawnWorktreeBase, spawnHomeDir = "", "auto", []string{}, "", ""
+		spawnNativeFn = origNative
+	})
+	spawnNativeFn = func(*types.Worker, string, string, string, int) (string, error) { return "", nil }
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(spawnCmd.Flags())
+	require.NoError(t, runSpawn(cmd, context.Background(), nil))
+
+	head, err := exec.Command("git", "-C", filepath.Join(home, "api"), "rev-parse", "HEAD").Output()
+	require.NoError(t, err)
+	assert.Equal(t, string(baseCommit), string(head), "the task branch should start at --worktree-base")
+}
+
+func TestRunSpawnRefusesBeyondMaxDepth(t *testing.T) {
+	origNative := spawnNativeFn
+	t.Cleanup(func() { spawnNativeFn = origNative })
+	spawnNativeFn = func(*types.Worker, string, string, string, int) (string, error) {
+		t.Fatal("spawn should be refused before a worker starts")
+		return "", nil
+	}
+
+	t.Setenv(runtime.DepthEnv, "2")
+	err := runSpawn(&cobra.Command{}, context.Background(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "refusing to spawn: already inside a yak-box worker at depth 2, and the maximum depth is 2")
+	assert.Equal(t, 1, errors.GetExitCode(err))
+
+	t.Setenv(runtime.DepthEnv, "1")
+	assert.NoError(t, checkSpawnDepth(runtime.DefaultMaxDepth), "a worker may spawn sub-workers")
+	assert.Error(t, checkSpawnDepth(1), "a lower --max-depth refuses")
+
+	t.Setenv(runtime.DepthEnv, "lots")
+	err = checkSpawnDepth(runtime.DefaultMaxDepth)
+	require.Error(t, err)
+	assert.Equal(t, 2, errors.GetExitCode(err))
+
+	t.Run("inherited limit cannot be raised", func(t *testing.T) {
+		t.Setenv(runtime.DepthEnv, "2")
+		t.Setenv(runtime.MaxDepthEnv, "2")
+		err := checkSpawnDepth(99)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the maximum depth is 2")
+
+		t.Setenv(runtime.DepthEnv, "1")
+		t.Setenv(runtime.MaxDepthEnv, "3")
+		assert.Error(t, checkSpawnDepth(1), "a nested spawn may lower the limit")
+		assert.NoError(t, checkSpawnDepth(99))
+	})
+
+	t.Run("invalid inherited limit", func(t *testing.T) {
+		t.Setenv(runtime.DepthEnv, "1")
+		t.Setenv(runtime.MaxDepthEnv, "many")
+		err := checkSpawnDepth(runtime.DefaultMaxDepth)
+		require.Error(t, err)
+		assert.Equal(t, 2, errors.GetExitCode(err))
+	})
+}
+
+func TestParseLabels(t *testing.T) {
+	labels, err := parseLabels([]string{"team=auth", "experiment=", "team=api"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "api", "experiment": ""}, labels)
+
+	labels, err = parseLabels(nil)
+	require.NoError(t, err)
+	assert.Nil(t, labels)
+
+	for _, bad := range []string{"=auth", "my team=auth"} {
+		_, err := parseLabels([]string{bad})
+		assert.ErrorContains(t, err, "must be KEY=VALUE", bad)
+	}
+}
+
+func TestRunSpawnRecordsLabels(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", dir).Run())
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".yaks", "api"), 0755))
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(dir))
+
+	spawnName, spawnRuntime, spawnCWD, spawnYaks, spawnLabels = "api", "native", dir, []string{"api"}, []string{"team=auth"}
+	origNative := spawnNativeFn
+	t.Cleanup(func() {
+		spawnName, spawnRuntime, spawnCWD, spawnYaks, spawnLabels = "", "auto", "", []string{}, []string{}
+		spawnNativeFn = origNative
+	})
+	spawnNativeFn = func(*types.Worker, string, string, string, int) (string, error) { return "", nil }
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(spawnCmd.Flags())
+	require.NoError(t, runSpawn(cmd, context.Background(), nil))
+
+	session, err := sessions.Get("api")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "auth"}, session.Labels)
+
+	found, err := sessions.Find(sessions.ByLabel("team"))
+	require.NoError(t, err)
+	assert.Contains(t, found, "api")
+}
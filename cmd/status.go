@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/tasks"
+	"github.com/wellmaintained/yak-box/pkg/types"
+)
+
+var (
+	statusClear   bool
+	statusYakPath string
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status <task> (<state> [message] | --clear)",
+	Short: "Set or clear a task's agent-status",
+	Long: `Write a task's .yaks/<task>/agent-status file, the status that check and
+wait --task read.
+
+The state must be one of wip, blocked or done. An optional message is
+appended after a colon, e.g. "blocked: waiting on review".
+
+With --clear, the status file is removed instead.`,
+	Example: `  # Mark a task as in progress
+  yak-box status auth/api wip
+
+  # Record why a task is blocked
+  yak-box status auth/api blocked "waiting on review"
+
+  # Remove the status
+  yak-box status auth/api --clear`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if statusClear {
+			if len(args) != 1 {
+				return errors.NewValidationError("--clear takes only the task: yak-box status <task> --clear", nil)
+			}
+			return nil
+		}
+		if len(args) < 2 || len(args) > 3 {
+			return errors.NewValidationError("expected a task and a state: yak-box status <task> <state> [message]", nil)
+		}
+		if !slices.Contains(tasks.States, strings.ToLower(args[1])) {
+			return errors.NewValidationError(fmt.Sprintf("state must be one of %s, got %q", strings.Join(tasks.States, ", "), args[1]), nil)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runStatus(cmd, args); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	var absYakPath string
+	var err error
+	if cmd.Flags().Changed("yak-path") {
+		absYakPath, err = filepath.Abs(statusYakPath)
+	} else {
+		var cwd string
+		if cwd, err = os.Getwd(); err == nil {
+			absYakPath, err = findYakPath(cwd, filepath.Base(statusYakPath))
+		}
+	}
+	if err != nil {
+		return errors.NewValidationError("failed to resolve yak path", err)
+	}
+
+	task := args[0]
+	taskDir, err := findTaskDir(absYakPath, types.SlugifyTaskPath(task))
+	if err != nil {
+		return errors.NewValidationError(fmt.Sprintf("task %q not found", task), err)
+	}
+	rel, err := filepath.Rel(absYakPath, taskDir)
+	if err != nil {
+		return err
+	}
+
+	if statusClear {
+		if err := tasks.ClearStatus(absYakPath, rel); err != nil {
+			return fmt.Errorf("failed to clear status of %s: %w", task, err)
+		}
+		fmt.Printf("%s: status cleared\n", task)
+		return nil
+	}
+
+	message := ""
+	if len(args) > 2 {
+		message = args[2]
+	}
+	if err := tasks.WriteStatus(absYakPath, rel, args[1], message); err != nil {
+		return fmt.Errorf("failed to set status of %s: %w", task, err)
+	}
+	fmt.Printf("%s: %s\n", task, tasks.FormatStatus(args[1], message))
+	return nil
+}
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusClear, "clear", false, "Remove the task's agent-status instead of setting it")
+	statusCmd.Flags().StringVar(&statusYakPath, "yak-path", ".yaks", "Path to task state directory")
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/tasks"
+)
+
+func TestStatusValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(statusCmd.Flags())
+	t.Cleanup(func() { statusClear = false })
+
+	tests := []struct {
+		args  []string
+		clear bool
+		err   string
+	}{
+		{args: []string{"auth/api", "wip"}},
+		{args: []string{"auth/api", "Blocked", "waiting on review"}},
+		{args: []string{"auth/api"}, clear: true},
+		{args: []string{"auth/api"}, err: "expected a task and a state"},
+		{args: []string{"auth/api", "finished"}, err: "state must be one of wip, blocked, done"},
+		{args: []string{"auth/api", "done", "msg", "extra"}, err: "expected a task and a state"},
+		{args: []string{"auth/api", "done"}, clear: true, err: "--clear takes only the task"},
+	}
+	for _, tt := range tests {
+		statusClear = tt.clear
+		err := statusCmd.PreRunE(cmd, tt.args)
+		if tt.err == "" {
+			assert.NoError(t, err, "args %q", tt.args)
+			continue
+		}
+		require.Error(t, err, "args %q", tt.args)
+		assert.Contains(t, err.Error(), tt.err)
+		assert.Equal(t, 2, errors.GetExitCode(err))
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	dir := t.TempDir()
+	taskDir := filepath.Join(dir, ".yaks", "auth", "api")
+	require.NoError(t, os.MkdirAll(taskDir, 0755))
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { statusClear = false })
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(statusCmd.Flags())
+
+	for _, state := range tasks.States {
+		out := captureStdout(t, func() {
+			require.NoError(t, runStatus(cmd, []string{"auth/api", state}))
+		})
+		assert.Equal(t, "auth/api: "+state+"\n", out)
+		status, err := tasks.ReadStatus(taskDir)
+		require.NoError(t, err)
+		assert.Equal(t, state, status)
+	}
+
+	captureStdout(t, func() {
+		require.NoError(t, runStatus(cmd, []string{"api", "blocked", "waiting on review"}))
+	})
+	status, err := tasks.ReadStatus(taskDir)
+	require.NoError(t, err)
+	assert.Equal(t, "blocked: waiting on review", status, "a task can be found by its leaf name")
+
+	statusClear = true
+	captureStdout(t, func() {
+		require.NoError(t, runStatus(cmd, []string{"auth/api"}))
+	})
+	_, err = os.Stat(filepath.Join(taskDir, tasks.StatusFile))
+	assert.True(t, os.IsNotExist(err), "--clear removes agent-status")
+
+	err = runStatus(cmd, []string{"missing"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `task "missing" not found`)
+}
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	goerrors "errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -12,28 +16,77 @@ import (
 	"github.com/wellmaintained/yak-box/internal/sessions"
 	"github.com/wellmaintained/yak-box/internal/ui"
 	"github.com/wellmaintained/yak-box/pkg/types"
+	"github.com/wellmaintained/yak-box/pkg/worktree"
 )
 
 var (
-	stopName    string
-	stopTimeout string
-	stopForce   bool
-	stopDryRun  bool
+	stopName      string
+	stopTimeout   string
+	stopForce     bool
+	stopDryRun    bool
+	stopAll       bool
+	stopContainer string
+	stopTab       string
+	stopRmTree    bool
+	stopInteract  bool
+)
+
+// stopAllConcurrency bounds how many workers `stop --all` tears down at once.
+const stopAllConcurrency = 4
+
+// stopOptions carries the settings shared by single and bulk stops.
+type stopOptions struct {
+	Timeout        time.Duration
+	Force          bool
+	DryRun         bool
+	RemoveWorktree bool
+}
+
+// stopWorkerFn is the per-worker stop used by `stop --all`; tests replace it.
+var stopWorkerFn = stopWorker
+
+// Teardown primitives used by teardownWorker; tests replace them.
+var (
+	stopContainerFn  = runtime.StopContainer
+	killProcessFn    = runtime.KillNativeProcessTree
+	closeTabFn       = runtime.StopNativeWorker
+	removeWorktreeFn = worktree.RemoveAtPath
 )
 
 var stopCmd = &cobra.Command{
-	Use:   "stop --name <worker-name> [flags]",
+	Use:   "stop (--name <worker-name> | --container <name> | --tab <name> | --all) [flags]",
 	Short: "Stop a worker",
 	Long: `Stop a running worker, optionally forcing termination.
 
 The stop command gracefully shuts down a worker by:
 1. Loading session from .yak-boxes/sessions.json
-2. Clearing task assignments (unless --force is set)
-3. Stopping the container or closing the Zellij tab
-4. Unregistering the session (home directory is preserved)
+2. Stopping the container or closing the Zellij tab
+3. Removing the --auto-worktree worktree (only with --remove-worktree)
+4. Clearing task assignments (unless --force is set)
+5. Unregistering the session (home directory is preserved)
+
+A container, process, tab or Zellij session that is already gone counts as
+stopped, with a warning. If the worker may still be running because the
+container cannot be stopped or the process tree cannot be killed, the
+session and task assignment are kept and stop exits non-zero, so the stop
+can be retried.
 
 If session is missing, the command attempts to detect the worker
-via Docker ps or Zellij tabs as a fallback.`,
+via Docker ps or Zellij tabs as a fallback.
+
+With --container or --tab, the worker is targeted directly by its Docker
+container or Zellij tab name instead of its session name. A session that
+owns the container or tab is still cleaned up and unregistered.
+
+A worktree with uncommitted changes is kept, and the changes are listed,
+unless --force is also set. The session then stays registered and stop
+exits non-zero, so it can be rerun with --force.
+
+With --all, every registered session is stopped concurrently and a
+summary is printed; the exit code is non-zero if any stop failed.
+
+With --interactive, a missing or unknown --name prompts for the worker to
+stop from a numbered list of active sessions.`,
 	Example: `  # Gracefully stop a worker (clears task assignments)
   yak-box stop --name api-auth
 
@@ -44,13 +97,42 @@ via Docker ps or Zellij tabs as a fallback.`,
   yak-box stop --name api-auth --dry-run
 
   # Stop with custom timeout
-  yak-box stop --name backend-worker --timeout 60s`,
+  yak-box stop --name backend-worker --timeout 60s
+
+  # Stop by container name when the session name is unknown
+  yak-box stop --container yak-worker-api-auth
+
+  # Close a worker's Zellij tab by name
+  yak-box stop --tab "Yakov 🪒🦬 api-auth"
+
+  # Stop a worker and remove its --auto-worktree worktree
+  yak-box stop --name api-auth --remove-worktree
+
+  # Stop every registered worker
+  yak-box stop --all
+
+  # Choose the worker to stop from a list
+  yak-box stop -i`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		var errs []error
 
 		// Validate required flags
-		if stopName == "" {
-			errs = append(errs, fmt.Errorf("--name is required (worker name to stop)"))
+		targets := 0
+		for _, set := range []bool{stopName != "", stopAll, stopContainer != "", stopTab != ""} {
+			if set {
+				targets++
+			}
+		}
+		if targets == 0 && !stopInteract {
+			errs = append(errs, fmt.Errorf("--name is required (worker name to stop) unless --all, --container, --tab or --interactive is set"))
+		}
+		if stopInteract && (stopAll || stopContainer != "" || stopTab != "") {
+			errs = append(errs, fmt.Errorf("--interactive only applies to --name"))
+		}
+		if stopName != "" && stopAll {
+			errs = append(errs, fmt.Errorf("--name and --all are mutually exclusive"))
+		} else if targets > 1 {
+			errs = append(errs, fmt.Errorf("--name, --all, --container and --tab are mutually exclusive"))
 		}
 
 		// Validate timeout format
@@ -72,26 +154,104 @@ via Docker ps or Zellij tabs as a fallback.`,
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runStop(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(errors.GetExitCode(err))
+			exitWithError(err)
 		}
 	},
 }
 
 func runStop() error {
-	ui.Info("⏳ Stopping worker: %s...\n", stopName)
-
 	timeout, err := time.ParseDuration(stopTimeout)
 	if err != nil {
 		return errors.NewValidationError("invalid timeout format. Use a valid duration like '30s', '1m', or '5m30s'", err)
 	}
+	opts := stopOptions{Timeout: timeout, Force: stopForce, DryRun: stopDryRun, RemoveWorktree: stopRmTree}
+
+	switch {
+	case stopAll:
+		return runStopAll(opts)
+	case stopContainer != "":
+		return stopByContainer(stopContainer, opts)
+	case stopTab != "":
+		return stopByTab(stopTab, opts)
+	}
+
+	name := stopName
+	if stopInteract {
+		if name, err = pickWorker(os.Stderr, os.Stdin, name); err != nil {
+			return err
+		}
+	}
+	return stopWorker(name, opts)
+}
+
+// runStopAll stops every registered session.
+func runStopAll(opts stopOptions) error {
+	all, err := sessions.Find(nil)
+	if err != nil {
+		return fmt.Errorf("failed to load sessions: %w", err)
+	}
+	if len(all) == 0 {
+		fmt.Println("No active sessions.")
+		return nil
+	}
+
+	return stopWorkers(all.IDs(), opts, stopAllConcurrency)
+}
+
+// stopWorkers stops each named worker using at most concurrency goroutines.
+// Every worker is attempted even if others fail; failures are aggregated.
+func stopWorkers(names []string, opts stopOptions, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failures = make(map[string]error)
+		sem      = make(chan struct{}, concurrency)
+	)
+	for _, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := stopWorkerFn(name, opts); err != nil {
+				mu.Lock()
+				failures[name] = err
+				mu.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	fmt.Printf("\nStopped %d of %d workers\n", len(names)-len(failures), len(names))
+	if len(failures) == 0 {
+		return nil
+	}
+
+	failed := make([]string, 0, len(failures))
+	for name := range failures {
+		failed = append(failed, name)
+	}
+	sort.Strings(failed)
+	for _, name := range failed {
+		ui.Error("❌ %s: %v\n", name, failures[name])
+	}
+	return errors.NewRuntimeError(fmt.Sprintf("failed to stop %d worker(s): %s", len(failed), strings.Join(failed, ", ")), nil)
+}
+
+// stopWorker tears down a single worker by session name.
+func stopWorker(name string, opts stopOptions) error {
+	ui.Info("⏳ Stopping worker: %s...\n", name)
 
-	session, err := sessions.Get(stopName)
+	session, err := sessions.Get(name)
 	if err != nil {
 		fmt.Printf("Warning: Could not load session: %v\n", err)
 		fmt.Println("Attempting fallback detection...")
 
-		containerName := "yak-worker-" + stopName
+		containerName := "yak-worker-" + name
 		workers, err := runtime.ListAllContainers()
 		if err == nil && len(workers) > 0 {
 			for _, w := range workers {
@@ -99,7 +259,7 @@ func runStop() error {
 					session = &sessions.Session{
 						Runtime:     "sandboxed",
 						Container:   containerName,
-						DisplayName: stopName,
+						DisplayName: name,
 					}
 					break
 				}
@@ -111,77 +271,191 @@ func runStop() error {
 		}
 	}
 
-	yakPath := ".yaks"
-	if !stopForce && session.Task != "" {
-		ui.Info("⏳ Clearing task assignments...\n")
-		taskSlug := types.SlugifyTaskPath(session.Task)
-		absYakPath, err := filepath.Abs(yakPath)
-		if err != nil {
-			fmt.Printf("Warning: Failed to resolve yak path: %v\n", err)
-		} else {
-			taskDir, err := findTaskDir(absYakPath, taskSlug)
-			if err != nil {
-				fmt.Printf("Warning: Failed to find task directory for %s: %v\n", session.Task, err)
-			} else {
-				taskFile := filepath.Join(taskDir, "assigned-to")
-				if err := os.Remove(taskFile); err != nil && !os.IsNotExist(err) {
-					fmt.Printf("Warning: Failed to clear assignment for %s: %v\n", session.Task, err)
-				} else {
-					ui.Success("✅ Cleared assignment: %s\n", session.Task)
-				}
-			}
+	if err := teardownWorker(name, "yak-worker-"+name, session, opts); err != nil {
+		return err
+	}
+	ui.Success("✅ Stopped: %s\n", name)
+	return nil
+}
+
+// stopByContainer stops a worker by its Docker container name, bypassing the
+// session-name lookup.
+func stopByContainer(containerName string, opts stopOptions) error {
+	ui.Info("⏳ Stopping container: %s...\n", containerName)
+
+	id, session, err := sessions.FindByContainer(containerName)
+	if err != nil {
+		if !goerrors.Is(err, sessions.ErrSessionNotFound) {
+			fmt.Printf("Warning: Could not load sessions: %v\n", err)
+		}
+		id, session = "", &sessions.Session{Runtime: "sandboxed", Container: containerName}
+	}
+
+	if err := teardownWorker(id, containerName, session, opts); err != nil {
+		return err
+	}
+	ui.Success("✅ Stopped: %s\n", containerName)
+	return nil
+}
+
+// stopByTab stops a worker by its Zellij tab name, bypassing the
+// session-name lookup. Without a matching session only the tab is closed.
+func stopByTab(tabName string, opts stopOptions) error {
+	ui.Info("⏳ Stopping tab: %s...\n", tabName)
+
+	id, session, err := sessions.FindByTab(tabName)
+	if err != nil {
+		if !goerrors.Is(err, sessions.ErrSessionNotFound) {
+			fmt.Printf("Warning: Could not load sessions: %v\n", err)
 		}
+		id, session = "", &sessions.Session{Runtime: "native", DisplayName: tabName}
+	}
+
+	if err := teardownWorker(id, session.Container, session, opts); err != nil {
+		return err
 	}
+	ui.Success("✅ Stopped: %s\n", tabName)
+	return nil
+}
 
+// teardownWorker closes the Zellij tab of session, stops containerName for
+// sandboxed workers or kills the process tree of native ones, then clears the
+// task assignment and unregisters the session under id. An empty id means no
+// session is registered for the worker. A container, process, tab or session
+// that is already gone counts as stopped. If the worker may still be running,
+// or its worktree cannot be removed, the session and task assignment are kept
+// so the stop can be retried, and the failures are returned.
+func teardownWorker(id, containerName string, session *sessions.Session, opts stopOptions) error {
+	var failures []string
 	if session.Runtime == "sandboxed" {
-		if stopDryRun {
-			fmt.Printf("[dry-run] Would close Zellij tab: %s\n", session.DisplayName)
-			fmt.Printf("[dry-run] Would stop container: %s\n", session.Container)
+		if opts.DryRun {
+			if session.DisplayName != "" && !session.Detached {
+				fmt.Printf("[dry-run] Would close Zellij tab: %s\n", session.DisplayName)
+			}
+			fmt.Printf("[dry-run] Would stop container: %s\n", containerName)
 		} else {
-			ui.Info("⏳ Closing Zellij tab...\n")
-			if err := runtime.StopNativeWorker(session.DisplayName, session.ZellijSession); err != nil {
-				fmt.Printf("Warning: failed to close tab: %v\n", err)
+			// Detached (--no-zellij) workers have no tab to close. The
+			// container is the worker, so a tab left open is only a warning.
+			if session.DisplayName != "" && !session.Detached {
+				ui.Info("⏳ Closing Zellij tab...\n")
+				if err := closeTabFn(session.DisplayName, session.ZellijSession); err != nil {
+					fmt.Printf("Warning: Failed to close tab: %v\n", err)
+				}
 			}
-			ui.Info("⏳ Stopping container...\n")
-			if err := runtime.StopSandboxedWorker(stopName, timeout); err != nil {
-				fmt.Printf("Warning: %v\n", err)
+			if containerName != "" {
+				ui.Info("⏳ Stopping container...\n")
+				err := stopContainerFn(containerName, opts.Timeout)
+				if goerrors.Is(err, runtime.ErrContainerNotFound) {
+					// Already gone, e.g. the worker exited and --rm removed it
+					fmt.Printf("Warning: %v\n", err)
+				} else if err != nil {
+					failures = append(failures, err.Error())
+				}
 			}
 		}
 	} else if session.Runtime == "native" {
-		if stopDryRun {
-			fmt.Printf("[dry-run] Would kill native process tree via PID file: %s\n", session.PidFile)
+		if opts.DryRun {
+			if session.PidFile != "" {
+				fmt.Printf("[dry-run] Would kill native process tree via PID file: %s\n", session.PidFile)
+			}
 			fmt.Printf("[dry-run] Would close Zellij tab: %s\n", session.DisplayName)
 		} else {
 			if session.PidFile != "" {
 				ui.Info("⏳ Killing native process tree...\n")
-				if err := runtime.KillNativeProcessTree(session.PidFile, timeout); err != nil {
-					fmt.Printf("Warning: failed to kill process tree: %v\n", err)
+				err := killProcessFn(session.PidFile, opts.Timeout)
+				if goerrors.Is(err, runtime.ErrPidFileNotFound) {
+					// Removed when the process exited, e.g. by an earlier stop
+					fmt.Printf("Warning: %v; the process has already exited\n", err)
+				} else if err != nil {
+					failures = append(failures, fmt.Sprintf("failed to kill process tree: %v", err))
 				} else {
 					ui.Success("✅ Process tree terminated\n")
 				}
 			}
 			ui.Info("⏳ Closing Zellij tab...\n")
-			if err := runtime.StopNativeWorker(session.DisplayName, session.ZellijSession); err != nil {
-				fmt.Printf("Warning: failed to close tab: %v\n", err)
+			err := closeTabFn(session.DisplayName, session.ZellijSession)
+			switch {
+			case goerrors.Is(err, runtime.ErrZellijSessionNotFound):
+				fmt.Printf("Warning: %v; the tab has already closed\n", err)
+			case err != nil && session.PidFile == "":
+				// Without a pid file the tab is the only handle on the process
+				failures = append(failures, fmt.Sprintf("failed to close tab: %v", err))
+			case err != nil:
+				fmt.Printf("Warning: Failed to close tab: %v\n", err)
+			}
+		}
+	}
+
+	// The worktree may still be in use while the worker runs
+	if len(failures) > 0 {
+		msg := strings.Join(failures, "; ")
+		if id != "" {
+			msg += fmt.Sprintf(". The %s session is still registered; rerun 'yak-box stop --name %s' to retry", id, id)
+		}
+		return errors.NewRuntimeError(msg, nil)
+	}
+
+	if opts.RemoveWorktree && session.WorktreePath != "" {
+		if opts.DryRun {
+			fmt.Printf("[dry-run] Would remove worktree: %s\n", session.WorktreePath)
+		} else {
+			ui.Info("⏳ Removing worktree...\n")
+			if err := removeWorktreeFn(session.ProjectPath, session.WorktreePath, opts.Force); err != nil {
+				// Keep the session so the removal can be retried with --force
+				msg := fmt.Sprintf("worktree not removed: %v", err)
+				if id != "" {
+					msg += fmt.Sprintf("\nThe worker is stopped but the %s session is still registered. Suggestion: Commit or save the changes, or rerun 'yak-box stop --name %s --remove-worktree --force' to discard them", id, id)
+				}
+				return errors.NewRuntimeError(msg, nil)
 			}
+			ui.Success("✅ Removed worktree: %s\n", session.WorktreePath)
 		}
 	}
 
-	if !stopDryRun {
-		if err := sessions.Unregister(stopName); err != nil {
+	// The task stays assigned until the worker is known to be stopped
+	if !opts.Force && session.Task != "" {
+		clearTaskAssignment(session.Task)
+	}
+
+	if !opts.DryRun && id != "" {
+		if err := sessions.Unregister(id); err != nil {
 			fmt.Printf("Warning: Failed to unregister session: %v\n", err)
 		}
 	}
 
-	ui.Success("✅ Stopped: %s\n", stopName)
 	return nil
 }
 
+// clearTaskAssignment unassigns task in .yaks, printing a warning if it
+// cannot be found or cleared.
+func clearTaskAssignment(task string) {
+	ui.Info("⏳ Clearing task assignments...\n")
+	absYakPath, err := filepath.Abs(".yaks")
+	if err != nil {
+		fmt.Printf("Warning: Failed to resolve yak path: %v\n", err)
+		return
+	}
+	taskDir, err := findTaskDir(absYakPath, types.SlugifyTaskPath(task))
+	if err != nil {
+		fmt.Printf("Warning: Failed to find task directory for %s: %v\n", task, err)
+		return
+	}
+	if err := unassignTask(absYakPath, taskDir); err != nil {
+		fmt.Printf("Warning: Failed to clear assignment for %s: %v\n", task, err)
+		return
+	}
+	ui.Success("✅ Cleared assignment: %s\n", task)
+}
+
 func init() {
-	stopCmd.Flags().StringVar(&stopName, "name", "", "Worker name to stop (required)")
-	stopCmd.MarkFlagRequired("name")
+	stopCmd.Flags().StringVar(&stopName, "name", "", "Worker name to stop (required unless --all, --container or --tab)")
+	stopCmd.Flags().StringVar(&stopContainer, "container", "", "Stop the worker running in this Docker container (e.g. 'yak-worker-api-auth')")
+	stopCmd.Flags().StringVar(&stopTab, "tab", "", "Stop the worker shown in this Zellij tab")
 
 	stopCmd.Flags().StringVar(&stopTimeout, "timeout", "30s", "Docker stop timeout (e.g., '30s', '1m')")
-	stopCmd.Flags().BoolVarP(&stopForce, "force", "f", false, "Skip task cleanup and stop immediately")
+	stopCmd.Flags().BoolVarP(&stopForce, "force", "f", false, "Skip task cleanup and stop immediately; with --remove-worktree, discard uncommitted changes")
 	stopCmd.Flags().BoolVar(&stopDryRun, "dry-run", false, "Show what would happen without actually stopping")
+	stopCmd.Flags().BoolVar(&stopAll, "all", false, "Stop every registered worker concurrently")
+	stopCmd.Flags().BoolVar(&stopRmTree, "remove-worktree", false, "Remove the worktree created by spawn --auto-worktree")
+	stopCmd.Flags().BoolVarP(&stopInteract, "interactive", "i", false, "Choose the worker from a list when --name is omitted or not found")
 }
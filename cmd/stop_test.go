@@ -1,12 +1,22 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/runtime"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+	"github.com/wellmaintained/yak-box/pkg/worktree"
 )
 
 func TestStopFlags(t *testing.T) {
@@ -221,3 +231,324 @@ func TestStopTimeoutEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestStopAllValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(stopCmd.Flags())
+	t.Cleanup(func() {
+		stopName = ""
+		stopAll = false
+		stopTimeout = "30s"
+	})
+	stopTimeout = "30s"
+
+	stopName, stopAll = "", true
+	assert.NoError(t, stopCmd.PreRunE(cmd, []string{}))
+
+	stopName, stopAll = "test-worker", true
+	err := stopCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "mutually exclusive")
+}
+
+func TestStopWorkersAttemptsAll(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		attempted []string
+		gotOpts   []stopOptions
+	)
+	orig := stopWorkerFn
+	t.Cleanup(func() { stopWorkerFn = orig })
+	stopWorkerFn = func(name string, opts stopOptions) error {
+		mu.Lock()
+		attempted = append(attempted, name)
+		gotOpts = append(gotOpts, opts)
+		mu.Unlock()
+		if name == "Yakov" {
+			return fmt.Errorf("container stuck")
+		}
+		return nil
+	}
+
+	names := []string{"Yakira", "Yakov", "Yakriel", "Yakueline", "Yakoub"}
+	opts := stopOptions{Timeout: 45 * time.Second, Force: true}
+	err := stopWorkers(names, opts, 2)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Yakov")
+	assert.Equal(t, 1, errors.GetExitCode(err))
+	assert.ElementsMatch(t, names, attempted)
+	for _, o := range gotOpts {
+		assert.Equal(t, opts, o)
+	}
+}
+
+func TestStopWorkersAllSucceed(t *testing.T) {
+	orig := stopWorkerFn
+	t.Cleanup(func() { stopWorkerFn = orig })
+	stopWorkerFn = func(name string, opts stopOptions) error { return nil }
+
+	assert.NoError(t, stopWorkers([]string{"Yakira", "Yakov"}, stopOptions{}, stopAllConcurrency))
+}
+
+func TestStopTargetValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(stopCmd.Flags())
+	t.Cleanup(func() { stopName, stopContainer, stopTab, stopTimeout = "", "", "", "30s" })
+	stopTimeout = "30s"
+
+	stopName, stopContainer, stopTab = "", "yak-worker-api", ""
+	assert.NoError(t, stopCmd.PreRunE(cmd, []string{}))
+
+	stopName, stopContainer, stopTab = "", "", "Yakov 🪒🦬 api"
+	assert.NoError(t, stopCmd.PreRunE(cmd, []string{}))
+
+	stopName, stopContainer, stopTab = "api", "yak-worker-api", ""
+	err := stopCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--container and --tab are mutually exclusive")
+}
+
+func TestStopInteractiveValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(stopCmd.Flags())
+	t.Cleanup(func() { stopName, stopAll, stopInteract, stopTimeout = "", false, false, "30s" })
+	stopTimeout = "30s"
+
+	stopName, stopInteract = "", true
+	assert.NoError(t, stopCmd.PreRunE(cmd, []string{}), "--interactive does not need --name")
+
+	stopAll = true
+	err := stopCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--interactive only applies to --name")
+}
+
+// fakeTeardown records the container and tab teardown calls made by stop.
+func fakeTeardown(t *testing.T) (containers, tabs *[]string) {
+	t.Helper()
+	containers, tabs = &[]string{}, &[]string{}
+	origStop, origClose := stopContainerFn, closeTabFn
+	t.Cleanup(func() { stopContainerFn, closeTabFn = origStop, origClose })
+	stopContainerFn = func(name string, _ time.Duration) error {
+		*containers = append(*containers, name)
+		return nil
+	}
+	closeTabFn = func(name, _ string) error {
+		*tabs = append(*tabs, name)
+		return nil
+	}
+	return containers, tabs
+}
+
+func TestStopByContainerUnregistersSession(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", repo).Run())
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(repo))
+
+	require.NoError(t, sessions.Register("api-auth", sessions.Session{Worker: "Yakov", Runtime: "sandboxed", Container: "yak-worker-api-auth", DisplayName: "Yakov 🪒🦬 api-auth"}))
+	require.NoError(t, sessions.Register("docs", sessions.Session{Worker: "Yakira", Runtime: "sandboxed", Container: "yak-worker-docs", DisplayName: "Yakira 🪒🦬 docs"}))
+
+	t.Run("registered container", func(t *testing.T) {
+		containers, tabs := fakeTeardown(t)
+		require.NoError(t, stopByContainer("yak-worker-api-auth", stopOptions{Timeout: time.Second, Force: true}))
+
+		assert.Equal(t, []string{"yak-worker-api-auth"}, *containers)
+		assert.Equal(t, []string{"Yakov 🪒🦬 api-auth"}, *tabs)
+		remaining, err := sessions.List()
+		require.NoError(t, err)
+		assert.Contains(t, remaining, "docs")
+		assert.NotContains(t, remaining, "api-auth")
+	})
+
+	t.Run("unregistered container", func(t *testing.T) {
+		containers, tabs := fakeTeardown(t)
+		require.NoError(t, stopByContainer("yak-worker-orphan", stopOptions{Timeout: time.Second, Force: true}))
+
+		assert.Equal(t, []string{"yak-worker-orphan"}, *containers)
+		assert.Empty(t, *tabs)
+		remaining, err := sessions.List()
+		require.NoError(t, err)
+		assert.Contains(t, remaining, "docs")
+	})
+
+	t.Run("tab", func(t *testing.T) {
+		containers, tabs := fakeTeardown(t)
+		require.NoError(t, stopByTab("Yakira 🪒🦬 docs", stopOptions{Timeout: time.Second, Force: true}))
+
+		assert.Equal(t, []string{"yak-worker-docs"}, *containers)
+		assert.Equal(t, []string{"Yakira 🪒🦬 docs"}, *tabs)
+		remaining, err := sessions.List()
+		require.NoError(t, err)
+		assert.Empty(t, remaining)
+	})
+}
+
+func TestStopKeepsSessionWhenTeardownFails(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", repo).Run())
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(repo))
+
+	fakeTeardown(t)
+	origKill := killProcessFn
+	t.Cleanup(func() { killProcessFn = origKill })
+
+	t.Run("container stop fails", func(t *testing.T) {
+		require.NoError(t, sessions.Register("api", sessions.Session{Worker: "Yakov", Runtime: "sandboxed", Container: "yak-worker-api", Detached: true}))
+		stopContainerFn = func(string, time.Duration) error { return fmt.Errorf("failed to stop container: exit status 1") }
+
+		err := stopWorker("api", stopOptions{Timeout: time.Second, Force: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to stop container")
+		assert.Contains(t, err.Error(), "still registered")
+		_, err = sessions.Get("api")
+		assert.NoError(t, err, "session should stay registered")
+	})
+
+	t.Run("container already gone", func(t *testing.T) {
+		stopContainerFn = func(name string, _ time.Duration) error {
+			return fmt.Errorf("%w: %s", runtime.ErrContainerNotFound, name)
+		}
+
+		require.NoError(t, stopWorker("api", stopOptions{Timeout: time.Second, Force: true}))
+		_, err := sessions.Get("api")
+		assert.Error(t, err, "session should be unregistered")
+	})
+
+	t.Run("native kill fails", func(t *testing.T) {
+		require.NoError(t, sessions.Register("docs", sessions.Session{Worker: "Yakira", Runtime: "native", DisplayName: "Yakira 🪒🦬 docs", PidFile: "/tmp/worker.pid"}))
+		killProcessFn = func(string, time.Duration) error { return fmt.Errorf("process did not exit") }
+
+		err := stopWorker("docs", stopOptions{Timeout: time.Second, Force: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to kill process tree")
+		_, err = sessions.Get("docs")
+		assert.NoError(t, err, "session should stay registered")
+	})
+
+	t.Run("native process already exited", func(t *testing.T) {
+		killProcessFn = func(pidFile string, _ time.Duration) error {
+			return fmt.Errorf("%w: %s", runtime.ErrPidFileNotFound, pidFile)
+		}
+
+		require.NoError(t, stopWorker("docs", stopOptions{Timeout: time.Second, Force: true, RemoveWorktree: true}))
+		_, err := sessions.Get("docs")
+		assert.Error(t, err, "session should be unregistered")
+	})
+
+	t.Run("zellij session already gone", func(t *testing.T) {
+		require.NoError(t, sessions.Register("notes", sessions.Session{Worker: "Yakira", Runtime: "native", DisplayName: "Yakira 🪒🦬 notes"}))
+		closeTabFn = func(string, string) error {
+			return fmt.Errorf("%w: failed to query tab names: exit status 1", runtime.ErrZellijSessionNotFound)
+		}
+
+		require.NoError(t, stopWorker("notes", stopOptions{Timeout: time.Second, Force: true}))
+		_, err := sessions.Get("notes")
+		assert.Error(t, err, "session should be unregistered")
+	})
+
+	t.Run("task stays assigned until the worker stops", func(t *testing.T) {
+		taskDir := filepath.Join(repo, ".yaks", "auth", "api")
+		require.NoError(t, os.MkdirAll(taskDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(taskDir, "assigned-to"), []byte("Yakov\n"), 0644))
+		require.NoError(t, sessions.Register("api", sessions.Session{Worker: "Yakov", Runtime: "sandboxed", Container: "yak-worker-api", Detached: true, Task: "auth/api"}))
+
+		stopContainerFn = func(string, time.Duration) error { return fmt.Errorf("failed to stop container: exit status 1") }
+		require.Error(t, stopWorker("api", stopOptions{Timeout: time.Second}))
+		assert.FileExists(t, filepath.Join(taskDir, "assigned-to"), "a running worker keeps its task")
+
+		stopContainerFn = func(string, time.Duration) error { return nil }
+		require.NoError(t, stopWorker("api", stopOptions{Timeout: time.Second}))
+		assert.NoFileExists(t, filepath.Join(taskDir, "assigned-to"))
+	})
+}
+
+func TestStopDetachedWorkerSkipsTab(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", repo).Run())
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(repo))
+
+	require.NoError(t, sessions.Register("ci", sessions.Session{Worker: "Yakov", Runtime: "sandboxed", Container: "yak-worker-ci", DisplayName: "Yakov 🪒🦬 ci", Detached: true}))
+
+	containers, tabs := fakeTeardown(t)
+	require.NoError(t, stopWorker("ci", stopOptions{Timeout: time.Second, Force: true}))
+
+	assert.Equal(t, []string{"yak-worker-ci"}, *containers)
+	assert.Empty(t, *tabs, "a --no-zellij worker has no tab to close")
+}
+
+func TestStopRemovesWorktree(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", repo).Run())
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(repo))
+
+	project := filepath.Join(t.TempDir(), "project")
+	initGitRepo(t, project)
+	t.Setenv(worktree.WorktreeRootEnv, t.TempDir())
+	wtPath, err := worktree.EnsureWorktree(project, "auth/api", "", false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(wtPath, "wip.txt"), []byte("unsaved\n"), 0644))
+
+	register := func() {
+		require.NoError(t, sessions.Register("api-auth", sessions.Session{
+			Worker:       "Yakov",
+			Runtime:      "sandboxed",
+			Container:    "yak-worker-api-auth",
+			WorktreePath: wtPath,
+			ProjectPath:  project,
+		}))
+	}
+	fakeTeardown(t)
+
+	t.Run("keeps a dirty worktree without force", func(t *testing.T) {
+		register()
+		err := stopWorker("api-auth", stopOptions{Timeout: time.Second, RemoveWorktree: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "wip.txt")
+		assert.Contains(t, err.Error(), "--force")
+		assert.DirExists(t, wtPath)
+		_, err = sessions.Get("api-auth")
+		assert.NoError(t, err, "session should stay registered so stop can be retried")
+	})
+
+	t.Run("dry run only reports", func(t *testing.T) {
+		register()
+		out := captureStdout(t, func() {
+			require.NoError(t, stopWorker("api-auth", stopOptions{Timeout: time.Second, Force: true, DryRun: true, RemoveWorktree: true}))
+		})
+		assert.Contains(t, out, "[dry-run] Would remove worktree: "+wtPath)
+		assert.DirExists(t, wtPath)
+	})
+
+	t.Run("force removes the worktree", func(t *testing.T) {
+		register()
+		require.NoError(t, stopWorker("api-auth", stopOptions{Timeout: time.Second, Force: true, RemoveWorktree: true}))
+		assert.NoDirExists(t, wtPath)
+	})
+}
+
+// captureStdout returns what fn prints to os.Stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = w
+	defer func() { os.Stdout = oldStdout }()
+	fn()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
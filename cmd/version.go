@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	goruntime "runtime"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the yak-box version",
+	Long: `Print the yak-box version.
+
+With --json, the version, the Go toolchain it was built with and the VCS
+commit it was built from are printed as a JSON object, for tooling that
+needs to check compatibility. The commit is empty when the binary was built
+outside a git checkout.`,
+	Example: `  # Show the version
+  yak-box version
+
+  # Machine-readable build information
+  yak-box version --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runVersion(cmd.OutOrStdout(), jsonOutput); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+// versionInfo is the build information printed by version --json.
+type versionInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Commit    string `json:"commit"`
+}
+
+// buildVersionInfo combines the version set by SetVersion with the Go
+// version and VCS revision embedded in the binary.
+func buildVersionInfo() versionInfo {
+	info := versionInfo{Version: version, GoVersion: goruntime.Version()}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if build.GoVersion != "" {
+			info.GoVersion = build.GoVersion
+		}
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
+
+func runVersion(w io.Writer, asJSON bool) error {
+	info := buildVersionInfo()
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+	_, err := fmt.Fprintf(w, "yak-box version %s\n", info.Version)
+	return err
+}
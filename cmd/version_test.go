@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	goruntime "runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionCommand(t *testing.T) {
+	SetVersion("v1.2.3")
+	t.Cleanup(func() {
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+		jsonOutput = false
+	})
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+
+	rootCmd.SetArgs([]string{"version"})
+	require.NoError(t, rootCmd.Execute())
+	assert.Equal(t, "yak-box version v1.2.3\n", out.String())
+
+	out.Reset()
+	rootCmd.SetArgs([]string{"version", "--json"})
+	require.NoError(t, rootCmd.Execute())
+
+	var info map[string]string
+	require.NoError(t, json.Unmarshal(out.Bytes(), &info), "output: %s", out.String())
+	assert.Equal(t, "v1.2.3", info["version"])
+	assert.Equal(t, goruntime.Version(), info["goVersion"])
+	assert.Contains(t, info, "commit")
+}
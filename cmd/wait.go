@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/runtime"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+	"github.com/wellmaintained/yak-box/internal/tasks"
+	"github.com/wellmaintained/yak-box/pkg/types"
+)
+
+var (
+	waitName    string
+	waitTask    string
+	waitStatus  string
+	waitYakPath string
+	waitFollow  bool
+	waitTimeout string
+)
+
+// waitPollInterval is how often wait re-derives the worker state.
+const waitPollInterval = 2 * time.Second
+
+var waitCmd = &cobra.Command{
+	Use:   "wait (--name <worker-name> | --task <task>) [flags]",
+	Short: "Wait for a worker to stop or a task to reach a status",
+	Long: `Block until a worker reaches a terminal state, or until a task's
+agent-status reaches a given state.
+
+The worker state is polled and derived from its container (sandboxed) or
+PID file (native):
+  Spawning  registered, but the container or process is not up yet
+  Running   the agent is running
+  Idle      the container is up but the agent has exited
+  Stopped   the container or process is gone (terminal)
+
+With --task, the task's .yaks/<task>/agent-status file is polled instead,
+independent of any container, until its state (the first word, e.g. "done"
+or "blocked") matches --status.
+
+With --follow, each state transition is printed as it happens.`,
+	Example: `  # Block until the worker stops
+  yak-box wait --name api-auth
+
+  # Stream state transitions
+  yak-box wait --name api-auth --follow
+
+  # Give up after ten minutes
+  yak-box wait --name api-auth --timeout 10m
+
+  # Block until a worker marks its task done
+  yak-box wait --task auth/api --status done`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		var errs []error
+
+		if waitName == "" && waitTask == "" {
+			errs = append(errs, fmt.Errorf("--name or --task is required (worker or task to wait for)"))
+		}
+		if waitName != "" && waitTask != "" {
+			errs = append(errs, fmt.Errorf("--name and --task are mutually exclusive"))
+		}
+		if waitTask != "" && strings.TrimSpace(waitStatus) == "" {
+			errs = append(errs, fmt.Errorf("--status cannot be empty"))
+		}
+
+		if waitTimeout != "" {
+			if _, err := time.ParseDuration(waitTimeout); err != nil {
+				errs = append(errs, fmt.Errorf("--timeout has invalid format: %v (use '30s', '10m', etc.)", err))
+			}
+		}
+
+		if len(errs) > 0 {
+			combined := "Validation errors:\n"
+			for _, err := range errs {
+				combined += fmt.Sprintf("  - %s\n", err)
+			}
+			return errors.NewValidationError(combined, nil)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runWait(cmd.Context(), cmd); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+// stateSource reports a worker's current state.
+type stateSource func(ctx context.Context) (runtime.WorkerState, error)
+
+func runWait(ctx context.Context, cmd *cobra.Command) error {
+	if waitTimeout != "" {
+		timeout, err := time.ParseDuration(waitTimeout)
+		if err != nil {
+			return errors.NewValidationError("invalid --timeout. Use a valid duration like '30s' or '10m'", err)
+		}
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+	}
+
+	if waitTask != "" {
+		return runWaitTask(ctx, cmd)
+	}
+
+	source := func(ctx context.Context) (runtime.WorkerState, error) {
+		session, err := sessions.Get(waitName)
+		if goerrors.Is(err, sessions.ErrSessionNotFound) {
+			return runtime.StateStopped, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return runtime.DeriveWorkerState(ctx, session)
+	}
+
+	state, err := waitForTerminal(ctx, source, waitPollInterval, os.Stdout, waitFollow)
+	if err != nil {
+		return err
+	}
+	if !waitFollow {
+		fmt.Printf("%s: %s\n", waitName, state)
+	}
+	return nil
+}
+
+// waitForTerminal polls source every interval until it reports a terminal
+// state. When follow is set, each state change is written to out once.
+func waitForTerminal(ctx context.Context, source stateSource, interval time.Duration, out io.Writer, follow bool) (runtime.WorkerState, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last runtime.WorkerState
+	for {
+		state, err := source(ctx)
+		if err != nil {
+			return last, fmt.Errorf("failed to determine worker state: %w", err)
+		}
+		if state != last {
+			if follow {
+				fmt.Fprintf(out, "%s  %s\n", time.Now().Format("15:04:05"), state)
+			}
+			last = state
+		}
+		if state.IsTerminal() {
+			return state, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return last, errors.NewRuntimeError(fmt.Sprintf("stopped waiting with worker in state %s", last), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func runWaitTask(ctx context.Context, cmd *cobra.Command) error {
+	var absYakPath string
+	var err error
+	if cmd.Flags().Changed("yak-path") {
+		absYakPath, err = filepath.Abs(waitYakPath)
+	} else {
+		var cwd string
+		if cwd, err = os.Getwd(); err == nil {
+			absYakPath, err = findYakPath(cwd, filepath.Base(waitYakPath))
+		}
+	}
+	if err != nil {
+		return errors.NewValidationError("failed to resolve yak path", err)
+	}
+
+	taskDir, err := findTaskDir(absYakPath, types.SlugifyTaskPath(waitTask))
+	if err != nil {
+		return errors.NewValidationError(fmt.Sprintf("task %q not found", waitTask), err)
+	}
+
+	status, err := waitForTaskStatus(ctx, taskDir, waitStatus, waitPollInterval, os.Stdout, waitFollow)
+	if err != nil {
+		return err
+	}
+	if !waitFollow {
+		fmt.Printf("%s: %s\n", waitTask, status)
+	}
+	return nil
+}
+
+// waitForTaskStatus polls the agent-status file in taskDir every interval
+// until its state matches want, and returns the full status line. When
+// follow is set, each status change is written to out once.
+func waitForTaskStatus(ctx context.Context, taskDir, want string, interval time.Duration, out io.Writer, follow bool) (string, error) {
+	want = tasks.State(strings.TrimSpace(want))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last, first := "", true
+	for {
+		status, err := tasks.ReadStatus(taskDir)
+		if err != nil {
+			return last, fmt.Errorf("failed to read task status: %w", err)
+		}
+		if status != last || first {
+			if follow {
+				shown := status
+				if shown == "" {
+					shown = "(no status)"
+				}
+				fmt.Fprintf(out, "%s  %s\n", time.Now().Format("15:04:05"), shown)
+			}
+			last, first = status, false
+		}
+		if tasks.State(status) == want {
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return last, errors.NewRuntimeError(fmt.Sprintf("stopped waiting with task status %q", last), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func init() {
+	waitCmd.Flags().StringVar(&waitName, "name", "", "Worker name to wait for")
+	waitCmd.Flags().StringVar(&waitTask, "task", "", "Task path to wait for (polls its agent-status)")
+	waitCmd.Flags().StringVar(&waitStatus, "status", "done", "Task state to wait for with --task (e.g., 'done', 'blocked')")
+	waitCmd.Flags().StringVar(&waitYakPath, "yak-path", ".yaks", "Path to task state directory")
+	waitCmd.Flags().BoolVarP(&waitFollow, "follow", "f", false, "Print each state transition as it happens")
+	waitCmd.Flags().StringVar(&waitTimeout, "timeout", "", "Give up after this duration (e.g., '10m'; default: wait indefinitely)")
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/runtime"
+	"github.com/wellmaintained/yak-box/internal/tasks"
+)
+
+// sequenceSource replays states in order, repeating the last one.
+func sequenceSource(states ...runtime.WorkerState) stateSource {
+	i := 0
+	return func(context.Context) (runtime.WorkerState, error) {
+		state := states[i]
+		if i < len(states)-1 {
+			i++
+		}
+		return state, nil
+	}
+}
+
+func TestWaitValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(waitCmd.Flags())
+	t.Cleanup(func() { waitName, waitTimeout = "", "" })
+
+	waitName, waitTimeout = "", ""
+	err := waitCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--name or --task is required")
+
+	waitName, waitTimeout = "api-auth", "soon"
+	err = waitCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--timeout has invalid format")
+
+	waitName, waitTimeout = "api-auth", "10m"
+	assert.NoError(t, waitCmd.PreRunE(cmd, []string{}))
+}
+
+func TestWaitTaskValidation(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(waitCmd.Flags())
+	t.Cleanup(func() { waitName, waitTask, waitStatus = "", "", "done" })
+
+	waitName, waitTask, waitStatus = "api-auth", "auth/api", "done"
+	err := waitCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "mutually exclusive")
+
+	waitName, waitTask, waitStatus = "", "auth/api", " "
+	err = waitCmd.PreRunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--status cannot be empty")
+
+	waitName, waitTask, waitStatus = "", "auth/api", "done"
+	assert.NoError(t, waitCmd.PreRunE(cmd, []string{}))
+}
+
+func TestWaitForTaskStatus(t *testing.T) {
+	taskDir := t.TempDir()
+	statusPath := filepath.Join(taskDir, tasks.StatusFile)
+	require.NoError(t, os.WriteFile(statusPath, []byte("wip\n"), 0644))
+
+	// Replace the file atomically so the poller never sees a partial write.
+	writeStatus := func(status string) {
+		tmp := statusPath + ".tmp"
+		_ = os.WriteFile(tmp, []byte(status), 0644)
+		_ = os.Rename(tmp, statusPath)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		writeStatus("blocked: needs review\n")
+		time.Sleep(20 * time.Millisecond)
+		writeStatus("done: merged\n")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var out bytes.Buffer
+	status, err := waitForTaskStatus(ctx, taskDir, "done", time.Millisecond, &out, true)
+	require.NoError(t, err)
+	assert.Equal(t, "done: merged", status)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "wip")
+	assert.Contains(t, lines[1], "blocked: needs review")
+	assert.Contains(t, lines[2], "done: merged")
+}
+
+func TestWaitForTaskStatusTimeout(t *testing.T) {
+	taskDir := t.TempDir()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var out bytes.Buffer
+	_, err := waitForTaskStatus(ctx, taskDir, "done", time.Millisecond, &out, true)
+	require.Error(t, err)
+	assert.Equal(t, 1, errors.GetExitCode(err))
+	assert.Contains(t, err.Error(), "stopped waiting with task status")
+	assert.Contains(t, out.String(), "(no status)")
+}
+
+func TestWaitForTerminalFollow(t *testing.T) {
+	source := sequenceSource(
+		runtime.StateSpawning,
+		runtime.StateSpawning,
+		runtime.StateRunning,
+		runtime.StateRunning,
+		runtime.StateRunning,
+		runtime.StateIdle,
+		runtime.StateStopped,
+	)
+
+	var out bytes.Buffer
+	state, err := waitForTerminal(context.Background(), source, time.Millisecond, &out, true)
+	require.NoError(t, err)
+	assert.Equal(t, runtime.StateStopped, state)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	for i, want := range []runtime.WorkerState{runtime.StateSpawning, runtime.StateRunning, runtime.StateIdle, runtime.StateStopped} {
+		assert.True(t, strings.HasSuffix(lines[i], string(want)), "line %d = %q, want %s", i, lines[i], want)
+	}
+}
+
+func TestWaitForTerminalSilentWithoutFollow(t *testing.T) {
+	var out bytes.Buffer
+	state, err := waitForTerminal(context.Background(), sequenceSource(runtime.StateRunning, runtime.StateStopped), time.Millisecond, &out, false)
+	require.NoError(t, err)
+	assert.Equal(t, runtime.StateStopped, state)
+	assert.Empty(t, out.String())
+}
+
+func TestWaitForTerminalTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	state, err := waitForTerminal(ctx, sequenceSource(runtime.StateRunning), time.Millisecond, &bytes.Buffer{}, false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Running")
+	assert.Equal(t, runtime.StateRunning, state)
+}
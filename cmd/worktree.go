@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+	"github.com/wellmaintained/yak-box/internal/ui"
+	"github.com/wellmaintained/yak-box/pkg/worktree"
+)
+
+var (
+	worktreeCWD          string
+	worktreeForce        bool
+	worktreeDeleteBranch bool
+)
+
+var worktreeCmd = &cobra.Command{
+	Use:   "worktree",
+	Short: "Manage task worktrees",
+	Long:  `Manage the git worktrees created by spawn --auto-worktree.`,
+}
+
+var worktreeRemoveCmd = &cobra.Command{
+	Use:   "remove <task>",
+	Short: "Remove the worktree for a task",
+	Long: `Remove the git worktree created for a task.
+
+The task path is mapped to its branch name the same way spawn --auto-worktree
+does it (slashes become dashes), and the worktree with that branch checked out
+is removed from the repository at --cwd.
+
+A worktree still used by an active session is not removed unless --force is
+given. --force also removes worktrees with local modifications.`,
+	Example: `  # Remove the worktree for a task
+  yak-box worktree remove release/docs --cwd ./repos/yak-box
+
+  # Also delete the task branch
+  yak-box worktree remove release/docs --cwd ./repos/yak-box --delete-branch`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if strings.TrimSpace(args[0]) == "" {
+			return errors.NewValidationError("task path cannot be empty", nil)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runWorktreeRemove(args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func runWorktreeRemove(taskPath string) error {
+	projectPath, err := filepath.Abs(worktreeCWD)
+	if err != nil {
+		return errors.NewValidationError("failed to resolve --cwd", err)
+	}
+	if !worktree.IsGitRepo(projectPath) {
+		return errors.NewValidationError(fmt.Sprintf("not a git repository: %s. Suggestion: Point --cwd at the repository the worktree was created from", projectPath), nil)
+	}
+
+	branchName := worktree.BranchForTask(strings.Trim(taskPath, "/"))
+	worktreePath, err := worktree.GetWorktreePath(projectPath, branchName)
+	if err != nil {
+		return errors.NewValidationError(fmt.Sprintf("no worktree for task %q in %s", taskPath, projectPath), err)
+	}
+
+	if !worktreeForce {
+		all, err := sessions.List()
+		if err != nil {
+			return fmt.Errorf("failed to load sessions: %w. Suggestion: Fix .yak-boxes/sessions.json or pass --force", err)
+		}
+		if users := sessionsUsingWorktree(all, branchName, worktreePath); len(users) > 0 {
+			return errors.NewValidationError(
+				fmt.Sprintf("worktree %s is in use by session(s): %s. Stop them first or pass --force", worktreePath, strings.Join(users, ", ")), nil)
+		}
+	}
+
+	if err := worktree.Remove(projectPath, branchName, worktreeForce); err != nil {
+		return errors.NewRuntimeError("failed to remove worktree", err)
+	}
+	ui.Success("✅ Removed worktree: %s\n", worktreePath)
+
+	if worktreeDeleteBranch {
+		if err := worktree.DeleteBranch(projectPath, branchName, worktreeForce); err != nil {
+			return errors.NewRuntimeError("failed to delete branch", err)
+		}
+		ui.Success("✅ Deleted branch: %s\n", branchName)
+	}
+	return nil
+}
+
+// sessionsUsingWorktree returns the sorted names of sessions whose task maps
+// to branchName or whose working directory is worktreePath.
+func sessionsUsingWorktree(all sessions.Sessions, branchName, worktreePath string) []string {
+	var users []string
+	for name, session := range all {
+		usesBranch := session.Task != "" && worktree.BranchForTask(strings.Trim(session.Task, "/")) == branchName
+		if usesBranch || (session.CWD != "" && filepath.Clean(session.CWD) == filepath.Clean(worktreePath)) {
+			users = append(users, name)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+func init() {
+	worktreeRemoveCmd.Flags().StringVar(&worktreeCWD, "cwd", ".", "Repository the worktree was created from")
+	worktreeRemoveCmd.Flags().BoolVarP(&worktreeForce, "force", "f", false, "Remove even if the worktree is dirty or used by an active session")
+	worktreeRemoveCmd.Flags().BoolVar(&worktreeDeleteBranch, "delete-branch", false, "Also delete the task branch")
+	worktreeCmd.AddCommand(worktreeRemoveCmd)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wellmaintained/yak-box/internal/sessions"
+	"github.com/wellmaintained/yak-box/pkg/worktree"
+)
+
+func initCommittedRepo(t *testing.T, repo string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(repo, 0755))
+	for _, args := range [][]string{
+		{"init"},
+		{"-c", "user.name=yak-box-test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+}
+
+func TestSessionsUsingWorktree(t *testing.T) {
+	all := sessions.Sessions{
+		"by-task": {Task: "release/docs/"},
+		"by-cwd":  {Task: "other", CWD: "/wt/release-docs/"},
+		"idle":    {Task: "other", CWD: "/elsewhere"},
+	}
+	assert.Equal(t, []string{"by-cwd", "by-task"}, sessionsUsingWorktree(all, "release-docs", "/wt/release-docs"))
+	assert.Empty(t, sessionsUsingWorktree(all, "unrelated", "/wt/unrelated"))
+}
+
+func TestRunWorktreeRemove(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := filepath.Join(tmpDir, "repo")
+	initCommittedRepo(t, repo)
+	t.Setenv(worktree.WorktreeRootEnv, filepath.Join(tmpDir, "worktrees"))
+
+	origWd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(origWd) })
+	require.NoError(t, os.Chdir(repo))
+
+	wtPath, err := worktree.EnsureWorktree(repo, "release/docs", "", false)
+	require.NoError(t, err)
+	require.NoError(t, sessions.Register("docs-worker", sessions.Session{Worker: "Yakov", Task: "release/docs"}))
+
+	t.Cleanup(func() { worktreeCWD, worktreeForce, worktreeDeleteBranch = ".", false, false })
+	worktreeCWD = repo
+
+	t.Run("refuses while a session uses it", func(t *testing.T) {
+		worktreeForce = false
+		err := runWorktreeRemove("release/docs")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "docs-worker")
+		_, statErr := os.Stat(wtPath)
+		assert.NoError(t, statErr)
+	})
+
+	t.Run("force removes it", func(t *testing.T) {
+		worktreeForce, worktreeDeleteBranch = true, true
+		require.NoError(t, runWorktreeRemove("release/docs"))
+
+		out, err := exec.Command("git", "-C", repo, "worktree", "list").Output()
+		require.NoError(t, err)
+		assert.NotContains(t, string(out), wtPath)
+		assert.Error(t, exec.Command("git", "-C", repo, "show-ref", "--verify", "--quiet", "refs/heads/release-docs").Run())
+	})
+
+	t.Run("unknown task", func(t *testing.T) {
+		err := runWorktreeRemove("release/docs")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no worktree for task")
+	})
+}
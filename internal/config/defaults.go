@@ -0,0 +1,100 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// DefaultsFile is the name of the flag defaults file inside the metadata dir.
+const DefaultsFile = "config.json"
+
+// Defaults holds per-command flag defaults, keyed by command path below the
+// root command (e.g. "spawn" or "worktree remove") and then by flag name.
+//
+//	{"spawn": {"runtime": "native", "tool": "claude", "env-file": ["a.env", "b.env"]}}
+type Defaults map[string]map[string]FlagValue
+
+// FlagValue is one or more values to pass to a flag's Set method. JSON
+// strings, numbers and booleans give a single value; arrays give one value
+// per element, for repeatable flags.
+type FlagValue []string
+
+// UnmarshalJSON accepts a scalar or an array of scalars.
+func (v *FlagValue) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		values := make(FlagValue, 0, len(raw))
+		for _, elem := range raw {
+			s, err := scalarString(elem)
+			if err != nil {
+				return err
+			}
+			values = append(values, s)
+		}
+		*v = values
+		return nil
+	}
+
+	s, err := scalarString(data)
+	if err != nil {
+		return err
+	}
+	*v = FlagValue{s}
+	return nil
+}
+
+// scalarString renders a JSON string, number or boolean as flag text
+func scalarString(data json.RawMessage) (string, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", err
+	}
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("flag values must be strings, numbers, booleans or arrays of them (got %s)", data)
+	}
+}
+
+// DefaultsPath returns the path of the flag defaults file for the current
+// workspace.
+func DefaultsPath() (string, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cfg.MetadataDir, DefaultsFile), nil
+}
+
+// LoadDefaults reads flag defaults from path. A missing file yields empty
+// defaults.
+func LoadDefaults(path string) (Defaults, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Defaults{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var defaults Defaults
+	if err := json.Unmarshal(data, &defaults); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if defaults == nil {
+		defaults = Defaults{}
+	}
+	return defaults, nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultsFile)
+	content := `{
+  "spawn": {"runtime": "native", "pids": 256, "init": true, "env-file": ["a.env", "b.env"]},
+  "worktree remove": {"delete-branch": false}
+}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	got, err := LoadDefaults(path)
+	if err != nil {
+		t.Fatalf("LoadDefaults() error = %v", err)
+	}
+	want := Defaults{
+		"spawn": {
+			"runtime":  {"native"},
+			"pids":     {"256"},
+			"init":     {"true"},
+			"env-file": {"a.env", "b.env"},
+		},
+		"worktree remove": {"delete-branch": {"false"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadDefaults() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadDefaultsMissingFile(t *testing.T) {
+	got, err := LoadDefaults(filepath.Join(t.TempDir(), DefaultsFile))
+	if err != nil {
+		t.Fatalf("LoadDefaults() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("LoadDefaults() = %v, want empty defaults", got)
+	}
+}
+
+func TestLoadDefaultsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"malformed json": `{"spawn": `,
+		"object value":   `{"spawn": {"runtime": {"name": "native"}}}`,
+		"nested array":   `{"spawn": {"env-file": [["a.env"]]}}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DefaultsFile)
+			os.WriteFile(path, []byte(content), 0644)
+			if _, err := LoadDefaults(path); err == nil {
+				t.Errorf("LoadDefaults(%s) expected error", content)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ParseEnvFile reads a dotenv-format file into a map.
+//
+// Each non-blank line that does not start with # must be KEY=VALUE, optionally
+// prefixed with "export ". Values may be double-quoted (supporting \n, \t, \"
+// and \\ escapes), single-quoted (taken literally) or bare, in which case a
+// trailing " #comment" is dropped and surrounding whitespace is trimmed.
+// Malformed lines are reported with their line number. Later assignments to
+// the same key override earlier ones.
+func ParseEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, err := parseEnvLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		vars[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// ParseAssignment splits a KEY=VALUE command-line assignment. Unlike a
+// dotenv line the value is taken literally, since the shell has already
+// handled any quoting; it may be empty or contain further "=" signs.
+func ParseAssignment(s string) (string, string, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return "", "", fmt.Errorf("expected KEY=VALUE, got %q", s)
+	}
+	if !isValidEnvKey(key) {
+		return "", "", fmt.Errorf("invalid variable name %q", key)
+	}
+	return key, value, nil
+}
+
+// parseEnvLine splits a trimmed, non-comment dotenv line into key and value.
+func parseEnvLine(line string) (string, string, error) {
+	line = strings.TrimPrefix(line, "export ")
+	key, raw, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", "", fmt.Errorf("expected KEY=VALUE, got %q", line)
+	}
+	key = strings.TrimSpace(key)
+	if !isValidEnvKey(key) {
+		return "", "", fmt.Errorf("invalid variable name %q", key)
+	}
+
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return key, "", nil
+	}
+
+	switch quote := raw[0]; quote {
+	case '"', '\'':
+		end := closingQuote(raw, quote)
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated quoted value for %s", key)
+		}
+		if rest := strings.TrimSpace(raw[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", "", fmt.Errorf("unexpected text after quoted value for %s", key)
+		}
+		value := raw[1:end]
+		if quote == '"' {
+			value = unescapeDoubleQuoted(value)
+		}
+		return key, value, nil
+	default:
+		if i := strings.Index(raw, " #"); i >= 0 {
+			raw = raw[:i]
+		}
+		return key, strings.TrimSpace(raw), nil
+	}
+}
+
+// closingQuote returns the index of the quote that closes raw[0], or -1.
+// Inside double quotes a backslash escapes the next character.
+func closingQuote(raw string, quote byte) int {
+	for i := 1; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeDoubleQuoted(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case '"', '\\':
+			sb.WriteByte(s[i])
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// isValidEnvKey reports whether key is a POSIX shell variable name.
+func isValidEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		if r == '_' || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
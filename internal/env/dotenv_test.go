@@ -0,0 +1,132 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeEnvFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+	return path
+}
+
+func TestParseEnvFile(t *testing.T) {
+	path := writeEnvFile(t, `# Team defaults
+LOG_LEVEL=debug
+
+export REGION=eu-west-1
+GREETING="hello world"
+ESCAPED="line1\nline2 \"quoted\""
+LITERAL='no $expansion \n here'
+TRAILING=value # explained here
+HASH_IN_VALUE=abc#123
+QUOTED_WITH_COMMENT="kept" # dropped
+EMPTY=
+  SPACED  =  padded
+LOG_LEVEL=info
+`)
+
+	got, err := ParseEnvFile(path)
+	if err != nil {
+		t.Fatalf("ParseEnvFile() error = %v", err)
+	}
+
+	want := map[string]string{
+		"LOG_LEVEL":           "info",
+		"REGION":              "eu-west-1",
+		"GREETING":            "hello world",
+		"ESCAPED":             "line1\nline2 \"quoted\"",
+		"LITERAL":             `no $expansion \n here`,
+		"TRAILING":            "value",
+		"HASH_IN_VALUE":       "abc#123",
+		"QUOTED_WITH_COMMENT": "kept",
+		"EMPTY":               "",
+		"SPACED":              "padded",
+	}
+	if len(got) != len(want) {
+		t.Errorf("ParseEnvFile() returned %d vars, want %d: %v", len(got), len(want), got)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %q, want %q", key, got[key], value)
+		}
+	}
+}
+
+func TestParseEnvFileBlankAndComments(t *testing.T) {
+	path := writeEnvFile(t, "\n# only comments\n   \n\t# indented comment\n")
+
+	got, err := ParseEnvFile(path)
+	if err != nil {
+		t.Fatalf("ParseEnvFile() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ParseEnvFile() = %v, want empty", got)
+	}
+}
+
+func TestParseEnvFileMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "missing equals", content: "GOOD=1\nNOT_AN_ASSIGNMENT\n", wantErr: ":2: expected KEY=VALUE"},
+		{name: "empty key", content: "=value\n", wantErr: "invalid variable name"},
+		{name: "invalid key", content: "MY-VAR=1\n", wantErr: `invalid variable name "MY-VAR"`},
+		{name: "leading digit", content: "1VAR=1\n", wantErr: "invalid variable name"},
+		{name: "unterminated double quote", content: "A=\"open\n", wantErr: "unterminated quoted value for A"},
+		{name: "unterminated single quote", content: "A='open\n", wantErr: "unterminated quoted value for A"},
+		{name: "text after quote", content: "A=\"x\" y\n", wantErr: "unexpected text after quoted value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseEnvFile(writeEnvFile(t, tt.content))
+			if err == nil {
+				t.Fatal("ParseEnvFile() expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want substring %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseEnvFileMissing(t *testing.T) {
+	if _, err := ParseEnvFile(filepath.Join(t.TempDir(), "missing.env")); !os.IsNotExist(err) {
+		t.Errorf("ParseEnvFile() error = %v, want not-exist", err)
+	}
+}
+
+func TestParseAssignment(t *testing.T) {
+	tests := []struct {
+		in, key, value string
+		wantErr        string
+	}{
+		{in: "LOG_LEVEL=debug", key: "LOG_LEVEL", value: "debug"},
+		{in: "QUERY=a=b", key: "QUERY", value: "a=b"},
+		{in: "EMPTY=", key: "EMPTY", value: ""},
+		{in: `GREETING="hi" # kept`, key: "GREETING", value: `"hi" # kept`},
+		{in: "LOG_LEVEL", wantErr: "expected KEY=VALUE"},
+		{in: "1BAD=x", wantErr: "invalid variable name"},
+	}
+	for _, tt := range tests {
+		key, value, err := ParseAssignment(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseAssignment(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || key != tt.key || value != tt.value {
+			t.Errorf("ParseAssignment(%q) = %q, %q, %v, want %q, %q", tt.in, key, value, err, tt.key, tt.value)
+		}
+	}
+}
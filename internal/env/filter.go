@@ -43,7 +43,7 @@ func FilterSensitive(envVars map[string]string) map[string]string {
 	var filteredKeys []string
 
 	for key, value := range envVars {
-		if isSensitive(key) {
+		if IsSensitive(key) {
 			filteredKeys = append(filteredKeys, key)
 		} else {
 			filtered[key] = value
@@ -58,9 +58,9 @@ func FilterSensitive(envVars map[string]string) map[string]string {
 	return filtered
 }
 
-// isSensitive checks if an environment variable key matches any of the sensitive patterns.
+// IsSensitive reports whether an environment variable key matches any of the sensitive patterns.
 // The check is case-insensitive and uses substring matching.
-func isSensitive(key string) bool {
+func IsSensitive(key string) bool {
 	upperKey := strings.ToUpper(key)
 
 	for _, pattern := range sensitivePatterns {
@@ -228,9 +228,9 @@ func TestIsSensitive(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.key, func(t *testing.T) {
-			result := isSensitive(tt.key)
+			result := IsSensitive(tt.key)
 			if result != tt.expected {
-				t.Errorf("isSensitive(%q) = %v, expected %v", tt.key, result, tt.expected)
+				t.Errorf("IsSensitive(%q) = %v, expected %v", tt.key, result, tt.expected)
 			}
 		})
 	}
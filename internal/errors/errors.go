@@ -6,6 +6,9 @@
 // Exit code conventions:
 //   - 1: General runtime errors (e.g., file I/O, network issues)
 //   - 2: Validation/usage errors (e.g., invalid flags, malformed input)
+//   - 3: No worker runtime available (ExitNoRuntime)
+//   - 4: Devcontainer image build failed (ExitBuildFailed)
+//   - 5: Git worktree could not be created (ExitWorktree)
 //
 // Example usage:
 //
@@ -25,6 +28,13 @@ import (
 	"fmt"
 )
 
+// Exit codes carried by CodedError so scripts can tell failures apart.
+const (
+	ExitNoRuntime   = 3
+	ExitBuildFailed = 4
+	ExitWorktree    = 5
+)
+
 // ValidationError represents a validation or usage error.
 // These errors indicate improper input or configuration and should result in exit code 2.
 type ValidationError struct {
@@ -65,6 +75,30 @@ func (e *RuntimeError) Unwrap() error {
 	return e.Cause
 }
 
+// CodedError is an error that exits with an explicit code.
+type CodedError struct {
+	Code    int
+	Message string
+	Cause   error
+}
+
+// Error implements the error interface for CodedError. Without a message the
+// cause's text is used as-is.
+func (e *CodedError) Error() string {
+	switch {
+	case e.Message == "" && e.Cause != nil:
+		return e.Cause.Error()
+	case e.Cause != nil:
+		return fmt.Sprintf("%s: %v", e.Message, e.Cause)
+	}
+	return e.Message
+}
+
+// Unwrap implements the error unwrapping interface for error chain inspection.
+func (e *CodedError) Unwrap() error {
+	return e.Cause
+}
+
 // NewValidationError creates a new ValidationError with the given message and cause.
 // Returns an error interface to support standard Go error handling.
 func NewValidationError(msg string, cause error) error {
@@ -83,15 +117,34 @@ func NewRuntimeError(msg string, cause error) error {
 	}
 }
 
+// NewCodedError creates a new CodedError that exits with code.
+func NewCodedError(code int, msg string, cause error) error {
+	return &CodedError{
+		Code:    code,
+		Message: msg,
+		Cause:   cause,
+	}
+}
+
+// WithExitCode wraps err so that it exits with code, keeping its message.
+func WithExitCode(code int, err error) error {
+	return NewCodedError(code, "", err)
+}
+
 // GetExitCode extracts the appropriate exit code from an error.
 // Returns:
+//   - the explicit code of a CodedError
 //   - 2 for ValidationError
 //   - 1 for RuntimeError
 //   - 1 for unknown errors
 func GetExitCode(err error) int {
+	var codedErr *CodedError
 	var validationErr *ValidationError
 	var runtimeErr *RuntimeError
 
+	if errors.As(err, &codedErr) {
+		return codedErr.Code
+	}
 	if errors.As(err, &validationErr) {
 		return 2
 	}
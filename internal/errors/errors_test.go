@@ -2,6 +2,7 @@ package errors
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -93,6 +94,16 @@ func TestGetExitCode(t *testing.T) {
 			err:      NewRuntimeError("runtime failure", errors.New("i/o error")),
 			wantCode: 1,
 		},
+		{
+			name:     "coded error returns its code",
+			err:      NewCodedError(ExitBuildFailed, "build failed", nil),
+			wantCode: 4,
+		},
+		{
+			name:     "wrapped coded error returns its code",
+			err:      fmt.Errorf("spawn: %w", WithExitCode(ExitWorktree, errors.New("not a git repo"))),
+			wantCode: 5,
+		},
 		{
 			name:     "unknown error returns code 1",
 			err:      errors.New("unknown error"),
@@ -115,6 +126,30 @@ func TestGetExitCode(t *testing.T) {
 	}
 }
 
+func TestCodedError(t *testing.T) {
+	cause := errors.New("docker build exited 1")
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "message and cause", err: NewCodedError(ExitBuildFailed, "build failed", cause), want: "build failed: docker build exited 1"},
+		{name: "message only", err: NewCodedError(ExitNoRuntime, "no runtime", nil), want: "no runtime"},
+		{name: "cause only", err: WithExitCode(ExitBuildFailed, cause), want: "docker build exited 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if !errors.Is(WithExitCode(ExitBuildFailed, cause), cause) {
+		t.Error("CodedError should unwrap to its cause")
+	}
+}
+
 func TestErrorUnwrapping(t *testing.T) {
 	tests := []struct {
 		name        string
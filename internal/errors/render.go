@@ -0,0 +1,64 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Error types reported in the "type" field of the JSON error envelope.
+const (
+	TypeValidation  = "validation"
+	TypeRuntime     = "runtime"
+	TypeNoRuntime   = "no_runtime"
+	TypeBuildFailed = "build_failed"
+	TypeWorktree    = "worktree"
+)
+
+// envelope is the JSON object Render writes in JSON mode.
+type envelope struct {
+	Error string `json:"error"`
+	Code  int    `json:"code"`
+	Type  string `json:"type"`
+}
+
+// GetType classifies err for the JSON error envelope, following the same
+// precedence as GetExitCode. Errors of no known type are runtime errors.
+func GetType(err error) string {
+	var codedErr *CodedError
+	var validationErr *ValidationError
+	if errors.As(err, &codedErr) {
+		switch codedErr.Code {
+		case ExitNoRuntime:
+			return TypeNoRuntime
+		case ExitBuildFailed:
+			return TypeBuildFailed
+		case ExitWorktree:
+			return TypeWorktree
+		case 2:
+			return TypeValidation
+		}
+		return TypeRuntime
+	}
+	if errors.As(err, &validationErr) {
+		return TypeValidation
+	}
+	return TypeRuntime
+}
+
+// Render writes err to w for the user. In JSON mode it writes a single line
+// {"error": "...", "code": N, "type": "..."}; otherwise the human
+// "Error: ..." line. The caller still exits with GetExitCode(err).
+func Render(err error, jsonMode bool, w io.Writer) {
+	if !jsonMode {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return
+	}
+	data, marshalErr := json.Marshal(envelope{Error: err.Error(), Code: GetExitCode(err), Type: GetType(err)})
+	if marshalErr != nil {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return
+	}
+	fmt.Fprintf(w, "%s\n", data)
+}
@@ -0,0 +1,77 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRenderJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantType string
+		wantMsg  string
+	}{
+		{
+			name:     "validation error",
+			err:      NewValidationError("--mode must be 'plan' or 'build'", nil),
+			wantCode: 2,
+			wantType: TypeValidation,
+			wantMsg:  "--mode must be 'plan' or 'build'",
+		},
+		{
+			name:     "runtime error",
+			err:      NewRuntimeError("failed to list containers", errors.New("daemon down")),
+			wantCode: 1,
+			wantType: TypeRuntime,
+			wantMsg:  "failed to list containers: daemon down",
+		},
+		{
+			name:     "plain error",
+			err:      errors.New("boom"),
+			wantCode: 1,
+			wantType: TypeRuntime,
+			wantMsg:  "boom",
+		},
+		{
+			name:     "coded error",
+			err:      fmt.Errorf("spawn: %w", NewCodedError(ExitWorktree, "failed to ensure worktree", nil)),
+			wantCode: ExitWorktree,
+			wantType: TypeWorktree,
+			wantMsg:  "spawn: failed to ensure worktree",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			Render(tt.err, true, &buf)
+
+			var got map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("output %q is not JSON: %v", buf.String(), err)
+			}
+			if got["error"] != tt.wantMsg {
+				t.Errorf("error = %v, want %q", got["error"], tt.wantMsg)
+			}
+			if got["code"] != float64(tt.wantCode) {
+				t.Errorf("code = %v, want %d", got["code"], tt.wantCode)
+			}
+			if got["type"] != tt.wantType {
+				t.Errorf("type = %v, want %q", got["type"], tt.wantType)
+			}
+		})
+	}
+}
+
+func TestRenderHuman(t *testing.T) {
+	var buf bytes.Buffer
+	Render(NewValidationError("bad flag", nil), false, &buf)
+	if buf.String() != "Error: bad flag\n" {
+		t.Errorf("got %q, want the human error line", buf.String())
+	}
+}
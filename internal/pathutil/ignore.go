@@ -0,0 +1,127 @@
+package pathutil
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreMatcher matches slash-separated relative paths against a list of
+// gitignore-style patterns. Supported syntax: blank lines and '#' comments,
+// '!' negation, a trailing '/' for directory-only patterns, a leading or inner
+// '/' to anchor a pattern to the root, and '**' to match any number of path
+// segments. Later patterns override earlier ones, as in .gitignore.
+type IgnoreMatcher struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ParseIgnore builds a matcher from the contents of an ignore file.
+func ParseIgnore(data string) *IgnoreMatcher {
+	m := &IgnoreMatcher{}
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var p ignorePattern
+		if rest, ok := strings.CutPrefix(line, "!"); ok {
+			p.negate = true
+			line = rest
+		}
+		if rest, ok := strings.CutSuffix(line, "/"); ok {
+			p.dirOnly = true
+			line = rest
+		}
+		if rest, ok := strings.CutPrefix(line, "/"); ok {
+			p.anchored = true
+			line = rest
+		}
+		if line == "" {
+			continue
+		}
+		if strings.Contains(line, "/") {
+			p.anchored = true
+		}
+		p.segments = strings.Split(line, "/")
+		m.patterns = append(m.patterns, p)
+	}
+	return m
+}
+
+// LoadIgnoreFile reads and parses the ignore file at path. A missing file
+// yields an empty matcher that ignores nothing.
+func LoadIgnoreFile(path string) (*IgnoreMatcher, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &IgnoreMatcher{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseIgnore(string(data)), nil
+}
+
+// Match reports whether relPath (relative to the ignore file's directory)
+// is ignored. isDir must be true when relPath names a directory.
+func (m *IgnoreMatcher) Match(relPath string, isDir bool) bool {
+	if m == nil || len(m.patterns) == 0 {
+		return false
+	}
+	relPath = strings.Trim(filepath.ToSlash(filepath.Clean(relPath)), "/")
+	if relPath == "" || relPath == "." {
+		return false
+	}
+	parts := strings.Split(relPath, "/")
+
+	ignored := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.matches(parts) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+func (p ignorePattern) matches(parts []string) bool {
+	if !p.anchored {
+		ok, _ := path.Match(p.segments[0], parts[len(parts)-1])
+		return ok
+	}
+	return matchSegments(p.segments, parts)
+}
+
+// matchSegments matches pattern segments against path segments, letting "**"
+// consume zero or more path segments.
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
@@ -0,0 +1,74 @@
+package pathutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	m := ParseIgnore(`
+# dependency trees
+node_modules/
+/archive
+drafts/**/old
+*.tmp
+!keep.tmp
+`)
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{path: "node_modules", isDir: true, want: true},
+		{path: "release/node_modules", isDir: true, want: true},
+		{path: "release/node_modules", isDir: false, want: false},
+		{path: "archive", isDir: true, want: true},
+		{path: "release/archive", isDir: true, want: false},
+		{path: "drafts/old", isDir: true, want: true},
+		{path: "drafts/a/b/old", isDir: true, want: true},
+		{path: "other/old", isDir: true, want: false},
+		{path: "notes.tmp", isDir: false, want: true},
+		{path: "release/keep.tmp", isDir: false, want: false},
+		{path: "release/task", isDir: true, want: false},
+		{path: ".", isDir: true, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := m.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, expected %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreMatcherNil(t *testing.T) {
+	var m *IgnoreMatcher
+	if m.Match("anything", true) {
+		t.Error("nil matcher should ignore nothing")
+	}
+}
+
+func TestLoadIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+
+	m, err := LoadIgnoreFile(filepath.Join(dir, ".yakignore"))
+	if err != nil {
+		t.Fatalf("LoadIgnoreFile() on missing file error = %v", err)
+	}
+	if m.Match("node_modules", true) {
+		t.Error("missing ignore file should ignore nothing")
+	}
+
+	path := filepath.Join(dir, ".yakignore")
+	if err := os.WriteFile(path, []byte("node_modules/\n"), 0644); err != nil {
+		t.Fatalf("failed to write ignore file: %v", err)
+	}
+	m, err = LoadIgnoreFile(path)
+	if err != nil {
+		t.Fatalf("LoadIgnoreFile() error = %v", err)
+	}
+	if !m.Match("a/node_modules", true) {
+		t.Error("expected node_modules to be ignored")
+	}
+}
@@ -3,11 +3,92 @@ package prompt
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/wellmaintained/yak-box/internal/workspace"
 )
 
+// TemplatesDir is the directory under .yak-boxes holding prompt templates,
+// named after the mode: plan.tmpl and build.tmpl.
+const TemplatesDir = "templates"
+
+// TemplateData is the context a prompt template is rendered with.
+type TemplateData struct {
+	Mode       string
+	YakPath    string
+	UserPrompt string
+	Yaks       []string
+	WorkerName string
+	Skills     []string
+	// Default is the built-in prompt, so a template can add to it
+	// ({{.Default}}) instead of replacing it.
+	Default string
+}
+
 // BuildPrompt assembles the initial prompt for a worker.
 // workerName is the persona name (e.g. "Yakueline"); skillNames are the skill folder basenames to reference.
-func BuildPrompt(mode string, yakPath string, userPrompt string, tasks []string, workerName string, skillNames []string) string {
+// When the workspace has .yak-boxes/templates/<mode>.tmpl it is rendered
+// with TemplateData; otherwise the built-in format is used.
+func BuildPrompt(mode string, yakPath string, userPrompt string, tasks []string, workerName string, skillNames []string) (string, error) {
+	builtIn := buildDefaultPrompt(mode, yakPath, userPrompt, tasks, workerName, skillNames)
+
+	tmpl, err := LoadTemplate(mode)
+	if err != nil {
+		return "", err
+	}
+	if tmpl == nil {
+		return builtIn, nil
+	}
+
+	var sb strings.Builder
+	data := TemplateData{
+		Mode:       mode,
+		YakPath:    yakPath,
+		UserPrompt: userPrompt,
+		Yaks:       tasks,
+		WorkerName: workerName,
+		Skills:     skillNames,
+		Default:    builtIn,
+	}
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", tmpl.Name(), err)
+	}
+	return sb.String(), nil
+}
+
+// LoadTemplate parses the workspace's .yak-boxes/templates/<mode>.tmpl. It
+// returns nil without an error when there is no such file, or no workspace
+// root to look in.
+func LoadTemplate(mode string) (*template.Template, error) {
+	root, err := workspace.FindRoot()
+	if err != nil {
+		return nil, nil
+	}
+	return loadTemplateFrom(root, mode)
+}
+
+// loadTemplateFrom is LoadTemplate for the workspace at root.
+func loadTemplateFrom(root, mode string) (*template.Template, error) {
+	path := filepath.Join(root, ".yak-boxes", TemplatesDir, mode+".tmpl")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template %s: %w", path, err)
+	}
+	return tmpl, nil
+}
+
+// buildDefaultPrompt is the built-in prompt format.
+func buildDefaultPrompt(mode string, yakPath string, userPrompt string, tasks []string, workerName string, skillNames []string) string {
 	var roleDescription string
 	if mode == "plan" {
 		roleDescription = "Your supervisor is Yakob. The yaks are tasks — your job is to scout them and plan the shave. Do NOT pick up the clippers."
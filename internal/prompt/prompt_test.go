@@ -0,0 +1,104 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newWorkspace makes a git-rooted workspace, changes into it and returns it.
+func newWorkspace(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	return root
+}
+
+func writeTemplate(t *testing.T, root, mode, content string) {
+	t.Helper()
+	dir := filepath.Join(root, ".yak-boxes", TemplatesDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, mode+".tmpl"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildPromptDefault(t *testing.T) {
+	newWorkspace(t)
+
+	got, err := BuildPrompt("build", ".yaks", "Fix the login bug.", []string{"auth/api"}, "Yakov", nil)
+	if err != nil {
+		t.Fatalf("BuildPrompt() error = %v", err)
+	}
+	if want := buildDefaultPrompt("build", ".yaks", "Fix the login bug.", []string{"auth/api"}, "Yakov", nil); got != want {
+		t.Errorf("without a template BuildPrompt() should use the built-in format, got:\n%s", got)
+	}
+	for _, want := range []string{"You are Yakov.", "Fix the login bug.", "You are assigned to work on: auth/api", "The task state lives in .yaks."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("prompt missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestBuildPromptTemplate(t *testing.T) {
+	root := newWorkspace(t)
+	writeTemplate(t, root, "plan", `ACME {{.Mode}} brief for {{.WorkerName}}
+Tasks:{{range .Yaks}} {{.}}{{end}}
+State: {{.YakPath}}
+{{.UserPrompt}}`)
+
+	got, err := BuildPrompt("plan", "/work/.yaks", "Scope the migration.", []string{"db/migrate", "db/seed"}, "Yakira", nil)
+	if err != nil {
+		t.Fatalf("BuildPrompt() error = %v", err)
+	}
+	want := "ACME plan brief for Yakira\nTasks: db/migrate db/seed\nState: /work/.yaks\nScope the migration."
+	if got != want {
+		t.Errorf("BuildPrompt() = %q, want %q", got, want)
+	}
+
+	// The build template is absent, so build mode keeps the built-in prompt
+	got, err = BuildPrompt("build", ".yaks", "Go.", nil, "", nil)
+	if err != nil {
+		t.Fatalf("BuildPrompt() error = %v", err)
+	}
+	if got != buildDefaultPrompt("build", ".yaks", "Go.", nil, "", nil) {
+		t.Errorf("build mode should fall back to the built-in prompt, got:\n%s", got)
+	}
+}
+
+func TestBuildPromptTemplateWrapsDefault(t *testing.T) {
+	root := newWorkspace(t)
+	writeTemplate(t, root, "build", "Follow the ACME handbook.\n\n{{.Default}}")
+
+	got, err := BuildPrompt("build", ".yaks", "Go.", nil, "Yakov", []string{"go-style"})
+	if err != nil {
+		t.Fatalf("BuildPrompt() error = %v", err)
+	}
+	if want := "Follow the ACME handbook.\n\n" + buildDefaultPrompt("build", ".yaks", "Go.", nil, "Yakov", []string{"go-style"}); got != want {
+		t.Errorf("BuildPrompt() = %q, want the built-in prompt after the preamble", got)
+	}
+}
+
+func TestLoadTemplateErrors(t *testing.T) {
+	root := newWorkspace(t)
+
+	if tmpl, err := LoadTemplate("build"); tmpl != nil || err != nil {
+		t.Errorf("LoadTemplate() without a file = (%v, %v), want (nil, nil)", tmpl, err)
+	}
+
+	writeTemplate(t, root, "build", "{{.UserPrompt")
+	if _, err := LoadTemplate("build"); err == nil || !strings.Contains(err.Error(), "invalid prompt template") {
+		t.Errorf("expected a parse error, got %v", err)
+	}
+
+	writeTemplate(t, root, "build", "{{.Unknown}}")
+	if _, err := BuildPrompt("build", ".yaks", "Go.", nil, "", nil); err == nil || !strings.Contains(err.Error(), "failed to render prompt template build.tmpl") {
+		t.Errorf("expected a render error, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// DepthEnv is set in every worker's environment to its nesting depth: 1 for
+// a worker spawned from the host, 2 for a worker spawned by that worker, and
+// so on. spawn reads it to stop runaway recursion.
+const DepthEnv = "YAK_BOX_DEPTH"
+
+// DefaultMaxDepth is the deepest worker spawn creates by default: workers
+// may spawn sub-workers, but those may not spawn their own.
+const DefaultMaxDepth = 2
+
+// MaxDepthEnv is set in every worker's environment to the --max-depth it was
+// spawned under. A spawn inside the worker may lower the limit but not raise
+// it, so an agent cannot lift its own recursion cap with --max-depth.
+const MaxDepthEnv = "YAK_BOX_MAX_DEPTH"
+
+// CurrentDepth returns the depth of the worker yak-box is running in, read
+// from DepthEnv, or 0 outside any worker.
+func CurrentDepth() (int, error) {
+	value := os.Getenv(DepthEnv)
+	if value == "" {
+		return 0, nil
+	}
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 0 {
+		return 0, fmt.Errorf("%s=%q is not a non-negative integer", DepthEnv, value)
+	}
+	return depth, nil
+}
+
+// workerDepth returns the DepthEnv value for a worker spawned from here. An
+// invalid current depth, which spawn rejects first, counts as the host.
+func workerDepth() int {
+	depth, _ := CurrentDepth()
+	return depth + 1
+}
+
+// EffectiveMaxDepth returns maxDepth, lowered to the MaxDepthEnv limit
+// inherited from the worker yak-box is running in, if any.
+func EffectiveMaxDepth(maxDepth int) (int, error) {
+	value := os.Getenv(MaxDepthEnv)
+	if value == "" {
+		return maxDepth, nil
+	}
+	inherited, err := strconv.Atoi(value)
+	if err != nil || inherited < 1 {
+		return 0, fmt.Errorf("%s=%q is not a positive integer", MaxDepthEnv, value)
+	}
+	return min(maxDepth, inherited), nil
+}
+
+// workerMaxDepth returns the MaxDepthEnv value for a worker spawned with
+// maxDepth, DefaultMaxDepth when unset. An invalid inherited limit, which
+// spawn rejects first, is ignored.
+func workerMaxDepth(maxDepth int) int {
+	if maxDepth < 1 {
+		maxDepth = DefaultMaxDepth
+	}
+	if limit, err := EffectiveMaxDepth(maxDepth); err == nil {
+		return limit
+	}
+	return maxDepth
+}
@@ -0,0 +1,107 @@
+package runtime
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wellmaintained/yak-box/pkg/types"
+)
+
+func TestCurrentDepth(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "1", want: 1},
+		{value: "3", want: 3},
+		{value: "-1", wantErr: true},
+		{value: "deep", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Setenv(DepthEnv, tt.value)
+		got, err := CurrentDepth()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("CurrentDepth() with %s=%q = %d, %v, want %d (error: %v)", DepthEnv, tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestEffectiveMaxDepth(t *testing.T) {
+	tests := []struct {
+		inherited string
+		flag      int
+		want      int
+		wantErr   bool
+	}{
+		{inherited: "", flag: 5, want: 5},
+		{inherited: "2", flag: 99, want: 2},
+		{inherited: "3", flag: 1, want: 1},
+		{inherited: "0", flag: 2, wantErr: true},
+		{inherited: "deep", flag: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Setenv(MaxDepthEnv, tt.inherited)
+		got, err := EffectiveMaxDepth(tt.flag)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("EffectiveMaxDepth(%d) with %s=%q = %d, %v, want %d (error: %v)", tt.flag, MaxDepthEnv, tt.inherited, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestScriptsExportMaxDepth(t *testing.T) {
+	tests := []struct {
+		inherited string
+		maxDepth  int
+		want      string
+	}{
+		{inherited: "", maxDepth: 0, want: "2"},
+		{inherited: "", maxDepth: 4, want: "4"},
+		{inherited: "2", maxDepth: 99, want: "2"},
+	}
+	for _, tt := range tests {
+		t.Setenv(MaxDepthEnv, tt.inherited)
+
+		cfg := &spawnConfig{
+			worker:   &types.Worker{Name: "test-worker", CWD: "/work", WorkerName: "TestWorker"},
+			profile:  GetResourceProfile("default"),
+			envVars:  map[string]string{MaxDepthEnv: "99"},
+			maxDepth: tt.maxDepth,
+		}
+		script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+		want := "\t-e YAK_BOX_MAX_DEPTH=" + tt.want + " \\\n"
+		if i := strings.Index(script, want); i < 0 || i < strings.Index(script, "YAK_BOX_MAX_DEPTH=99") {
+			t.Errorf("run script with max depth %d under %q should end with %q:\n%s", tt.maxDepth, tt.inherited, want, script)
+		}
+
+		for _, tool := range []string{"claude", "cursor", "opencode"} {
+			script, _ := nativeWrapperScript(&types.Worker{Tool: tool, CWD: "/work"}, "/s/prompt.txt", "/s/worker.pid", "/s/worker.log", tt.maxDepth)
+			if want := "export YAK_BOX_MAX_DEPTH=" + tt.want + "\n"; !strings.Contains(script, want) {
+				t.Errorf("%s run.sh with max depth %d under %q missing %q:\n%s", tool, tt.maxDepth, tt.inherited, want, script)
+			}
+		}
+	}
+}
+
+func TestScriptsIncrementDepth(t *testing.T) {
+	for _, tt := range []struct{ current, want string }{{"", "1"}, {"1", "2"}} {
+		t.Setenv(DepthEnv, tt.current)
+
+		cfg := &spawnConfig{
+			worker:  &types.Worker{Name: "test-worker", CWD: "/work", WorkerName: "TestWorker"},
+			profile: GetResourceProfile("default"),
+		}
+		script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+		if want := "\t-e YAK_BOX_DEPTH=" + tt.want + " \\\n"; !strings.Contains(script, want) {
+			t.Errorf("run script at depth %q missing %q:\n%s", tt.current, want, script)
+		}
+
+		for _, tool := range []string{"claude", "cursor", "opencode"} {
+			script, _ := nativeWrapperScript(&types.Worker{Tool: tool, CWD: "/work"}, "/s/prompt.txt", "/s/worker.pid", "/s/worker.log", 0)
+			if want := "export YAK_BOX_DEPTH=" + tt.want + "\n"; !strings.Contains(script, want) {
+				t.Errorf("%s run.sh at depth %q missing %q:\n%s", tool, tt.current, want, script)
+			}
+		}
+	}
+}
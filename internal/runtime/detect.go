@@ -0,0 +1,74 @@
+package runtime
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// lookPath finds runtime binaries on PATH; tests replace it.
+var lookPath = exec.LookPath
+
+// RuntimeReport records what DetectRuntimes found on the host and the
+// runtime it chose: "sandboxed", "native" or "unknown".
+type RuntimeReport struct {
+	DockerInstalled bool
+	DockerDaemonUp  bool
+	PodmanInstalled bool
+	ZellijInstalled bool
+	Runtime         string
+}
+
+// DetectRuntimes probes for docker (and whether its daemon answers), podman
+// and zellij. A reachable docker daemon selects the sandboxed runtime;
+// otherwise zellij selects native. Podman is reported but never selected.
+func DetectRuntimes() RuntimeReport {
+	var report RuntimeReport
+	if _, err := lookPath("docker"); err == nil {
+		report.DockerInstalled = true
+		report.DockerDaemonUp = dockerCommander.CommandContext(context.Background(), "docker", "ps").Run() == nil
+	}
+	if _, err := lookPath("podman"); err == nil {
+		report.PodmanInstalled = true
+	}
+	if _, err := lookPath("zellij"); err == nil {
+		report.ZellijInstalled = true
+	}
+
+	switch {
+	case report.DockerDaemonUp:
+		report.Runtime = "sandboxed"
+	case report.ZellijInstalled:
+		report.Runtime = "native"
+	default:
+		report.Runtime = "unknown"
+	}
+	return report
+}
+
+// DetectRuntime detects the available runtime (sandboxed/docker or native/zellij)
+func DetectRuntime() string {
+	return DetectRuntimes().Runtime
+}
+
+// Diagnosis explains in one line why each runtime is or is not usable.
+func (r RuntimeReport) Diagnosis() string {
+	var parts []string
+	switch {
+	case !r.DockerInstalled:
+		parts = append(parts, "Docker not installed")
+	case !r.DockerDaemonUp:
+		parts = append(parts, "Docker installed but daemon not running")
+	default:
+		parts = append(parts, "Docker daemon running")
+	}
+	if r.PodmanInstalled {
+		parts = append(parts, "Podman installed (not supported)")
+	}
+	if r.ZellijInstalled {
+		parts = append(parts, "Zellij installed")
+	} else {
+		parts = append(parts, "Zellij not installed")
+	}
+	return strings.Join(parts, "; ")
+}
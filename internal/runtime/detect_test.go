@@ -0,0 +1,95 @@
+package runtime
+
+import (
+	"os/exec"
+	"testing"
+)
+
+// withLookPath makes only the named binaries appear to be on PATH.
+func withLookPath(t *testing.T, installed ...string) {
+	t.Helper()
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(file string) (string, error) {
+		for _, name := range installed {
+			if name == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+}
+
+func TestDetectRuntimes(t *testing.T) {
+	daemonUp := &scriptCommander{script: "exit 0"}
+	daemonDown := &scriptCommander{script: `echo "Cannot connect to the Docker daemon" >&2; exit 1`}
+
+	tests := []struct {
+		name      string
+		installed []string
+		docker    *scriptCommander
+		want      RuntimeReport
+		diagnosis string
+	}{
+		{
+			name:      "docker running",
+			installed: []string{"docker", "zellij"},
+			docker:    daemonUp,
+			want:      RuntimeReport{DockerInstalled: true, DockerDaemonUp: true, ZellijInstalled: true, Runtime: "sandboxed"},
+			diagnosis: "Docker daemon running; Zellij installed",
+		},
+		{
+			name:      "daemon down falls back to zellij",
+			installed: []string{"docker", "zellij"},
+			docker:    daemonDown,
+			want:      RuntimeReport{DockerInstalled: true, ZellijInstalled: true, Runtime: "native"},
+			diagnosis: "Docker installed but daemon not running; Zellij installed",
+		},
+		{
+			name:      "daemon down without zellij",
+			installed: []string{"docker"},
+			docker:    daemonDown,
+			want:      RuntimeReport{DockerInstalled: true, Runtime: "unknown"},
+			diagnosis: "Docker installed but daemon not running; Zellij not installed",
+		},
+		{
+			name:      "only podman",
+			installed: []string{"podman"},
+			docker:    daemonUp,
+			want:      RuntimeReport{PodmanInstalled: true, Runtime: "unknown"},
+			diagnosis: "Docker not installed; Podman installed (not supported); Zellij not installed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withLookPath(t, tt.installed...)
+			tt.docker.calls = nil
+			withDockerCommander(t, tt.docker)
+
+			got := DetectRuntimes()
+			if got != tt.want {
+				t.Errorf("DetectRuntimes() = %+v, want %+v", got, tt.want)
+			}
+			if d := got.Diagnosis(); d != tt.diagnosis {
+				t.Errorf("Diagnosis() = %q, want %q", d, tt.diagnosis)
+			}
+			if DetectRuntime() != tt.want.Runtime {
+				t.Errorf("DetectRuntime() = %q, want %q", DetectRuntime(), tt.want.Runtime)
+			}
+		})
+	}
+}
+
+func TestDetectRuntimes_SkipsDaemonCheckWithoutDocker(t *testing.T) {
+	withLookPath(t, "zellij")
+	cmdr := &scriptCommander{script: "exit 0"}
+	withDockerCommander(t, cmdr)
+
+	if got := DetectRuntimes(); got.DockerDaemonUp {
+		t.Errorf("DetectRuntimes() reported a daemon without docker installed: %+v", got)
+	}
+	if len(cmdr.calls) != 0 {
+		t.Errorf("docker was run without being installed: %v", cmdr.calls)
+	}
+}
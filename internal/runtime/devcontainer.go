@@ -1,20 +1,31 @@
 package runtime
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/wellmaintained/yak-box/internal/ui"
 	"github.com/wellmaintained/yak-box/internal/workspace"
 )
 
 const devcontainerPath = ".devcontainer"
 const workerImageName = "yak-worker:latest"
 
+// ErrBuildCancelled is returned when the image build is interrupted by
+// context cancellation, e.g. Ctrl-C during spawn.
+var ErrBuildCancelled = errors.New("image build cancelled")
+
+// buildWaitDelay bounds how long a cancelled build may keep its output open.
+const buildWaitDelay = 2 * time.Second
+
 func getStoredDevcontainerCommit() (string, error) {
-	cmd := exec.Command("docker", "image", "inspect", workerImageName, "--format", "{{index .Config.Labels \"yak-box.devcontainer.commit\"}}")
+	cmd := newDockerCommand("image", "inspect", workerImageName, "--format", "{{index .Config.Labels \"yak-box.devcontainer.commit\"}}")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", err
@@ -54,7 +65,7 @@ func isImageUpToDate() (bool, error) {
 }
 
 func isDevcontainerDirty(workspaceRoot string) (bool, error) {
-	cmd := exec.Command("git", "status", "--porcelain", ".")
+	cmd := newCommand("git", "status", "--porcelain", ".")
 	cmd.Dir = workspaceRoot + "/" + devcontainerPath
 	output, err := cmd.Output()
 	if err != nil {
@@ -64,7 +75,8 @@ func isDevcontainerDirty(workspaceRoot string) (bool, error) {
 }
 
 // RebuildDevcontainer rebuilds the yak-worker Docker image from the .devcontainer directory.
-func RebuildDevcontainer() error {
+// The build output is streamed through ui and the build is aborted when ctx is cancelled.
+func RebuildDevcontainer(ctx context.Context) error {
 	workspaceRoot, err := workspace.FindRoot()
 	if err != nil {
 		return fmt.Errorf("failed to find workspace root: %w", err)
@@ -75,27 +87,37 @@ func RebuildDevcontainer() error {
 		return fmt.Errorf("failed to get devcontainer commit: %w", err)
 	}
 
-	fmt.Println("Rebuilding yak-worker image...")
+	ui.Info("Rebuilding yak-worker image...\n")
+	if err := buildImage(ctx, workspaceRoot, commitHash); err != nil {
+		return err
+	}
+	ui.Info("Image rebuilt successfully\n")
+	return nil
+}
 
-	cmd := exec.Command("docker", "build",
+// buildImage runs docker build for the worker image in workspaceRoot.
+func buildImage(ctx context.Context, workspaceRoot, commitHash string) error {
+	cmd := dockerCommander.CommandContext(ctx, "docker", "build",
 		"-t", workerImageName,
 		"-f", devcontainerPath+"/Dockerfile",
 		"--label", "yak-box.devcontainer.commit="+commitHash,
 		".")
 	cmd.Dir = workspaceRoot
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = ui.Stream()
+	cmd.Stderr = ui.Stream()
+	cmd.WaitDelay = buildWaitDelay
 
 	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: %v", ErrBuildCancelled, ctx.Err())
+		}
 		return fmt.Errorf("failed to rebuild docker image: %w", err)
 	}
-
-	fmt.Println("Image rebuilt successfully")
 	return nil
 }
 
 func getDevcontainerCommit(workspaceRoot string) (string, error) {
-	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd := newCommand("git", "rev-parse", "HEAD")
 	cmd.Dir = workspaceRoot + "/" + devcontainerPath
 	output, err := cmd.Output()
 	if err != nil {
@@ -109,8 +131,9 @@ func dirExists(path string) bool {
 	return err == nil && info.IsDir()
 }
 
-// EnsureDevcontainer ensures the Docker image exists and is up-to-date
-func EnsureDevcontainer() error {
+// EnsureDevcontainer ensures the Docker image exists and is up-to-date,
+// building it when needed. A build interrupted by ctx returns ErrBuildCancelled.
+func EnsureDevcontainer(ctx context.Context) error {
 	workspaceRoot, err := workspace.FindRoot()
 	if err != nil {
 		return fmt.Errorf("failed to find workspace root: %w", err)
@@ -129,7 +152,7 @@ func EnsureDevcontainer() error {
 			return fmt.Errorf("failed to check image status: %w", err)
 		}
 		if !upToDate {
-			return RebuildDevcontainer()
+			return RebuildDevcontainer(ctx)
 		}
 		return nil
 	}
@@ -139,13 +162,13 @@ func EnsureDevcontainer() error {
 		return fmt.Errorf("yak-worker:latest image not found and no .devcontainer/Dockerfile to build from")
 	}
 
-	fmt.Println("Building yak-worker image for the first time...")
-	return RebuildDevcontainer()
+	ui.Info("Building yak-worker image for the first time...\n")
+	return RebuildDevcontainer(ctx)
 }
 
 // ImageExists checks if the yak-worker Docker image exists locally
 func ImageExists() (bool, error) {
-	cmd := exec.Command("docker", "image", "inspect", workerImageName)
+	cmd := newDockerCommand("image", "inspect", workerImageName)
 	err := cmd.Run()
 	if err == nil {
 		return true, nil
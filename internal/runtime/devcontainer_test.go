@@ -0,0 +1,42 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBuildImage_Cancelled(t *testing.T) {
+	withDockerCommander(t, &scriptCommander{script: "echo 'Step 1/3'; exec sleep 10"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := buildImage(ctx, t.TempDir(), "abc123")
+	if !errors.Is(err, ErrBuildCancelled) {
+		t.Fatalf("buildImage() error = %v, expected ErrBuildCancelled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("buildImage() took %s after cancellation", elapsed)
+	}
+}
+
+func TestBuildImage(t *testing.T) {
+	cmdr := &scriptCommander{script: "exit 0"}
+	withDockerCommander(t, cmdr)
+
+	if err := buildImage(context.Background(), t.TempDir(), "abc123"); err != nil {
+		t.Fatalf("buildImage() error = %v", err)
+	}
+	if len(cmdr.calls) != 1 || cmdr.calls[0][1] != "build" {
+		t.Fatalf("expected one docker build call, got %v", cmdr.calls)
+	}
+
+	withDockerCommander(t, &scriptCommander{script: "exit 1"})
+	err := buildImage(context.Background(), t.TempDir(), "abc123")
+	if err == nil || errors.Is(err, ErrBuildCancelled) {
+		t.Errorf("buildImage() error = %v, expected a build failure", err)
+	}
+}
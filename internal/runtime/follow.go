@@ -0,0 +1,65 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// followPollDelay is how often FollowContainerLogs checks whether the
+// container has been created. A variable so tests can avoid sleeping.
+var followPollDelay = time.Second
+
+// WorkerLogPath returns the worker.log that spawn writes for a worker with
+// the given scripts dir and home, matching SpawnSandboxedWorker and
+// SpawnNativeWorker.
+func WorkerLogPath(scriptsDir, homeDir string) string {
+	return filepath.Join(scriptsDirFor(scriptsDir, homeDir), workerLogName)
+}
+
+// FollowContainerLogs streams `docker logs -f` for container to out until the
+// container exits or ctx is cancelled. A container started from a Zellij tab
+// may not exist yet, so it waits up to readyTimeout for docker to create it.
+// Cancelling ctx detaches without stopping the container and is not an error.
+func FollowContainerLogs(ctx context.Context, container string, readyTimeout time.Duration, out io.Writer) error {
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		err := dockerCommander.CommandContext(ctx, "docker", "inspect", "--format", "{{.State.Status}}", container).Run()
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("container %s did not start within %s. Suggestion: Check the worker's Zellij tab or run 'docker ps -a' to see what happened", container, readyTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(followPollDelay):
+		}
+	}
+
+	cmd := dockerCommander.CommandContext(ctx, "docker", "logs", "-f", container)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to follow logs for %s: %w. Suggestion: Run 'docker logs -f %s' manually", container, err, container)
+	}
+	return nil
+}
+
+// FollowLogFile streams logPath with `tail -F` to out until ctx is cancelled.
+// tail -F keeps retrying while the file does not exist yet.
+func FollowLogFile(ctx context.Context, logPath string, out io.Writer) error {
+	cmd := hostCommander.CommandContext(ctx, "tail", "-n", "+1", "-F", logPath)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to follow %s: %w. Suggestion: Run 'tail -F %s' manually", logPath, err, logPath)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkerLogPath(t *testing.T) {
+	if got, want := WorkerLogPath("", "/home/yakov"), filepath.Join("/home/yakov", "scripts", workerLogName); got != want {
+		t.Errorf("WorkerLogPath() = %q, want %q", got, want)
+	}
+	if got, want := WorkerLogPath("/runs/1", "/home/yakov"), filepath.Join("/runs/1", workerLogName); got != want {
+		t.Errorf("WorkerLogPath() with a scripts dir = %q, want %q", got, want)
+	}
+}
+
+func TestFollowContainerLogs(t *testing.T) {
+	cmdr := &recordingCommander{routes: map[string]string{"logs": "echo building"}}
+	withDockerCommander(t, cmdr)
+
+	var out bytes.Buffer
+	if err := FollowContainerLogs(context.Background(), "yak-worker-api", time.Second, &out); err != nil {
+		t.Fatalf("FollowContainerLogs() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "building") {
+		t.Errorf("output = %q, want the container logs", out.String())
+	}
+	last := cmdr.calls[len(cmdr.calls)-1]
+	if want := "docker logs -f yak-worker-api"; strings.Join(last, " ") != want {
+		t.Errorf("last call = %q, want %q", last, want)
+	}
+}
+
+func TestFollowContainerLogs_NeverStarts(t *testing.T) {
+	orig := followPollDelay
+	followPollDelay = time.Millisecond
+	t.Cleanup(func() { followPollDelay = orig })
+
+	cmdr := &recordingCommander{routes: map[string]string{"inspect": "exit 1"}}
+	withDockerCommander(t, cmdr)
+
+	err := FollowContainerLogs(context.Background(), "yak-worker-api", 10*time.Millisecond, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "did not start") {
+		t.Fatalf("expected a start timeout, got %v", err)
+	}
+	for _, call := range cmdr.calls {
+		if len(call) > 1 && call[1] == "logs" {
+			t.Error("docker logs should not run before the container exists")
+		}
+	}
+}
+
+func TestFollowContainerLogs_CancelDetaches(t *testing.T) {
+	withDockerCommander(t, routeCommander{"logs": "exec sleep 10"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := FollowContainerLogs(ctx, "yak-worker-api", time.Second, &bytes.Buffer{}); err != nil {
+		t.Errorf("cancelling should detach without an error, got %v", err)
+	}
+}
+
+func TestFollowLogFile(t *testing.T) {
+	cmdr := &scriptCommander{script: "echo done"}
+	withHostCommander(t, cmdr)
+
+	var out bytes.Buffer
+	if err := FollowLogFile(context.Background(), "/runs/1/worker.log", &out); err != nil {
+		t.Fatalf("FollowLogFile() error = %v", err)
+	}
+	if out.String() != "done\n" {
+		t.Errorf("output = %q, want the tail output", out.String())
+	}
+	if want := "tail -n +1 -F /runs/1/worker.log"; len(cmdr.calls) != 1 || strings.Join(cmdr.calls[0], " ") != want {
+		t.Errorf("calls = %q, want %q", cmdr.calls, want)
+	}
+}
@@ -1,48 +1,138 @@
 package runtime
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/wellmaintained/yak-box/internal/pathutil"
 	"github.com/wellmaintained/yak-box/pkg/devcontainer"
+	"github.com/wellmaintained/yak-box/pkg/worktree"
 )
 
-func generateInitScript() string {
+// userEnvProbeFlags maps a devcontainer userEnvProbe value to the bash flags
+// used to probe the environment, or "" when probing is disabled. Unset and
+// unknown values use the spec default, loginInteractiveShell.
+func userEnvProbeFlags(probe string) string {
+	switch probe {
+	case "none":
+		return ""
+	case "loginShell":
+		return "-lc"
+	case "interactiveShell":
+		return "-ic"
+	default:
+		return "-lic"
+	}
+}
+
+// scriptsDirFor returns where a worker's run artifacts go: dir if set,
+// otherwise the scripts directory inside homeDir.
+func scriptsDirFor(dir, homeDir string) string {
+	if dir != "" {
+		return dir
+	}
+	return filepath.Join(homeDir, "scripts")
+}
+
+// workerLogName is the file in a worker's scripts directory that receives a
+// copy of the tool's output.
+const workerLogName = "worker.log"
+
+// logPTYEnv is set when a worker script re-runs itself under script(1).
+const logPTYEnv = "YAK_LOG_PTY"
+
+// rerunUnderScript returns bash that re-runs the current script and its
+// arguments under script(1) with logPTYEnv set, so the tool the script starts
+// keeps a terminal while a copy of its output is appended to logFile, a shell
+// word, after anything already logged. Its status is the re-run script's:
+// util-linux script passes it on with -e, BSD script does so by default.
+func rerunUnderScript(logFile string) string {
+	return fmt.Sprintf(`if script --version >/dev/null 2>&1; then
+    %[2]s=1 script -qaefc "bash $(printf '%%q ' "$0" "$@")" %[1]s
+  else
+    %[2]s=1 script -qa %[1]s bash "$0" "$@"
+  fi`, logFile, logPTYEnv)
+}
+
+// generateInitScript returns the script run inside the container. Unless
+// userEnvProbe is "none", it first imports the environment that the
+// container's shell init files (.profile, .bashrc) set up, so tools installed
+// through them are on PATH. The tool runs on a terminal under script(1),
+// which copies its output to /opt/worker/worker.log. The setup
+// lifecycle commands run before the tool; the first to fail ends the script
+// with its status.
+func generateInitScript(userEnvProbe string, setup []lifecycleStep) string {
+	probe := ""
+	if flags := userEnvProbeFlags(userEnvProbe); flags != "" {
+		// Only the exports reach fd 3; rc file output is discarded.
+		probe = fmt.Sprintf(`eval "$(bash %s 'export -p >&3' 3>&1 >/dev/null 2>&1 </dev/null)"
+`, flags)
+	}
 	return `#!/usr/bin/env bash
-WORKSPACE_ROOT="${WORKSPACE_ROOT:-/home/yakob/yak-box}"
+` + probe + `WORKSPACE_ROOT="${WORKSPACE_ROOT:-/home/yakob/yak-box}"
 COST_DIR="${WORKSPACE_ROOT}/.worker-costs"
 mkdir -p "$COST_DIR"
 
 PROMPT_FILE="/opt/worker/prompt.txt"
+LOG_FILE="${YAK_LOG_FILE:-/opt/worker/worker.log}"
 PROMPT="$(cat "$PROMPT_FILE")"
 TOOL="${YAK_TOOL:-opencode}"
 MODEL="${YAK_MODEL:-}"
 AGENT_NAME="${YAK_AGENT_NAME:-}"
 WORKSPACE="${YAK_WORKSPACE:-$PWD}"
 
-case "$TOOL" in
-  claude)
-    CLAUDE_ARGS=(--dangerously-skip-permissions)
-    if [[ -n "$AGENT_NAME" ]]; then
-      CLAUDE_ARGS=(--agent "$AGENT_NAME" "${CLAUDE_ARGS[@]}")
-    fi
-    if [[ -n "$MODEL" ]]; then
-      CLAUDE_ARGS+=(--model "$MODEL")
-    fi
-    claude "${CLAUDE_ARGS[@]}" @"$PROMPT_FILE"
-    ;;
-  cursor)
-    if [[ -n "$MODEL" ]]; then
-      agent --force --model "$MODEL" --workspace "$WORKSPACE" "$PROMPT"
-    else
-      agent --force --workspace "$WORKSPACE" "$PROMPT"
-    fi
-    ;;
-  *)
-    opencode --prompt "$PROMPT" --agent "$1"
-    ;;
-esac
+run_tool() {
+  case "$TOOL" in
+    claude)
+      CLAUDE_ARGS=(--dangerously-skip-permissions)
+      if [[ -n "$AGENT_NAME" ]]; then
+        CLAUDE_ARGS=(--agent "$AGENT_NAME" "${CLAUDE_ARGS[@]}")
+      fi
+      if [[ -n "$MODEL" ]]; then
+        CLAUDE_ARGS+=(--model "$MODEL")
+      fi
+      claude "${CLAUDE_ARGS[@]}" @"$PROMPT_FILE"
+      ;;
+    cursor)
+      if [[ -n "$MODEL" ]]; then
+        agent --force --model "$MODEL" --workspace "$WORKSPACE" "$PROMPT"
+      else
+        agent --force --workspace "$WORKSPACE" "$PROMPT"
+      fi
+      ;;
+    *)
+      OPENCODE_ARGS=(--prompt "$PROMPT" --agent "$1")
+      if [[ -n "$MODEL" ]]; then
+        OPENCODE_ARGS=(--model "$MODEL" "${OPENCODE_ARGS[@]}")
+      fi
+      opencode "${OPENCODE_ARGS[@]}"
+      ;;
+  esac
+}
+
+# Under script(1) this script is re-run only to start the tool
+if [[ -n "${` + logPTYEnv + `:-}" ]]; then
+  unset ` + logPTYEnv + `
+  run_tool "$@"
+  exit $?
+fi
+
+` + lifecycleScript(setup) + `# Keep a copy of the tool's output for after the container is gone. Unlike a
+# pipe through tee, script(1) leaves the tool on a terminal.
+if command -v script >/dev/null 2>&1 && : 2>/dev/null >>"$LOG_FILE"; then
+  ` + rerunUnderScript(`"$LOG_FILE"`) + `
+else
+  run_tool "$@"
+fi
 EXIT_CODE=$?
 
 if [[ "$TOOL" == "opencode" ]]; then
@@ -59,12 +149,21 @@ exit $EXIT_CODE
 `
 }
 
-func generateWaitScript() string {
-	return `#!/usr/bin/env bash
+// waitRetryDelay is how long shell-exec.sh sleeps between container checks.
+const waitRetryDelay = time.Second
+
+// generateWaitScript returns the shell pane helper that waits up to
+// readyTimeout for the container to run before exec'ing into it.
+func generateWaitScript(readyTimeout time.Duration) string {
+	retries := int((readyTimeout + waitRetryDelay - 1) / waitRetryDelay)
+	if retries < 1 {
+		retries = 1
+	}
+	return fmt.Sprintf(`#!/usr/bin/env bash
 set -euo pipefail
 CONTAINER_NAME="$1"
-MAX_RETRIES=30
-RETRY_DELAY=1
+MAX_RETRIES=%d
+RETRY_DELAY=%d
 
 for i in $(seq 1 $MAX_RETRIES); do
     if docker inspect --format='{{.State.Status}}' "$CONTAINER_NAME" 2>/dev/null | grep -q "running"; then
@@ -76,9 +175,21 @@ for i in $(seq 1 $MAX_RETRIES); do
     sleep $RETRY_DELAY
 done
 
-echo "ERROR: Container did not start within $MAX_RETRIES seconds"
+echo "ERROR: Container did not start after ${SECONDS}s (ready timeout: %s)"
 exit 1
-`
+`, retries, int(waitRetryDelay/time.Second), readyTimeout)
+}
+
+// credentialEnvVar is the container variable set from WithCredentialFile.
+const credentialEnvVar = "OPENCODE_API_KEY"
+
+// stopTimeoutSeconds converts timeout to docker's whole-second
+// --stop-timeout, rounding up. Zero falls back to DefaultStopTimeout.
+func stopTimeoutSeconds(timeout time.Duration) int {
+	if timeout <= 0 {
+		timeout = DefaultStopTimeout
+	}
+	return int((timeout + time.Second - 1) / time.Second)
 }
 
 func generateRunScript(cfg *spawnConfig, workspaceRoot, promptFile, innerScript, passwdFile, groupFile, networkMode string) string {
@@ -86,13 +197,43 @@ func generateRunScript(cfg *spawnConfig, workspaceRoot, promptFile, innerScript,
 
 	var sb strings.Builder
 	sb.WriteString("#!/usr/bin/env bash\n")
-	sb.WriteString("exec docker run -it --rm \\\n")
+	if cfg.credentialFile != "" {
+		// docker reads a bare "-e NAME" from this environment, keeping the key off argv
+		sb.WriteString(fmt.Sprintf("%s=\"$(cat %s)\" || exit 1\n", credentialEnvVar, shellQuote(cfg.credentialFile)))
+		sb.WriteString(fmt.Sprintf("export %s\n", credentialEnvVar))
+	}
+	if cfg.noZellij {
+		// Detached: docker prints the container ID and returns. The tools
+		// are interactive, so the container still gets a TTY and stdin.
+		sb.WriteString("exec docker run -dit --rm \\\n")
+	} else {
+		sb.WriteString("exec docker run -it --rm \\\n")
+	}
 	sb.WriteString(fmt.Sprintf("\t--name %s \\\n", containerName))
-	sb.WriteString(fmt.Sprintf("\t--user \"%d:%d\" \\\n", os.Getuid(), os.Getgid()))
+	uid, gid := containerUser(cfg)
+	sb.WriteString(fmt.Sprintf("\t--user \"%d:%d\" \\\n", uid, gid))
 	sb.WriteString(fmt.Sprintf("\t--network %s \\\n", networkMode))
+	if useInit(cfg) {
+		sb.WriteString("\t--init \\\n")
+	}
 	sb.WriteString("\t--security-opt no-new-privileges \\\n")
-	sb.WriteString("\t--cap-drop ALL \\\n")
-	sb.WriteString("\t--tmpfs /tmp:rw,exec,size=2g \\\n")
+	if !cfg.noCapDrop {
+		sb.WriteString("\t--cap-drop ALL \\\n")
+	}
+	if cfg.devConfig != nil {
+		for _, capability := range cfg.devConfig.CapAdd {
+			sb.WriteString(fmt.Sprintf("\t--cap-add %s \\\n", shellQuote(capability)))
+		}
+		for _, opt := range cfg.devConfig.SecurityOpt {
+			sb.WriteString(fmt.Sprintf("\t--security-opt %s \\\n", shellQuote(opt)))
+		}
+	}
+	tmpOpts := cfg.profile.Tmpfs["/tmp"]
+	if tmpOpts == "" {
+		tmpOpts = tmpfsOptions("2g")
+	}
+	tmpOpts = withTmpfsOwner(tmpOpts, uid, gid)
+	sb.WriteString(fmt.Sprintf("\t--tmpfs /tmp:rw,%s \\\n", tmpOpts))
 	sb.WriteString(fmt.Sprintf("\t--cpus %s \\\n", cfg.profile.CPUs))
 	sb.WriteString(fmt.Sprintf("\t--memory %s \\\n", cfg.profile.Memory))
 
@@ -101,34 +242,27 @@ func generateRunScript(cfg *spawnConfig, workspaceRoot, promptFile, innerScript,
 	}
 
 	sb.WriteString(fmt.Sprintf("\t--pids-limit %d \\\n", cfg.profile.PIDs))
-	sb.WriteString("\t--stop-timeout 7200 \\\n")
-
-	// Standard mounts
-	sb.WriteString(fmt.Sprintf("\t-v \"%s:%s:rw\" \\\n", workspaceRoot, workspaceRoot))
-	sb.WriteString(fmt.Sprintf("\t-v \"%s:/opt/worker/prompt.txt:ro\" \\\n", promptFile))
-	sb.WriteString(fmt.Sprintf("\t-v \"%s:/opt/worker/start.sh:ro\" \\\n", innerScript))
-
-	if cfg.homeDir != "" {
-		sb.WriteString(fmt.Sprintf("\t-v \"%s:/home/yak-shaver:rw\" \\\n", cfg.homeDir))
-	}
+	sb.WriteString(fmt.Sprintf("\t--stop-timeout %d \\\n", stopTimeoutSeconds(cfg.stopTimeout)))
 
-	if cfg.worker.WorktreePath != "" {
-		sb.WriteString(fmt.Sprintf("\t-v \"%s:%s:rw\" \\\n", cfg.worker.WorktreePath, cfg.worker.WorktreePath))
+	// Managed and devcontainer mounts; conflicts are reported by
+	// SpawnSandboxedWorker before the script is written
+	mounts, _ := runMounts(cfg, workspaceRoot, promptFile, innerScript, passwdFile, groupFile)
+	for _, mount := range mounts {
+		if strings.Contains(mount, "=") {
+			// docker run -v cannot parse "type=...,source=..." mounts
+			sb.WriteString(fmt.Sprintf("\t--mount %s \\\n", shellQuote(mount)))
+		} else {
+			sb.WriteString(fmt.Sprintf("\t-v %s \\\n", shellQuote(mount)))
+		}
 	}
 
-	homeDirHost := os.Getenv("HOME")
-	sb.WriteString(fmt.Sprintf("\t-v \"%s/.local/share/opencode/auth.json:/home/yak-shaver/.local/share/opencode/auth.json:ro\" \\\n", homeDirHost))
-	sb.WriteString(fmt.Sprintf("\t-v \"%s:/etc/passwd:ro\" \\\n", passwdFile))
-	sb.WriteString(fmt.Sprintf("\t-v \"%s:/etc/group:ro\" \\\n", groupFile))
-
-	// Devcontainer mounts
-	if cfg.devConfig != nil {
-		for _, mount := range cfg.devConfig.Mounts {
-			sb.WriteString(fmt.Sprintf("\t-v \"%s\" \\\n", mount))
-		}
+	// Forwarded ports are published on the host's loopback interface only,
+	// on a host port docker picks so concurrent workers do not collide
+	for _, port := range forwardedPorts(cfg) {
+		sb.WriteString(fmt.Sprintf("\t-p 127.0.0.1::%d \\\n", port))
 	}
 
-	sb.WriteString(fmt.Sprintf("\t-w \"%s\" \\\n", cfg.worker.CWD))
+	sb.WriteString(fmt.Sprintf("\t-w \"%s\" \\\n", containerPath(cfg, workspaceRoot, cfg.worker.CWD)))
 	sb.WriteString("\t-e HOME=/home/yak-shaver \\\n")
 	sb.WriteString("\t-e TERM=\"${TERM:-xterm-256color}\" \\\n")
 	sb.WriteString("\t-e GOPATH=/home/yak-shaver/.go \\\n")
@@ -140,63 +274,571 @@ func generateRunScript(cfg *spawnConfig, workspaceRoot, promptFile, innerScript,
 	}
 
 	sb.WriteString(fmt.Sprintf("\t-e WORKER_NAME=\"%s\" \\\n", cfg.worker.WorkerName))
-	sb.WriteString(fmt.Sprintf("\t-e YAK_PATH=\"%s\" \\\n", cfg.worker.YakPath))
+	// Cost exports go to <workspace>/.worker-costs so they outlive the container
+	sb.WriteString(fmt.Sprintf("\t-e WORKSPACE_ROOT=\"%s\" \\\n", containerPath(cfg, workspaceRoot, workspaceRoot)))
+	sb.WriteString(fmt.Sprintf("\t-e YAK_PATH=\"%s\" \\\n", containerPath(cfg, workspaceRoot, cfg.worker.YakPath)))
 	sb.WriteString(fmt.Sprintf("\t-e YAK_TOOL=\"%s\" \\\n", cfg.worker.Tool))
-	sb.WriteString(fmt.Sprintf("\t-e YAK_WORKSPACE=\"%s\" \\\n", cfg.worker.CWD))
+	sb.WriteString(fmt.Sprintf("\t-e YAK_WORKSPACE=\"%s\" \\\n", containerPath(cfg, workspaceRoot, cfg.worker.CWD)))
 	if cfg.worker.Model != "" {
-		sb.WriteString(fmt.Sprintf("\t-e YAK_MODEL=\"%s\" \\\n", cfg.worker.Model))
+		sb.WriteString(fmt.Sprintf("\t-e %s \\\n", shellQuote("YAK_MODEL="+cfg.worker.Model)))
+	}
+	if cfg.credentialFile != "" {
+		sb.WriteString(fmt.Sprintf("\t-e %s \\\n", credentialEnvVar))
 	}
 	// Devcontainer envs
-	imageName := "yak-worker:latest"
 	if cfg.devConfig != nil {
-		if cfg.devConfig.Image != "" {
-			imageName = cfg.devConfig.Image
+		ctx := newSubstituteContext(cfg, workspaceRoot)
+		resolvedEnv := cfg.devConfig.GetResolvedEnvironment(ctx)
+		for k, v := range resolvedEnv {
+			sb.WriteString(fmt.Sprintf("\t-e %s=\"%s\" \\\n", k, v))
 		}
 
-		ctx := &devcontainer.SubstituteContext{
-			LocalWorkspaceFolder:     cfg.worker.CWD,
-			ContainerWorkspaceFolder: cfg.worker.CWD,
-			LocalEnv:                 make(map[string]string),
-			ContainerEnv:             make(map[string]string),
+		// User-supplied run args come after the managed flags so they can
+		// augment them; security-sensitive ones are gated by checkSecurityConfig.
+		for _, arg := range resolvedRunArgs(cfg, workspaceRoot) {
+			sb.WriteString(fmt.Sprintf("\t%s \\\n", shellQuote(arg)))
 		}
+	}
 
-		for _, envVar := range os.Environ() {
-			kv := strings.SplitN(envVar, "=", 2)
-			if len(kv) == 2 {
-				ctx.LocalEnv[kv[0]] = kv[1]
+	// --env-file vars follow the devcontainer env so they take precedence
+	envKeys := make([]string, 0, len(cfg.envVars))
+	for k := range cfg.envVars {
+		envKeys = append(envKeys, k)
+	}
+	sort.Strings(envKeys)
+	for _, k := range envKeys {
+		sb.WriteString(fmt.Sprintf("\t-e %s \\\n", shellQuote(k+"="+cfg.envVars[k])))
+	}
+
+	// The nesting depth and limit come last so no env var or run arg can
+	// raise them for the worker
+	sb.WriteString(fmt.Sprintf("\t-e %s=%d \\\n", DepthEnv, workerDepth()))
+	sb.WriteString(fmt.Sprintf("\t-e %s=%d \\\n", MaxDepthEnv, workerMaxDepth(cfg.maxDepth)))
+	sb.WriteString(fmt.Sprintf("\t%s \\\n", shellQuote(runImage(cfg))))
+	sb.WriteString("\tbash /opt/worker/start.sh build\n")
+
+	return sb.String()
+}
+
+// runMounts returns the mount specs for the container: the mounts yak-box
+// manages followed by the devcontainer mounts, with their ${...} variables
+// resolved. A mount repeated verbatim is
+// kept once. Two different mounts onto the same container path are a
+// conflict: the first is kept and an error describes every conflict.
+func runMounts(cfg *spawnConfig, workspaceRoot, promptFile, innerScript, passwdFile, groupFile string) ([]string, error) {
+	managed := []string{
+		fmt.Sprintf("%s:%s:rw", workspaceRoot, containerPath(cfg, workspaceRoot, workspaceRoot)),
+		fmt.Sprintf("%s:/opt/worker/prompt.txt:ro", promptFile),
+		fmt.Sprintf("%s:/opt/worker/start.sh:ro", innerScript),
+		fmt.Sprintf("%s:/opt/worker/%s:rw", filepath.Join(filepath.Dir(innerScript), workerLogName), workerLogName),
+	}
+	if cfg.homeDir != "" {
+		managed = append(managed, fmt.Sprintf("%s:/home/yak-shaver:rw", cfg.homeDir))
+	}
+	if cfg.worker.WorktreePath != "" {
+		managed = append(managed, fmt.Sprintf("%s:%s:rw", cfg.worker.WorktreePath, containerPath(cfg, workspaceRoot, cfg.worker.WorktreePath)))
+		// The worktree's .git file names the main repository's git dir by
+		// its host path, which a remounted workspace no longer provides
+		if cfg.containerWorkspace != "" {
+			if gitDir, err := worktree.CommonGitDir(cfg.worker.WorktreePath); err == nil {
+				managed = append(managed, fmt.Sprintf("%s:%s:rw", gitDir, gitDir))
 			}
 		}
+	}
+	if !cfg.noAuthMount {
+		managed = append(managed, fmt.Sprintf("%s/.local/share/opencode/auth.json:/home/yak-shaver/.local/share/opencode/auth.json:ro", os.Getenv("HOME")))
+	}
+	managed = append(managed,
+		fmt.Sprintf("%s:/etc/passwd:ro", passwdFile),
+		fmt.Sprintf("%s:/etc/group:ro", groupFile),
+	)
 
-		resolvedEnv := cfg.devConfig.GetResolvedEnvironment(ctx)
-		for k, v := range resolvedEnv {
-			sb.WriteString(fmt.Sprintf("\t-e %s=\"%s\" \\\n", k, v))
+	var devMounts []string
+	if cfg.devConfig != nil {
+		ctx := newSubstituteContext(cfg, workspaceRoot)
+		for _, mount := range cfg.devConfig.Mounts {
+			resolved, _ := devcontainer.Substitute(ctx, mount).(string)
+			devMounts = append(devMounts, resolved)
 		}
 	}
 
-	sb.WriteString(fmt.Sprintf("\t%s \\\n", imageName))
-	sb.WriteString("\tbash /opt/worker/start.sh build\n")
+	var (
+		mounts    []string
+		conflicts []string
+	)
+	owners := make(map[string]string, len(managed)+len(devMounts))
+	add := func(mount string, fromDevConfig bool) {
+		target := mountTarget(mount)
+		if target == "" {
+			mounts = append(mounts, mount)
+			return
+		}
+		prior, taken := owners[target]
+		switch {
+		case !taken:
+			owners[target] = mount
+			mounts = append(mounts, mount)
+		case prior == mount:
+			// an exact duplicate adds nothing
+		case fromDevConfig && slices.Contains(managed, prior):
+			conflicts = append(conflicts, fmt.Sprintf("devcontainer mount %q targets %s, which yak-box already mounts (%q)", mount, target, prior))
+		default:
+			conflicts = append(conflicts, fmt.Sprintf("mounts %q and %q both target %s", prior, mount, target))
+		}
+	}
+	for _, mount := range managed {
+		add(mount, false)
+	}
+	for _, mount := range devMounts {
+		add(mount, true)
+	}
 
-	return sb.String()
+	if len(conflicts) > 0 {
+		return mounts, fmt.Errorf("conflicting container mounts: %s. Suggestion: Remove the mount from devcontainer.json or give it a different target", strings.Join(conflicts, "; "))
+	}
+	return mounts, nil
+}
+
+// mountTarget extracts the container path of a mount in either
+// "source=...,target=..." or "host:container[:opts]" form, or "" if it has
+// none.
+func mountTarget(mount string) string {
+	if !strings.Contains(mount, "=") {
+		parts := strings.Split(mount, ":")
+		if len(parts) < 2 || parts[1] == "" {
+			return ""
+		}
+		return path.Clean(parts[1])
+	}
+
+	for _, part := range strings.Split(mount, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch strings.TrimSpace(key) {
+		case "target", "dst", "destination":
+			if value != "" {
+				return path.Clean(value)
+			}
+		}
+	}
+	return ""
+}
+
+// forwardedPorts returns the devcontainer forwardPorts to publish, leaving
+// out ports whose onAutoForward is "ignore".
+func forwardedPorts(cfg *spawnConfig) []int {
+	if cfg.devConfig == nil {
+		return nil
+	}
+	var ports []int
+	for _, port := range cfg.devConfig.ForwardPortNumbers() {
+		if devcontainer.ShouldForward(cfg.devConfig.GetPortAttributes(strconv.Itoa(port))) {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// announcedPorts returns the forwarded ports to tell the user about. Ports
+// marked "silent" are forwarded without a line.
+func announcedPorts(cfg *spawnConfig) []int {
+	var ports []int
+	for _, port := range forwardedPorts(cfg) {
+		if cfg.devConfig.GetPortAttributes(strconv.Itoa(port)).OnAutoForward != "silent" {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// portAnnouncements returns one line per announced port with the host port
+// docker published it on, from published. A port missing from published
+// points at `docker port` instead. Browser and preview actions are announced
+// like "notify", since yak-box opens nothing itself.
+func portAnnouncements(cfg *spawnConfig, containerName string, published map[int]int) []string {
+	var lines []string
+	for _, port := range announcedPorts(cfg) {
+		attrs := cfg.devConfig.GetPortAttributes(strconv.Itoa(port))
+		scheme := "http"
+		if attrs.Protocol == "https" {
+			scheme = "https"
+		}
+		var line string
+		if hostPort, ok := published[port]; ok {
+			line = fmt.Sprintf("Forwarding port %d to %s://localhost:%d", port, scheme, hostPort)
+		} else {
+			line = fmt.Sprintf("Forwarding port %d to a host port docker picks; run 'docker port %s %d' to find it", port, containerName, port)
+		}
+		if attrs.Label != "" {
+			line += fmt.Sprintf(" (%s)", attrs.Label)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// portPollDelay is how often publishedPorts asks docker for the host ports
+// while the container starts. A variable so tests can avoid sleeping.
+var portPollDelay = time.Second
+
+// publishedPorts returns the host port docker published for each container
+// port, waiting up to readyTimeout for the container to start. Ports docker
+// has not reported by then are left out.
+func publishedPorts(ctx context.Context, commander Commander, containerName string, ports []int, readyTimeout time.Duration) map[int]int {
+	published := make(map[int]int, len(ports))
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		for _, port := range ports {
+			if _, ok := published[port]; ok {
+				continue
+			}
+			output, err := commander.CommandContext(ctx, "docker", "port", containerName, fmt.Sprintf("%d/tcp", port)).Output()
+			if err != nil {
+				continue
+			}
+			if hostPort, ok := parseDockerPort(string(output)); ok {
+				published[port] = hostPort
+			}
+		}
+		if len(published) == len(ports) || !time.Now().Before(deadline) {
+			return published
+		}
+		select {
+		case <-ctx.Done():
+			return published
+		case <-time.After(portPollDelay):
+		}
+	}
+}
+
+// parseDockerPort extracts the host port from the first line of `docker port`
+// output, e.g. "127.0.0.1:49153".
+func parseDockerPort(output string) (int, bool) {
+	line, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	i := strings.LastIndex(line, ":")
+	if i < 0 {
+		return 0, false
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(line[i+1:]))
+	return port, err == nil
+}
+
+// newSubstituteContext builds the variable context used to resolve
+// ${localWorkspaceFolder}-style references in the devcontainer config.
+func newSubstituteContext(cfg *spawnConfig, workspaceRoot string) *devcontainer.SubstituteContext {
+	ctx := &devcontainer.SubstituteContext{
+		LocalWorkspaceFolder:     cfg.worker.CWD,
+		ContainerWorkspaceFolder: containerPath(cfg, workspaceRoot, cfg.worker.CWD),
+		LocalEnv:                 make(map[string]string),
+		ContainerEnv:             make(map[string]string),
+	}
+
+	for _, envVar := range os.Environ() {
+		kv := strings.SplitN(envVar, "=", 2)
+		if len(kv) == 2 {
+			ctx.LocalEnv[kv[0]] = kv[1]
+		}
+	}
+	return ctx
+}
+
+// containerWorktreeDir is where the worktree is mounted, relative to the
+// container workspace, when WithContainerWorkspace is set.
+const containerWorktreeDir = ".worktree"
+
+// containerPath returns where hostPath appears inside the container. By
+// default host paths are mounted at the same location; with
+// WithContainerWorkspace, paths under the worktree or the workspace root are
+// rewritten to live under the container workspace instead.
+func containerPath(cfg *spawnConfig, workspaceRoot, hostPath string) string {
+	if cfg.containerWorkspace == "" || hostPath == "" {
+		return hostPath
+	}
+	if wt := cfg.worker.WorktreePath; wt != "" {
+		if rel, ok := relativeTo(wt, hostPath); ok {
+			return path.Join(cfg.containerWorkspace, containerWorktreeDir, rel)
+		}
+	}
+	if rel, ok := relativeTo(workspaceRoot, hostPath); ok {
+		return path.Join(cfg.containerWorkspace, rel)
+	}
+	return hostPath
+}
+
+// relativeTo returns target relative to base when target is base or lies
+// beneath it.
+func relativeTo(base, target string) (string, bool) {
+	rel, err := filepath.Rel(base, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// validateMounts rejects host paths that would be bind-mounted from outside
+// the permitted roots: the workspace root, the worker home, the worktree root
+// and any roots added with WithAllowedMountRoots.
+func validateMounts(cfg *spawnConfig, workspaceRoot string) error {
+	roots := []string{workspaceRoot}
+	if cfg.homeDir != "" {
+		roots = append(roots, cfg.homeDir)
+	}
+	if wtRoot, err := worktree.WorktreeRoot(); err == nil {
+		roots = append(roots, wtRoot)
+	}
+	roots = append(roots, cfg.allowedMountRoots...)
+
+	if cfg.worker.WorktreePath != "" && !isWithinRoots(cfg.worker.WorktreePath, roots) {
+		return fmt.Errorf("worktree path %s is outside the permitted mount roots (%s). Suggestion: Pass --allow-mount %s to permit it", cfg.worker.WorktreePath, strings.Join(roots, ", "), cfg.worker.WorktreePath)
+	}
+
+	if cfg.devConfig == nil {
+		return nil
+	}
+	ctx := newSubstituteContext(cfg, workspaceRoot)
+	checkSource := func(kind, mount string) error {
+		source, isBind := bindMountSource(mount)
+		if !isBind {
+			return nil
+		}
+		source, _ = devcontainer.Substitute(ctx, source).(string)
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(workspaceRoot, source)
+		}
+		if !isWithinRoots(source, roots) {
+			return fmt.Errorf("%s %q has source %s outside the permitted mount roots (%s). Suggestion: Remove the mount from devcontainer.json, or pass --allow-mount %s to permit it", kind, mount, source, strings.Join(roots, ", "), source)
+		}
+		return nil
+	}
+	for _, mount := range cfg.devConfig.Mounts {
+		if err := checkSource("devcontainer mount", mount); err != nil {
+			return err
+		}
+	}
+	for _, mount := range runArgMounts(resolvedRunArgs(cfg, workspaceRoot)) {
+		if err := checkSource("runArgs mount", mount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolvedRunArgs returns the devcontainer runArgs with their ${...}
+// variables substituted, as they are passed to docker run.
+func resolvedRunArgs(cfg *spawnConfig, workspaceRoot string) []string {
+	if cfg.devConfig == nil || len(cfg.devConfig.RunArgs) == 0 {
+		return nil
+	}
+	ctx := newSubstituteContext(cfg, workspaceRoot)
+	args := make([]string, 0, len(cfg.devConfig.RunArgs))
+	for _, arg := range cfg.devConfig.RunArgs {
+		resolved, _ := devcontainer.Substitute(ctx, arg).(string)
+		args = append(args, resolved)
+	}
+	return args
+}
+
+// runArgMounts returns the mounts that docker run args add with -v, --volume
+// or --mount, whether the value is a separate arg ("-v", "/a:/b"), joined
+// with "=" ("--volume=/a:/b") or attached to the short flag ("-v/a:/b").
+func runArgMounts(args []string) []string {
+	var mounts []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-v" || arg == "--volume" || arg == "--mount":
+			if i+1 < len(args) {
+				i++
+				mounts = append(mounts, args[i])
+			}
+		case strings.HasPrefix(arg, "--volume="):
+			mounts = append(mounts, strings.TrimPrefix(arg, "--volume="))
+		case strings.HasPrefix(arg, "--mount="):
+			mounts = append(mounts, strings.TrimPrefix(arg, "--mount="))
+		case strings.HasPrefix(arg, "-v") && !strings.HasPrefix(arg, "--"):
+			mounts = append(mounts, strings.TrimPrefix(arg[2:], "="))
+		}
+	}
+	return mounts
+}
+
+// bindMountSource extracts the host source of a mount in either
+// "source=...,target=..." or "host:container[:opts]" form. Named volumes and
+// tmpfs mounts have no host source and report false.
+func bindMountSource(mount string) (string, bool) {
+	if !strings.Contains(mount, "=") {
+		source, _, found := strings.Cut(mount, ":")
+		if !found || !(strings.HasPrefix(source, "/") || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "$")) {
+			return "", false
+		}
+		return source, true
+	}
+
+	mountType := "bind"
+	var source string
+	for _, part := range strings.Split(mount, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch strings.TrimSpace(key) {
+		case "type":
+			mountType = value
+		case "source", "src":
+			source = value
+		}
+	}
+	if mountType != "bind" || source == "" {
+		return "", false
+	}
+	return source, true
+}
+
+func isWithinRoots(path string, roots []string) bool {
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		if err := pathutil.ValidatePath(path, root); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSecurityConfig refuses devcontainer security settings that
+// ValidateSecurityConfig flags as critical, unless explicitly allowed.
+// The warnings themselves are printed when the config is loaded.
+func checkSecurityConfig(cfg *spawnConfig, workspaceRoot string) error {
+	if cfg.devConfig == nil {
+		return nil
+	}
+	// Malformed values are refused even with --allow-unsafe-security
+	if err := checkSecurityValues(cfg.devConfig); err != nil {
+		return err
+	}
+	if cfg.allowUnsafeSecurity {
+		return nil
+	}
+
+	var critical []string
+	for _, warning := range devcontainer.FilterCritical(devcontainer.ValidateSecurityConfig(cfg.devConfig)) {
+		critical = append(critical, warning.Message)
+	}
+	critical = append(critical, restrictedRunArgs(resolvedRunArgs(cfg, workspaceRoot))...)
+	if len(critical) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("devcontainer requests unsafe security settings:\n  - %s\nSuggestion: Remove them from devcontainer.json, or pass --allow-unsafe-security to proceed anyway", strings.Join(critical, "\n  - "))
+}
+
+// capabilityName matches a Linux capability name as docker --cap-add takes it.
+var capabilityName = regexp.MustCompile(`^[A-Z_]+$`)
+
+// securityOptUnsafeChars are characters no docker --security-opt value needs
+// but a shell would interpret.
+const securityOptUnsafeChars = " \t\r\n;&|$`'\"\\<>(){}[]*?!#~"
+
+// checkSecurityValues rejects capAdd and securityOpt entries that are not
+// plain capability names or option values.
+func checkSecurityValues(devConfig *devcontainer.Config) error {
+	var invalid []string
+	for _, capability := range devConfig.CapAdd {
+		if !capabilityName.MatchString(capability) {
+			invalid = append(invalid, fmt.Sprintf("capAdd %q is not a capability name like NET_ADMIN", capability))
+		}
+	}
+	for _, opt := range devConfig.SecurityOpt {
+		if opt == "" || strings.ContainsAny(opt, securityOptUnsafeChars) {
+			invalid = append(invalid, fmt.Sprintf("securityOpt %q must not be empty or contain whitespace or shell metacharacters", opt))
+		}
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+	return fmt.Errorf("devcontainer has invalid security settings:\n  - %s\nSuggestion: Fix them in devcontainer.json", strings.Join(invalid, "\n  - "))
+}
+
+// securityWarnings describes sandbox hardening the caller has opted out of.
+func securityWarnings(cfg *spawnConfig) []string {
+	var warnings []string
+	if cfg.noCapDrop {
+		warnings = append(warnings, "--no-cap-drop: the container keeps Docker's default capabilities (e.g. CHOWN, NET_RAW, SETUID) instead of dropping them all")
+	}
+	return warnings
+}
+
+// managedRunArgFlags are docker run flags that runArgs may not use, with any
+// value, without --allow-unsafe-security: they override the sandbox's
+// capability, security, network and user settings or expose host devices.
+var managedRunArgFlags = []string{
+	"--privileged", "--cap-add", "--cap-drop", "--security-opt",
+	"--network", "--net", "--device", "--device-cgroup-rule",
+	"--user", "-u", "--group-add",
+}
+
+// hostNamespaceFlags are docker run flags that runArgs may not set to "host",
+// which would share that host namespace with the container.
+var hostNamespaceFlags = []string{"--pid", "--ipc", "--uts", "--userns", "--cgroupns"}
+
+// restrictedRunArgs describes each entry of the resolved args that would
+// weaken the sandbox. A flag's value may be joined with "=", attached to a
+// short flag ("-u0") or given as the next arg.
+func restrictedRunArgs(args []string) []string {
+	var restricted []string
+	for i, arg := range args {
+		flag, value, joined := strings.Cut(arg, "=")
+		if !joined && strings.HasPrefix(arg, "-u") && len(arg) > 2 {
+			flag, value, joined = "-u", arg[2:], true
+		}
+		if !joined && i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch {
+		case slices.Contains(managedRunArgFlags, flag):
+			restricted = append(restricted, "runArgs entry "+arg+" would override the managed security flags")
+		case slices.Contains(hostNamespaceFlags, flag) && value == "host":
+			restricted = append(restricted, fmt.Sprintf("runArgs entry %s would share the host's %s namespace", arg, strings.TrimPrefix(flag, "--")))
+		}
+	}
+	return restricted
+}
+
+// shellQuote single-quotes s for bash unless it only contains characters
+// that are safe to leave bare.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_=.,:/@+%", r))
+	}) == -1 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// useInit reports whether the container should run docker's tiny init process
+// for zombie reaping, either forced via --init or requested by the devcontainer.
+func useInit(cfg *spawnConfig) bool {
+	if cfg.init {
+		return true
+	}
+	return cfg.devConfig != nil && cfg.devConfig.Init != nil && *cfg.devConfig.Init
 }
 
 func createZellijLayout(workerName, wrapperScript, shellExecScript, containerName string) string {
 	return fmt.Sprintf(`layout {
-    tab name="%s" {
+    tab name=%s {
         pane size=1 borderless=true {
             plugin location="compact-bar"
         }
         pane size="67%%" name="opencode (build) [docker]" focus=true {
             command "bash"
-            args "%s"
+            args %s
         }
         pane size="33%%" name="shell: container" {
             command "bash"
-            args "%s" "%s"
+            args %s %s
         }
         pane size=2 borderless=true {
             plugin location="status-bar"
         }
     }
 }
-`, workerName, wrapperScript, shellExecScript, containerName)
+`, kdlString(tabName(workerName)), kdlString(wrapperScript), kdlString(shellExecScript), kdlString(containerName))
 }
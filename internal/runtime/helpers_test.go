@@ -1,31 +1,218 @@
 package runtime
 
 import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/wellmaintained/yak-box/pkg/devcontainer"
 	"github.com/wellmaintained/yak-box/pkg/types"
+	"github.com/wellmaintained/yak-box/pkg/worktree"
 )
 
 func TestGenerateInitScript(t *testing.T) {
-	script := generateInitScript()
+	script := generateInitScript("", nil)
 	if !strings.Contains(script, "WORKSPACE_ROOT=") {
 		t.Error("Init script missing WORKSPACE_ROOT")
 	}
-	if !strings.Contains(script, "opencode --prompt") {
+	if !strings.Contains(script, `opencode "${OPENCODE_ARGS[@]}"`) {
 		t.Error("Init script missing opencode command")
 	}
 }
 
+func TestGenerateInitScript_OpencodeModel(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	binDir := filepath.Join(tmpDir, "bin")
+	os.MkdirAll(binDir, 0755)
+	argsFile := filepath.Join(tmpDir, "args.txt")
+	fakeOpencode := "#!/usr/bin/env bash\n" + `printf '%s\n' "$*" >> "` + argsFile + `"` + "\n"
+	os.WriteFile(filepath.Join(binDir, "opencode"), []byte(fakeOpencode), 0755)
+
+	script := filepath.Join(tmpDir, "start.sh")
+	os.WriteFile(script, []byte(generateInitScript("none", nil)), 0755)
+
+	tests := []struct {
+		name  string
+		model string
+		want  string
+	}{
+		{name: "with model", model: "anthropic/claude-sonnet-4-5", want: "--model anthropic/claude-sonnet-4-5 --prompt  --agent build"},
+		{name: "without model", model: "", want: "--prompt  --agent build"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(argsFile)
+			cmd := exec.Command(bash, script, "build")
+			cmd.Env = append(os.Environ(),
+				"PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"),
+				"WORKSPACE_ROOT="+tmpDir,
+				"YAK_TOOL=opencode",
+				"YAK_MODEL="+tt.model,
+			)
+			_ = cmd.Run()
+
+			data, err := os.ReadFile(argsFile)
+			if err != nil {
+				t.Fatalf("opencode was not invoked: %v", err)
+			}
+			first := strings.SplitN(string(data), "\n", 2)[0]
+			if first != tt.want {
+				t.Errorf("opencode args = %q, want %q", first, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateInitScript_CapturesLog(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	binDir := filepath.Join(tmpDir, "bin")
+	os.MkdirAll(binDir, 0755)
+	fakeOpencode := "#!/usr/bin/env bash\necho 'to stdout'\necho 'to stderr' >&2\n[[ -t 1 ]] && echo 'on a terminal'\nexit 3\n"
+	os.WriteFile(filepath.Join(binDir, "opencode"), []byte(fakeOpencode), 0755)
+
+	script := filepath.Join(tmpDir, "start.sh")
+	os.WriteFile(script, []byte(generateInitScript("none", nil)), 0755)
+	logFile := filepath.Join(tmpDir, "worker.log")
+
+	cmd := exec.Command(bash, script, "build")
+	cmd.Env = append(os.Environ(),
+		"PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"WORKSPACE_ROOT="+tmpDir,
+		"YAK_TOOL=opencode",
+		"YAK_LOG_FILE="+logFile,
+	)
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("init script exit = %v, want tool's exit code 3", err)
+	}
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("worker log not written: %v", err)
+	}
+	for _, want := range []string{"to stdout", "to stderr", "on a terminal"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("worker log missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestGenerateInitScript_UserEnvProbe(t *testing.T) {
+	tests := []struct {
+		probe string
+		want  string
+	}{
+		{probe: "", want: "bash -lic "},
+		{probe: "loginInteractiveShell", want: "bash -lic "},
+		{probe: "loginShell", want: "bash -lc "},
+		{probe: "interactiveShell", want: "bash -ic "},
+		{probe: "bogus", want: "bash -lic "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.probe, func(t *testing.T) {
+			script := generateInitScript(tt.probe, nil)
+			if !strings.Contains(script, tt.want) {
+				t.Errorf("init script for %q missing %q:\n%s", tt.probe, tt.want, script)
+			}
+		})
+	}
+
+	if script := generateInitScript("none", nil); strings.Contains(script, "export -p") {
+		t.Errorf("init script for none should not probe the environment:\n%s", script)
+	}
+}
+
+func TestGenerateInitScript_UserEnvProbeImportsRCEnv(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	binDir := filepath.Join(tmpDir, "bin")
+	os.MkdirAll(binDir, 0755)
+	outFile := filepath.Join(tmpDir, "out.txt")
+	fakeOpencode := "#!/usr/bin/env bash\n" + `printf '%s\n' "$FROM_RC" > "` + outFile + `"` + "\n"
+	os.WriteFile(filepath.Join(binDir, "opencode"), []byte(fakeOpencode), 0755)
+
+	home := filepath.Join(tmpDir, "home")
+	os.MkdirAll(home, 0755)
+	os.WriteFile(filepath.Join(home, ".bashrc"), []byte("echo 'welcome'\nexport FROM_RC=from-bashrc\n"), 0644)
+
+	script := filepath.Join(tmpDir, "start.sh")
+	os.WriteFile(script, []byte(generateInitScript("interactiveShell", nil)), 0755)
+
+	cmd := exec.Command(bash, script, "build")
+	cmd.Env = append(os.Environ(),
+		"HOME="+home,
+		"PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"WORKSPACE_ROOT="+tmpDir,
+		"YAK_TOOL=opencode",
+	)
+	_ = cmd.Run()
+
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("opencode was not invoked: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "from-bashrc" {
+		t.Errorf("FROM_RC = %q, expected the value exported by .bashrc", got)
+	}
+}
+
 func TestGenerateWaitScript(t *testing.T) {
-	script := generateWaitScript()
+	script := generateWaitScript(DefaultReadyTimeout)
 	if !strings.Contains(script, "CONTAINER_NAME=\"$1\"") {
 		t.Error("Wait script missing CONTAINER_NAME")
 	}
 	if !strings.Contains(script, "docker inspect") {
 		t.Error("Wait script missing docker inspect")
 	}
+	if !strings.Contains(script, "MAX_RETRIES=30\n") {
+		t.Error("Default wait script should retry 30 times")
+	}
+	if !strings.Contains(script, "${SECONDS}s") {
+		t.Error("Wait script should report elapsed time when giving up")
+	}
+}
+
+func TestGenerateWaitScript_ReadyTimeout(t *testing.T) {
+	tests := []struct {
+		timeout time.Duration
+		want    string
+	}{
+		{timeout: 2 * time.Minute, want: "MAX_RETRIES=120\n"},
+		{timeout: 1500 * time.Millisecond, want: "MAX_RETRIES=2\n"},
+		{timeout: 10 * time.Millisecond, want: "MAX_RETRIES=1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timeout.String(), func(t *testing.T) {
+			script := generateWaitScript(tt.timeout)
+			if !strings.Contains(script, tt.want) {
+				t.Errorf("expected %q in wait script:\n%s", tt.want, script)
+			}
+			if !strings.Contains(script, "RETRY_DELAY=1\n") {
+				t.Error("Wait script should poll every second")
+			}
+		})
+	}
 }
 
 func TestGenerateRunScript(t *testing.T) {
@@ -60,9 +247,11 @@ func TestGenerateRunScript(t *testing.T) {
 		"--network test-net",
 		"--cpus 1.0",
 		"--memory 2g",
-		"-v \"/test/workspace:/test/workspace:rw\"",
+		"-v /test/workspace:/test/workspace:rw",
+		"-v /test/worker.log:/opt/worker/worker.log:rw",
 		"-w \"/test/cwd\"",
 		`WORKER_NAME="TestWorker"`,
+		`WORKSPACE_ROOT="/test/workspace"`,
 	}
 
 	for _, exp := range expected {
@@ -72,6 +261,93 @@ func TestGenerateRunScript(t *testing.T) {
 	}
 }
 
+func TestGenerateRunScript_ContainerWorkspace(t *testing.T) {
+	newCfg := func(cwd, worktreePath string) *spawnConfig {
+		cfg := &spawnConfig{
+			worker: &types.Worker{
+				Name:         "test-worker",
+				CWD:          cwd,
+				YakPath:      "/host/repo/.yaks",
+				WorkerName:   "TestWorker",
+				WorktreePath: worktreePath,
+			},
+			profile: types.ResourceProfile{Name: "default", CPUs: "1.0", Memory: "2g", PIDs: 512},
+			devConfig: &devcontainer.Config{
+				RemoteEnv: map[string]string{"SRC_DIR": "${containerWorkspaceFolder}/src"},
+			},
+		}
+		if err := WithContainerWorkspace("/workspace")(cfg); err != nil {
+			t.Fatalf("WithContainerWorkspace() error = %v", err)
+		}
+		return cfg
+	}
+
+	t.Run("workspace mounted at canonical path", func(t *testing.T) {
+		script := generateRunScript(newCfg("/host/repo/api", ""), "/host/repo", "/p", "/i", "/pw", "/g", "net")
+		for _, want := range []string{
+			`-v /host/repo:/workspace:rw`,
+			`-w "/workspace/api"`,
+			`-e YAK_PATH="/workspace/.yaks"`,
+			`-e YAK_WORKSPACE="/workspace/api"`,
+			`-e SRC_DIR="/workspace/api/src"`,
+		} {
+			if !strings.Contains(script, want) {
+				t.Errorf("run script missing %q:\n%s", want, script)
+			}
+		}
+		if strings.Contains(script, "/host/repo:/host/repo") {
+			t.Error("workspace should not be mounted at its host path")
+		}
+	})
+
+	t.Run("worktree mapped under the workspace", func(t *testing.T) {
+		wt := "/host/worktrees/repo/auth-api"
+		script := generateRunScript(newCfg(wt, wt), "/host/repo", "/p", "/i", "/pw", "/g", "net")
+		for _, want := range []string{
+			`-v /host/repo:/workspace:rw`,
+			`-v /host/worktrees/repo/auth-api:/workspace/.worktree:rw`,
+			`-w "/workspace/.worktree"`,
+			`-e YAK_WORKSPACE="/workspace/.worktree"`,
+		} {
+			if !strings.Contains(script, want) {
+				t.Errorf("run script missing %q:\n%s", want, script)
+			}
+		}
+	})
+
+	t.Run("git worktree keeps its main repository", func(t *testing.T) {
+		t.Setenv(worktree.WorktreeRootEnv, t.TempDir())
+		repo, err := filepath.EvalSymlinks(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{
+			{"init"},
+			{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "init"},
+		} {
+			if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+		wt, err := worktree.EnsureWorktree(repo, "auth/api", "", false)
+		if err != nil {
+			t.Fatalf("EnsureWorktree() error = %v", err)
+		}
+
+		script := generateRunScript(newCfg(wt, wt), repo, "/p", "/i", "/pw", "/g", "net")
+		gitDir := filepath.Join(repo, ".git")
+		if want := "-v " + shellQuote(gitDir+":"+gitDir+":rw"); !strings.Contains(script, want) {
+			t.Errorf("run script missing %q:\n%s", want, script)
+		}
+	})
+
+	t.Run("relative path rejected", func(t *testing.T) {
+		if err := WithContainerWorkspace("workspace")(&spawnConfig{}); err == nil {
+			t.Error("expected an error for a relative container workspace")
+		}
+	})
+}
+
 func TestGenerateRunScript_WithDevConfig(t *testing.T) {
 	cfg := &spawnConfig{
 		worker: &types.Worker{
@@ -102,7 +378,7 @@ func TestGenerateRunScript_WithDevConfig(t *testing.T) {
 
 	expected := []string{
 		"custom-image:latest",
-		"-v \"source=/foo,target=/bar,type=bind\"",
+		"--mount source=/foo,target=/bar,type=bind",
 		"-e CUSTOM_ENV=\"value\"",
 	}
 
@@ -113,6 +389,517 @@ func TestGenerateRunScript_WithDevConfig(t *testing.T) {
 	}
 }
 
+func TestGenerateRunScript_ValidatedMounts(t *testing.T) {
+	t.Setenv("HOME", "/home/host")
+	devConfig := &devcontainer.Config{
+		Mounts: []string{
+			"source=${localWorkspaceFolder}/data,target=/data,type=bind",
+			"type=tmpfs,target=/scratch",
+			"${localEnv:HOME}/.npmrc:/home/node/.npmrc:ro",
+		},
+	}
+	if errs := devcontainer.Validate(devConfig); len(errs) != 0 {
+		t.Fatalf("Validate() = %v, expected the mounts to be valid", errs)
+	}
+
+	cfg := &spawnConfig{
+		worker:      &types.Worker{Name: "test-worker", CWD: "/ws/api", WorkerName: "TestWorker"},
+		profile:     types.ResourceProfile{CPUs: "1.0", Memory: "2g", PIDs: 512},
+		noAuthMount: true,
+		devConfig:   devConfig,
+	}
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+
+	for _, want := range []string{
+		"\t--mount source=/ws/api/data,target=/data,type=bind \\\n",
+		"\t--mount type=tmpfs,target=/scratch \\\n",
+		"\t-v /home/host/.npmrc:/home/node/.npmrc:ro \\\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("run script missing %q:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "${local") {
+		t.Errorf("run script should not contain unresolved variables:\n%s", script)
+	}
+}
+
+func TestGenerateRunScript_QuotesMounts(t *testing.T) {
+	t.Setenv("HOME", `/home/host "$(touch pwned)"`)
+	cfg := &spawnConfig{
+		worker:      &types.Worker{Name: "test-worker", CWD: "/ws/api", WorkerName: "TestWorker"},
+		profile:     types.ResourceProfile{CPUs: "1.0", Memory: "2g", PIDs: 512},
+		noAuthMount: true,
+		devConfig: &devcontainer.Config{
+			Mounts: []string{"source=${localEnv:HOME}/cache,target=/cache,type=bind", "${localEnv:HOME}/.npmrc:/home/node/.npmrc:ro"},
+		},
+	}
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+
+	for _, want := range []string{
+		"\t--mount 'source=/home/host \"$(touch pwned)\"/cache,target=/cache,type=bind' \\\n",
+		"\t-v '/home/host \"$(touch pwned)\"/.npmrc:/home/node/.npmrc:ro' \\\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("run script missing %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestGenerateRunScript_QuotesModel(t *testing.T) {
+	cfg := &spawnConfig{
+		worker:  &types.Worker{Name: "test-worker", WorkerName: "TestWorker", Model: `x/$(id)"`},
+		profile: types.ResourceProfile{CPUs: "1.0", Memory: "2g", PIDs: 512},
+	}
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	if want := "\t-e 'YAK_MODEL=x/$(id)\"' \\\n"; !strings.Contains(script, want) {
+		t.Errorf("run script missing %q:\n%s", want, script)
+	}
+}
+
+func TestGenerateRunScript_ImageOverride(t *testing.T) {
+	cfg := &spawnConfig{
+		worker: &types.Worker{Name: "test-worker", WorkerName: "TestWorker"},
+		profile: types.ResourceProfile{
+			Name:   "default",
+			CPUs:   "1.0",
+			Memory: "2g",
+			PIDs:   512,
+		},
+		devConfig: &devcontainer.Config{Image: "custom-image:latest"},
+	}
+
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	if !strings.Contains(script, "\tcustom-image:latest \\\n") {
+		t.Errorf("expected devcontainer image without override, got:\n%s", script)
+	}
+
+	if err := WithImage("ghcr.io/acme/base:1.2")(cfg); err != nil {
+		t.Fatalf("WithImage() error = %v", err)
+	}
+	script = generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	if !strings.Contains(script, "\tghcr.io/acme/base:1.2 \\\n") {
+		t.Errorf("expected --image override in run script, got:\n%s", script)
+	}
+	if strings.Contains(script, "custom-image:latest") {
+		t.Error("devcontainer image should be replaced by the override")
+	}
+}
+
+func TestGenerateRunScript_Detached(t *testing.T) {
+	cfg := &spawnConfig{
+		worker: &types.Worker{Name: "test-worker", WorkerName: "TestWorker"},
+		profile: types.ResourceProfile{
+			Name:   "default",
+			CPUs:   "1.0",
+			Memory: "2g",
+			PIDs:   512,
+		},
+	}
+
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	if !strings.Contains(script, "exec docker run -it --rm \\\n") {
+		t.Errorf("expected an interactive run by default, got:\n%s", script)
+	}
+
+	cfg.noZellij = true
+	script = generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	if !strings.Contains(script, "exec docker run -dit --rm \\\n") {
+		t.Errorf("expected a detached run with a TTY and stdin with noZellij, got:\n%s", script)
+	}
+	if !strings.HasSuffix(script, "\tbash /opt/worker/start.sh build\n") {
+		t.Errorf("detached run script should still start the worker, got:\n%s", script)
+	}
+}
+
+func TestGenerateRunScript_Init(t *testing.T) {
+	enabled := true
+	disabled := false
+
+	tests := []struct {
+		name      string
+		forceInit bool
+		devInit   *bool
+		want      bool
+	}{
+		{name: "no flag and no devcontainer setting", want: false},
+		{name: "devcontainer init true", devInit: &enabled, want: true},
+		{name: "devcontainer init false", devInit: &disabled, want: false},
+		{name: "flag forces init", forceInit: true, want: true},
+		{name: "flag overrides devcontainer init false", forceInit: true, devInit: &disabled, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &spawnConfig{
+				worker: &types.Worker{
+					Name:       "test-worker",
+					CWD:        "/test/cwd",
+					WorkerName: "TestWorker",
+				},
+				profile: types.ResourceProfile{CPUs: "1.0", Memory: "2g", PIDs: 512},
+				init:    tt.forceInit,
+			}
+			if tt.devInit != nil {
+				cfg.devConfig = &devcontainer.Config{Init: tt.devInit}
+			}
+
+			script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+			got := strings.Contains(script, "\t--init \\\n")
+			if got != tt.want {
+				t.Errorf("--init present = %v, want %v\nscript:\n%s", got, tt.want, script)
+			}
+		})
+	}
+}
+
+func TestGenerateRunScript_CapAddAndSecurityOpt(t *testing.T) {
+	cfg := &spawnConfig{
+		worker: &types.Worker{
+			Name:       "test-worker",
+			CWD:        "/test/cwd",
+			WorkerName: "TestWorker",
+		},
+		profile: types.ResourceProfile{CPUs: "1.0", Memory: "2g", PIDs: 512},
+		devConfig: &devcontainer.Config{
+			CapAdd:      []string{"NET_BIND_SERVICE"},
+			SecurityOpt: []string{"apparmor=docker-default"},
+		},
+	}
+
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+
+	expected := []string{
+		"--security-opt no-new-privileges",
+		"--cap-drop ALL",
+		"--cap-add NET_BIND_SERVICE",
+		"--security-opt apparmor=docker-default",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(script, exp) {
+			t.Errorf("Run script missing expected string: %s", exp)
+		}
+	}
+	if strings.Index(script, "--cap-add") < strings.Index(script, "--cap-drop ALL") {
+		t.Error("--cap-add must come after --cap-drop ALL")
+	}
+}
+
+func TestGenerateRunScript_NoCapDrop(t *testing.T) {
+	cfg := &spawnConfig{
+		worker: &types.Worker{
+			Name:       "test-worker",
+			CWD:        "/test/cwd",
+			WorkerName: "TestWorker",
+		},
+		profile: types.ResourceProfile{CPUs: "1.0", Memory: "2g", PIDs: 512},
+	}
+
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	if !strings.Contains(script, "--cap-drop ALL") {
+		t.Error("Run script should drop all capabilities by default")
+	}
+	if len(securityWarnings(cfg)) != 0 {
+		t.Errorf("securityWarnings() = %v, expected none by default", securityWarnings(cfg))
+	}
+
+	if err := WithNoCapDrop(true)(cfg); err != nil {
+		t.Fatalf("WithNoCapDrop() error = %v", err)
+	}
+	script = generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	if strings.Contains(script, "--cap-drop") {
+		t.Error("Run script should omit --cap-drop with WithNoCapDrop(true)")
+	}
+	if !strings.Contains(script, "--security-opt no-new-privileges") {
+		t.Error("WithNoCapDrop should not affect no-new-privileges")
+	}
+	warnings := securityWarnings(cfg)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "--no-cap-drop") {
+		t.Errorf("securityWarnings() = %v, expected a --no-cap-drop warning", warnings)
+	}
+}
+
+func TestGenerateRunScript_RunArgs(t *testing.T) {
+	t.Setenv("YAK_TEST_HOST_IP", "10.0.0.5")
+	cfg := &spawnConfig{
+		worker: &types.Worker{
+			Name:       "test-worker",
+			CWD:        "/test/cwd",
+			WorkerName: "TestWorker",
+		},
+		profile: types.ResourceProfile{CPUs: "1.0", Memory: "2g", PIDs: 512},
+		devConfig: &devcontainer.Config{
+			Image:   "custom-image:latest",
+			RunArgs: []string{"--add-host=db:${localEnv:YAK_TEST_HOST_IP}", "--label", "team=a b"},
+		},
+	}
+
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+
+	for _, exp := range []string{"\t--add-host=db:10.0.0.5 \\\n", "\t--label \\\n", "\t'team=a b' \\\n"} {
+		if !strings.Contains(script, exp) {
+			t.Errorf("Run script missing expected string: %q", exp)
+		}
+	}
+	if strings.Index(script, "--add-host") < strings.Index(script, "--cap-drop ALL") {
+		t.Error("runArgs must come after the managed security flags")
+	}
+	if strings.Index(script, "--add-host") > strings.Index(script, "custom-image:latest") {
+		t.Error("runArgs must come before the image name")
+	}
+}
+
+func TestGenerateRunScript_ForwardPorts(t *testing.T) {
+	cfg := &spawnConfig{
+		worker:  &types.Worker{Name: "test-worker", CWD: "/test/cwd", WorkerName: "TestWorker"},
+		profile: types.ResourceProfile{CPUs: "1.0", Memory: "2g", PIDs: 512},
+		devConfig: &devcontainer.Config{
+			ForwardPorts: []interface{}{float64(3000), float64(5432), "db:5432", float64(9229)},
+			PortsAttributes: map[string]devcontainer.PortAttributes{
+				"5432": {OnAutoForward: "ignore"},
+			},
+			OtherPortsAttributes: devcontainer.PortAttributes{OnAutoForward: "silent"},
+		},
+	}
+
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+
+	for _, exp := range []string{"\t-p 127.0.0.1::3000 \\\n", "\t-p 127.0.0.1::9229 \\\n"} {
+		if !strings.Contains(script, exp) {
+			t.Errorf("Run script missing expected string: %q", exp)
+		}
+	}
+	if strings.Contains(script, "5432") {
+		t.Errorf("port with onAutoForward ignore must not be published:\n%s", script)
+	}
+}
+
+func TestPortAnnouncements(t *testing.T) {
+	cfg := &spawnConfig{
+		devConfig: &devcontainer.Config{
+			ForwardPorts: []interface{}{float64(3000), float64(8443), float64(9229), float64(5432)},
+			PortsAttributes: map[string]devcontainer.PortAttributes{
+				"3000": {Label: "Web", OnAutoForward: "notify"},
+				"8443": {Protocol: "https", OnAutoForward: "openBrowser"},
+				"9229": {Label: "Debugger", OnAutoForward: "silent"},
+				"5432": {OnAutoForward: "ignore"},
+			},
+		},
+	}
+
+	got := portAnnouncements(cfg, "yak-worker-api", map[int]int{3000: 49153})
+	want := []string{
+		"Forwarding port 3000 to http://localhost:49153 (Web)",
+		"Forwarding port 8443 to a host port docker picks; run 'docker port yak-worker-api 8443' to find it",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("portAnnouncements() = %q, want %q", got, want)
+	}
+
+	if lines := portAnnouncements(&spawnConfig{}, "yak-worker-api", nil); len(lines) != 0 {
+		t.Errorf("no devcontainer config should announce nothing, got %q", lines)
+	}
+}
+
+func TestPublishedPorts(t *testing.T) {
+	orig := portPollDelay
+	portPollDelay = time.Millisecond
+	t.Cleanup(func() { portPollDelay = orig })
+
+	// The container has started once the first port query has run
+	started := filepath.Join(t.TempDir(), "started")
+	cmdr := &recordingCommander{routes: map[string]string{
+		"port": `[ -e ` + started + ` ] || { touch ` + started + `; exit 1; }; echo "127.0.0.1:49153"; echo "[::1]:49153"`,
+	}}
+
+	got := publishedPorts(context.Background(), cmdr, "yak-worker-api", []int{3000}, time.Second)
+	if want := map[int]int{3000: 49153}; !reflect.DeepEqual(got, want) {
+		t.Errorf("publishedPorts() = %v, want %v", got, want)
+	}
+	if want := []string{"docker", "port", "yak-worker-api", "3000/tcp"}; !reflect.DeepEqual(cmdr.calls[0], want) {
+		t.Errorf("first call = %q, want %q", cmdr.calls[0], want)
+	}
+	if len(cmdr.calls) != 2 {
+		t.Errorf("expected a retry until the container started, got %d calls", len(cmdr.calls))
+	}
+
+	withoutContainer := &recordingCommander{routes: map[string]string{"port": "exit 1"}}
+	if got := publishedPorts(context.Background(), withoutContainer, "yak-worker-api", []int{3000}, 10*time.Millisecond); len(got) != 0 {
+		t.Errorf("publishedPorts() = %v, want none when docker never reports the port", got)
+	}
+}
+
+func TestRunMounts(t *testing.T) {
+	mountsFor := func(devMounts ...string) ([]string, error) {
+		cfg := &spawnConfig{
+			worker:      &types.Worker{Name: "test-worker", CWD: "/ws/api", WorkerName: "TestWorker"},
+			homeDir:     "/ws/.yak-boxes/@home/TestWorker",
+			noAuthMount: true,
+			devConfig:   &devcontainer.Config{Mounts: devMounts},
+		}
+		return runMounts(cfg, "/ws", "/s/prompt.txt", "/s/inner.sh", "/s/passwd", "/s/group")
+	}
+
+	t.Run("distinct mounts pass through unchanged", func(t *testing.T) {
+		devMounts := []string{
+			"source=/ws/cache,target=/home/yak-shaver/.cache,type=bind",
+			"/ws/data:/data:ro",
+		}
+		mounts, err := mountsFor(devMounts...)
+		if err != nil {
+			t.Fatalf("runMounts() error = %v", err)
+		}
+		if got := mounts[len(mounts)-2:]; !reflect.DeepEqual(got, devMounts) {
+			t.Errorf("devcontainer mounts = %q, want %q", got, devMounts)
+		}
+	})
+
+	t.Run("exact duplicate is kept once", func(t *testing.T) {
+		mounts, err := mountsFor("/ws/data:/data:ro", "/ws/data:/data:ro")
+		if err != nil {
+			t.Fatalf("runMounts() error = %v", err)
+		}
+		if mounts[len(mounts)-1] != "/ws/data:/data:ro" || mounts[len(mounts)-2] == "/ws/data:/data:ro" {
+			t.Errorf("duplicate mount not collapsed: %q", mounts)
+		}
+	})
+
+	t.Run("devcontainer mount colliding with the home mount", func(t *testing.T) {
+		mounts, err := mountsFor("source=/elsewhere,target=/home/yak-shaver/,type=bind")
+		if err == nil {
+			t.Fatal("expected a conflict error")
+		}
+		for _, want := range []string{"target=/home/yak-shaver/", "/home/yak-shaver", "yak-box already mounts", "/ws/.yak-boxes/@home/TestWorker:/home/yak-shaver:rw"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q missing %q", err, want)
+			}
+		}
+		for _, mount := range mounts {
+			if strings.Contains(mount, "/elsewhere") {
+				t.Errorf("conflicting devcontainer mount should be dropped, got %q", mounts)
+			}
+		}
+	})
+
+	t.Run("two devcontainer mounts on one target", func(t *testing.T) {
+		_, err := mountsFor("/a:/data", "/b:/data")
+		if err == nil || !strings.Contains(err.Error(), `mounts "/a:/data" and "/b:/data" both target /data`) {
+			t.Errorf("runMounts() error = %v, want both-target conflict", err)
+		}
+	})
+}
+
+func TestSpawnSandboxedWorker_MountConflict(t *testing.T) {
+	homeDir := t.TempDir()
+	worker := &types.Worker{Name: "test-worker", DisplayName: "Test Worker", CWD: homeDir, WorkerName: "TestBot"}
+
+	cmdr := &TestCommander{}
+	err := SpawnSandboxedWorker(
+		context.Background(),
+		WithWorker(worker),
+		WithHomeDir(homeDir),
+		WithDevConfig(&devcontainer.Config{Mounts: []string{homeDir + ":/etc/passwd:ro"}}),
+		WithCommander(cmdr),
+	)
+	if err == nil || !strings.Contains(err.Error(), "conflicting container mounts") {
+		t.Fatalf("SpawnSandboxedWorker() error = %v, want a mount conflict", err)
+	}
+	if cmdr.hasCommand("zellij") {
+		t.Error("zellij must not be launched when mounts conflict")
+	}
+}
+
+func TestSecurityValuesAreValidatedAndQuoted(t *testing.T) {
+	devConfig := &devcontainer.Config{
+		CapAdd:      []string{"CHOWN; touch /tmp/pwned"},
+		SecurityOpt: []string{"label=disable;id"},
+	}
+	for _, allow := range []bool{false, true} {
+		cfg := &spawnConfig{worker: &types.Worker{CWD: "/ws"}, devConfig: devConfig, allowUnsafeSecurity: allow}
+		err := checkSecurityConfig(cfg, "/ws")
+		if err == nil || !strings.Contains(err.Error(), "not a capability name") || !strings.Contains(err.Error(), "shell metacharacters") {
+			t.Errorf("checkSecurityConfig(allow=%v) = %v, want both values rejected", allow, err)
+		}
+	}
+
+	cfg := &spawnConfig{
+		worker:    &types.Worker{Name: "test-worker", CWD: "/ws", WorkerName: "TestWorker"},
+		profile:   GetResourceProfile("default"),
+		devConfig: devConfig,
+	}
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	for _, want := range []string{
+		"\t--cap-add 'CHOWN; touch /tmp/pwned' \\\n",
+		"\t--security-opt 'label=disable;id' \\\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("run script missing quoted %q:\n%s", want, script)
+		}
+	}
+	if strings.Count(script, "; touch /tmp/pwned") != 1 {
+		t.Errorf("payload should appear only inside its quoted argument:\n%s", script)
+	}
+
+	if err := checkSecurityConfig(&spawnConfig{devConfig: &devcontainer.Config{
+		CapAdd:      []string{"NET_BIND_SERVICE"},
+		SecurityOpt: []string{"apparmor=docker-default", "seccomp=/etc/docker/seccomp.json"},
+	}}, "/ws"); err != nil {
+		t.Errorf("plain values should pass: %v", err)
+	}
+}
+
+func TestCheckSecurityConfig(t *testing.T) {
+	t.Setenv("YAK_TEST_RUN_ARG", "--privileged")
+	privileged := true
+
+	tests := []struct {
+		name      string
+		devConfig *devcontainer.Config
+		allow     bool
+		wantErr   bool
+	}{
+		{name: "no devcontainer", devConfig: nil},
+		{name: "benign capability", devConfig: &devcontainer.Config{CapAdd: []string{"NET_BIND_SERVICE"}}},
+		{name: "dangerous capability blocked", devConfig: &devcontainer.Config{CapAdd: []string{"SYS_ADMIN"}}, wantErr: true},
+		{name: "dangerous security opt blocked", devConfig: &devcontainer.Config{SecurityOpt: []string{"seccomp=unconfined"}}, wantErr: true},
+		{name: "privileged blocked", devConfig: &devcontainer.Config{Privileged: &privileged}, wantErr: true},
+		{name: "dangerous capability allowed", devConfig: &devcontainer.Config{CapAdd: []string{"SYS_ADMIN"}}, allow: true},
+		{name: "benign run args", devConfig: &devcontainer.Config{RunArgs: []string{"--shm-size=2g"}}},
+		{name: "run args privileged blocked", devConfig: &devcontainer.Config{RunArgs: []string{"--privileged"}}, wantErr: true},
+		{name: "run args cap-add blocked", devConfig: &devcontainer.Config{RunArgs: []string{"--cap-add", "NET_ADMIN"}}, wantErr: true},
+		{name: "run args security-opt blocked", devConfig: &devcontainer.Config{RunArgs: []string{"--security-opt=seccomp=unconfined"}}, wantErr: true},
+		{name: "run args allowed with flag", devConfig: &devcontainer.Config{RunArgs: []string{"--privileged"}}, allow: true},
+		{name: "run args pid host blocked", devConfig: &devcontainer.Config{RunArgs: []string{"--pid=host"}}, wantErr: true},
+		{name: "run args userns host blocked", devConfig: &devcontainer.Config{RunArgs: []string{"--userns", "host"}}, wantErr: true},
+		{name: "run args ipc host blocked", devConfig: &devcontainer.Config{RunArgs: []string{"--ipc=host"}}, wantErr: true},
+		{name: "run args ipc private allowed", devConfig: &devcontainer.Config{RunArgs: []string{"--ipc=private"}}},
+		{name: "run args network blocked", devConfig: &devcontainer.Config{RunArgs: []string{"--network=host"}}, wantErr: true},
+		{name: "run args net blocked", devConfig: &devcontainer.Config{RunArgs: []string{"--net", "host"}}, wantErr: true},
+		{name: "run args device blocked", devConfig: &devcontainer.Config{RunArgs: []string{"--device", "/dev/kvm"}}, wantErr: true},
+		{name: "run args user blocked", devConfig: &devcontainer.Config{RunArgs: []string{"--user", "0"}}, wantErr: true},
+		{name: "run args short user blocked", devConfig: &devcontainer.Config{RunArgs: []string{"-u0"}}, wantErr: true},
+		{name: "run args group-add blocked", devConfig: &devcontainer.Config{RunArgs: []string{"--group-add=docker"}}, wantErr: true},
+		{name: "run args checked after substitution", devConfig: &devcontainer.Config{RunArgs: []string{"${localEnv:YAK_TEST_RUN_ARG}"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &spawnConfig{worker: &types.Worker{CWD: "/ws"}, devConfig: tt.devConfig, allowUnsafeSecurity: tt.allow}
+			err := checkSecurityConfig(cfg, "/ws")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error for unsafe security config")
+				}
+				if !strings.Contains(err.Error(), "--allow-unsafe-security") {
+					t.Errorf("error should mention --allow-unsafe-security: %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
 func TestCreateZellijLayout(t *testing.T) {
 	layout := createZellijLayout("Test Worker", "/run.sh", "/wait.sh", "yak-worker-test")
 
@@ -128,3 +915,167 @@ func TestCreateZellijLayout(t *testing.T) {
 		}
 	}
 }
+
+func TestRunArgMounts(t *testing.T) {
+	args := []string{
+		"--label", "team=a",
+		"-v", "/a:/a",
+		"-v/b:/b",
+		"-v=/c:/c",
+		"--volume", "/d:/d",
+		"--volume=/e:/e",
+		"--mount", "type=bind,source=/f,target=/f",
+		"--mount=type=volume,source=g,target=/g",
+		"--add-host=db:10.0.0.5",
+		"--mount",
+	}
+	want := []string{"/a:/a", "/b:/b", "/c:/c", "/d:/d", "/e:/e", "type=bind,source=/f,target=/f", "type=volume,source=g,target=/g"}
+	if got := runArgMounts(args); !reflect.DeepEqual(got, want) {
+		t.Errorf("runArgMounts() = %q, want %q", got, want)
+	}
+}
+
+func TestKDLString(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{in: "Test Worker", want: `"Test Worker"`},
+		{in: `say "hi"`, want: `"say \"hi\""`},
+		{in: "line\nbreak\ttab", want: `"line\nbreak\ttab"`},
+		{in: `C:\path`, want: `"C:\\path"`},
+		{in: "bell\a", want: `"bell\u{7}"`},
+	}
+	for _, tt := range tests {
+		if got := kdlString(tt.in); got != tt.want {
+			t.Errorf("kdlString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBindMountSource(t *testing.T) {
+	tests := []struct {
+		mount      string
+		wantSource string
+		wantBind   bool
+	}{
+		{mount: "source=/data,target=/data,type=bind", wantSource: "/data", wantBind: true},
+		{mount: "type=bind,src=/data,dst=/data", wantSource: "/data", wantBind: true},
+		{mount: "source=/data,target=/data", wantSource: "/data", wantBind: true},
+		{mount: "source=cache-vol,target=/cache,type=volume", wantBind: false},
+		{mount: "type=tmpfs,target=/scratch", wantBind: false},
+		{mount: "/host/path:/container:ro", wantSource: "/host/path", wantBind: true},
+		{mount: "named-volume:/container", wantBind: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mount, func(t *testing.T) {
+			source, isBind := bindMountSource(tt.mount)
+			if isBind != tt.wantBind {
+				t.Fatalf("bindMountSource(%q) bind = %v, want %v", tt.mount, isBind, tt.wantBind)
+			}
+			if source != tt.wantSource {
+				t.Errorf("bindMountSource(%q) source = %q, want %q", tt.mount, source, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestGenerateRunScript_EnvVars(t *testing.T) {
+	cfg := &spawnConfig{
+		worker: &types.Worker{
+			Name:       "test-worker",
+			CWD:        "/test/cwd",
+			WorkerName: "TestWorker",
+		},
+		profile: types.ResourceProfile{CPUs: "1.0", Memory: "2g", PIDs: 512},
+		devConfig: &devcontainer.Config{
+			ContainerEnv: map[string]string{"LOG_LEVEL": "warn"},
+		},
+	}
+	if err := WithEnvVars(map[string]string{"LOG_LEVEL": "debug", "GREETING": "hello world"})(cfg); err != nil {
+		t.Fatalf("WithEnvVars() error = %v", err)
+	}
+	if err := WithEnvVars(map[string]string{"REGION": "eu-west-1", "GREETING": "hi there"})(cfg); err != nil {
+		t.Fatalf("WithEnvVars() error = %v", err)
+	}
+
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	for _, want := range []string{"-e 'GREETING=hi there'", "-e LOG_LEVEL=debug", "-e REGION=eu-west-1"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Run script missing %q", want)
+		}
+	}
+	devEnv := strings.Index(script, `-e LOG_LEVEL="warn"`)
+	fileEnv := strings.Index(script, "-e LOG_LEVEL=debug")
+	if devEnv < 0 || fileEnv < devEnv {
+		t.Error("Env-file vars should follow devcontainer env so they take precedence")
+	}
+	if strings.Index(script, "GREETING") > strings.Index(script, "REGION") {
+		t.Error("Env-file vars should be emitted in sorted order")
+	}
+}
+
+func TestGenerateRunScript_EnvVarsOverrideRemoteEnv(t *testing.T) {
+	cfg := &spawnConfig{
+		worker: &types.Worker{
+			Name:       "test-worker",
+			CWD:        "/test/cwd",
+			WorkerName: "TestWorker",
+		},
+		profile: types.ResourceProfile{CPUs: "1.0", Memory: "2g", PIDs: 512},
+		devConfig: &devcontainer.Config{
+			RemoteEnv: map[string]string{"NODE_ENV": "development"},
+		},
+	}
+	if err := WithEnvVars(map[string]string{"NODE_ENV": "test"})(cfg); err != nil {
+		t.Fatalf("WithEnvVars() error = %v", err)
+	}
+
+	// docker keeps the last -e for a name, so the override must come later
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	devEnv := strings.Index(script, `-e NODE_ENV="development"`)
+	override := strings.LastIndex(script, "-e NODE_ENV=test")
+	if devEnv < 0 || override < devEnv {
+		t.Errorf("override should follow the remoteEnv value:\n%s", script)
+	}
+}
+
+func TestGenerateRunScript_NoAuthMountWithCredentialFile(t *testing.T) {
+	credFile := filepath.Join(t.TempDir(), "api-key")
+	if err := os.WriteFile(credFile, []byte("sk-super-secret\n"), 0600); err != nil {
+		t.Fatalf("failed to write credential file: %v", err)
+	}
+	cfg := &spawnConfig{
+		worker: &types.Worker{
+			Name:       "test-worker",
+			CWD:        "/test/cwd",
+			WorkerName: "TestWorker",
+		},
+		profile: types.ResourceProfile{CPUs: "1.0", Memory: "2g", PIDs: 512},
+	}
+
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	if !strings.Contains(script, "opencode/auth.json") {
+		t.Error("Run script should mount auth.json by default")
+	}
+	if strings.Contains(script, credentialEnvVar) {
+		t.Error("Run script should not inject a credential by default")
+	}
+
+	for _, opt := range []SpawnOption{WithNoAuthMount(true), WithCredentialFile(credFile)} {
+		if err := opt(cfg); err != nil {
+			t.Fatalf("option error = %v", err)
+		}
+	}
+	script = generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	if strings.Contains(script, "auth.json") {
+		t.Error("Run script should omit the auth.json mount with WithNoAuthMount(true)")
+	}
+	if !strings.Contains(script, "\t-e OPENCODE_API_KEY \\\n") {
+		t.Error("Run script should pass OPENCODE_API_KEY through from the environment")
+	}
+	if !strings.Contains(script, "OPENCODE_API_KEY=\"$(cat "+credFile+")\"") {
+		t.Errorf("Run script should read the credential file at start-up:\n%s", script)
+	}
+	if strings.Contains(script, "sk-super-secret") {
+		t.Error("Run script must not contain the credential value")
+	}
+}
@@ -0,0 +1,155 @@
+package runtime
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/internal/ui"
+	"github.com/wellmaintained/yak-box/internal/workspace"
+	"github.com/wellmaintained/yak-box/pkg/devcontainer"
+)
+
+// AllowedImagesFile lists glob patterns for the images workers may use, one
+// per line, relative to .yak-boxes. Without it every image is allowed.
+const AllowedImagesFile = "allowed-images.txt"
+
+// LoadAllowedImages reads the image patterns from
+// <workspaceRoot>/.yak-boxes/allowed-images.txt. Blank lines and # comments
+// are ignored. A missing file returns nil, meaning no restriction; a file
+// with no patterns allows nothing.
+func LoadAllowedImages(workspaceRoot string) ([]string, error) {
+	file := filepath.Join(workspaceRoot, workerCacheDir, AllowedImagesFile)
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	patterns := []string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		pattern := strings.TrimSpace(line)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid image pattern %q: %w", file, i+1, pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// IsImageAllowed reports whether image matches any of the glob patterns.
+// Patterns use path.Match syntax, so "myregistry/*" matches
+// "myregistry/app:1.0" but not "myregistry/team/app". A pattern without a
+// tag or digest matches every tag of that repository; "node:20" matches
+// only that tag.
+func IsImageAllowed(image string, patterns []string) bool {
+	repo := imageRepository(image)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, image); ok {
+			return true
+		}
+		if imageRepository(pattern) == pattern {
+			if ok, _ := path.Match(pattern, repo); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// imageRepository strips the tag and digest from an image reference. A colon
+// before the last slash belongs to a registry port, not a tag.
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// runImage returns the image the run script starts: the --image override,
+// else the devcontainer image, else the default worker image.
+func runImage(cfg *spawnConfig) string {
+	if cfg.image != "" {
+		return cfg.image
+	}
+	if cfg.devConfig != nil && cfg.devConfig.Image != "" {
+		return cfg.devConfig.Image
+	}
+	return workerImageName
+}
+
+// checkAllowedImage rejects the spawn when .yak-boxes/allowed-images.txt
+// exists and the run image matches none of its patterns.
+func checkAllowedImage(cfg *spawnConfig, workspaceRoot string) error {
+	patterns, err := LoadAllowedImages(workspaceRoot)
+	if err != nil {
+		return err
+	}
+	if patterns == nil {
+		return nil
+	}
+	image := runImage(cfg)
+	if IsImageAllowed(image, patterns) {
+		return nil
+	}
+	allowed := strings.Join(patterns, ", ")
+	if allowed == "" {
+		allowed = "none"
+	}
+	return errors.NewValidationError(fmt.Sprintf("image %s is not allowed by %s/%s (allowed: %s). Suggestion: Use an allowed image with --image, or add a matching pattern to the allowlist", image, workerCacheDir, AllowedImagesFile, allowed), nil)
+}
+
+// CheckAllowedImage applies the allowlist to the image a sandboxed spawn
+// with these settings would run, so spawn can reject it before building or
+// pulling anything.
+func CheckAllowedImage(image string, devConfig *devcontainer.Config) error {
+	workspaceRoot, err := workspace.FindRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find workspace root: %w", err)
+	}
+	return checkAllowedImage(&spawnConfig{image: image, devConfig: devConfig}, workspaceRoot)
+}
+
+// EnsureImage pulls the image ref unless it is already present locally, so a
+// remote image is fetched with visible progress before a worker starts
+// instead of silently inside its Zellij pane.
+func EnsureImage(ctx context.Context, ref string) error {
+	return ensureImage(ctx, dockerCommander, ref)
+}
+
+func ensureImage(ctx context.Context, commander Commander, ref string) error {
+	err := commander.CommandContext(ctx, "docker", "image", "inspect", ref).Run()
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if !goerrors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		return fmt.Errorf("failed to inspect image %s: %w. Suggestion: Check that Docker is installed and the daemon is running", ref, err)
+	}
+
+	ui.Info("📥 Pulling image %s...\n", ref)
+	cmd := commander.CommandContext(ctx, "docker", "pull", ref)
+	cmd.Stdout = ui.Stream()
+	cmd.Stderr = ui.Stream()
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("pull of %s cancelled: %w", ref, ctx.Err())
+		}
+		return fmt.Errorf("failed to pull image %s: %w. Suggestion: Check the image name and tag, and run 'docker login' if the registry is private", ref, err)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package runtime
+
+import (
+	"context"
+	goerrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/pkg/devcontainer"
+	"github.com/wellmaintained/yak-box/pkg/types"
+)
+
+func TestIsImageAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		image    string
+		patterns []string
+		want     bool
+	}{
+		{"exact match", "yak-worker:latest", []string{"yak-worker:latest"}, true},
+		{"exact mismatch", "ubuntu:22.04", []string{"yak-worker:latest"}, false},
+		{"wildcard registry", "myregistry/app:1.0", []string{"myregistry/*"}, true},
+		{"wildcard registry without tag", "myregistry/app", []string{"myregistry/*"}, true},
+		{"wildcard does not cross slashes", "myregistry/team/app:1.0", []string{"myregistry/*"}, false},
+		{"wildcard other registry", "evil/app:1.0", []string{"myregistry/*"}, false},
+		{"tag-specific match", "node:20", []string{"node:20"}, true},
+		{"tag-specific mismatch", "node:21", []string{"node:20"}, false},
+		{"wildcard tag", "node:20-alpine", []string{"node:20*"}, true},
+		{"untagged pattern allows any tag", "node:21", []string{"node"}, true},
+		{"untagged pattern allows digest", "node@sha256:abc", []string{"node"}, true},
+		{"registry port is not a tag", "localhost:5000/app:1", []string{"localhost:5000/app"}, true},
+		{"second pattern matches", "ghcr.io/acme/base:1.2", []string{"yak-worker:*", "ghcr.io/acme/*"}, true},
+		{"no patterns", "yak-worker:latest", []string{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsImageAllowed(tt.image, tt.patterns); got != tt.want {
+				t.Errorf("IsImageAllowed(%q, %q) = %v, want %v", tt.image, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func writeAllowedImages(t *testing.T, root, content string) {
+	t.Helper()
+	dir := filepath.Join(root, workerCacheDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, AllowedImagesFile), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadAllowedImages(t *testing.T) {
+	root := t.TempDir()
+
+	patterns, err := LoadAllowedImages(root)
+	if err != nil || patterns != nil {
+		t.Fatalf("missing file: got %q, %v; want nil, nil", patterns, err)
+	}
+
+	writeAllowedImages(t, root, "# approved bases\nyak-worker:*\n\n  myregistry/*  \n")
+	patterns, err = LoadAllowedImages(root)
+	if err != nil {
+		t.Fatalf("LoadAllowedImages() error = %v", err)
+	}
+	if want := []string{"yak-worker:*", "myregistry/*"}; strings.Join(patterns, ",") != strings.Join(want, ",") {
+		t.Errorf("patterns = %q, want %q", patterns, want)
+	}
+
+	writeAllowedImages(t, root, "# nothing yet\n")
+	patterns, err = LoadAllowedImages(root)
+	if err != nil || patterns == nil || len(patterns) != 0 {
+		t.Errorf("comment-only file: got %q, %v; want an empty, non-nil list", patterns, err)
+	}
+
+	writeAllowedImages(t, root, "ok:*\nbad[\n")
+	if _, err := LoadAllowedImages(root); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("expected an error naming line 2, got %v", err)
+	}
+}
+
+func TestSpawnSandboxedWorker_AllowedImages(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	writeAllowedImages(t, root, "myregistry/*\n")
+
+	spawn := func(opts ...SpawnOption) (*TestCommander, error) {
+		homeDir := t.TempDir()
+		cmdr := &TestCommander{}
+		opts = append([]SpawnOption{
+			WithWorker(&types.Worker{Name: "test-worker", DisplayName: "Test Worker", CWD: root, WorkerName: "TestBot"}),
+			WithPrompt("test prompt"),
+			WithHomeDir(homeDir),
+			WithCommander(cmdr),
+		}, opts...)
+		return cmdr, SpawnSandboxedWorker(context.Background(), opts...)
+	}
+
+	for name, opts := range map[string][]SpawnOption{
+		"default image":      nil,
+		"devcontainer image": {WithDevConfig(&devcontainer.Config{Image: "ubuntu:22.04"})},
+		"image override":     {WithDevConfig(&devcontainer.Config{Image: "myregistry/app:1"}), WithImage("evil/app:1")},
+	} {
+		t.Run(name+" is rejected", func(t *testing.T) {
+			cmdr, err := spawn(opts...)
+			var validationErr *errors.ValidationError
+			if !goerrors.As(err, &validationErr) {
+				t.Fatalf("expected a ValidationError, got %v", err)
+			}
+			if !strings.Contains(err.Error(), AllowedImagesFile) {
+				t.Errorf("error should name the allowlist: %v", err)
+			}
+			if cmdr.hasCommand("zellij") {
+				t.Error("zellij should not be called when the image is rejected")
+			}
+		})
+	}
+
+	t.Run("allowed image is spawned", func(t *testing.T) {
+		cmdr, err := spawn(WithImage("myregistry/app:1"))
+		if err != nil {
+			t.Fatalf("SpawnSandboxedWorker failed: %v", err)
+		}
+		if !cmdr.hasCommand("zellij") {
+			t.Error("zellij command was not called")
+		}
+	})
+}
+
+func TestEnsureImage(t *testing.T) {
+	t.Run("present image is not pulled", func(t *testing.T) {
+		cmdr := &recordingCommander{}
+		withDockerCommander(t, cmdr)
+
+		if err := EnsureImage(context.Background(), "ghcr.io/acme/base:1.2"); err != nil {
+			t.Fatalf("EnsureImage() error = %v", err)
+		}
+		if len(cmdr.calls) != 1 || strings.Join(cmdr.calls[0], " ") != "docker image inspect ghcr.io/acme/base:1.2" {
+			t.Errorf("calls = %q, want only the inspect", cmdr.calls)
+		}
+	})
+
+	t.Run("missing image is pulled", func(t *testing.T) {
+		cmdr := &recordingCommander{routes: map[string]string{"image": "exit 1"}}
+		withDockerCommander(t, cmdr)
+
+		if err := EnsureImage(context.Background(), "ghcr.io/acme/base:1.2"); err != nil {
+			t.Fatalf("EnsureImage() error = %v", err)
+		}
+		if len(cmdr.calls) != 2 || strings.Join(cmdr.calls[1], " ") != "docker pull ghcr.io/acme/base:1.2" {
+			t.Errorf("calls = %q, want an inspect then a pull", cmdr.calls)
+		}
+	})
+
+	t.Run("failed pull is an error", func(t *testing.T) {
+		withDockerCommander(t, routeCommander{"image": "exit 1", "pull": "echo 'manifest unknown' >&2; exit 1"})
+
+		err := EnsureImage(context.Background(), "ghcr.io/acme/base:9.9")
+		if err == nil || !strings.Contains(err.Error(), "failed to pull image ghcr.io/acme/base:9.9") {
+			t.Errorf("expected a pull error, got %v", err)
+		}
+	})
+
+	t.Run("docker failure is not treated as missing", func(t *testing.T) {
+		cmdr := &recordingCommander{routes: map[string]string{"image": "exit 125"}}
+		withDockerCommander(t, cmdr)
+
+		err := EnsureImage(context.Background(), "ghcr.io/acme/base:1.2")
+		if err == nil || !strings.Contains(err.Error(), "failed to inspect image") {
+			t.Errorf("expected an inspect error, got %v", err)
+		}
+		if len(cmdr.calls) != 1 {
+			t.Errorf("calls = %q, expected no pull", cmdr.calls)
+		}
+	})
+}
+
+func TestSpawnSandboxedWorker_PullFailureAbortsSpawn(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+
+	homeDir := t.TempDir()
+	cmdr := &recordingCommander{routes: map[string]string{"image": "exit 1", "pull": "exit 1"}}
+	err := SpawnSandboxedWorker(context.Background(),
+		WithWorker(&types.Worker{Name: "test-worker", DisplayName: "Test Worker", CWD: root, WorkerName: "TestBot"}),
+		WithPrompt("test prompt"),
+		WithHomeDir(homeDir),
+		WithImage("ghcr.io/acme/base:9.9"),
+		WithCommander(cmdr),
+	)
+	if err == nil || !strings.Contains(err.Error(), "failed to pull image") {
+		t.Fatalf("expected a pull error, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(homeDir, "scripts", "run.sh")); !os.IsNotExist(statErr) {
+		t.Error("run.sh should not be written when the pull fails")
+	}
+	for _, call := range cmdr.calls {
+		if call[0] == "zellij" {
+			t.Error("zellij should not be called when the pull fails")
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/pkg/devcontainer"
+)
+
+// Markers the init script writes to the worker log once the waitFor
+// lifecycle commands have all succeeded, or when one of them fails.
+const (
+	lifecycleReadyMarker  = "[yak-box] lifecycle commands finished"
+	lifecycleFailedMarker = "[yak-box] lifecycle command failed:"
+)
+
+// lifecyclePollDelay is how often waitForLifecycle checks the worker log and
+// container. A variable so tests can avoid sleeping.
+var lifecyclePollDelay = time.Second
+
+// lifecycleStep is one devcontainer lifecycle command run in the container
+// before the AI tool starts.
+type lifecycleStep struct {
+	Name    string // e.g. "postCreateCommand"
+	Command string // shell command passed to bash -c
+}
+
+// waitForSteps returns the in-container lifecycle commands that must finish
+// before the worker is ready: those from onCreateCommand up to and including
+// devConfig.WaitFor, in the order the devcontainer spec runs them. It
+// returns nil when WaitFor is unset or names initializeCommand, which runs
+// on the host rather than in the container.
+func waitForSteps(devConfig *devcontainer.Config) []lifecycleStep {
+	if devConfig == nil || devConfig.WaitFor == "" {
+		return nil
+	}
+
+	commands := []struct {
+		name    string
+		command *devcontainer.LifecycleCommand
+	}{
+		{"onCreateCommand", devConfig.OnCreateCommand},
+		{"updateContentCommand", devConfig.UpdateContentCommand},
+		{"postCreateCommand", devConfig.PostCreateCommand},
+		{"postStartCommand", devConfig.PostStartCommand},
+	}
+
+	var steps []lifecycleStep
+	for _, c := range commands {
+		// Parallel (object) commands run one after another, which is
+		// slower but keeps the first failure's output readable
+		for _, command := range c.command.ToStringSlice() {
+			if strings.TrimSpace(command) != "" {
+				steps = append(steps, lifecycleStep{Name: c.name, Command: command})
+			}
+		}
+		if c.name == devConfig.WaitFor {
+			return steps
+		}
+	}
+	return nil
+}
+
+// lifecycleScript returns the init script section that runs steps in order,
+// exiting with a failing step's status so the AI tool never starts on a
+// half-built environment. Their output and a closing ready or failed marker
+// are appended to the worker log, which spawn watches to report the result.
+func lifecycleScript(steps []lifecycleStep) string {
+	if len(steps) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`# Lifecycle commands up to waitFor must succeed before the tool starts.
+run_lifecycle() {
+  echo "Running $1: $2" | tee -a "$LOG_FILE" 2>/dev/null
+  bash -c "$2" 2>&1 | tee -a "$LOG_FILE" 2>/dev/null
+  local status=${PIPESTATUS[0]}
+  if [[ $status -ne 0 ]]; then
+    echo "` + lifecycleFailedMarker + ` $1 exited with status $status; not starting $TOOL" | tee -a "$LOG_FILE" >&2 2>/dev/null
+    exit "$status"
+  fi
+}
+`)
+	for _, step := range steps {
+		sb.WriteString(fmt.Sprintf("run_lifecycle %s %s\n", step.Name, shellQuote(step.Command)))
+	}
+	sb.WriteString(fmt.Sprintf("echo %s | tee -a \"$LOG_FILE\" 2>/dev/null\n\n", shellQuote(lifecycleReadyMarker)))
+	return sb.String()
+}
+
+// waitForLifecycle blocks until the init script of containerName writes the
+// lifecycle ready or failed marker to logFile. It fails if a command fails,
+// if the container exits first, or if it has not started within
+// readyTimeout. Cancelling ctx stops waiting without stopping the worker.
+func waitForLifecycle(ctx context.Context, commander Commander, containerName, logFile string, readyTimeout time.Duration) error {
+	deadline := time.Now().Add(readyTimeout)
+	started := false
+	for {
+		switch state := lifecycleState(logFile); {
+		case state == lifecycleReadyMarker:
+			return nil
+		case state != "":
+			return lifecycleError(state, logFile)
+		}
+
+		output, err := commander.CommandContext(ctx, "docker", "inspect", "--format", "{{.State.Running}}", containerName).Output()
+		running := err == nil && strings.TrimSpace(string(output)) == "true"
+		if running {
+			started = true
+		} else if started || !time.Now().Before(deadline) {
+			// The script may have written its marker just before exiting
+			if state := lifecycleState(logFile); state == lifecycleReadyMarker {
+				return nil
+			} else if state != "" {
+				return lifecycleError(state, logFile)
+			}
+			if started {
+				return lifecycleError("the container exited before they finished", logFile)
+			}
+			return lifecycleError(fmt.Sprintf("the container did not start within %s", readyTimeout), logFile)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(lifecyclePollDelay):
+		}
+	}
+}
+
+// lifecycleState returns lifecycleReadyMarker once logFile contains it, the
+// failed marker's line once a command has failed, and "" until then.
+func lifecycleState(logFile string) string {
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == lifecycleReadyMarker {
+			return line
+		}
+		if strings.HasPrefix(line, lifecycleFailedMarker) {
+			return strings.TrimSpace(strings.TrimPrefix(line, lifecycleFailedMarker))
+		}
+	}
+	return ""
+}
+
+// lifecycleError is the RuntimeError spawn fails with when the waitFor
+// commands do not finish, for the given reason.
+func lifecycleError(reason, logFile string) error {
+	return errors.NewRuntimeError("devcontainer waitFor commands did not finish", fmt.Errorf("%s. Suggestion: See the command output in %s", reason, logFile))
+}
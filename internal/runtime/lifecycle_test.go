@@ -0,0 +1,181 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	yberrors "github.com/wellmaintained/yak-box/internal/errors"
+	"github.com/wellmaintained/yak-box/pkg/devcontainer"
+)
+
+func lifecycleConfig(t *testing.T, data string) *devcontainer.Config {
+	t.Helper()
+	var cfg devcontainer.Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	return &cfg
+}
+
+func TestWaitForSteps(t *testing.T) {
+	const commands = `"onCreateCommand": "make deps", "updateContentCommand": ["npm", "ci"], "postCreateCommand": "make setup", "postStartCommand": "make serve"`
+
+	tests := []struct {
+		name    string
+		waitFor string
+		want    []lifecycleStep
+	}{
+		{name: "unset", waitFor: "", want: nil},
+		{name: "host command", waitFor: "initializeCommand", want: nil},
+		{name: "onCreateCommand", waitFor: "onCreateCommand", want: []lifecycleStep{
+			{Name: "onCreateCommand", Command: "make deps"},
+		}},
+		{name: "postCreateCommand", waitFor: "postCreateCommand", want: []lifecycleStep{
+			{Name: "onCreateCommand", Command: "make deps"},
+			{Name: "updateContentCommand", Command: "npm ci"},
+			{Name: "postCreateCommand", Command: "make setup"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := lifecycleConfig(t, `{`+commands+`, "waitFor": "`+tt.waitFor+`"}`)
+			if got := waitForSteps(cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("waitForSteps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := waitForSteps(nil); got != nil {
+		t.Errorf("waitForSteps(nil) = %v, want nil", got)
+	}
+}
+
+func TestGenerateInitScript_WaitForRunsBeforeTool(t *testing.T) {
+	cfg := lifecycleConfig(t, `{"onCreateCommand": "make deps", "postCreateCommand": "make setup", "postStartCommand": "make serve", "waitFor": "postCreateCommand"}`)
+	script := generateInitScript("none", waitForSteps(cfg))
+
+	deps := strings.Index(script, "run_lifecycle onCreateCommand 'make deps'")
+	setup := strings.Index(script, "run_lifecycle postCreateCommand 'make setup'")
+	tool := strings.Index(script, `script -qaefc`)
+	if deps < 0 || setup < deps || tool < setup {
+		t.Errorf("expected onCreateCommand, then postCreateCommand, then the tool:\n%s", script)
+	}
+	if strings.Contains(script, "make serve") {
+		t.Error("commands after waitFor should not gate the tool")
+	}
+}
+
+func TestGenerateInitScript_FailingWaitForStopsTool(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	binDir := filepath.Join(tmpDir, "bin")
+	os.MkdirAll(binDir, 0755)
+	toolRan := filepath.Join(tmpDir, "tool-ran")
+	os.WriteFile(filepath.Join(binDir, "opencode"), []byte("#!/usr/bin/env bash\ntouch "+toolRan+"\n"), 0755)
+
+	run := func(steps []lifecycleStep) error {
+		script := filepath.Join(tmpDir, "start.sh")
+		os.WriteFile(script, []byte(generateInitScript("none", steps)), 0755)
+		cmd := exec.Command(bash, script, "build")
+		cmd.Env = append(os.Environ(),
+			"PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"),
+			"WORKSPACE_ROOT="+tmpDir,
+			"YAK_TOOL=opencode",
+			"YAK_LOG_FILE="+filepath.Join(tmpDir, "worker.log"),
+		)
+		return cmd.Run()
+	}
+
+	err = run([]lifecycleStep{{Name: "postCreateCommand", Command: "echo 'npm ERR! missing script' >&2; exit 4"}})
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 4 {
+		t.Errorf("init script exit = %v, want the lifecycle command's exit code 4", err)
+	}
+	if _, err := os.Stat(toolRan); !os.IsNotExist(err) {
+		t.Error("the tool should not start when the waitFor command fails")
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, "worker.log"))
+	if err != nil {
+		t.Fatalf("worker log not written: %v", err)
+	}
+	for _, want := range []string{"npm ERR! missing script", lifecycleFailedMarker + " postCreateCommand exited with status 4"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("worker log missing %q:\n%s", want, data)
+		}
+	}
+	os.Remove(filepath.Join(tmpDir, "worker.log"))
+
+	if err := run([]lifecycleStep{{Name: "postCreateCommand", Command: "true"}}); err != nil {
+		t.Errorf("init script error = %v, want success", err)
+	}
+	if _, err := os.Stat(toolRan); err != nil {
+		t.Error("the tool should start once the waitFor command succeeds")
+	}
+	data, err = os.ReadFile(filepath.Join(tmpDir, "worker.log"))
+	if err != nil {
+		t.Fatalf("worker log not written: %v", err)
+	}
+	if !strings.Contains(string(data), "Running postCreateCommand: true\n"+lifecycleReadyMarker+"\n") {
+		t.Errorf("lifecycle output and ready marker should stay in the log once the tool starts:\n%s", data)
+	}
+}
+
+func TestWaitForLifecycle(t *testing.T) {
+	orig := lifecyclePollDelay
+	lifecyclePollDelay = time.Millisecond
+	t.Cleanup(func() { lifecyclePollDelay = orig })
+
+	// seen is a scratch file, so an inspect can report the container
+	// running the first time and gone afterwards
+	runningOnce := `if [ -e "$SEEN" ]; then exit 1; fi; touch "$SEEN"; echo true`
+	tests := []struct {
+		name    string
+		log     string
+		inspect string
+		wantErr string
+	}{
+		{name: "ready", log: "Running postCreateCommand: make\n" + lifecycleReadyMarker + "\n", inspect: "echo true"},
+		{name: "command failed", log: lifecycleFailedMarker + " postCreateCommand exited with status 4; not starting claude\n", inspect: "echo true", wantErr: "postCreateCommand exited with status 4"},
+		{name: "container never started", log: "", inspect: "exit 1", wantErr: "did not start within"},
+		{name: "container exited", log: "Running postCreateCommand: make\n", inspect: runningOnce, wantErr: "exited before they finished"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			logFile := filepath.Join(dir, "worker.log")
+			if err := os.WriteFile(logFile, []byte(tt.log), 0644); err != nil {
+				t.Fatal(err)
+			}
+			route := routeCommander{"inspect": "SEEN=" + filepath.Join(dir, "seen") + "; " + tt.inspect}
+
+			err := waitForLifecycle(context.Background(), route, "yak-worker-api", logFile, 10*time.Millisecond)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("waitForLifecycle() error = %v", err)
+				}
+				return
+			}
+			var runtimeErr *yberrors.RuntimeError
+			if !errors.As(err, &runtimeErr) {
+				t.Fatalf("waitForLifecycle() = %v, want a RuntimeError", err)
+			}
+			for _, want := range []string{tt.wantErr, logFile} {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q should contain %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package runtime
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/wellmaintained/yak-box/internal/sessions"
+)
+
+// NewLivenessChecker returns a checker suitable for sessions.Prune. Sandboxed
+// sessions are alive while their container exists (running or stopped);
+// native sessions are alive while the PID in their PID file responds to
+// signal 0. Anything that cannot be verified, such as when Docker is
+// unreachable, is reported alive so it is never pruned by mistake.
+func NewLivenessChecker() func(sessions.Session) bool {
+	containers, listErr := ListAllContainers()
+	existing := make(map[string]bool, len(containers))
+	for _, name := range containers {
+		existing[name] = true
+	}
+
+	return func(session sessions.Session) bool {
+		switch session.Runtime {
+		case "sandboxed":
+			if listErr != nil || session.Container == "" {
+				return true
+			}
+			return existing[session.Container]
+		case "native":
+			if session.PidFile == "" {
+				return true
+			}
+			return pidFileAlive(session.PidFile)
+		default:
+			return true
+		}
+	}
+}
+
+// pidFileAlive reports whether the process recorded in pidFile is running.
+// A missing PID file means the worker never started or has been cleaned up.
+func pidFileAlive(pidFile string) bool {
+	data, err := os.ReadFile(pidFile)
+	if os.IsNotExist(err) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return false
+	}
+
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// EPERM means the process exists but belongs to another user.
+	err = proc.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
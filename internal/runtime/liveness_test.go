@@ -0,0 +1,44 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wellmaintained/yak-box/internal/sessions"
+)
+
+func TestPidFileAlive(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	self := filepath.Join(tmpDir, "self.pid")
+	os.WriteFile(self, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644)
+	if !pidFileAlive(self) {
+		t.Error("current process should be reported alive")
+	}
+
+	if pidFileAlive(filepath.Join(tmpDir, "missing.pid")) {
+		t.Error("missing pid file should be reported dead")
+	}
+
+	garbage := filepath.Join(tmpDir, "garbage.pid")
+	os.WriteFile(garbage, []byte("not-a-pid"), 0644)
+	if pidFileAlive(garbage) {
+		t.Error("invalid pid should be reported dead")
+	}
+}
+
+func TestNewLivenessChecker_NativeAndUnknown(t *testing.T) {
+	alive := NewLivenessChecker()
+
+	if !alive(sessions.Session{Runtime: "native"}) {
+		t.Error("native session without pid file cannot be verified and should be kept")
+	}
+	if alive(sessions.Session{Runtime: "native", PidFile: filepath.Join(t.TempDir(), "gone.pid")}) {
+		t.Error("native session with missing pid file should be pruned")
+	}
+	if !alive(sessions.Session{Runtime: "something-else"}) {
+		t.Error("unknown runtimes should be kept")
+	}
+}
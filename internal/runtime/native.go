@@ -1,6 +1,8 @@
 package runtime
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -16,9 +18,20 @@ import (
 
 // SpawnNativeWorker spawns a worker in a Zellij session on the host.
 // Returns the path to the PID file so callers can store it in the session for cleanup.
-func SpawnNativeWorker(worker *types.Worker, prompt string, homeDir string) (pidFile string, err error) {
-	// Use persistent scripts directory in worker's home
-	workerDir := filepath.Join(homeDir, "scripts")
+// Scripts go to scriptsDir, or homeDir/scripts when it is empty.
+func SpawnNativeWorker(worker *types.Worker, prompt string, homeDir, scriptsDir string, maxDepth int) (pidFile string, err error) {
+	// The tool runs in worker.CWD, often a worktree without the task state,
+	// so YAK_PATH is exported as an absolute path checked from there
+	yakPath, err := nativeYakPath(worker)
+	if err != nil {
+		return "", err
+	}
+	resolved := *worker
+	resolved.YakPath = yakPath
+	worker = &resolved
+
+	// Use persistent scripts directory in worker's home unless scriptsDir is set
+	workerDir := scriptsDirFor(scriptsDir, homeDir)
 	if err := os.MkdirAll(workerDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create scripts dir: %w", err)
 	}
@@ -30,50 +43,12 @@ func SpawnNativeWorker(worker *types.Worker, prompt string, homeDir string) (pid
 
 	pidFile = filepath.Join(workerDir, "worker.pid")
 
-	var wrapperContent string
-	var paneName string
-
-	if worker.Tool == "claude" {
-		paneName = "claude (build)"
-		// Clean CLAUDECODE env var to avoid nested session conflicts
-		wrapperContent = fmt.Sprintf(`#!/usr/bin/env bash
-export YAK_PATH="%s"
-unset CLAUDECODE
-MODEL=%q
-PROMPT_FILE=%q
-CLAUDE_ARGS=(--dangerously-skip-permissions)
-if [[ -n "$MODEL" ]]; then
-  CLAUDE_ARGS+=(--model "$MODEL")
-fi
-# Write PID before exec so yak-box stop can find and kill the process tree.
-echo $$ > "%s"
-exec claude "${CLAUDE_ARGS[@]}" @"$PROMPT_FILE"
-`, worker.YakPath, worker.Model, promptFile, pidFile)
-	} else if worker.Tool == "cursor" {
-		paneName = "cursor (build)"
-		wrapperContent = fmt.Sprintf(`#!/usr/bin/env bash
-export YAK_PATH="%s"
-PROMPT="$(cat "%s")"
-MODEL=%q
-# Write PID before exec so yak-box stop can find and kill the process tree.
-echo $$ > "%s"
-if [[ -n "$MODEL" ]]; then
-  exec agent --force --model "$MODEL" --workspace "%s" "$PROMPT"
-else
-  exec agent --force --workspace "%s" "$PROMPT"
-fi
-`, worker.YakPath, promptFile, worker.Model, pidFile, worker.CWD, worker.CWD)
-	} else {
-		paneName = "opencode (build)"
-		wrapperContent = fmt.Sprintf(`#!/usr/bin/env bash
-export YAK_PATH="%s"
-PROMPT="$(cat "%s")"
-# Write PID before exec so yak-box stop can find and kill the process tree.
-# exec replaces this process, so $$ will be the PID of opencode.
-echo $$ > "%s"
-exec opencode --prompt "$PROMPT" --agent build
-`, worker.YakPath, promptFile, pidFile)
+	logFile := filepath.Join(workerDir, workerLogName)
+	// The wrapper appends to the log, so each spawn starts it afresh
+	if err := os.WriteFile(logFile, nil, 0644); err != nil {
+		return "", fmt.Errorf("failed to create worker log: %w", err)
 	}
+	wrapperContent, paneName := nativeWrapperScript(worker, promptFile, pidFile, logFile, maxDepth)
 
 	wrapperScript := filepath.Join(workerDir, "run.sh")
 	if err := os.WriteFile(wrapperScript, []byte(wrapperContent), 0755); err != nil {
@@ -82,41 +57,128 @@ exec opencode --prompt "$PROMPT" --agent build
 
 	layoutFile := filepath.Join(workerDir, "layout.kdl")
 	layoutContent := fmt.Sprintf(`layout {
-    tab name="%s" cwd="%s" {
+    tab name=%s cwd=%s {
         pane size=1 borderless=true {
             plugin location="compact-bar"
         }
-        pane size="67%%" name="%s" focus=true {
+        pane size="67%%" name=%s focus=true {
             command "bash"
-            args "%s"
+            args %s
         }
-        pane size="33%%" name="shell: %s"
+        pane size="33%%" name=%s
         pane size=2 borderless=true {
             plugin location="status-bar"
         }
     }
 }
-`, worker.DisplayName, worker.CWD, paneName, wrapperScript, worker.CWD)
+`, kdlString(tabName(worker.DisplayName)), kdlString(worker.CWD), kdlString(paneName), kdlString(wrapperScript), kdlString("shell: "+worker.CWD))
 	if err := os.WriteFile(layoutFile, []byte(layoutContent), 0644); err != nil {
 		return "", fmt.Errorf("failed to write layout file: %w", err)
 	}
 
-	zellijSession := worker.SessionName
-	var zellijCmd *exec.Cmd
-	if zellijSession != "" {
-		zellijCmd = exec.Command("zellij", "--session", zellijSession, "action", "new-tab", "--layout", layoutFile, "--name", worker.DisplayName, "--cwd", worker.CWD)
-	} else {
-		zellijCmd = exec.Command("zellij", "action", "new-tab", "--layout", layoutFile, "--name", worker.DisplayName, "--cwd", worker.CWD)
+	if err := newZellijTab(context.Background(), hostCommander, worker.SessionName, "--layout", layoutFile, "--name", tabName(worker.DisplayName), "--cwd", worker.CWD); err != nil {
+		return "", err
 	}
 
-	output, err := zellijCmd.CombinedOutput()
+	return pidFile, nil
+}
+
+// nativeYakPath returns worker.YakPath made absolute against worker.CWD,
+// failing if it is not a directory there. An empty yak path is left empty.
+func nativeYakPath(worker *types.Worker) (string, error) {
+	if worker.YakPath == "" {
+		return "", nil
+	}
+	yakPath := worker.YakPath
+	if !filepath.IsAbs(yakPath) {
+		yakPath = filepath.Join(worker.CWD, yakPath)
+	}
+	info, err := os.Stat(yakPath)
+	if err == nil && !info.IsDir() {
+		err = fmt.Errorf("not a directory")
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to create zellij tab: %w (output: %s)", err, string(output))
+		return "", fmt.Errorf("yak path %s is not reachable from working directory %s: %w. Suggestion: Pass --yak-path as an absolute path to the task directory", worker.YakPath, worker.CWD, err)
 	}
+	return yakPath, nil
+}
 
-	return pidFile, nil
+// nativeWrapperScript returns the run.sh that launches worker.Tool on the host
+// from worker.CWD and the name of the pane it runs in. When script(1) is
+// available the script re-runs itself under it, so the tool keeps its
+// terminal while its output is copied to logFile. The script exits with the
+// tool's status.
+func nativeWrapperScript(worker *types.Worker, promptFile, pidFile, logFile string, maxDepth int) (content, paneName string) {
+	logPTY := fmt.Sprintf(`# Re-run under script(1) to copy the tool's output to the log
+if [[ -z "${%[1]s:-}" ]] && command -v script >/dev/null 2>&1 && : 2>/dev/null >>%[3]s; then
+  %[2]s
+  exit $?
+fi
+unset %[1]s
+`, logPTYEnv, rerunUnderScript(shellQuote(logFile)), shellQuote(logFile))
+
+	switch worker.Tool {
+	case "claude":
+		// Clean CLAUDECODE env var to avoid nested session conflicts
+		return fmt.Sprintf(`#!/usr/bin/env bash
+cd %s || exit 1
+export YAK_PATH=%s
+export YAK_BOX_DEPTH=%d
+export YAK_BOX_MAX_DEPTH=%d
+unset CLAUDECODE
+%sMODEL=%s
+PROMPT_FILE=%s
+CLAUDE_ARGS=(--dangerously-skip-permissions)
+if [[ -n "$MODEL" ]]; then
+  CLAUDE_ARGS+=(--model "$MODEL")
+fi
+# Write PID so yak-box stop can find and kill the process group.
+echo $$ > %s
+claude "${CLAUDE_ARGS[@]}" @"$PROMPT_FILE"
+`, shellQuote(worker.CWD), shellQuote(worker.YakPath), workerDepth(), workerMaxDepth(maxDepth), logPTY, shellQuote(worker.Model), shellQuote(promptFile), shellQuote(pidFile)), "claude (build)"
+	case "cursor":
+		return fmt.Sprintf(`#!/usr/bin/env bash
+cd %s || exit 1
+export YAK_PATH=%s
+export YAK_BOX_DEPTH=%d
+export YAK_BOX_MAX_DEPTH=%d
+%sPROMPT_FILE=%s
+PROMPT="$(cat "$PROMPT_FILE")"
+MODEL=%s
+WORKSPACE=%s
+# Write PID so yak-box stop can find and kill the process group.
+echo $$ > %s
+if [[ -n "$MODEL" ]]; then
+  agent --force --model "$MODEL" --workspace "$WORKSPACE" "$PROMPT"
+else
+  agent --force --workspace "$WORKSPACE" "$PROMPT"
+fi
+`, shellQuote(worker.CWD), shellQuote(worker.YakPath), workerDepth(), workerMaxDepth(maxDepth), logPTY, shellQuote(promptFile), shellQuote(worker.Model), shellQuote(worker.CWD), shellQuote(pidFile)), "cursor (build)"
+	default:
+		return fmt.Sprintf(`#!/usr/bin/env bash
+cd %s || exit 1
+export YAK_PATH=%s
+export YAK_BOX_DEPTH=%d
+export YAK_BOX_MAX_DEPTH=%d
+%sPROMPT_FILE=%s
+PROMPT="$(cat "$PROMPT_FILE")"
+MODEL=%s
+OPENCODE_ARGS=(--prompt "$PROMPT" --agent build)
+if [[ -n "$MODEL" ]]; then
+  OPENCODE_ARGS=(--model "$MODEL" "${OPENCODE_ARGS[@]}")
+fi
+# Write PID so yak-box stop can find and kill the process group.
+echo $$ > %s
+opencode "${OPENCODE_ARGS[@]}"
+`, shellQuote(worker.CWD), shellQuote(worker.YakPath), workerDepth(), workerMaxDepth(maxDepth), logPTY, shellQuote(promptFile), shellQuote(worker.Model), shellQuote(pidFile)), "opencode (build)"
+	}
 }
 
+// ErrZellijSessionNotFound is returned by StopNativeWorker when the tabs of
+// the worker's Zellij session cannot be listed, e.g. because the session has
+// exited, so there is no tab left to close.
+var ErrZellijSessionNotFound = errors.New("zellij session not found")
+
 // StopNativeWorker stops a native worker by closing the Zellij tab.
 // Uses query-tab-names to find the tab's index, then navigates by index
 // before closing. This avoids the race where go-to-tab-name fails silently
@@ -129,9 +191,9 @@ func StopNativeWorker(name, sessionName string) error {
 	if fileExists(closeTabScript) {
 		var cmd *exec.Cmd
 		if sessionName != "" {
-			cmd = exec.Command(closeTabScript, "--session", sessionName, name)
+			cmd = newCommand(closeTabScript, "--session", sessionName, name)
 		} else {
-			cmd = exec.Command(closeTabScript, name)
+			cmd = newCommand(closeTabScript, name)
 		}
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("failed to close zellij tab via script: %w", err)
@@ -149,11 +211,11 @@ func StopNativeWorker(name, sessionName string) error {
 
 	var goCmd, closeCmd *exec.Cmd
 	if sessionName != "" {
-		goCmd = exec.Command("zellij", "--session", sessionName, "action", "go-to-tab", fmt.Sprintf("%d", tabIndex))
-		closeCmd = exec.Command("zellij", "--session", sessionName, "action", "close-tab")
+		goCmd = newCommand("zellij", "--session", sessionName, "action", "go-to-tab", fmt.Sprintf("%d", tabIndex))
+		closeCmd = newCommand("zellij", "--session", sessionName, "action", "close-tab")
 	} else {
-		goCmd = exec.Command("zellij", "action", "go-to-tab", fmt.Sprintf("%d", tabIndex))
-		closeCmd = exec.Command("zellij", "action", "close-tab")
+		goCmd = newCommand("zellij", "action", "go-to-tab", fmt.Sprintf("%d", tabIndex))
+		closeCmd = newCommand("zellij", "action", "close-tab")
 	}
 
 	if err := goCmd.Run(); err != nil {
@@ -172,14 +234,14 @@ func StopNativeWorker(name, sessionName string) error {
 func findZellijTabIndex(name, sessionName string) (int, error) {
 	var queryCmd *exec.Cmd
 	if sessionName != "" {
-		queryCmd = exec.Command("zellij", "--session", sessionName, "action", "query-tab-names")
+		queryCmd = newCommand("zellij", "--session", sessionName, "action", "query-tab-names")
 	} else {
-		queryCmd = exec.Command("zellij", "action", "query-tab-names")
+		queryCmd = newCommand("zellij", "action", "query-tab-names")
 	}
 
 	output, err := queryCmd.Output()
 	if err != nil {
-		return -1, fmt.Errorf("failed to query tab names: %w", err)
+		return -1, fmt.Errorf("%w: failed to query tab names: %v", ErrZellijSessionNotFound, err)
 	}
 
 	tabs := strings.Split(strings.TrimSpace(string(output)), "\n")
@@ -197,11 +259,18 @@ func fileExists(path string) bool {
 	return err == nil
 }
 
+// ErrPidFileNotFound is returned by KillNativeProcessTree when the pid file
+// is gone, which it removes once the process has exited.
+var ErrPidFileNotFound = errors.New("pid file not found")
+
 // KillNativeProcessTree reads the PID from pidFile, sends SIGTERM to the
 // process group, waits up to timeout, then escalates to SIGKILL.
 // This ensures child processes (gopls, bash-language-server, etc.) are also killed.
 func KillNativeProcessTree(pidFile string, timeout time.Duration) error {
 	data, err := os.ReadFile(pidFile)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrPidFileNotFound, pidFile)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to read pid file %s: %w", pidFile, err)
 	}
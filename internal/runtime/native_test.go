@@ -0,0 +1,267 @@
+package runtime
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wellmaintained/yak-box/pkg/types"
+)
+
+func TestNativeWrapperScript_CapturesLog(t *testing.T) {
+	for _, tool := range []string{"claude", "cursor", "opencode"} {
+		t.Run(tool, func(t *testing.T) {
+			worker := &types.Worker{Tool: tool, CWD: "/work"}
+			script, paneName := nativeWrapperScript(worker, "/s/prompt.txt", "/s/worker.pid", "/s/worker.log", 0)
+			if paneName != tool+" (build)" {
+				t.Errorf("paneName = %q, want %q", paneName, tool+" (build)")
+			}
+			for _, want := range []string{`script -qaefc "bash $(printf '%q ' "$0" "$@")" /s/worker.log`, "unset YAK_LOG_PTY\n"} {
+				if !strings.Contains(script, want) {
+					t.Errorf("run.sh missing %q:\n%s", want, script)
+				}
+			}
+			if strings.Contains(script, "| tee") {
+				t.Error("piping the tool through tee would take away its terminal")
+			}
+		})
+	}
+}
+
+func TestNativeWrapperScript_PreservesExitCode(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	binDir := filepath.Join(tmpDir, "bin")
+	os.MkdirAll(binDir, 0755)
+	fakeClaude := "#!/usr/bin/env bash\necho 'worker output'\n[[ -t 1 ]] && echo 'on a terminal'\nexit 7\n"
+	os.WriteFile(filepath.Join(binDir, "claude"), []byte(fakeClaude), 0755)
+
+	logFile := filepath.Join(tmpDir, "worker.log")
+	worker := &types.Worker{Tool: "claude", CWD: tmpDir}
+	content, _ := nativeWrapperScript(worker, filepath.Join(tmpDir, "prompt.txt"), filepath.Join(tmpDir, "worker.pid"), logFile, 0)
+	script := filepath.Join(tmpDir, "run.sh")
+	os.WriteFile(script, []byte(content), 0755)
+
+	cmd := exec.Command(bash, script)
+	cmd.Env = append(os.Environ(), "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 7 {
+		t.Errorf("run.sh exit = %v, want tool's exit code 7", err)
+	}
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("worker log not written: %v", err)
+	}
+	for _, want := range []string{"worker output", "on a terminal"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("worker log = %q, want %q", data, want)
+		}
+	}
+}
+
+func TestNativeWrapperScript_QuotesModel(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	binDir := filepath.Join(tmpDir, "bin")
+	os.MkdirAll(binDir, 0755)
+	argsFile := filepath.Join(tmpDir, "args.txt")
+	fakeOpencode := "#!/usr/bin/env bash\n" + `printf '%s\n' "$2" > "` + argsFile + `"` + "\n"
+	os.WriteFile(filepath.Join(binDir, "opencode"), []byte(fakeOpencode), 0755)
+
+	pwned := filepath.Join(tmpDir, "pwned")
+	model := `x/$(touch ` + pwned + `)"$HOME` + "`id`'"
+	promptDir := filepath.Join(tmpDir, "it's $HOME")
+	os.MkdirAll(promptDir, 0755)
+	promptFile := filepath.Join(promptDir, "prompt.txt")
+	os.WriteFile(promptFile, []byte("hello"), 0644)
+
+	worker := &types.Worker{Tool: "opencode", CWD: tmpDir, Model: model}
+	content, _ := nativeWrapperScript(worker, promptFile, filepath.Join(tmpDir, "worker.pid"), filepath.Join(tmpDir, "worker.log"), 0)
+	script := filepath.Join(tmpDir, "run.sh")
+	os.WriteFile(script, []byte(content), 0755)
+
+	cmd := exec.Command(bash, script)
+	cmd.Env = append(os.Environ(), "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("run.sh error = %v: %s", err, out)
+	}
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("opencode was not invoked: %v", err)
+	}
+	if got := strings.TrimSuffix(string(data), "\n"); got != model {
+		t.Errorf("--model = %q, want %q passed through literally", got, model)
+	}
+	if _, err := os.Stat(pwned); !os.IsNotExist(err) {
+		t.Error("the model name was expanded by the shell")
+	}
+}
+
+func withHostCommander(t *testing.T, cmdr Commander) {
+	t.Helper()
+	orig := hostCommander
+	hostCommander = cmdr
+	t.Cleanup(func() { hostCommander = orig })
+}
+
+func TestStopNativeWorker_ClosesTabByIndex(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cmdr := &scriptCommander{script: `printf 'Yakira docs\nYakov api\n'`}
+	withHostCommander(t, cmdr)
+
+	if err := StopNativeWorker("Yakov api", "yak-shaving"); err != nil {
+		t.Fatalf("StopNativeWorker() error = %v", err)
+	}
+
+	want := [][]string{
+		{"zellij", "--session", "yak-shaving", "action", "query-tab-names"},
+		{"zellij", "--session", "yak-shaving", "action", "go-to-tab", "2"},
+		{"zellij", "--session", "yak-shaving", "action", "close-tab"},
+	}
+	if !reflect.DeepEqual(cmdr.calls, want) {
+		t.Errorf("zellij calls = %v, want %v", cmdr.calls, want)
+	}
+}
+
+func TestStopNativeWorker_MissingTab(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cmdr := &scriptCommander{script: `printf 'Yakira docs\n'`}
+	withHostCommander(t, cmdr)
+
+	if err := StopNativeWorker("Yakov api", ""); err != nil {
+		t.Fatalf("StopNativeWorker() error = %v", err)
+	}
+	if len(cmdr.calls) != 1 {
+		t.Errorf("expected only the tab query when the tab is gone, got %v", cmdr.calls)
+	}
+}
+
+func TestSpawnNativeWorker_ScriptsDir(t *testing.T) {
+	t.Chdir(t.TempDir())
+	homeDir := t.TempDir()
+	scriptsDir := t.TempDir()
+	cmdr := &scriptCommander{}
+	withHostCommander(t, cmdr)
+
+	worker := &types.Worker{Name: "api", DisplayName: "Yakov api", CWD: homeDir, WorkerName: "Yakov", Tool: "opencode"}
+	pidFile, err := SpawnNativeWorker(worker, "test prompt", homeDir, scriptsDir, 0)
+	if err != nil {
+		t.Fatalf("SpawnNativeWorker() error = %v", err)
+	}
+
+	if want := filepath.Join(scriptsDir, "worker.pid"); pidFile != want {
+		t.Errorf("pidFile = %q, want %q", pidFile, want)
+	}
+	for _, file := range []string{"prompt.txt", "run.sh", "layout.kdl"} {
+		if _, err := os.Stat(filepath.Join(scriptsDir, file)); err != nil {
+			t.Errorf("Expected %s in the scripts dir: %v", file, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(homeDir, "scripts")); !os.IsNotExist(err) {
+		t.Error("scripts should not be written to the worker home when a scripts dir is set")
+	}
+
+	if _, err := SpawnNativeWorker(worker, "test prompt", homeDir, "", 0); err != nil {
+		t.Fatalf("SpawnNativeWorker() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(homeDir, "scripts", "run.sh")); err != nil {
+		t.Errorf("an empty scripts dir should default to the worker home: %v", err)
+	}
+}
+
+func TestSpawnNativeWorker_Worktree(t *testing.T) {
+	t.Chdir(t.TempDir())
+	withHostCommander(t, &scriptCommander{})
+
+	project := t.TempDir()
+	yakPath := filepath.Join(project, ".yaks")
+	if err := os.Mkdir(yakPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	wt := filepath.Join(t.TempDir(), "auth-api")
+	if err := os.Mkdir(wt, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	homeDir := t.TempDir()
+	worker := &types.Worker{Name: "api", DisplayName: "Yakov api", CWD: wt, YakPath: yakPath, WorktreePath: wt, WorkerName: "Yakov", Tool: "cursor"}
+	if _, err := SpawnNativeWorker(worker, "test prompt", homeDir, "", 0); err != nil {
+		t.Fatalf("SpawnNativeWorker() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(homeDir, "scripts", "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := string(data)
+	for _, want := range []string{"cd " + shellQuote(wt) + " ", "WORKSPACE=" + shellQuote(wt) + "\n", "export YAK_PATH=" + shellQuote(yakPath) + "\n"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("run.sh missing %q:\n%s", want, script)
+		}
+	}
+
+	layout, err := os.ReadFile(filepath.Join(homeDir, "scripts", "layout.kdl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(layout), `cwd="`+wt+`"`) {
+		t.Errorf("layout should open the tab in the worktree:\n%s", layout)
+	}
+}
+
+func TestSpawnNativeWorker_YakPathReachability(t *testing.T) {
+	t.Chdir(t.TempDir())
+	withHostCommander(t, &scriptCommander{})
+
+	wt := t.TempDir()
+	homeDir := t.TempDir()
+	worker := &types.Worker{Name: "api", DisplayName: "Yakov api", CWD: wt, YakPath: ".yaks", WorkerName: "Yakov", Tool: "opencode"}
+
+	_, err := SpawnNativeWorker(worker, "test prompt", homeDir, "", 0)
+	if err == nil || !strings.Contains(err.Error(), "not reachable from working directory "+wt) {
+		t.Fatalf("expected an unreachable yak path error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(homeDir, "scripts", "run.sh")); !os.IsNotExist(err) {
+		t.Error("run.sh should not be written when the yak path is unreachable")
+	}
+
+	if err := os.Mkdir(filepath.Join(wt, ".yaks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SpawnNativeWorker(worker, "test prompt", homeDir, "", 0); err != nil {
+		t.Fatalf("SpawnNativeWorker() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(homeDir, "scripts", "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "export YAK_PATH=" + shellQuote(filepath.Join(wt, ".yaks")) + "\n"; !strings.Contains(string(data), want) {
+		t.Errorf("relative yak path should be made absolute, run.sh:\n%s", data)
+	}
+	if worker.YakPath != ".yaks" {
+		t.Errorf("SpawnNativeWorker should not modify the caller's worker, YakPath = %q", worker.YakPath)
+	}
+}
+
+func TestKillNativeProcessTree_MissingPidFile(t *testing.T) {
+	err := KillNativeProcessTree(filepath.Join(t.TempDir(), "worker.pid"), time.Second)
+	if !errors.Is(err, ErrPidFileNotFound) {
+		t.Errorf("KillNativeProcessTree() = %v, want ErrPidFileNotFound", err)
+	}
+}
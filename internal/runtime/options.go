@@ -3,7 +3,12 @@ package runtime
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/wellmaintained/yak-box/pkg/devcontainer"
 	"github.com/wellmaintained/yak-box/pkg/types"
@@ -14,12 +19,27 @@ type Commander interface {
 	CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd
 }
 
-type defaultCommander struct{}
+type defaultCommander struct {
+	verbose bool
+}
 
 func (c *defaultCommander) CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	if c.verbose || verbose.Load() {
+		traceCommand(name, args)
+	}
 	return exec.CommandContext(ctx, name, args...)
 }
 
+// Package-level commanders for functions that take no SpawnOptions. They
+// default to real execution; tests replace them to fake docker, zellij and
+// git and to assert the exact arguments used.
+var (
+	// dockerCommander runs every docker CLI call outside SpawnSandboxedWorker.
+	dockerCommander Commander = &defaultCommander{}
+	// hostCommander runs zellij, git and other host tools.
+	hostCommander Commander = &defaultCommander{}
+)
+
 type spawnConfig struct {
 	worker    *types.Worker
 	prompt    string
@@ -27,8 +47,34 @@ type spawnConfig struct {
 	homeDir   string
 	devConfig *devcontainer.Config
 	commander Commander
+	init      bool
+
+	allowUnsafeSecurity bool
+	noCapDrop           bool
+	allowedMountRoots   []string
+	readyTimeout        time.Duration
+	stopTimeout         time.Duration
+	verbose             bool
+	envVars             map[string]string
+	noAuthMount         bool
+	credentialFile      string
+	image               string
+	containerWorkspace  string
+	progress            func(step string)
+	scriptsDir          string
+	noZellij            bool
+	uid                 *int
+	gid                 *int
+	maxDepth            int
 }
 
+// DefaultReadyTimeout is how long the shell pane waits for the container to start
+const DefaultReadyTimeout = 30 * time.Second
+
+// DefaultStopTimeout is how long docker waits after SIGTERM before killing a
+// worker container when stopped without -t, e.g. at daemon shutdown
+const DefaultStopTimeout = 300 * time.Second
+
 // SpawnOption configures the spawn process
 type SpawnOption func(*spawnConfig) error
 
@@ -78,4 +124,233 @@ func WithCommander(cmdr Commander) SpawnOption {
 		c.commander = cmdr
 		return nil
 	}
-}
\ No newline at end of file
+}
+
+// WithInit forces docker's --init process regardless of the devcontainer setting
+func WithInit(init bool) SpawnOption {
+	return func(c *spawnConfig) error {
+		c.init = init
+		return nil
+	}
+}
+
+// WithAllowUnsafeSecurity permits devcontainer security settings that
+// ValidateSecurityConfig flags as critical
+func WithAllowUnsafeSecurity(allow bool) SpawnOption {
+	return func(c *spawnConfig) error {
+		c.allowUnsafeSecurity = allow
+		return nil
+	}
+}
+
+// WithNoCapDrop omits --cap-drop ALL so the container keeps Docker's default
+// capability set
+func WithNoCapDrop(noCapDrop bool) SpawnOption {
+	return func(c *spawnConfig) error {
+		c.noCapDrop = noCapDrop
+		return nil
+	}
+}
+
+// WithVerbose echoes each command the spawn runs to stderr before running it
+func WithVerbose(verbose bool) SpawnOption {
+	return func(c *spawnConfig) error {
+		c.verbose = verbose
+		return nil
+	}
+}
+
+// WithEnvVars injects extra environment variables into the container.
+// Later calls override earlier values for the same key
+func WithEnvVars(vars map[string]string) SpawnOption {
+	return func(c *spawnConfig) error {
+		if c.envVars == nil {
+			c.envVars = make(map[string]string, len(vars))
+		}
+		for k, v := range vars {
+			c.envVars[k] = v
+		}
+		return nil
+	}
+}
+
+// WithNoAuthMount skips mounting the host's OpenCode auth.json into the container
+func WithNoAuthMount(noAuthMount bool) SpawnOption {
+	return func(c *spawnConfig) error {
+		c.noAuthMount = noAuthMount
+		return nil
+	}
+}
+
+// WithCredentialFile injects the contents of path as OPENCODE_API_KEY. The
+// run script reads the file when the container starts, so the key is never
+// written to the script or passed on a command line
+func WithCredentialFile(path string) SpawnOption {
+	return func(c *spawnConfig) error {
+		if path == "" {
+			c.credentialFile = ""
+			return nil
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("invalid credential file %q: %w", path, err)
+		}
+		c.credentialFile = abs
+		return nil
+	}
+}
+
+// WithImage overrides both the default worker image and the devcontainer image
+func WithImage(image string) SpawnOption {
+	return func(c *spawnConfig) error {
+		c.image = image
+		return nil
+	}
+}
+
+// WithContainerWorkspace mounts the workspace at path inside the container,
+// instead of at its host path, and rewrites the working directory to match
+func WithContainerWorkspace(path string) SpawnOption {
+	return func(c *spawnConfig) error {
+		if path != "" && !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("container workspace %q must be an absolute path", path)
+		}
+		c.containerWorkspace = path
+		return nil
+	}
+}
+
+// WithAllowedMountRoots permits bind mounts from the given host directories
+// in addition to the workspace root, worker home and worktree root
+func WithAllowedMountRoots(roots ...string) SpawnOption {
+	return func(c *spawnConfig) error {
+		for _, root := range roots {
+			abs, err := filepath.Abs(root)
+			if err != nil {
+				return fmt.Errorf("invalid mount root %q: %w", root, err)
+			}
+			c.allowedMountRoots = append(c.allowedMountRoots, abs)
+		}
+		return nil
+	}
+}
+
+// WithReadyTimeout sets how long the shell pane waits for the container to start
+func WithReadyTimeout(timeout time.Duration) SpawnOption {
+	return func(c *spawnConfig) error {
+		if timeout <= 0 {
+			return fmt.Errorf("ready timeout must be positive, got %s", timeout)
+		}
+		c.readyTimeout = timeout
+		return nil
+	}
+}
+
+// WithStopTimeout sets the container's --stop-timeout, rounded up to whole
+// seconds
+func WithStopTimeout(timeout time.Duration) SpawnOption {
+	return func(c *spawnConfig) error {
+		if timeout <= 0 {
+			return fmt.Errorf("stop timeout must be positive, got %s", timeout)
+		}
+		c.stopTimeout = timeout
+		return nil
+	}
+}
+
+// WithProgress registers a callback that SpawnSandboxedWorker calls as it
+// enters each phase ("pulling image" for a remote image that is not local,
+// "writing scripts", "generating layout", "launching zellij tab", or
+// "starting container" with WithNoZellij). A nil callback
+// restores the default no-op
+func WithProgress(progress func(step string)) SpawnOption {
+	return func(c *spawnConfig) error {
+		if progress == nil {
+			progress = func(string) {}
+		}
+		c.progress = progress
+		return nil
+	}
+}
+
+// WithScriptsDir writes the prompt, run scripts, layout and log to dir
+// instead of the worker home's scripts directory
+func WithScriptsDir(dir string) SpawnOption {
+	return func(c *spawnConfig) error {
+		if dir == "" {
+			c.scriptsDir = ""
+			return nil
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("invalid scripts dir %q: %w", dir, err)
+		}
+		c.scriptsDir = abs
+		return nil
+	}
+}
+
+// ContainerUIDEnv and ContainerGIDEnv name the environment variables that
+// spawn reads when --uid or --gid is not given.
+const (
+	ContainerUIDEnv = "YAK_BOX_CONTAINER_UID"
+	ContainerGIDEnv = "YAK_BOX_CONTAINER_GID"
+)
+
+// WithUID runs the container as uid instead of the host user's, also
+// owning the generated passwd entry and the /tmp tmpfs
+func WithUID(uid int) SpawnOption {
+	return func(c *spawnConfig) error {
+		if uid < 0 {
+			return fmt.Errorf("uid must be non-negative, got %d", uid)
+		}
+		c.uid = &uid
+		return nil
+	}
+}
+
+// WithGID runs the container with gid instead of the host user's group,
+// also used by the generated passwd and group files and the /tmp tmpfs
+func WithGID(gid int) SpawnOption {
+	return func(c *spawnConfig) error {
+		if gid < 0 {
+			return fmt.Errorf("gid must be non-negative, got %d", gid)
+		}
+		c.gid = &gid
+		return nil
+	}
+}
+
+// containerUser returns the uid and gid the container runs as: the WithUID
+// and WithGID overrides, else the host user's.
+func containerUser(cfg *spawnConfig) (uid, gid int) {
+	uid, gid = os.Getuid(), os.Getgid()
+	if cfg.uid != nil {
+		uid = *cfg.uid
+	}
+	if cfg.gid != nil {
+		gid = *cfg.gid
+	}
+	return uid, gid
+}
+
+// WithNoZellij starts the container detached with docker run -d instead of
+// in a Zellij tab, for hosts without a terminal multiplexer
+func WithNoZellij(noZellij bool) SpawnOption {
+	return func(c *spawnConfig) error {
+		c.noZellij = noZellij
+		return nil
+	}
+}
+
+// WithMaxDepth sets the nesting limit the worker inherits, exported to it as
+// MaxDepthEnv. Zero uses DefaultMaxDepth.
+func WithMaxDepth(maxDepth int) SpawnOption {
+	return func(c *spawnConfig) error {
+		if maxDepth < 0 {
+			return fmt.Errorf("max depth must be non-negative, got %d", maxDepth)
+		}
+		c.maxDepth = maxDepth
+		return nil
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/wellmaintained/yak-box/pkg/devcontainer"
 	"github.com/wellmaintained/yak-box/pkg/types"
@@ -32,7 +33,6 @@ func TestWithWorker(t *testing.T) {
 	}
 }
 
-
 func TestWithPrompt(t *testing.T) {
 	cfg := &spawnConfig{}
 	prompt := "test prompt"
@@ -93,6 +93,30 @@ func TestWithCommander(t *testing.T) {
 	}
 }
 
+func TestWithInit(t *testing.T) {
+	cfg := &spawnConfig{}
+	opt := WithInit(true)
+	if err := opt(cfg); err != nil {
+		t.Errorf("WithInit returned error: %v", err)
+	}
+	if !cfg.init {
+		t.Error("WithInit failed to set init")
+	}
+}
+
+func TestWithReadyTimeout(t *testing.T) {
+	cfg := &spawnConfig{}
+	if err := WithReadyTimeout(90 * time.Second)(cfg); err != nil {
+		t.Errorf("WithReadyTimeout returned error: %v", err)
+	}
+	if cfg.readyTimeout != 90*time.Second {
+		t.Errorf("WithReadyTimeout set %s, expected 1m30s", cfg.readyTimeout)
+	}
+	if err := WithReadyTimeout(0)(cfg); err == nil {
+		t.Error("WithReadyTimeout should reject a non-positive timeout")
+	}
+}
+
 func TestOptionError(t *testing.T) {
 	errOption := func(c *spawnConfig) error {
 		return errors.New("option failed")
@@ -0,0 +1,116 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrDaemonUnavailable is returned when docker is missing or its daemon cannot
+// be reached, as opposed to the daemon reporting no matching containers.
+var ErrDaemonUnavailable = errors.New("docker daemon not reachable")
+
+// daemonErrorMarkers are stderr fragments docker prints when it cannot talk to the daemon.
+var daemonErrorMarkers = []string{
+	"Cannot connect to the Docker daemon",
+	"Is the docker daemon running",
+	"error during connect",
+}
+
+// PsFormat is the docker ps --format template whose output ParsePsOutput understands.
+const PsFormat = "{{.Names}}\t{{.Status}}\t{{.RunningFor}}\t{{.State}}\t{{.Image}}"
+
+// ContainerRow is one container as reported by docker ps with PsFormat
+type ContainerRow struct {
+	Name       string
+	Status     string // e.g. "Up 2 hours" or "Exited (0) 5 minutes ago"
+	RunningFor string // e.g. "2 hours ago"
+	State      string // e.g. "running" or "exited"
+	Image      string
+}
+
+// ParsePsOutput parses docker ps output produced with PsFormat. Fields are
+// tab-separated so values containing spaces survive intact; trailing fields
+// missing from a line are left empty. Blank lines are skipped.
+func ParsePsOutput(output string) []ContainerRow {
+	var rows []ContainerRow
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		field := func(i int) string {
+			if i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+		rows = append(rows, ContainerRow{
+			Name:       field(0),
+			Status:     field(1),
+			RunningFor: field(2),
+			State:      field(3),
+			Image:      field(4),
+		})
+	}
+	return rows
+}
+
+// ListWorkerContainers returns worker containers as structured rows. When all
+// is false only running containers are included; extra filters (e.g.
+// "status=exited") are passed to docker ps as --filter values. No matching
+// containers is an empty result, not an error; an unreachable daemon yields
+// an error wrapping ErrDaemonUnavailable.
+func ListWorkerContainers(all bool, filters ...string) ([]ContainerRow, error) {
+	args := []string{"ps"}
+	if all {
+		args = append(args, "-a")
+	}
+	args = append(args, "--filter", "name="+containerNamePrefix)
+	for _, filter := range filters {
+		args = append(args, "--filter", filter)
+	}
+	args = append(args, "--format", PsFormat)
+
+	output, err := dockerCommander.CommandContext(context.Background(), "docker", args...).Output()
+	if err != nil {
+		return nil, classifyDockerError(err)
+	}
+	return ParsePsOutput(string(output)), nil
+}
+
+// classifyDockerError wraps err with ErrDaemonUnavailable when docker is not
+// installed or reports that its daemon is unreachable.
+func classifyDockerError(err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%w: docker not found in PATH", ErrDaemonUnavailable)
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		stderr := strings.TrimSpace(string(exitErr.Stderr))
+		for _, marker := range daemonErrorMarkers {
+			if strings.Contains(stderr, marker) {
+				return fmt.Errorf("%w: %s", ErrDaemonUnavailable, stderr)
+			}
+		}
+		if stderr != "" {
+			return fmt.Errorf("docker ps failed: %s", stderr)
+		}
+	}
+	return fmt.Errorf("docker ps failed: %w", err)
+}
+
+func containerNames(rows []ContainerRow) []string {
+	var names []string
+	for _, row := range rows {
+		if row.Name != "" {
+			names = append(names, row.Name)
+		}
+	}
+	return names
+}
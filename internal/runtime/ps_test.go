@@ -0,0 +1,138 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestParsePsOutput(t *testing.T) {
+	output := "yak-worker-api\tUp 2 hours\t2 hours ago\trunning\tyak-worker:latest\n" +
+		"\n" +
+		"yak-worker-ui\tExited (137) 5 minutes ago\t3 days ago\texited\tcustom-image:1.0\r\n"
+
+	rows := ParsePsOutput(output)
+	if len(rows) != 2 {
+		t.Fatalf("ParsePsOutput() returned %d rows, expected 2", len(rows))
+	}
+
+	want := []ContainerRow{
+		{Name: "yak-worker-api", Status: "Up 2 hours", RunningFor: "2 hours ago", State: "running", Image: "yak-worker:latest"},
+		{Name: "yak-worker-ui", Status: "Exited (137) 5 minutes ago", RunningFor: "3 days ago", State: "exited", Image: "custom-image:1.0"},
+	}
+	for i, row := range rows {
+		if row != want[i] {
+			t.Errorf("row %d = %+v, expected %+v", i, row, want[i])
+		}
+	}
+}
+
+func TestParsePsOutput_MissingFields(t *testing.T) {
+	rows := ParsePsOutput("yak-worker-api\tUp About a minute\n")
+	if len(rows) != 1 {
+		t.Fatalf("ParsePsOutput() returned %d rows, expected 1", len(rows))
+	}
+	if rows[0].Name != "yak-worker-api" || rows[0].Status != "Up About a minute" {
+		t.Errorf("unexpected row: %+v", rows[0])
+	}
+	if rows[0].RunningFor != "" || rows[0].State != "" || rows[0].Image != "" {
+		t.Errorf("missing fields should be empty: %+v", rows[0])
+	}
+}
+
+func TestParsePsOutput_Empty(t *testing.T) {
+	if rows := ParsePsOutput("  \n\n"); len(rows) != 0 {
+		t.Errorf("ParsePsOutput() of blank output returned %d rows", len(rows))
+	}
+}
+
+func TestContainerNames(t *testing.T) {
+	names := containerNames([]ContainerRow{{Name: "yak-worker-a"}, {}, {Name: "yak-worker-b"}})
+	if len(names) != 2 || names[0] != "yak-worker-a" || names[1] != "yak-worker-b" {
+		t.Errorf("containerNames() = %v", names)
+	}
+}
+
+// scriptCommander runs a shell snippet in place of every command.
+type scriptCommander struct {
+	script string
+	calls  [][]string
+}
+
+func (c *scriptCommander) CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	c.calls = append(c.calls, append([]string{name}, args...))
+	return exec.CommandContext(ctx, "sh", "-c", c.script)
+}
+
+func withDockerCommander(t *testing.T, cmdr Commander) {
+	t.Helper()
+	orig := dockerCommander
+	dockerCommander = cmdr
+	t.Cleanup(func() { dockerCommander = orig })
+}
+
+func TestListWorkerContainers_DaemonDown(t *testing.T) {
+	withDockerCommander(t, &scriptCommander{
+		script: `echo "Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?" >&2; exit 1`,
+	})
+
+	rows, err := ListWorkerContainers(false)
+	if !errors.Is(err, ErrDaemonUnavailable) {
+		t.Fatalf("expected ErrDaemonUnavailable, got %v", err)
+	}
+	if rows != nil {
+		t.Errorf("expected no rows, got %v", rows)
+	}
+
+	if _, err := ListRunningContainers(); !errors.Is(err, ErrDaemonUnavailable) {
+		t.Errorf("ListRunningContainers() should surface ErrDaemonUnavailable, got %v", err)
+	}
+}
+
+func TestListWorkerContainers_EmptyList(t *testing.T) {
+	withDockerCommander(t, &scriptCommander{script: `exit 0`})
+
+	rows, err := ListWorkerContainers(true, "status=exited")
+	if err != nil {
+		t.Fatalf("empty docker ps output should not be an error: %v", err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("expected no rows, got %v", rows)
+	}
+}
+
+func TestListWorkerContainers_OtherFailure(t *testing.T) {
+	withDockerCommander(t, &scriptCommander{script: `echo "invalid filter 'bogus'" >&2; exit 1`})
+
+	_, err := ListWorkerContainers(false, "bogus")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if errors.Is(err, ErrDaemonUnavailable) {
+		t.Errorf("non-daemon failures should not be reported as daemon unavailable: %v", err)
+	}
+	if !strings.Contains(err.Error(), "invalid filter") {
+		t.Errorf("error should include docker's message: %v", err)
+	}
+}
+
+func TestListWorkerContainers_Args(t *testing.T) {
+	cmdr := &scriptCommander{script: `printf 'yak-worker-a\tUp 1 minute\t1 minute ago\trunning\tyak-worker:latest\n'`}
+	withDockerCommander(t, cmdr)
+
+	rows, err := ListWorkerContainers(true, "status=exited")
+	if err != nil {
+		t.Fatalf("ListWorkerContainers() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].Name != "yak-worker-a" {
+		t.Errorf("unexpected rows: %+v", rows)
+	}
+
+	got := strings.Join(cmdr.calls[0], " ")
+	want := "docker ps -a --filter name=yak-worker- --filter status=exited --format " + PsFormat
+	if got != want {
+		t.Errorf("docker invoked as %q, want %q", got, want)
+	}
+}
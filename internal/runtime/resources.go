@@ -0,0 +1,127 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wellmaintained/yak-box/pkg/types"
+)
+
+// memorySizePattern matches Docker memory sizes such as "512m" or "2g".
+var memorySizePattern = regexp.MustCompile(`^[0-9]+[bkmgBKMG]?$`)
+
+// tmpfsOptions returns the mount options for a tmpfs of the given size. The
+// container runs as the host user (see generateRunScript's --user), so the
+// tmpfs is owned by the host uid and gid rather than a fixed 1000.
+// generateRunScript swaps in the WithUID and WithGID owners when set.
+func tmpfsOptions(size string) string {
+	return fmt.Sprintf("size=%s,exec,uid=%d,gid=%d", size, os.Getuid(), os.Getgid())
+}
+
+// withTmpfsOwner replaces the uid and gid in tmpfs mount options, for a
+// container that does not run as the host user.
+func withTmpfsOwner(opts string, uid, gid int) string {
+	var kept []string
+	for _, opt := range strings.Split(opts, ",") {
+		if !strings.HasPrefix(opt, "uid=") && !strings.HasPrefix(opt, "gid=") {
+			kept = append(kept, opt)
+		}
+	}
+	return strings.Join(append(kept, fmt.Sprintf("uid=%d", uid), fmt.Sprintf("gid=%d", gid)), ",")
+}
+
+// ResourceOverrides replaces individual fields of a ResourceProfile. Empty
+// strings and a zero PIDs leave the profile's value unchanged.
+type ResourceOverrides struct {
+	CPUs      string
+	Memory    string
+	Swap      string
+	PIDs      int
+	TmpfsSize string // size of the /tmp tmpfs
+}
+
+// Validate returns one error per override with an invalid format, each
+// starting with the name of the overridden field.
+func (o ResourceOverrides) Validate() []error {
+	var errs []error
+	if o.CPUs != "" {
+		if n, err := strconv.ParseFloat(o.CPUs, 64); err != nil || n < 0 {
+			errs = append(errs, fmt.Errorf("cpus %q must be a non-negative number like '1.5'", o.CPUs))
+		}
+	}
+	if o.Memory != "" && !memorySizePattern.MatchString(o.Memory) {
+		errs = append(errs, fmt.Errorf("memory %q must be a size like '512m' or '2g'", o.Memory))
+	}
+	if o.Swap != "" && o.Swap != "-1" && !memorySizePattern.MatchString(o.Swap) {
+		errs = append(errs, fmt.Errorf("swap %q must be a size like '4g', or -1 for unlimited", o.Swap))
+	}
+	if mem, ok := memoryBytes(o.Memory); ok {
+		if swap, ok := memoryBytes(o.Swap); ok && swap < mem {
+			errs = append(errs, fmt.Errorf("swap %q must be at least memory %q; docker counts memory within the swap limit", o.Swap, o.Memory))
+		}
+	}
+	if o.PIDs < 0 {
+		errs = append(errs, fmt.Errorf("pids %d must be positive", o.PIDs))
+	}
+	if o.TmpfsSize != "" && !memorySizePattern.MatchString(o.TmpfsSize) {
+		errs = append(errs, fmt.Errorf("tmpfs-size %q must be a size like '512m' or '4g'", o.TmpfsSize))
+	}
+	return errs
+}
+
+// Apply returns profile with the set overrides applied.
+func (o ResourceOverrides) Apply(profile types.ResourceProfile) types.ResourceProfile {
+	if o.CPUs != "" {
+		profile.CPUs = o.CPUs
+	}
+	if o.Memory != "" {
+		profile.Memory = o.Memory
+	}
+	if o.Swap != "" {
+		profile.Swap = o.Swap
+	} else if mem, ok := memoryBytes(o.Memory); ok {
+		// docker rejects a swap limit below the memory limit, so a profile
+		// swap is raised to cover a larger --memory
+		if swap, ok := memoryBytes(profile.Swap); ok && swap < mem {
+			profile.Swap = o.Memory
+		}
+	}
+	if o.PIDs > 0 {
+		profile.PIDs = o.PIDs
+	}
+	if o.TmpfsSize != "" {
+		tmpfs := make(map[string]string, len(profile.Tmpfs)+1)
+		for target, opts := range profile.Tmpfs {
+			tmpfs[target] = opts
+		}
+		tmpfs["/tmp"] = tmpfsOptions(o.TmpfsSize)
+		profile.Tmpfs = tmpfs
+	}
+	return profile
+}
+
+// memoryBytes converts a Docker memory size such as "512m" to bytes. ok is
+// false for an empty or malformed size and for -1 (unlimited swap).
+func memoryBytes(size string) (n int64, ok bool) {
+	if !memorySizePattern.MatchString(size) {
+		return 0, false
+	}
+	unit := int64(1)
+	switch strings.ToLower(size[len(size)-1:]) {
+	case "k":
+		unit = 1 << 10
+	case "m":
+		unit = 1 << 20
+	case "g":
+		unit = 1 << 30
+	}
+	digits := strings.TrimRight(size, "bkmgBKMG")
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n * unit, true
+}
@@ -0,0 +1,109 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/wellmaintained/yak-box/pkg/types"
+)
+
+func TestResourceOverridesValidate(t *testing.T) {
+	valid := ResourceOverrides{CPUs: "1.5", Memory: "3g", Swap: "-1", PIDs: 128}
+	if errs := valid.Validate(); len(errs) != 0 {
+		t.Errorf("Validate() = %v, expected no errors", errs)
+	}
+	if errs := (ResourceOverrides{Memory: "512m", Swap: "4G"}).Validate(); len(errs) != 0 {
+		t.Errorf("Validate() = %v, expected no errors", errs)
+	}
+
+	invalid := ResourceOverrides{CPUs: "lots", Memory: "3 gigs", Swap: "big", PIDs: -1}
+	errs := invalid.Validate()
+	if len(errs) != 4 {
+		t.Fatalf("Validate() = %v, expected 4 errors", errs)
+	}
+	for i, flag := range []string{"cpus", "memory", "swap", "pids"} {
+		if !strings.Contains(errs[i].Error(), flag) {
+			t.Errorf("error %d = %q, expected it to mention %s", i, errs[i], flag)
+		}
+	}
+}
+
+func TestResourceOverridesMemoryAboveSwap(t *testing.T) {
+	errs := ResourceOverrides{Memory: "8g", Swap: "4g"}.Validate()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "must be at least memory") {
+		t.Errorf("Validate() = %v, expected swap below memory to be rejected", errs)
+	}
+	for _, o := range []ResourceOverrides{{Memory: "4g", Swap: "4096m"}, {Memory: "8g", Swap: "-1"}} {
+		if errs := o.Validate(); len(errs) != 0 {
+			t.Errorf("%+v.Validate() = %v, expected no errors", o, errs)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		overrides ResourceOverrides
+		profile   string
+		wantSwap  string
+	}{
+		{name: "memory above profile swap raises it", overrides: ResourceOverrides{Memory: "32g"}, profile: "ram", wantSwap: "32g"},
+		{name: "memory below profile swap keeps it", overrides: ResourceOverrides{Memory: "4g"}, profile: "ram", wantSwap: "16g"},
+		{name: "explicit swap wins", overrides: ResourceOverrides{Memory: "32g", Swap: "-1"}, profile: "ram", wantSwap: "-1"},
+		{name: "profile without swap stays unset", overrides: ResourceOverrides{Memory: "32g"}, profile: "default", wantSwap: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.overrides.Apply(GetResourceProfile(tt.profile)).Swap; got != tt.wantSwap {
+				t.Errorf("Swap = %q, want %q", got, tt.wantSwap)
+			}
+		})
+	}
+}
+
+func TestResourceOverridesInRunScript(t *testing.T) {
+	profile := ResourceOverrides{Memory: "3g", Swap: "6g", PIDs: 777}.Apply(GetResourceProfile("default"))
+	if profile.CPUs != "1.0" {
+		t.Errorf("CPUs = %q, expected the default profile value to be kept", profile.CPUs)
+	}
+
+	cfg := &spawnConfig{
+		worker:  &types.Worker{Name: "test-worker", WorkerName: "TestWorker"},
+		profile: profile,
+	}
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	for _, want := range []string{"--cpus 1.0 ", "--memory 3g ", "--memory-swap 6g ", "--pids-limit 777 "} {
+		if !strings.Contains(script, want) {
+			t.Errorf("run script missing %q", want)
+		}
+	}
+}
+
+func TestTmpfsUsesHostUser(t *testing.T) {
+	owner := fmt.Sprintf("uid=%d,gid=%d", os.Getuid(), os.Getgid())
+	for _, name := range []string{"light", "default", "heavy", "ram"} {
+		for target, opts := range GetResourceProfile(name).Tmpfs {
+			if !strings.HasSuffix(opts, ","+owner) {
+				t.Errorf("%s profile tmpfs %s = %q, expected it to end with %s", name, target, opts, owner)
+			}
+		}
+	}
+
+	cfg := &spawnConfig{
+		worker:  &types.Worker{Name: "test-worker", WorkerName: "TestWorker"},
+		profile: GetResourceProfile("default"),
+	}
+	script := generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	if want := "\t--tmpfs /tmp:rw,size=2g,exec," + owner + " \\\n"; !strings.Contains(script, want) {
+		t.Errorf("run script missing %q:\n%s", want, script)
+	}
+
+	cfg.profile = ResourceOverrides{TmpfsSize: "6g"}.Apply(cfg.profile)
+	script = generateRunScript(cfg, "/ws", "/p", "/i", "/pw", "/g", "net")
+	if want := "\t--tmpfs /tmp:rw,size=6g,exec," + owner + " \\\n"; !strings.Contains(script, want) {
+		t.Errorf("run script missing %q after --tmpfs-size override:\n%s", want, script)
+	}
+	if strings.Contains(script, "uid=1000") && os.Getuid() != 1000 {
+		t.Error("run script should not hardcode uid 1000")
+	}
+}
@@ -2,9 +2,9 @@ package runtime
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -19,7 +19,8 @@ const (
 	networkName         = "yak-shavers"
 )
 
-// GetResourceProfile returns the resource profile for a given name
+// GetResourceProfile returns the resource profile for a given name. Every
+// profile gets a 2g /tmp; spawn --tmpfs-size overrides it.
 func GetResourceProfile(name string) types.ResourceProfile {
 	switch name {
 	case "light":
@@ -30,9 +31,9 @@ func GetResourceProfile(name string) types.ResourceProfile {
 			Swap:   "",
 			PIDs:   256,
 			Tmpfs: map[string]string{
-				"/tmp":                    "size=1g,exec,uid=1000,gid=1000",
-				"/home/yak-shaver":        "size=512m,exec,uid=1000,gid=1000",
-				"/home/yak-shaver/.cache": "size=512m,exec,uid=1000,gid=1000",
+				"/tmp":                    tmpfsOptions("2g"),
+				"/home/yak-shaver":        tmpfsOptions("512m"),
+				"/home/yak-shaver/.cache": tmpfsOptions("512m"),
 			},
 		}
 	case "heavy":
@@ -43,9 +44,9 @@ func GetResourceProfile(name string) types.ResourceProfile {
 			Swap:   "",
 			PIDs:   1024,
 			Tmpfs: map[string]string{
-				"/tmp":                    "size=2g,exec,uid=1000,gid=1000",
-				"/home/yak-shaver":        "size=1g,exec,uid=1000,gid=1000",
-				"/home/yak-shaver/.cache": "size=1g,exec,uid=1000,gid=1000",
+				"/tmp":                    tmpfsOptions("2g"),
+				"/home/yak-shaver":        tmpfsOptions("1g"),
+				"/home/yak-shaver/.cache": tmpfsOptions("1g"),
 			},
 		}
 	case "ram":
@@ -56,9 +57,9 @@ func GetResourceProfile(name string) types.ResourceProfile {
 			Swap:   "16g",
 			PIDs:   2048,
 			Tmpfs: map[string]string{
-				"/tmp":                    "size=4g,exec,uid=1000,gid=1000",
-				"/home/yak-shaver":        "size=2g,exec,uid=1000,gid=1000",
-				"/home/yak-shaver/.cache": "size=2g,exec,uid=1000,gid=1000",
+				"/tmp":                    tmpfsOptions("2g"),
+				"/home/yak-shaver":        tmpfsOptions("2g"),
+				"/home/yak-shaver/.cache": tmpfsOptions("2g"),
 			},
 		}
 	default:
@@ -69,41 +70,32 @@ func GetResourceProfile(name string) types.ResourceProfile {
 			Swap:   "",
 			PIDs:   512,
 			Tmpfs: map[string]string{
-				"/tmp":                    "size=1g,exec,uid=1000,gid=1000",
-				"/home/yak-shaver":        "size=512m,exec,uid=1000,gid=1000",
-				"/home/yak-shaver/.cache": "size=512m,exec,uid=1000,gid=1000",
+				"/tmp":                    tmpfsOptions("2g"),
+				"/home/yak-shaver":        tmpfsOptions("512m"),
+				"/home/yak-shaver/.cache": tmpfsOptions("512m"),
 			},
 		}
 	}
 }
 
-// DetectRuntime detects the available runtime (sandboxed/docker or native/zellij)
-func DetectRuntime() string {
-	if _, err := exec.LookPath("docker"); err == nil {
-		if err := exec.Command("docker", "ps").Run(); err == nil {
-			return "sandboxed"
-		}
-	}
-	if _, err := exec.LookPath("zellij"); err == nil {
-		return "native"
-	}
-	return "unknown"
-}
-
 // GetNetworkMode returns the network mode for Docker
 func GetNetworkMode(ctx context.Context) string {
-	cmd := exec.CommandContext(ctx, "docker", "network", "inspect", networkName)
+	cmd := dockerCommander.CommandContext(ctx, "docker", "network", "inspect", networkName)
 	if err := cmd.Run(); err != nil {
 		return "bridge"
 	}
 	return networkName
 }
 
-// SpawnSandboxedWorker spawns a worker in a Docker container via Zellij tab
+// SpawnSandboxedWorker spawns a worker in a Docker container via Zellij tab,
+// or as a detached container with WithNoZellij
 func SpawnSandboxedWorker(ctx context.Context, opts ...SpawnOption) error {
 	cfg := &spawnConfig{
-		commander: &defaultCommander{},
-		profile:   GetResourceProfile("default"),
+		commander:    &defaultCommander{},
+		profile:      GetResourceProfile("default"),
+		readyTimeout: DefaultReadyTimeout,
+		stopTimeout:  DefaultStopTimeout,
+		progress:     func(string) {},
 	}
 	for _, opt := range opts {
 		if err := opt(cfg); err != nil {
@@ -111,6 +103,10 @@ func SpawnSandboxedWorker(ctx context.Context, opts ...SpawnOption) error {
 		}
 	}
 
+	if cfg.verbose {
+		cfg.commander = withTracing(cfg.commander)
+	}
+
 	if cfg.worker == nil {
 		return fmt.Errorf("worker is required. Suggestion: Ensure worker config is provided via spawn options")
 	}
@@ -119,13 +115,37 @@ func SpawnSandboxedWorker(ctx context.Context, opts ...SpawnOption) error {
 	networkMode := GetNetworkMode(ctx)
 	workspaceRoot, err := workspace.FindRoot()
 	if err != nil {
-		return fmt.Errorf("failed to find workspace root: %w. Suggestion: Ensure you're in a valid yak-box workspace with a .yak-box directory", err)
+		return fmt.Errorf("failed to find workspace root: %w", err)
+	}
+
+	if err := checkSecurityConfig(cfg, workspaceRoot); err != nil {
+		return err
+	}
+	for _, warning := range securityWarnings(cfg) {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", warning)
 	}
 
-	// Create worker directory for scripts (persist in .yak-boxes)
-	workerDir := filepath.Join(cfg.homeDir, "scripts")
+	if err := validateMounts(cfg, workspaceRoot); err != nil {
+		return err
+	}
+	if err := checkAllowedImage(cfg, workspaceRoot); err != nil {
+		return err
+	}
+
+	// The default worker image is built locally by EnsureDevcontainer
+	if image := runImage(cfg); image != workerImageName {
+		cfg.progress("pulling image")
+		if err := ensureImage(ctx, cfg.commander, image); err != nil {
+			return err
+		}
+	}
+
+	cfg.progress("writing scripts")
+
+	// Create worker directory for scripts (persist in .yak-boxes unless overridden)
+	workerDir := scriptsDirFor(cfg.scriptsDir, cfg.homeDir)
 	if err := os.MkdirAll(workerDir, 0755); err != nil {
-		return fmt.Errorf("failed to create scripts dir: %w. Suggestion: Check that .yak-boxes home directory is writable", err)
+		return fmt.Errorf("failed to create scripts dir: %w. Suggestion: Check that .yak-boxes home directory (or --scripts-dir) is writable", err)
 	}
 
 	// Write prompt to file
@@ -136,19 +156,27 @@ func SpawnSandboxedWorker(ctx context.Context, opts ...SpawnOption) error {
 
 	// Create inner script that runs inside container
 	innerScript := filepath.Join(workerDir, "inner.sh")
-	if err := os.WriteFile(innerScript, []byte(generateInitScript()), 0755); err != nil {
+	userEnvProbe := ""
+	if cfg.devConfig != nil {
+		userEnvProbe = cfg.devConfig.UserEnvProbe
+	}
+	if err := os.WriteFile(innerScript, []byte(generateInitScript(userEnvProbe, waitForSteps(cfg.devConfig))), 0755); err != nil {
 		return fmt.Errorf("failed to write inner script: %w. Suggestion: Check disk space and file permissions in .yak-boxes directory", err)
 	}
 
+	// The log is bind-mounted as a file, so it must exist before docker runs
+	if err := os.WriteFile(filepath.Join(workerDir, workerLogName), nil, 0644); err != nil {
+		return fmt.Errorf("failed to create worker log: %w. Suggestion: Check disk space and file permissions in .yak-boxes directory", err)
+	}
+
 	// Create shell-exec helper script that waits for container to be ready
 	shellExecScript := filepath.Join(workerDir, "shell-exec.sh")
-	if err := os.WriteFile(shellExecScript, []byte(generateWaitScript()), 0755); err != nil {
+	if err := os.WriteFile(shellExecScript, []byte(generateWaitScript(cfg.readyTimeout)), 0755); err != nil {
 		return fmt.Errorf("failed to write shell-exec script: %w. Suggestion: Check .yak-boxes directory exists and is writable", err)
 	}
 
 	// Generate custom /etc/passwd and /etc/group for the container
-	uid := os.Getuid()
-	gid := os.Getgid()
+	uid, gid := containerUser(cfg)
 	passwdContent := fmt.Sprintf("root:x:0:0:root:/root:/bin/bash\nyakshaver:x:%d:%d:Yak Shaver:/home/yak-shaver:/bin/bash\n", uid, gid)
 	groupContent := fmt.Sprintf("root:x:0:\nyakshaver:x:%d:\n", gid)
 	passwdFile := filepath.Join(workerDir, "passwd")
@@ -162,60 +190,106 @@ func SpawnSandboxedWorker(ctx context.Context, opts ...SpawnOption) error {
 
 	// Create wrapper script that runs docker in background with -d flag for detached
 	wrapperScript := filepath.Join(workerDir, "run.sh")
+	if _, err := runMounts(cfg, workspaceRoot, promptFile, innerScript, passwdFile, groupFile); err != nil {
+		return err
+	}
 	runScriptContent := generateRunScript(cfg, workspaceRoot, promptFile, innerScript, passwdFile, groupFile, networkMode)
 
 	if err := os.WriteFile(wrapperScript, []byte(runScriptContent), 0755); err != nil {
 		return fmt.Errorf("failed to write wrapper script: %w. Suggestion: Check .yak-boxes directory permissions and disk space", err)
 	}
 
-	// Create Zellij layout file
-	layoutFile := filepath.Join(workerDir, "layout.kdl")
-	layoutContent := createZellijLayout(cfg.worker.DisplayName, wrapperScript, shellExecScript, containerName)
+	if cfg.noZellij {
+		cfg.progress("starting container")
+		if err := startDetached(ctx, cfg.commander, wrapperScript); err != nil {
+			return err
+		}
+	} else {
+		cfg.progress("generating layout")
+
+		// Create Zellij layout file
+		layoutFile := filepath.Join(workerDir, "layout.kdl")
+		layoutContent := createZellijLayout(cfg.worker.DisplayName, wrapperScript, shellExecScript, containerName)
+
+		if err := os.WriteFile(layoutFile, []byte(layoutContent), 0644); err != nil {
+			return fmt.Errorf("failed to write layout file: %w. Suggestion: Ensure .yak-boxes directory is writable", err)
+		}
+
+		cfg.progress("launching zellij tab")
 
-	if err := os.WriteFile(layoutFile, []byte(layoutContent), 0644); err != nil {
-		return fmt.Errorf("failed to write layout file: %w. Suggestion: Ensure .yak-boxes directory is writable", err)
+		// Spawn Zellij tab with the layout
+		if err := newZellijTab(ctx, cfg.commander, cfg.worker.SessionName, "--layout", layoutFile, "--name", tabName(cfg.worker.DisplayName)); err != nil {
+			return err
+		}
 	}
 
-	// Spawn Zellij tab with the layout
-	var zellijCmd *exec.Cmd
-	sessionName := cfg.worker.SessionName
-	if sessionName != "" {
-		zellijCmd = cfg.commander.CommandContext(ctx, "zellij", "--session", sessionName, "action", "new-tab", "--layout", layoutFile, "--name", cfg.worker.DisplayName)
-	} else {
-		zellijCmd = cfg.commander.CommandContext(ctx, "zellij", "action", "new-tab", "--layout", layoutFile, "--name", cfg.worker.DisplayName)
+	if ports := announcedPorts(cfg); len(ports) > 0 {
+		published := publishedPorts(ctx, cfg.commander, containerName, ports, cfg.readyTimeout)
+		for _, line := range portAnnouncements(cfg, containerName, published) {
+			fmt.Fprintln(os.Stderr, line)
+		}
 	}
 
-	if err := zellijCmd.Run(); err != nil {
-		return fmt.Errorf("failed to create Zellij tab: %w. Suggestion: Ensure Zellij is installed and you're in a Zellij session, or use --runtime=sandboxed", err)
+	// The tool only starts once the waitFor commands succeed, so a failure
+	// there is a failed spawn
+	if steps := waitForSteps(cfg.devConfig); len(steps) > 0 {
+		cfg.progress("waiting for " + cfg.devConfig.WaitFor)
+		logFile := filepath.Join(workerDir, workerLogName)
+		if err := waitForLifecycle(ctx, cfg.commander, containerName, logFile, cfg.readyTimeout); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// startDetached runs a run.sh generated for --no-zellij, which returns once
+// docker has started the container in the background
+func startDetached(ctx context.Context, commander Commander, runScript string) error {
+	output, err := commander.CommandContext(ctx, "bash", runScript).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to start detached container: %w\nOutput: %s\nSuggestion: Run 'bash %s' to see the docker error", err, strings.TrimSpace(string(output)), runScript)
+	}
+	return nil
+}
+
 // StopSandboxedWorker stops a sandboxed worker with timeout
 func StopSandboxedWorker(name string, timeout time.Duration) error {
-	containerName := containerNamePrefix + name
+	return StopContainer(containerNamePrefix+name, timeout)
+}
+
+// ErrContainerNotFound is returned by StopContainer when no container, running
+// or stopped, has the given name.
+var ErrContainerNotFound = errors.New("container not found")
 
+// StopContainer stops and removes a worker container by its full name
+func StopContainer(containerName string, timeout time.Duration) error {
 	// Check if container exists
-	cmd := exec.Command("docker", "ps", "-a", "--filter", fmt.Sprintf("name=^%s$", containerName), "--format", "{{.Names}}")
-	output, err := cmd.Output()
+	listContainer := func() ([]byte, error) {
+		return newDockerCommand("ps", "-a", "--filter", fmt.Sprintf("name=^%s$", containerName), "--format", "{{.Names}}").Output()
+	}
+	output, err := listContainer()
 	if err != nil {
 		return fmt.Errorf("failed to check container: %w. Suggestion: Ensure Docker is running with 'docker ps'", err)
 	}
 
 	if strings.TrimSpace(string(output)) == "" {
-		return fmt.Errorf("container %s not found. Suggestion: Use 'docker ps -a' to see available containers, or check worker name is correct", containerName)
+		return fmt.Errorf("%w: %s. Suggestion: Use 'docker ps -a' to see available containers, or check worker name is correct", ErrContainerNotFound, containerName)
 	}
 
 	// Stop container
-	stopCmd := exec.Command("docker", "stop", "-t", fmt.Sprintf("%d", int(timeout.Seconds())), containerName)
+	stopCmd := newDockerCommand("stop", "-t", fmt.Sprintf("%d", int(timeout.Seconds())), containerName)
 	if err := stopCmd.Run(); err != nil {
 		return fmt.Errorf("failed to stop container: %w. Suggestion: Check Docker is running or try 'docker stop %s' manually", err, containerName)
 	}
 
 	// Remove container
-	rmCmd := exec.Command("docker", "rm", containerName)
+	rmCmd := newDockerCommand("rm", containerName)
 	if err := rmCmd.Run(); err != nil {
+		// A --rm container may already be removed once docker stop returns
+		if output, psErr := listContainer(); psErr == nil && strings.TrimSpace(string(output)) == "" {
+			return nil
+		}
 		return fmt.Errorf("failed to remove container: %w. Suggestion: The container may still be running; try 'docker rm -f %s' manually", err, containerName)
 	}
 
@@ -224,34 +298,18 @@ func StopSandboxedWorker(name string, timeout time.Duration) error {
 
 // ListRunningContainers returns list of running worker containers
 func ListRunningContainers() ([]string, error) {
-	cmd := exec.Command("docker", "ps", "--filter", "name=yak-worker-", "--format", "{{.Names}}")
-	output, err := cmd.Output()
+	rows, err := ListWorkerContainers(false)
 	if err != nil {
 		return nil, err
 	}
-
-	var containers []string
-	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
-		if line != "" {
-			containers = append(containers, line)
-		}
-	}
-	return containers, nil
+	return containerNames(rows), nil
 }
 
 // ListAllContainers returns list of all worker containers (running and stopped)
 func ListAllContainers() ([]string, error) {
-	cmd := exec.Command("docker", "ps", "-a", "--filter", "name=yak-worker-", "--format", "{{.Names}}")
-	output, err := cmd.Output()
+	rows, err := ListWorkerContainers(true)
 	if err != nil {
 		return nil, err
 	}
-
-	var containers []string
-	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
-		if line != "" {
-			containers = append(containers, line)
-		}
-	}
-	return containers, nil
+	return containerNames(rows), nil
 }
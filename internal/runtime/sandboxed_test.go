@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -98,7 +99,328 @@ func TestSpawnSandboxedWorker_SuccessfulSpawnWithDefaults(t *testing.T) {
 	}
 }
 
-// TestSpawnSandboxedWorker_WithSessionName tests spawn with session name
+// TestSpawnSandboxedWorker_ReportsProgress tests the phases reported via WithProgress
+func TestSpawnSandboxedWorker_ReportsProgress(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	worker := &types.Worker{
+		Name:        "test-worker",
+		DisplayName: "Test Worker",
+		CWD:         tmpDir,
+		WorkerName:  "TestBot",
+	}
+
+	var steps []string
+	cmdr := &TestCommander{}
+	err := SpawnSandboxedWorker(
+		context.Background(),
+		WithWorker(worker),
+		WithPrompt("test prompt"),
+		WithHomeDir(tmpDir),
+		WithCommander(cmdr),
+		WithProgress(func(step string) {
+			if step == "launching zellij tab" && cmdr.hasCommand("zellij") {
+				t.Error("launching zellij tab should be reported before zellij runs")
+			}
+			steps = append(steps, step)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("SpawnSandboxedWorker failed: %v", err)
+	}
+
+	want := []string{"writing scripts", "generating layout", "launching zellij tab"}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("steps = %q, want %q", steps, want)
+	}
+}
+
+// TestSpawnSandboxedWorker_OverriddenHomeDir tests that scripts and the home mount follow WithHomeDir
+func TestSpawnSandboxedWorker_OverriddenHomeDir(t *testing.T) {
+	cwd := t.TempDir()
+	homeDir := t.TempDir()
+
+	worker := &types.Worker{
+		Name:        "test-worker",
+		DisplayName: "Test Worker",
+		CWD:         cwd,
+		YakPath:     "/test/yak",
+		WorkerName:  "TestBot",
+	}
+
+	cmdr := &TestCommander{}
+	_ = SpawnSandboxedWorker(
+		context.Background(),
+		WithWorker(worker),
+		WithPrompt("test prompt"),
+		WithHomeDir(homeDir),
+		WithCommander(cmdr),
+	)
+
+	for _, file := range []string{"prompt.txt", "inner.sh", "run.sh", "layout.kdl"} {
+		if _, err := os.Stat(filepath.Join(homeDir, "scripts", file)); err != nil {
+			t.Errorf("Expected %s under overridden home: %v", file, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cwd, "scripts")); !os.IsNotExist(err) {
+		t.Error("scripts should not be written outside the overridden home")
+	}
+
+	content, err := os.ReadFile(filepath.Join(homeDir, "scripts", "run.sh"))
+	if err != nil {
+		t.Fatalf("Failed to read run.sh: %v", err)
+	}
+	if !strings.Contains(string(content), homeDir+":/home/yak-shaver:rw") {
+		t.Error("run.sh should mount the overridden home as /home/yak-shaver")
+	}
+}
+
+func TestSpawnSandboxedWorker_ScriptsDir(t *testing.T) {
+	homeDir := t.TempDir()
+	scriptsDir := filepath.Join(t.TempDir(), "run-1")
+
+	worker := &types.Worker{
+		Name:        "test-worker",
+		DisplayName: "Test Worker",
+		CWD:         homeDir,
+		WorkerName:  "TestBot",
+	}
+
+	cmdr := &TestCommander{}
+	err := SpawnSandboxedWorker(
+		context.Background(),
+		WithWorker(worker),
+		WithPrompt("test prompt"),
+		WithHomeDir(homeDir),
+		WithScriptsDir(scriptsDir),
+		WithCommander(cmdr),
+	)
+	if err != nil {
+		t.Fatalf("SpawnSandboxedWorker failed: %v", err)
+	}
+
+	for _, file := range []string{"prompt.txt", "inner.sh", "shell-exec.sh", "run.sh", "layout.kdl", "passwd", "group", workerLogName} {
+		if _, err := os.Stat(filepath.Join(scriptsDir, file)); err != nil {
+			t.Errorf("Expected %s in the scripts dir: %v", file, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(homeDir, "scripts")); !os.IsNotExist(err) {
+		t.Error("scripts should not be written to the worker home when a scripts dir is set")
+	}
+
+	content, err := os.ReadFile(filepath.Join(scriptsDir, "run.sh"))
+	if err != nil {
+		t.Fatalf("Failed to read run.sh: %v", err)
+	}
+	if !strings.Contains(string(content), filepath.Join(scriptsDir, "prompt.txt")+":/opt/worker/prompt.txt:ro") {
+		t.Error("run.sh should mount the prompt from the scripts dir")
+	}
+}
+
+func TestSpawnSandboxedWorker_NoZellij(t *testing.T) {
+	homeDir := t.TempDir()
+
+	worker := &types.Worker{
+		Name:        "test-worker",
+		DisplayName: "Test Worker",
+		CWD:         homeDir,
+		WorkerName:  "TestBot",
+		SessionName: "main",
+	}
+
+	var steps []string
+	cmdr := &TestCommander{}
+	err := SpawnSandboxedWorker(
+		context.Background(),
+		WithWorker(worker),
+		WithPrompt("test prompt"),
+		WithHomeDir(homeDir),
+		WithNoZellij(true),
+		WithCommander(cmdr),
+		WithProgress(func(step string) { steps = append(steps, step) }),
+	)
+	if err != nil {
+		t.Fatalf("SpawnSandboxedWorker failed: %v", err)
+	}
+
+	if cmdr.hasCommand("zellij") {
+		t.Error("zellij should not be called with WithNoZellij")
+	}
+	runScript := filepath.Join(homeDir, "scripts", "run.sh")
+	call := cmdr.getCall(len(cmdr.calls) - 1)
+	if call == nil || call.name != "bash" || !reflect.DeepEqual(call.args, []string{runScript}) {
+		t.Errorf("last call = %+v, want bash %s", call, runScript)
+	}
+	if _, err := os.Stat(filepath.Join(homeDir, "scripts", "layout.kdl")); !os.IsNotExist(err) {
+		t.Error("layout.kdl should not be written with WithNoZellij")
+	}
+
+	content, err := os.ReadFile(runScript)
+	if err != nil {
+		t.Fatalf("Failed to read run.sh: %v", err)
+	}
+	if !strings.Contains(string(content), "exec docker run -dit --rm") {
+		t.Errorf("run.sh should start the container detached, got:\n%s", content)
+	}
+
+	want := []string{"writing scripts", "starting container"}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("steps = %q, want %q", steps, want)
+	}
+}
+
+func TestSpawnSandboxedWorker_NoZellijStartFails(t *testing.T) {
+	homeDir := t.TempDir()
+
+	worker := &types.Worker{
+		Name:        "test-worker",
+		DisplayName: "Test Worker",
+		CWD:         homeDir,
+		WorkerName:  "TestBot",
+	}
+
+	err := SpawnSandboxedWorker(
+		context.Background(),
+		WithWorker(worker),
+		WithPrompt("test prompt"),
+		WithHomeDir(homeDir),
+		WithNoZellij(true),
+		WithCommander(&TestCommander{failingCmd: "bash"}),
+	)
+	if err == nil || !strings.Contains(err.Error(), "failed to start detached container") {
+		t.Errorf("expected a detached start error, got %v", err)
+	}
+}
+
+func TestSpawnSandboxedWorker_ReadyTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	worker := &types.Worker{
+		Name:        "test-worker",
+		DisplayName: "Test Worker",
+		CWD:         tmpDir,
+		YakPath:     "/test/yak",
+		WorkerName:  "TestBot",
+	}
+
+	cmdr := &TestCommander{}
+	_ = SpawnSandboxedWorker(
+		context.Background(),
+		WithWorker(worker),
+		WithHomeDir(tmpDir),
+		WithReadyTimeout(5*time.Minute),
+		WithCommander(cmdr),
+	)
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "scripts", "shell-exec.sh"))
+	if err != nil {
+		t.Fatalf("Failed to read shell-exec.sh: %v", err)
+	}
+	if !strings.Contains(string(content), "MAX_RETRIES=300\n") {
+		t.Errorf("shell-exec.sh should retry 300 times for a 5m timeout:\n%s", content)
+	}
+}
+
+func TestSpawnSandboxedWorker_StopTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []SpawnOption
+		want string
+	}{
+		{name: "default", want: "--stop-timeout 300 "},
+		{name: "configured", opts: []SpawnOption{WithStopTimeout(90 * time.Second)}, want: "--stop-timeout 90 "},
+		{name: "rounded up", opts: []SpawnOption{WithStopTimeout(1500 * time.Millisecond)}, want: "--stop-timeout 2 "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			worker := &types.Worker{
+				Name:        "test-worker",
+				DisplayName: "Test Worker",
+				CWD:         tmpDir,
+				YakPath:     "/test/yak",
+				WorkerName:  "TestBot",
+			}
+
+			opts := append([]SpawnOption{WithWorker(worker), WithHomeDir(tmpDir), WithCommander(&TestCommander{})}, tt.opts...)
+			_ = SpawnSandboxedWorker(context.Background(), opts...)
+
+			content, err := os.ReadFile(filepath.Join(tmpDir, "scripts", "run.sh"))
+			if err != nil {
+				t.Fatalf("Failed to read run.sh: %v", err)
+			}
+			if !strings.Contains(string(content), tt.want) {
+				t.Errorf("run.sh should contain %q:\n%s", tt.want, content)
+			}
+		})
+	}
+
+	if err := SpawnSandboxedWorker(context.Background(), WithStopTimeout(0)); err == nil || !strings.Contains(err.Error(), "stop timeout must be positive") {
+		t.Errorf("WithStopTimeout(0) error = %v, want a positive-timeout error", err)
+	}
+}
+
+func TestSpawnSandboxedWorker_UIDGIDOverride(t *testing.T) {
+	tmpDir := t.TempDir()
+	worker := &types.Worker{
+		Name:        "test-worker",
+		DisplayName: "Test Worker",
+		CWD:         tmpDir,
+		YakPath:     "/test/yak",
+		WorkerName:  "TestBot",
+	}
+
+	_ = SpawnSandboxedWorker(
+		context.Background(),
+		WithWorker(worker),
+		WithHomeDir(tmpDir),
+		WithUID(4242),
+		WithGID(4343),
+		WithCommander(&TestCommander{}),
+	)
+
+	read := func(name string) string {
+		t.Helper()
+		content, err := os.ReadFile(filepath.Join(tmpDir, "scripts", name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		return string(content)
+	}
+	checks := map[string][]string{
+		"run.sh": {"--user \"4242:4343\" ", "--tmpfs /tmp:rw,size=2g,exec,uid=4242,gid=4343 "},
+		"passwd": {"yakshaver:x:4242:4343:"},
+		"group":  {"yakshaver:x:4343:"},
+	}
+	for file, wants := range checks {
+		content := read(file)
+		for _, want := range wants {
+			if !strings.Contains(content, want) {
+				t.Errorf("%s should contain %q:\n%s", file, want, content)
+			}
+		}
+	}
+	if host := fmt.Sprintf("uid=%d,", os.Getuid()); os.Getuid() != 4242 && strings.Contains(read("run.sh"), host) {
+		t.Errorf("run.sh should not keep the host %s", host)
+	}
+
+	if err := SpawnSandboxedWorker(context.Background(), WithUID(-1)); err == nil || !strings.Contains(err.Error(), "uid must be non-negative") {
+		t.Errorf("WithUID(-1) error = %v, want a non-negative error", err)
+	}
+}
+
+func TestContainerUser(t *testing.T) {
+	cfg := &spawnConfig{}
+	if uid, gid := containerUser(cfg); uid != os.Getuid() || gid != os.Getgid() {
+		t.Errorf("containerUser() = %d, %d, want the host user", uid, gid)
+	}
+	if err := WithGID(0)(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if uid, gid := containerUser(cfg); uid != os.Getuid() || gid != 0 {
+		t.Errorf("containerUser() = %d, %d, want the host uid and gid 0", uid, gid)
+	}
+}
+
 func TestSpawnSandboxedWorker_WithSessionName(t *testing.T) {
 	tmpDir := t.TempDir()
 	defer os.RemoveAll(tmpDir)
@@ -270,6 +592,7 @@ func TestSpawnSandboxedWorker_WithDevConfig(t *testing.T) {
 		WithPrompt("test prompt"),
 		WithHomeDir(tmpDir),
 		WithDevConfig(devConfig),
+		WithAllowedMountRoots("/custom"),
 		WithCommander(cmdr),
 	)
 
@@ -291,6 +614,207 @@ func TestSpawnSandboxedWorker_WithDevConfig(t *testing.T) {
 	}
 }
 
+func TestSpawnSandboxedWorker_RunArgs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	worker := &types.Worker{
+		Name:        "test-worker",
+		DisplayName: "Test Worker",
+		CWD:         tmpDir,
+		YakPath:     "/test/yak",
+		WorkerName:  "TestBot",
+	}
+	devConfig := &devcontainer.Config{RunArgs: []string{"--shm-size=2g"}}
+
+	cmdr := &TestCommander{}
+	err := SpawnSandboxedWorker(
+		context.Background(),
+		WithWorker(worker),
+		WithPrompt("test prompt"),
+		WithHomeDir(tmpDir),
+		WithDevConfig(devConfig),
+		WithCommander(cmdr),
+	)
+	if err != nil {
+		t.Fatalf("SpawnSandboxedWorker failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "scripts", "run.sh"))
+	if err != nil {
+		t.Fatalf("Failed to read run.sh: %v", err)
+	}
+	if !strings.Contains(string(content), "--shm-size=2g") {
+		t.Error("run.sh missing runArgs entry --shm-size=2g")
+	}
+}
+
+func TestSpawnSandboxedWorker_UnsafeSecurityConfig(t *testing.T) {
+	newWorker := func(dir string) *types.Worker {
+		return &types.Worker{
+			Name:        "test-worker",
+			DisplayName: "Test Worker",
+			CWD:         dir,
+			YakPath:     "/test/yak",
+			WorkerName:  "TestBot",
+		}
+	}
+	devConfig := &devcontainer.Config{CapAdd: []string{"SYS_ADMIN"}}
+
+	t.Run("blocked without allow flag", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		cmdr := &TestCommander{}
+		err := SpawnSandboxedWorker(
+			context.Background(),
+			WithWorker(newWorker(tmpDir)),
+			WithHomeDir(tmpDir),
+			WithDevConfig(devConfig),
+			WithCommander(cmdr),
+		)
+		if err == nil {
+			t.Fatal("Expected error for dangerous capability")
+		}
+		if !strings.Contains(err.Error(), "SYS_ADMIN") {
+			t.Errorf("error should name the capability: %v", err)
+		}
+		if cmdr.hasCommand("zellij") {
+			t.Error("zellij should not be called when spawn is blocked")
+		}
+	})
+
+	t.Run("allowed with allow flag", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		cmdr := &TestCommander{}
+		err := SpawnSandboxedWorker(
+			context.Background(),
+			WithWorker(newWorker(tmpDir)),
+			WithHomeDir(tmpDir),
+			WithDevConfig(devConfig),
+			WithAllowUnsafeSecurity(true),
+			WithCommander(cmdr),
+		)
+		if err != nil {
+			t.Fatalf("SpawnSandboxedWorker failed: %v", err)
+		}
+		content, err := os.ReadFile(filepath.Join(tmpDir, "scripts", "run.sh"))
+		if err != nil {
+			t.Fatalf("Failed to read run.sh: %v", err)
+		}
+		if !strings.Contains(string(content), "--cap-add SYS_ADMIN") {
+			t.Error("run.sh missing --cap-add SYS_ADMIN")
+		}
+	})
+}
+
+func TestSpawnSandboxedWorker_MountValidation(t *testing.T) {
+	newWorker := func(dir string) *types.Worker {
+		return &types.Worker{
+			Name:        "test-worker",
+			DisplayName: "Test Worker",
+			CWD:         dir,
+			YakPath:     "/test/yak",
+			WorkerName:  "TestBot",
+		}
+	}
+
+	t.Run("escaping mount is rejected", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		cmdr := &TestCommander{}
+		mount := "source=/,target=/host,type=bind"
+		err := SpawnSandboxedWorker(
+			context.Background(),
+			WithWorker(newWorker(tmpDir)),
+			WithHomeDir(tmpDir),
+			WithDevConfig(&devcontainer.Config{Mounts: []string{mount}}),
+			WithCommander(cmdr),
+		)
+		if err == nil {
+			t.Fatal("Expected error for mount of /")
+		}
+		if !strings.Contains(err.Error(), mount) {
+			t.Errorf("error should name the offending mount: %v", err)
+		}
+		if cmdr.hasCommand("zellij") {
+			t.Error("zellij should not be called when a mount is rejected")
+		}
+	})
+
+	for _, runArgs := range [][]string{
+		{"-v", "/:/host"},
+		{"-v/:/host"},
+		{"-v=/:/host"},
+		{"--volume", "/etc:/host-etc:ro"},
+		{"--volume=/etc:/host-etc:ro"},
+		{"--mount", "type=bind,source=/,target=/host"},
+		{"--mount=type=bind,src=/etc,dst=/host-etc"},
+	} {
+		t.Run("escaping runArgs mount "+strings.Join(runArgs, " ")+" is rejected", func(t *testing.T) {
+			tmpDir := t.TempDir()
+			cmdr := &TestCommander{}
+			err := SpawnSandboxedWorker(
+				context.Background(),
+				WithWorker(newWorker(tmpDir)),
+				WithHomeDir(tmpDir),
+				WithDevConfig(&devcontainer.Config{RunArgs: runArgs}),
+				WithCommander(cmdr),
+			)
+			if err == nil || !strings.Contains(err.Error(), "runArgs mount") || !strings.Contains(err.Error(), "permitted mount roots") {
+				t.Fatalf("expected a runArgs mount error, got %v", err)
+			}
+			if cmdr.hasCommand("zellij") {
+				t.Error("zellij should not be called when a mount is rejected")
+			}
+		})
+	}
+
+	t.Run("mount inside worker home is permitted", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		cacheDir := filepath.Join(tmpDir, "cache")
+		cmdr := &TestCommander{}
+		err := SpawnSandboxedWorker(
+			context.Background(),
+			WithWorker(newWorker(tmpDir)),
+			WithHomeDir(tmpDir),
+			WithDevConfig(&devcontainer.Config{Mounts: []string{"source=" + cacheDir + ",target=/cache,type=bind"}}),
+			WithCommander(cmdr),
+		)
+		if err != nil {
+			t.Fatalf("mount inside worker home should be permitted: %v", err)
+		}
+		assertRunScriptMounts(t, tmpDir, "--mount "+shellQuote("source="+cacheDir+",target=/cache,type=bind"))
+	})
+
+	t.Run("allow-mount root is permitted", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		extra := t.TempDir()
+		cmdr := &TestCommander{}
+		err := SpawnSandboxedWorker(
+			context.Background(),
+			WithWorker(newWorker(tmpDir)),
+			WithHomeDir(tmpDir),
+			WithDevConfig(&devcontainer.Config{Mounts: []string{"source=" + extra + "/data,target=/data,type=bind"}}),
+			WithAllowedMountRoots(extra),
+			WithCommander(cmdr),
+		)
+		if err != nil {
+			t.Fatalf("mount under --allow-mount root should be permitted: %v", err)
+		}
+		assertRunScriptMounts(t, tmpDir, "--mount "+shellQuote("source="+extra+"/data,target=/data,type=bind"))
+	})
+}
+
+// assertRunScriptMounts checks that the run.sh written under homeDir passes
+// the docker mount argument want.
+func assertRunScriptMounts(t *testing.T, homeDir, want string) {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(homeDir, "scripts", "run.sh"))
+	if err != nil {
+		t.Fatalf("failed to read run.sh: %v", err)
+	}
+	if !strings.Contains(string(content), "\t"+want+" \\\n") {
+		t.Errorf("run.sh missing mount %q:\n%s", want, content)
+	}
+}
+
 func TestSpawnSandboxedWorker_WithWorktreePath(t *testing.T) {
 	tmpDir := t.TempDir()
 	defer os.RemoveAll(tmpDir)
@@ -413,6 +937,48 @@ func TestSpawnSandboxedWorker_ZellijLayoutContent(t *testing.T) {
 	}
 }
 
+func TestSpawnSandboxedWorker_DisplayNameIsEscaped(t *testing.T) {
+	tmpDir := t.TempDir()
+	worker := &types.Worker{
+		Name:        "test-worker",
+		DisplayName: "Yakov 🪒🦬 say \"hi\"\nthere",
+		CWD:         tmpDir,
+		YakPath:     "/test/yak",
+		WorkerName:  "Yakov",
+	}
+
+	cmdr := &TestCommander{}
+	if err := SpawnSandboxedWorker(context.Background(), WithWorker(worker), WithHomeDir(tmpDir), WithCommander(cmdr)); err != nil {
+		t.Fatalf("SpawnSandboxedWorker failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "scripts", "layout.kdl"))
+	if err != nil {
+		t.Fatalf("Failed to read layout.kdl: %v", err)
+	}
+	if want := `tab name="Yakov 🪒🦬 say \"hi\" there" {`; !strings.Contains(string(content), want) {
+		t.Errorf("layout.kdl should contain %q:\n%s", want, content)
+	}
+	// Every line must close the strings it opens
+	for i, line := range strings.Split(string(content), "\n") {
+		if quotes := strings.Count(line, `"`) - strings.Count(line, `\"`); quotes%2 != 0 {
+			t.Errorf("layout.kdl line %d has an unterminated string: %s", i+1, line)
+		}
+	}
+
+	var nameArg string
+	for _, call := range cmdr.calls {
+		for i, arg := range call.args {
+			if call.name == "zellij" && arg == "--name" && i+1 < len(call.args) {
+				nameArg = call.args[i+1]
+			}
+		}
+	}
+	if nameArg != `Yakov 🪒🦬 say "hi" there` {
+		t.Errorf("zellij --name = %q, want a single-line name", nameArg)
+	}
+}
+
 func TestSpawnSandboxedWorker_InnerScriptContent(t *testing.T) {
 	tmpDir := t.TempDir()
 	defer os.RemoveAll(tmpDir)
@@ -517,26 +1083,88 @@ func TestGetNetworkMode_ContextCancellation(t *testing.T) {
 	}
 }
 
+// recordingCommander routes docker subcommands like routeCommander and
+// records every command it is asked to run.
+type recordingCommander struct {
+	routes map[string]string
+	calls  [][]string
+}
+
+func (r *recordingCommander) CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	r.calls = append(r.calls, append([]string{name}, args...))
+	return routeCommander(r.routes).CommandContext(ctx, name, args...)
+}
+
 // TestStopSandboxedWorker_Success tests successful container stop
 func TestStopSandboxedWorker_Success(t *testing.T) {
-	err := StopSandboxedWorker("nonexistent-worker", 30*time.Second)
+	cmdr := &recordingCommander{routes: map[string]string{"ps": "echo yak-worker-api"}}
+	withDockerCommander(t, cmdr)
 
-	if err == nil {
-		t.Error("Expected error for nonexistent container")
+	if err := StopSandboxedWorker("api", 30*time.Second); err != nil {
+		t.Fatalf("StopSandboxedWorker() error = %v", err)
 	}
-	if !strings.Contains(err.Error(), "not found") {
-		t.Errorf("Wrong error message: %v", err)
+
+	want := [][]string{
+		{"docker", "ps", "-a", "--filter", "name=^yak-worker-api$", "--format", "{{.Names}}"},
+		{"docker", "stop", "-t", "30", "yak-worker-api"},
+		{"docker", "rm", "yak-worker-api"},
+	}
+	if !reflect.DeepEqual(cmdr.calls, want) {
+		t.Errorf("docker calls = %v, want %v", cmdr.calls, want)
 	}
 }
 
 func TestStopSandboxedWorker_ContainerNotFound(t *testing.T) {
+	cmdr := &recordingCommander{routes: map[string]string{"ps": "exit 0"}}
+	withDockerCommander(t, cmdr)
+
 	err := StopSandboxedWorker("definitely-not-a-real-container", 30*time.Second)
 
 	if err == nil {
-		t.Error("Expected error when container not found")
+		t.Fatal("Expected error when container not found")
 	}
-	if !strings.Contains(err.Error(), "not found") {
-		t.Errorf("Wrong error message: %v", err)
+	if !errors.Is(err, ErrContainerNotFound) {
+		t.Errorf("error = %v, want ErrContainerNotFound", err)
+	}
+	if len(cmdr.calls) != 1 {
+		t.Errorf("expected only the existence check, got %v", cmdr.calls)
+	}
+}
+
+func TestStopSandboxedWorker_StopFails(t *testing.T) {
+	cmdr := &recordingCommander{routes: map[string]string{"ps": "echo yak-worker-api", "stop": "exit 1"}}
+	withDockerCommander(t, cmdr)
+
+	err := StopSandboxedWorker("api", 10*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "failed to stop container") {
+		t.Fatalf("StopSandboxedWorker() error = %v, want stop failure", err)
+	}
+	for _, call := range cmdr.calls {
+		if call[1] == "rm" {
+			t.Errorf("container removed after stop failed: %v", cmdr.calls)
+		}
+	}
+}
+
+func TestStopSandboxedWorker_AlreadyRemoved(t *testing.T) {
+	// The first ps finds the container; once stopped, --rm has removed it
+	listed := filepath.Join(t.TempDir(), "listed")
+	withDockerCommander(t, &recordingCommander{routes: map[string]string{
+		"ps": "[ -e " + listed + " ] || { touch " + listed + "; echo yak-worker-api; }",
+		"rm": "exit 1",
+	}})
+
+	if err := StopSandboxedWorker("api", 10*time.Second); err != nil {
+		t.Errorf("StopSandboxedWorker() error = %v, want success for a container removed by --rm", err)
+	}
+}
+
+func TestStopSandboxedWorker_RemoveFails(t *testing.T) {
+	withDockerCommander(t, &recordingCommander{routes: map[string]string{"ps": "echo yak-worker-api", "rm": "exit 1"}})
+
+	err := StopSandboxedWorker("api", 10*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "failed to remove container") {
+		t.Errorf("StopSandboxedWorker() error = %v, want remove failure", err)
 	}
 }
 
@@ -703,6 +1331,10 @@ func TestSpawnSandboxedWorker_ZellijCommandFails(t *testing.T) {
 		WorkerName:  "TestBot",
 	}
 
+	origDelay := zellijRetryDelay
+	zellijRetryDelay = 0
+	t.Cleanup(func() { zellijRetryDelay = origDelay })
+
 	failingCmdr := &TestCommander{failingCmd: "zellij"}
 	err := SpawnSandboxedWorker(
 		context.Background(),
@@ -713,11 +1345,21 @@ func TestSpawnSandboxedWorker_ZellijCommandFails(t *testing.T) {
 	)
 
 	if err == nil {
-		t.Error("Expected error when zellij command fails")
+		t.Fatal("Expected error when zellij command fails")
 	}
 	if !strings.Contains(err.Error(), "failed to create Zellij tab") {
 		t.Errorf("Wrong error message: %v", err)
 	}
+
+	zellijCalls := 0
+	for _, call := range failingCmdr.calls {
+		if call.name == "zellij" {
+			zellijCalls++
+		}
+	}
+	if zellijCalls != zellijTabAttempts {
+		t.Errorf("Expected %d zellij attempts, got %d", zellijTabAttempts, zellijCalls)
+	}
 }
 
 func TestDetectRuntime_DockerUnavailable(t *testing.T) {
@@ -783,10 +1425,15 @@ func TestSpawnSandboxedWorker_MultipleOptions(t *testing.T) {
 }
 
 func TestStopSandboxedWorker_CheckContainerError(t *testing.T) {
+	withDockerCommander(t, &recordingCommander{routes: map[string]string{"ps": "exit 1"}})
+
 	err := StopSandboxedWorker("test-worker-that-doesnt-exist", 10*time.Second)
 
 	if err == nil {
-		t.Error("Expected error when checking container fails")
+		t.Fatal("Expected error when checking container fails")
+	}
+	if !strings.Contains(err.Error(), "failed to check container") {
+		t.Errorf("Wrong error message: %v", err)
 	}
 }
 
@@ -929,7 +1576,7 @@ func TestSpawnSandboxedWorker_WorktreePathOptional(t *testing.T) {
 	}
 	contentStr := string(content)
 
-	if strings.Contains(contentStr, "-v \":") {
+	if strings.Contains(contentStr, "-v :") {
 		t.Error("run.sh contains an empty mount entry when worktree path is unset")
 	}
 }
@@ -0,0 +1,96 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/wellmaintained/yak-box/internal/sessions"
+)
+
+// WorkerState is the lifecycle state of a worker as observed from the host
+type WorkerState string
+
+const (
+	// StateSpawning means the worker is registered but its container or process is not up yet
+	StateSpawning WorkerState = "Spawning"
+	// StateRunning means the agent is running
+	StateRunning WorkerState = "Running"
+	// StateIdle means the container is up but the agent process has exited
+	StateIdle WorkerState = "Idle"
+	// StateStopped means the container or process is gone
+	StateStopped WorkerState = "Stopped"
+)
+
+// IsTerminal reports whether no further transitions are expected
+func (s WorkerState) IsTerminal() bool {
+	return s == StateStopped
+}
+
+// agentProcessPattern matches the AI tool processes started by the init script.
+const agentProcessPattern = "opencode|claude|agent --force"
+
+// DeriveWorkerState inspects a session's container or PID file and returns
+// its current state. A nil session is Stopped. A sandboxed container that is
+// not found yet counts as Spawning for DefaultReadyTimeout after the spawn.
+func DeriveWorkerState(ctx context.Context, session *sessions.Session) (WorkerState, error) {
+	if session == nil {
+		return StateStopped, nil
+	}
+
+	switch session.Runtime {
+	case "native":
+		if session.PidFile == "" {
+			return StateRunning, nil
+		}
+		if pidFileAlive(session.PidFile) {
+			return StateRunning, nil
+		}
+		if recentlySpawned(session) {
+			return StateSpawning, nil
+		}
+		return StateStopped, nil
+	default:
+		return deriveContainerState(ctx, session)
+	}
+}
+
+func deriveContainerState(ctx context.Context, session *sessions.Session) (WorkerState, error) {
+	output, err := dockerCommander.CommandContext(ctx, "docker", "inspect", "--format", "{{.State.Status}}", session.Container).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && strings.Contains(string(exitErr.Stderr), "No such") {
+			if recentlySpawned(session) {
+				return StateSpawning, nil
+			}
+			return StateStopped, nil
+		}
+		return "", classifyDockerError(err)
+	}
+
+	switch strings.TrimSpace(string(output)) {
+	case "created", "restarting":
+		return StateSpawning, nil
+	case "running", "paused":
+		return agentState(ctx, session.Container), nil
+	default:
+		return StateStopped, nil
+	}
+}
+
+// agentState distinguishes a running agent from an idle container. pgrep exits
+// 1 when nothing matches; any other failure leaves the worker Running.
+func agentState(ctx context.Context, container string) WorkerState {
+	err := dockerCommander.CommandContext(ctx, "docker", "exec", container, "pgrep", "-f", agentProcessPattern).Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return StateIdle
+	}
+	return StateRunning
+}
+
+func recentlySpawned(session *sessions.Session) bool {
+	return !session.SpawnedAt.IsZero() && time.Since(session.SpawnedAt) < DefaultReadyTimeout
+}
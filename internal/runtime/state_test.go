@@ -0,0 +1,115 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wellmaintained/yak-box/internal/sessions"
+)
+
+// routeCommander runs a shell snippet chosen by the docker subcommand (args[0]).
+type routeCommander map[string]string
+
+func (r routeCommander) CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	script := "exit 0"
+	if len(args) > 0 {
+		if s, ok := r[args[0]]; ok {
+			script = s
+		}
+	}
+	return exec.CommandContext(ctx, "sh", "-c", script)
+}
+
+func TestDeriveWorkerState_Sandboxed(t *testing.T) {
+	tests := []struct {
+		name    string
+		route   routeCommander
+		session sessions.Session
+		want    WorkerState
+	}{
+		{
+			name:    "running agent",
+			route:   routeCommander{"inspect": "echo running", "exec": "exit 0"},
+			session: sessions.Session{Runtime: "sandboxed", Container: "yak-worker-a"},
+			want:    StateRunning,
+		},
+		{
+			name:    "idle container",
+			route:   routeCommander{"inspect": "echo running", "exec": "exit 1"},
+			session: sessions.Session{Runtime: "sandboxed", Container: "yak-worker-a"},
+			want:    StateIdle,
+		},
+		{
+			name:    "created container",
+			route:   routeCommander{"inspect": "echo created"},
+			session: sessions.Session{Runtime: "sandboxed", Container: "yak-worker-a"},
+			want:    StateSpawning,
+		},
+		{
+			name:    "exited container",
+			route:   routeCommander{"inspect": "echo exited"},
+			session: sessions.Session{Runtime: "sandboxed", Container: "yak-worker-a"},
+			want:    StateStopped,
+		},
+		{
+			name:    "missing container just after spawn",
+			route:   routeCommander{"inspect": "echo 'Error: No such object: yak-worker-a' >&2; exit 1"},
+			session: sessions.Session{Runtime: "sandboxed", Container: "yak-worker-a", SpawnedAt: time.Now()},
+			want:    StateSpawning,
+		},
+		{
+			name:    "missing container long after spawn",
+			route:   routeCommander{"inspect": "echo 'Error: No such object: yak-worker-a' >&2; exit 1"},
+			session: sessions.Session{Runtime: "sandboxed", Container: "yak-worker-a", SpawnedAt: time.Now().Add(-time.Hour)},
+			want:    StateStopped,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withDockerCommander(t, tt.route)
+			got, err := DeriveWorkerState(context.Background(), &tt.session)
+			if err != nil {
+				t.Fatalf("DeriveWorkerState() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DeriveWorkerState() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeriveWorkerState_DaemonDown(t *testing.T) {
+	withDockerCommander(t, routeCommander{"inspect": "echo 'Cannot connect to the Docker daemon' >&2; exit 1"})
+	_, err := DeriveWorkerState(context.Background(), &sessions.Session{Runtime: "sandboxed", Container: "yak-worker-a"})
+	if err == nil {
+		t.Fatal("expected an error when the daemon is unreachable")
+	}
+}
+
+func TestDeriveWorkerState_Native(t *testing.T) {
+	tmpDir := t.TempDir()
+	pidFile := filepath.Join(tmpDir, "worker.pid")
+	os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", os.Getpid())), 0644)
+
+	state, _ := DeriveWorkerState(context.Background(), &sessions.Session{Runtime: "native", PidFile: pidFile})
+	if state != StateRunning {
+		t.Errorf("live native worker = %s, want Running", state)
+	}
+
+	missing := filepath.Join(tmpDir, "missing.pid")
+	state, _ = DeriveWorkerState(context.Background(), &sessions.Session{Runtime: "native", PidFile: missing, SpawnedAt: time.Now().Add(-time.Hour)})
+	if state != StateStopped {
+		t.Errorf("dead native worker = %s, want Stopped", state)
+	}
+
+	state, _ = DeriveWorkerState(context.Background(), nil)
+	if state != StateStopped || !state.IsTerminal() {
+		t.Errorf("nil session = %s, want terminal Stopped", state)
+	}
+}
@@ -0,0 +1,83 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+
+	"github.com/wellmaintained/yak-box/internal/env"
+)
+
+var (
+	// verbose makes every command started through this package echo first.
+	verbose atomic.Bool
+	// traceOutput receives echoed commands; tests replace it.
+	traceOutput io.Writer = os.Stderr
+)
+
+// SetVerbose makes the runtime echo each docker/zellij command to stderr
+// before running it, in the style of `set -x`. It covers functions that
+// take no SpawnOptions, such as StopSandboxedWorker and SpawnNativeWorker.
+func SetVerbose(v bool) {
+	verbose.Store(v)
+}
+
+// traceCommand writes name and args to traceOutput as a shell command line.
+// Values of sensitive-looking KEY=VALUE arguments are masked.
+func traceCommand(name string, args []string) {
+	quoted := make([]string, 0, len(args)+1)
+	quoted = append(quoted, shellQuote(name))
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(maskSensitiveArg(arg)))
+	}
+	fmt.Fprintf(traceOutput, "+ %s\n", strings.Join(quoted, " "))
+}
+
+// maskSensitiveArg replaces the value of a KEY=VALUE argument with *** when
+// KEY looks like a secret.
+func maskSensitiveArg(arg string) string {
+	key, value, ok := strings.Cut(arg, "=")
+	if !ok || value == "" || strings.HasPrefix(key, "-") || !env.IsSensitive(key) {
+		return arg
+	}
+	return key + "=***"
+}
+
+// tracingCommander echoes each command before delegating to the wrapped
+// Commander, so that commands sent to a caller-supplied Commander are
+// traced too.
+type tracingCommander struct {
+	Commander
+}
+
+func (c *tracingCommander) CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	traceCommand(name, args)
+	return c.Commander.CommandContext(ctx, name, args...)
+}
+
+// withTracing returns commander set up to echo its commands.
+func withTracing(commander Commander) Commander {
+	switch c := commander.(type) {
+	case *defaultCommander:
+		return &defaultCommander{verbose: true}
+	case *tracingCommander:
+		return c
+	default:
+		return &tracingCommander{Commander: commander}
+	}
+}
+
+// newCommand is exec.Command for helpers without a Commander. It runs
+// through hostCommander, so the command is echoed when SetVerbose is on.
+func newCommand(name string, args ...string) *exec.Cmd {
+	return hostCommander.CommandContext(context.Background(), name, args...)
+}
+
+// newDockerCommand is newCommand for the docker CLI.
+func newDockerCommand(args ...string) *exec.Cmd {
+	return dockerCommander.CommandContext(context.Background(), "docker", args...)
+}
@@ -0,0 +1,105 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/wellmaintained/yak-box/pkg/types"
+)
+
+func captureTrace(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	orig := traceOutput
+	traceOutput = &buf
+	t.Cleanup(func() { traceOutput = orig })
+	return &buf
+}
+
+func TestSpawnSandboxedWorker_VerboseTracesCommands(t *testing.T) {
+	tmpDir := t.TempDir()
+	worker := &types.Worker{
+		Name:        "test-worker",
+		DisplayName: "Test Worker",
+		CWD:         tmpDir,
+		YakPath:     "/test/yak",
+		SessionName: "test-session",
+		WorkerName:  "TestBot",
+	}
+
+	run := func(verbose bool) (*TestCommander, string) {
+		buf := captureTrace(t)
+		cmdr := &TestCommander{}
+		_ = SpawnSandboxedWorker(
+			context.Background(),
+			WithWorker(worker),
+			WithHomeDir(tmpDir),
+			WithCommander(cmdr),
+			WithVerbose(verbose),
+		)
+		return cmdr, buf.String()
+	}
+
+	cmdr, trace := run(false)
+	if len(cmdr.calls) == 0 {
+		t.Fatal("expected the commander to be called")
+	}
+	if trace != "" {
+		t.Errorf("expected no trace without verbose, got %q", trace)
+	}
+
+	cmdr, trace = run(true)
+	if len(cmdr.calls) == 0 {
+		t.Fatal("expected the tracing commander to delegate to the intercepted commander")
+	}
+	expected := "+ zellij --session test-session action new-tab --layout " + tmpDir + "/scripts/layout.kdl --name 'Test Worker'\n"
+	if !strings.Contains(trace, expected) {
+		t.Errorf("trace missing zellij command:\nexpected %q\ngot %q", expected, trace)
+	}
+}
+
+func TestDefaultCommanderSetVerbose(t *testing.T) {
+	buf := captureTrace(t)
+	t.Cleanup(func() { SetVerbose(false) })
+
+	_ = newCommand("docker", "ps", "-a")
+	if buf.Len() != 0 {
+		t.Errorf("expected no trace by default, got %q", buf.String())
+	}
+
+	SetVerbose(true)
+	_ = newCommand("docker", "stop", "-t", "30", "yak-worker-a b")
+	if got := buf.String(); got != "+ docker stop -t 30 'yak-worker-a b'\n" {
+		t.Errorf("trace = %q", got)
+	}
+}
+
+func TestWithTracing(t *testing.T) {
+	if c, ok := withTracing(&defaultCommander{}).(*defaultCommander); !ok || !c.verbose {
+		t.Error("withTracing should turn on verbose for the default commander")
+	}
+	traced := withTracing(&TestCommander{})
+	if _, ok := traced.(*tracingCommander); !ok {
+		t.Fatal("withTracing should wrap other commanders")
+	}
+	if withTracing(traced) != traced {
+		t.Error("withTracing should not wrap a commander twice")
+	}
+}
+
+func TestTraceCommandMasksSensitiveValues(t *testing.T) {
+	buf := captureTrace(t)
+	traceCommand("docker", []string{"run", "-e", "OPENCODE_API_KEY=sk-super-secret", "-e", "LOG_LEVEL=debug", "-e", "OPENCODE_API_KEY"})
+
+	trace := buf.String()
+	if strings.Contains(trace, "sk-super-secret") {
+		t.Errorf("trace leaked a credential: %q", trace)
+	}
+	for _, want := range []string{"OPENCODE_API_KEY=***", "LOG_LEVEL=debug", "-e OPENCODE_API_KEY\n"} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("trace %q missing %q", trace, want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package runtime
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// zellijTabAttempts bounds how often newZellijTab runs `zellij action new-tab`.
+// Zellij rejects actions briefly while it is switching sessions, so a failed
+// attempt is retried after zellijRetryDelay.
+const zellijTabAttempts = 3
+
+// zellijRetryDelay is a variable so tests can avoid sleeping.
+var zellijRetryDelay = 500 * time.Millisecond
+
+// kdlString quotes s as a KDL string for a layout file. Backslashes, quotes
+// and control characters are escaped, so a display name or path can never
+// end the string or the line early.
+func kdlString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if unicode.IsControl(r) {
+				fmt.Fprintf(&sb, `\u{%x}`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// tabName returns a worker's display name as a single-line Zellij tab name,
+// replacing control characters such as newlines with spaces.
+func tabName(displayName string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, displayName))
+}
+
+// newZellijTab opens a Zellij tab with `zellij action new-tab args...`,
+// targeting sessionName when it is set. A missing zellij binary is not
+// retried. The final error says whether yak-box is outside a Zellij session
+// or the zellij command itself failed.
+func newZellijTab(ctx context.Context, commander Commander, sessionName string, args ...string) error {
+	var zellijArgs []string
+	if sessionName != "" {
+		zellijArgs = append(zellijArgs, "--session", sessionName)
+	}
+	zellijArgs = append(zellijArgs, "action", "new-tab")
+	zellijArgs = append(zellijArgs, args...)
+
+	var err error
+	var output []byte
+	for attempt := 1; attempt <= zellijTabAttempts; attempt++ {
+		output, err = commander.CommandContext(ctx, "zellij", zellijArgs...).CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		if goerrors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("failed to create Zellij tab: %w. Suggestion: Install Zellij and run yak-box inside a Zellij session", err)
+		}
+		if attempt == zellijTabAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to create Zellij tab: %w", ctx.Err())
+		case <-time.After(zellijRetryDelay):
+		}
+	}
+
+	if sessionName == "" && os.Getenv("ZELLIJ_SESSION_NAME") == "" {
+		return fmt.Errorf("failed to create Zellij tab: not in a Zellij session (%w). Suggestion: Run yak-box inside Zellij or pass --session <name>", err)
+	}
+	detail := strings.TrimSpace(string(output))
+	if detail != "" {
+		return fmt.Errorf("failed to create Zellij tab: zellij command failed after %d attempts: %w (output: %s)", zellijTabAttempts, err, detail)
+	}
+	return fmt.Errorf("failed to create Zellij tab: zellij command failed after %d attempts: %w", zellijTabAttempts, err)
+}
@@ -0,0 +1,89 @@
+package runtime
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// flakyCommander fails the first failures commands it builds, then succeeds.
+type flakyCommander struct {
+	failures int
+	calls    [][]string
+}
+
+func (fc *flakyCommander) CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	fc.calls = append(fc.calls, append([]string{name}, args...))
+	if len(fc.calls) <= fc.failures {
+		return exec.CommandContext(ctx, "sh", "-c", "echo 'session is switching' >&2; exit 1")
+	}
+	return exec.CommandContext(ctx, "true")
+}
+
+func withoutZellijRetryDelay(t *testing.T) {
+	t.Helper()
+	orig := zellijRetryDelay
+	zellijRetryDelay = 0
+	t.Cleanup(func() { zellijRetryDelay = orig })
+}
+
+func TestNewZellijTab_RetriesTransientFailure(t *testing.T) {
+	withoutZellijRetryDelay(t)
+
+	cmdr := &flakyCommander{failures: zellijTabAttempts - 1}
+	if err := newZellijTab(context.Background(), cmdr, "dev", "--name", "Worker"); err != nil {
+		t.Fatalf("newZellijTab() error = %v", err)
+	}
+	if len(cmdr.calls) != zellijTabAttempts {
+		t.Errorf("Expected %d attempts, got %d", zellijTabAttempts, len(cmdr.calls))
+	}
+	want := "zellij --session dev action new-tab --name Worker"
+	if got := strings.Join(cmdr.calls[0], " "); got != want {
+		t.Errorf("Command = %q, want %q", got, want)
+	}
+}
+
+func TestNewZellijTab_CommandFailed(t *testing.T) {
+	withoutZellijRetryDelay(t)
+
+	cmdr := &flakyCommander{failures: zellijTabAttempts}
+	err := newZellijTab(context.Background(), cmdr, "dev", "--name", "Worker")
+	if err == nil {
+		t.Fatal("Expected error after exhausting attempts")
+	}
+	if !strings.Contains(err.Error(), "zellij command failed after 3 attempts") {
+		t.Errorf("Wrong error message: %v", err)
+	}
+	if !strings.Contains(err.Error(), "session is switching") {
+		t.Errorf("Expected zellij output in error: %v", err)
+	}
+}
+
+func TestNewZellijTab_NotInSession(t *testing.T) {
+	withoutZellijRetryDelay(t)
+	t.Setenv("ZELLIJ_SESSION_NAME", "")
+
+	cmdr := &flakyCommander{failures: zellijTabAttempts}
+	err := newZellijTab(context.Background(), cmdr, "", "--name", "Worker")
+	if err == nil {
+		t.Fatal("Expected error outside a Zellij session")
+	}
+	if !strings.Contains(err.Error(), "not in a Zellij session") {
+		t.Errorf("Wrong error message: %v", err)
+	}
+}
+
+func TestNewZellijTab_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cmdr := &flakyCommander{failures: zellijTabAttempts}
+	err := newZellijTab(ctx, cmdr, "dev", "--name", "Worker")
+	if err == nil {
+		t.Fatal("Expected error when context is cancelled")
+	}
+	if len(cmdr.calls) != 1 {
+		t.Errorf("Expected a single attempt after cancellation, got %d", len(cmdr.calls))
+	}
+}
@@ -0,0 +1,105 @@
+package sessions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const bindingsFile = "bindings.json"
+
+// Bindings maps a task path to the persona pinned to it
+type Bindings map[string]string
+
+func getBindingsPath() (string, error) {
+	root, err := getRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, yakBoxesDir, bindingsFile), nil
+}
+
+func normalizeTask(task string) string {
+	return strings.Trim(strings.TrimSpace(task), "/")
+}
+
+// LoadBindings loads task→persona bindings from .yak-boxes/bindings.json
+func LoadBindings() (Bindings, error) {
+	sessionsMu.RLock()
+	defer sessionsMu.RUnlock()
+	return loadBindingsUnlocked()
+}
+
+func loadBindingsUnlocked() (Bindings, error) {
+	path, err := getBindingsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return make(Bindings), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bindings file: %w", err)
+	}
+
+	var bindings Bindings
+	if err := json.Unmarshal(data, &bindings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal bindings: %w", err)
+	}
+	if bindings == nil {
+		bindings = make(Bindings)
+	}
+	return bindings, nil
+}
+
+// GetBinding returns the persona pinned to task, or "" if the task is unbound
+func GetBinding(task string) (string, error) {
+	bindings, err := LoadBindings()
+	if err != nil {
+		return "", err
+	}
+	return bindings[normalizeTask(task)], nil
+}
+
+// Bind pins persona to task so later spawns for the task reuse it
+func Bind(task, persona string) error {
+	task = normalizeTask(task)
+	if task == "" || persona == "" {
+		return fmt.Errorf("task and persona are required to create a binding")
+	}
+
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	bindings, err := loadBindingsUnlocked()
+	if err != nil {
+		return err
+	}
+	bindings[task] = persona
+
+	if err := ensureYakBoxesDir(); err != nil {
+		return fmt.Errorf("failed to ensure yak-boxes dir: %w", err)
+	}
+	path, err := getBindingsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(bindings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bindings: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write bindings file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to finalize bindings file: %w", err)
+	}
+	return nil
+}
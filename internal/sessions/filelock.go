@@ -0,0 +1,25 @@
+package sessions
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// withFileLock runs fn while holding an exclusive flock on lockPath, creating
+// the lock file if needed. The lock serialises read-modify-write cycles on
+// shared state across yak-box processes, which sessionsMu alone cannot do.
+func withFileLock(lockPath string, fn func() error) error {
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer f.Close()
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", lockPath, err)
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	return fn()
+}
@@ -1,10 +1,15 @@
 package sessions
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 // OpenCodeSession represents a session returned by `opencode session list --format json`.
@@ -15,6 +20,10 @@ type OpenCodeSession struct {
 	Created   int64  `json:"created"`
 	ProjectID string `json:"projectId"`
 	Directory string `json:"directory"`
+
+	// Archived is set for sessions read from an export file of a worker
+	// whose container has stopped; they can no longer receive messages.
+	Archived bool `json:"-"`
 }
 
 // MessageResult holds the result of sending a message to a worker.
@@ -23,40 +32,160 @@ type MessageResult struct {
 	ExitCode int
 }
 
+// ErrOpenCodeNotFound means the opencode CLI is not installed where the
+// worker runs: on the host PATH for native workers, or inside the container
+// for sandboxed ones.
+var ErrOpenCodeNotFound = errors.New("opencode CLI not found")
+
+// ClassifyOpenCodeError returns an error wrapping ErrOpenCodeNotFound when
+// err and output from running opencode (directly or via docker exec) show the
+// binary is missing, and err unchanged otherwise. A missing docker binary or
+// container is not reported as a missing opencode.
+func ClassifyOpenCodeError(err error, output []byte) error {
+	if err == nil {
+		return nil
+	}
+
+	var execErr *exec.Error
+	if errors.As(err, &execErr) {
+		if execErr.Name == "opencode" && errors.Is(execErr.Err, exec.ErrNotFound) {
+			return fmt.Errorf("%w on PATH: %v", ErrOpenCodeNotFound, err)
+		}
+		return err
+	}
+
+	// docker exec exits 126/127 when it cannot start the requested binary
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && (exitErr.ExitCode() == 126 || exitErr.ExitCode() == 127) {
+		out := string(output)
+		if strings.Contains(out, "opencode") && strings.Contains(out, "not found") {
+			return fmt.Errorf("%w in container: %s", ErrOpenCodeNotFound, strings.TrimSpace(out))
+		}
+	}
+	return err
+}
+
+// execWaitDelay bounds how long Run waits for output pipes to close after the
+// process has been killed on cancellation.
+const execWaitDelay = 2 * time.Second
+
 // CommandRunner abstracts command execution for testability.
 type CommandRunner interface {
-	Run(name string, args ...string) ([]byte, error)
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
 }
 
 // ExecRunner is the real command runner using os/exec.
 type ExecRunner struct{}
 
-// Run executes a command and returns combined output.
-func (r *ExecRunner) Run(name string, args ...string) ([]byte, error) {
-	cmd := exec.Command(name, args...)
-	return cmd.CombinedOutput()
+// Run executes a command and returns combined output. The process is killed
+// when ctx is cancelled, in which case the context error is returned.
+func (r *ExecRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = execWaitDelay
+	output, err := cmd.CombinedOutput()
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return output, ctxErr
+	}
+	return output, err
 }
 
 // DiscoverOpenCodeSessions finds OpenCode sessions for a worker.
 // For Docker workers, it exec's into the container.
 // For native workers, it runs opencode locally with the worker's CWD.
-func DiscoverOpenCodeSessions(runner CommandRunner, session *Session) ([]OpenCodeSession, error) {
+func DiscoverOpenCodeSessions(ctx context.Context, runner CommandRunner, session *Session) ([]OpenCodeSession, error) {
 	var output []byte
 	var err error
 
 	if session.Runtime == "sandboxed" {
-		output, err = runner.Run("docker", "exec", session.Container, "opencode", "session", "list", "--format", "json")
+		output, err = runner.Run(ctx, "docker", "exec", session.Container, "opencode", "session", "list", "--format", "json")
 	} else {
-		output, err = runner.Run("opencode", "session", "list", "--format", "json", "--dir", session.CWD)
+		output, err = runner.Run(ctx, "opencode", "session", "list", "--format", "json", "--dir", session.CWD)
 	}
 
 	if err != nil {
+		if classified := ClassifyOpenCodeError(err, output); errors.Is(classified, ErrOpenCodeNotFound) {
+			return nil, classified
+		}
+		if session.Runtime == "sandboxed" && containerNotRunning(output) {
+			if archived := archivedSessionsFor(session); len(archived) > 0 {
+				return archived, nil
+			}
+		}
 		return nil, fmt.Errorf("failed to list opencode sessions: %w\nOutput: %s", err, string(output))
 	}
 
 	return ParseOpenCodeSessions(output)
 }
 
+// containerNotRunning reports whether docker exec output says the target
+// container is stopped or gone.
+func containerNotRunning(output []byte) bool {
+	out := string(output)
+	return strings.Contains(out, "is not running") || strings.Contains(out, "No such container")
+}
+
+// archivedSessionsFor returns the exported sessions of session's worker, or
+// nil if there are none or they cannot be read.
+func archivedSessionsFor(session *Session) []OpenCodeSession {
+	costsDir, err := GetWorkerCostsDir()
+	if err != nil {
+		return nil
+	}
+	archived, err := DiscoverArchivedSessions(costsDir, session.Worker)
+	if err != nil {
+		return nil
+	}
+	return archived
+}
+
+// openCodeExport is the part of `opencode export` output that identifies the
+// exported session.
+type openCodeExport struct {
+	Info struct {
+		ID        string `json:"id"`
+		Title     string `json:"title"`
+		ProjectID string `json:"projectID"`
+		Directory string `json:"directory"`
+		Time      struct {
+			Created int64 `json:"created"`
+			Updated int64 `json:"updated"`
+		} `json:"time"`
+	} `json:"info"`
+}
+
+// DiscoverArchivedSessions reads the OpenCode sessions that worker exported
+// to costsDir (as <worker>-<timestamp>.json) when its container exited. The
+// returned sessions are marked Archived. A missing costsDir yields no
+// sessions; unreadable or malformed exports are skipped.
+func DiscoverArchivedSessions(costsDir, worker string) ([]OpenCodeSession, error) {
+	matches, err := filepath.Glob(filepath.Join(costsDir, worker+"-*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session exports: %w", err)
+	}
+
+	var archived []OpenCodeSession
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var export openCodeExport
+		if err := json.Unmarshal(data, &export); err != nil || export.Info.ID == "" {
+			continue
+		}
+		archived = append(archived, OpenCodeSession{
+			ID:        export.Info.ID,
+			Title:     export.Info.Title,
+			Created:   export.Info.Time.Created,
+			Updated:   export.Info.Time.Updated,
+			ProjectID: export.Info.ProjectID,
+			Directory: export.Info.Directory,
+			Archived:  true,
+		})
+	}
+	return archived, nil
+}
+
 // ParseOpenCodeSessions parses the JSON output from `opencode session list --format json`.
 func ParseOpenCodeSessions(data []byte) ([]OpenCodeSession, error) {
 	// Trim any non-JSON prefix (e.g., RTK plugin messages)
@@ -94,7 +223,7 @@ func FindMostRecentSession(sessions []OpenCodeSession) *OpenCodeSession {
 // SendMessage sends a message to a worker's OpenCode session.
 // For Docker workers, it exec's into the container.
 // For native workers, it runs opencode locally with --dir pointing to the worker's CWD.
-func SendMessage(runner CommandRunner, session *Session, openCodeSessionID string, message string, format string) (*MessageResult, error) {
+func SendMessage(ctx context.Context, runner CommandRunner, session *Session, openCodeSessionID string, message string, format string) (*MessageResult, error) {
 	baseCmd := "opencode"
 	var args []string
 
@@ -112,7 +241,7 @@ func SendMessage(runner CommandRunner, session *Session, openCodeSessionID strin
 	}
 	args = append(args, message)
 
-	output, err := runner.Run(baseCmd, args...)
+	output, err := runner.Run(ctx, baseCmd, args...)
 
 	result := &MessageResult{
 		Output:   string(output),
@@ -120,6 +249,9 @@ func SendMessage(runner CommandRunner, session *Session, openCodeSessionID strin
 	}
 
 	if err != nil {
+		if classified := ClassifyOpenCodeError(err, output); errors.Is(classified, ErrOpenCodeNotFound) {
+			return result, classified
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			result.ExitCode = exitErr.ExitCode()
 		} else {
@@ -1,8 +1,17 @@
 package sessions
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -19,7 +28,7 @@ type mockCall struct {
 	args []string
 }
 
-func (m *mockRunner) Run(name string, args ...string) ([]byte, error) {
+func (m *mockRunner) Run(_ context.Context, name string, args ...string) ([]byte, error) {
 	m.calls = append(m.calls, mockCall{name: name, args: args})
 	return m.output, m.err
 }
@@ -220,7 +229,7 @@ func TestDiscoverOpenCodeSessions(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			runner := &mockRunner{output: tt.mockOutput, err: tt.mockErr}
-			sessions, err := DiscoverOpenCodeSessions(runner, tt.session)
+			sessions, err := DiscoverOpenCodeSessions(context.Background(), runner, tt.session)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -233,11 +242,89 @@ func TestDiscoverOpenCodeSessions(t *testing.T) {
 	}
 }
 
+const sampleOpenCodeExport = `{
+  "info": {
+    "id": "ses_archived2",
+    "title": "Fix login",
+    "projectID": "proj1",
+    "directory": "/home/yakob/yak-box",
+    "time": {"created": 1700000000000, "updated": 1700000500000}
+  },
+  "messages": [{"info": {"id": "msg_1", "role": "user"}, "parts": []}]
+}`
+
+func TestDiscoverArchivedSessions(t *testing.T) {
+	dir := t.TempDir()
+	older := strings.NewReplacer("ses_archived2", "ses_archived1", "1700000500000", "1600000000000").Replace(sampleOpenCodeExport)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Yakov-20260101T000000Z.json"), []byte(older), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Yakov-20260102T000000Z.json"), []byte(sampleOpenCodeExport), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Yakov-20260102T000000Z.stats.txt"), []byte("stats"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Yakov-20260103T000000Z.json"), []byte("truncated {"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Yakira-20260102T000000Z.json"), []byte(sampleOpenCodeExport), 0644))
+
+	archived, err := DiscoverArchivedSessions(dir, "Yakov")
+	require.NoError(t, err)
+	require.Len(t, archived, 2, "malformed exports and other workers are skipped")
+
+	most := FindMostRecentSession(archived)
+	require.NotNil(t, most)
+	assert.Equal(t, OpenCodeSession{
+		ID:        "ses_archived2",
+		Title:     "Fix login",
+		Created:   1700000000000,
+		Updated:   1700000500000,
+		ProjectID: "proj1",
+		Directory: "/home/yakob/yak-box",
+		Archived:  true,
+	}, *most)
+
+	none, err := DiscoverArchivedSessions(filepath.Join(dir, "missing"), "Yakov")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestDiscoverOpenCodeSessionsFallsBackToArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, initTestGitRepo(tmpDir))
+	originalWD, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { os.Chdir(originalWD) })
+	require.NoError(t, os.Chdir(tmpDir))
+
+	costsDir := filepath.Join(tmpDir, ".worker-costs")
+	require.NoError(t, os.MkdirAll(costsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(costsDir, "Yakov-20260102T000000Z.json"), []byte(sampleOpenCodeExport), 0644))
+
+	stopped := []byte("Error response from daemon: container 3f2a is not running")
+	session := &Session{Worker: "Yakov", Runtime: "sandboxed", Container: "yak-worker-api"}
+
+	t.Run("stopped container uses export", func(t *testing.T) {
+		runner := &mockRunner{output: stopped, err: fmt.Errorf("exit status 1")}
+		found, err := DiscoverOpenCodeSessions(context.Background(), runner, session)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "ses_archived2", found[0].ID)
+		assert.True(t, found[0].Archived)
+	})
+
+	t.Run("no export keeps the live error", func(t *testing.T) {
+		runner := &mockRunner{output: stopped, err: fmt.Errorf("exit status 1")}
+		_, err := DiscoverOpenCodeSessions(context.Background(), runner, &Session{Worker: "Yakira", Runtime: "sandboxed", Container: "yak-worker-docs"})
+		assert.ErrorContains(t, err, "failed to list opencode sessions")
+	})
+
+	t.Run("other failures do not fall back", func(t *testing.T) {
+		runner := &mockRunner{output: []byte("permission denied"), err: fmt.Errorf("exit status 1")}
+		_, err := DiscoverOpenCodeSessions(context.Background(), runner, session)
+		assert.Error(t, err)
+	})
+}
+
 func TestDiscoverOpenCodeSessionsDockerArgs(t *testing.T) {
 	runner := &mockRunner{output: []byte("[]")}
 	session := &Session{Runtime: "sandboxed", Container: "yak-worker-api"}
 
-	_, err := DiscoverOpenCodeSessions(runner, session)
+	_, err := DiscoverOpenCodeSessions(context.Background(), runner, session)
 	require.NoError(t, err)
 	require.Len(t, runner.calls, 1)
 
@@ -250,7 +337,7 @@ func TestDiscoverOpenCodeSessionsNativeArgs(t *testing.T) {
 	runner := &mockRunner{output: []byte("[]")}
 	session := &Session{Runtime: "native", CWD: "/home/user/project"}
 
-	_, err := DiscoverOpenCodeSessions(runner, session)
+	_, err := DiscoverOpenCodeSessions(context.Background(), runner, session)
 	require.NoError(t, err)
 	require.Len(t, runner.calls, 1)
 
@@ -312,7 +399,7 @@ func TestSendMessage(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			runner := &mockRunner{output: tt.mockOutput, err: tt.mockErr}
-			result, err := SendMessage(runner, tt.session, tt.sessionID, tt.message, tt.format)
+			result, err := SendMessage(context.Background(), runner, tt.session, tt.sessionID, tt.message, tt.format)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -330,7 +417,7 @@ func TestSendMessageDockerArgs(t *testing.T) {
 	runner := &mockRunner{output: []byte("ok")}
 	session := &Session{Runtime: "sandboxed", Container: "yak-worker-api"}
 
-	_, err := SendMessage(runner, session, "ses_1", "hello", "json")
+	_, err := SendMessage(context.Background(), runner, session, "ses_1", "hello", "json")
 	require.NoError(t, err)
 	require.Len(t, runner.calls, 1)
 
@@ -343,7 +430,7 @@ func TestSendMessageNativeArgs(t *testing.T) {
 	runner := &mockRunner{output: []byte("ok")}
 	session := &Session{Runtime: "native", CWD: "/home/user/project"}
 
-	_, err := SendMessage(runner, session, "ses_2", "do work", "")
+	_, err := SendMessage(context.Background(), runner, session, "ses_2", "do work", "")
 	require.NoError(t, err)
 	require.Len(t, runner.calls, 1)
 
@@ -356,7 +443,7 @@ func TestSendMessageNativeWithFormatArgs(t *testing.T) {
 	runner := &mockRunner{output: []byte("ok")}
 	session := &Session{Runtime: "native", CWD: "/home/user/project"}
 
-	_, err := SendMessage(runner, session, "ses_2", "do work", "json")
+	_, err := SendMessage(context.Background(), runner, session, "ses_2", "do work", "json")
 	require.NoError(t, err)
 	require.Len(t, runner.calls, 1)
 
@@ -369,7 +456,7 @@ func TestSendMessageNativeEmptyCWDOmitsDir(t *testing.T) {
 	runner := &mockRunner{output: []byte("ok")}
 	session := &Session{Runtime: "native", CWD: ""}
 
-	_, err := SendMessage(runner, session, "ses_5", "test", "")
+	_, err := SendMessage(context.Background(), runner, session, "ses_5", "test", "")
 	require.NoError(t, err)
 	require.Len(t, runner.calls, 1)
 
@@ -383,10 +470,116 @@ func TestSendMessageDefaultFormatOmitted(t *testing.T) {
 	runner := &mockRunner{output: []byte("ok")}
 	session := &Session{Runtime: "native", CWD: "/tmp"}
 
-	_, err := SendMessage(runner, session, "ses_3", "test", "default")
+	_, err := SendMessage(context.Background(), runner, session, "ses_3", "test", "default")
 	require.NoError(t, err)
 	require.Len(t, runner.calls, 1)
 
 	call := runner.calls[0]
 	assert.Equal(t, []string{"run", "--session", "ses_3", "--dir", "/tmp", "test"}, call.args)
 }
+
+func TestExecRunnerKillsProcessOnCancel(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := (&ExecRunner{}).Run(ctx, "sh", "-c", fmt.Sprintf("echo $$ > %s; exec sleep 30", pidFile))
+		done <- err
+	}()
+
+	var pid int
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(pidFile)
+		if err != nil {
+			return false
+		}
+		pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+
+	assert.Error(t, syscall.Kill(pid, 0), "process %d should have been killed", pid)
+}
+
+func TestExecRunnerReturnsCommandError(t *testing.T) {
+	output, err := (&ExecRunner{}).Run(context.Background(), "sh", "-c", "echo boom; exit 3")
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, exitErr.ExitCode())
+	assert.Equal(t, "boom\n", string(output))
+}
+
+func TestSendMessageCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	session := &Session{Runtime: "native", CWD: t.TempDir()}
+
+	_, err := SendMessage(ctx, &ExecRunner{}, session, "ses_1", "hello", "")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// exitError runs a shell that exits with code so tests get a real *exec.ExitError.
+func exitError(t *testing.T, code int) error {
+	t.Helper()
+	err := exec.Command("sh", "-c", "exit "+strconv.Itoa(code)).Run()
+	require.Error(t, err)
+	return err
+}
+
+func TestClassifyOpenCodeError(t *testing.T) {
+	containerMissingBinary := []byte(`OCI runtime exec failed: exec failed: unable to start container process: exec: "opencode": executable file not found in $PATH: unknown`)
+
+	tests := []struct {
+		name        string
+		err         error
+		output      []byte
+		wantMissing bool
+	}{
+		{name: "native binary not on PATH", err: &exec.Error{Name: "opencode", Err: exec.ErrNotFound}, wantMissing: true},
+		{name: "docker not on PATH", err: &exec.Error{Name: "docker", Err: exec.ErrNotFound}},
+		{name: "missing in container (126)", err: exitError(t, 126), output: containerMissingBinary, wantMissing: true},
+		{name: "missing in container (127)", err: exitError(t, 127), output: containerMissingBinary, wantMissing: true},
+		{name: "container does not exist", err: exitError(t, 1), output: []byte("Error response from daemon: No such container: yak-worker-x")},
+		{name: "opencode itself failed", err: exitError(t, 127), output: []byte("model unavailable")},
+		{name: "other error", err: fmt.Errorf("boom")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifyOpenCodeError(tt.err, tt.output)
+			assert.Equal(t, tt.wantMissing, errors.Is(got, ErrOpenCodeNotFound), "classified: %v", got)
+			if !tt.wantMissing {
+				assert.Equal(t, tt.err, got)
+			}
+		})
+	}
+
+	assert.NoError(t, ClassifyOpenCodeError(nil, nil))
+}
+
+func TestOpenCodeMissingSurfacesFromRunner(t *testing.T) {
+	native := &Session{Runtime: "native", CWD: "/work"}
+	runner := &mockRunner{err: &exec.Error{Name: "opencode", Err: exec.ErrNotFound}}
+
+	_, err := DiscoverOpenCodeSessions(context.Background(), runner, native)
+	assert.ErrorIs(t, err, ErrOpenCodeNotFound)
+
+	_, err = SendMessage(context.Background(), runner, native, "ses_1", "hi", "")
+	assert.ErrorIs(t, err, ErrOpenCodeNotFound)
+
+	sandboxed := &Session{Runtime: "sandboxed", Container: "yak-worker-x"}
+	runner = &mockRunner{
+		err:    exitError(t, 127),
+		output: []byte(`exec: "opencode": executable file not found in $PATH`),
+	}
+	_, err = SendMessage(context.Background(), runner, sandboxed, "ses_1", "hi", "")
+	assert.ErrorIs(t, err, ErrOpenCodeNotFound)
+}
@@ -0,0 +1,131 @@
+package sessions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/wellmaintained/yak-box/pkg/types"
+)
+
+// LastPersonaFile stores the round-robin index of the next persona, relative to .yak-boxes
+const LastPersonaFile = ".last-persona"
+
+// PersonasFile lists custom persona names, one per line, relative to .yak-boxes
+const PersonasFile = "personas.txt"
+
+// LoadWorkerNames returns the persona pool: the names in
+// .yak-boxes/personas.txt when that file exists and lists any, otherwise
+// types.WorkerNames. Blank lines and # comments are ignored and duplicates are
+// dropped. Names must be usable as a home directory name.
+func LoadWorkerNames() ([]string, error) {
+	dir, err := GetYakBoxesDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, PersonasFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return types.WorkerNames, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for i, line := range strings.Split(string(data), "\n") {
+		name := strings.TrimSpace(line)
+		if name == "" || strings.HasPrefix(name, "#") || seen[name] {
+			continue
+		}
+		if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return nil, fmt.Errorf("%s:%d: invalid persona name %q", path, i+1, name)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return types.WorkerNames, nil
+	}
+	return names, nil
+}
+
+// nextPersonaIndex reads the round-robin index from path. A missing or
+// invalid state file starts from the first persona, as does an index left
+// over from a longer persona list.
+func nextPersonaIndex(path string, n int) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	idx, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	if idx < 0 || idx >= n {
+		return 0
+	}
+	return idx
+}
+
+func lastPersonaPath() (string, error) {
+	dir, err := GetYakBoxesDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, LastPersonaFile), nil
+}
+
+// PeekNextPersona returns the persona the next round-robin spawn will use
+// without advancing the state in .yak-boxes/.last-persona.
+func PeekNextPersona() (string, error) {
+	names, err := LoadWorkerNames()
+	if err != nil {
+		return "", err
+	}
+	n := len(names)
+	if n == 0 {
+		return "", fmt.Errorf("no personas configured")
+	}
+	path, err := lastPersonaPath()
+	if err != nil {
+		return "", err
+	}
+	return names[nextPersonaIndex(path, n)], nil
+}
+
+// NextPersona returns the next round-robin persona and advances the state so
+// consecutive spawns get different personas. The state is updated under a
+// lock on .last-persona.lock, so parallel spawns (even from separate
+// processes) get distinct personas within a cycle.
+func NextPersona() (string, error) {
+	names, err := LoadWorkerNames()
+	if err != nil {
+		return "", err
+	}
+	n := len(names)
+	if n == 0 {
+		return "", fmt.Errorf("no personas configured")
+	}
+	path, err := lastPersonaPath()
+	if err != nil {
+		return "", err
+	}
+
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	var idx int
+	var writeErr error
+	if err := withFileLock(path+".lock", func() error {
+		idx = nextPersonaIndex(path, n)
+		writeErr = os.WriteFile(path, []byte(strconv.Itoa((idx+1)%n)), 0644)
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	if writeErr != nil {
+		return names[idx], fmt.Errorf("failed to advance persona state: %w", writeErr)
+	}
+	return names[idx], nil
+}
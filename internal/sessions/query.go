@@ -0,0 +1,77 @@
+package sessions
+
+import (
+	"sort"
+	"time"
+)
+
+// Predicate selects sessions for Find and Filter
+type Predicate func(id string, s Session) bool
+
+// Find loads sessions.json and returns the sessions for which pred is true.
+// A nil pred matches every session.
+func Find(pred Predicate) (Sessions, error) {
+	sessions, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return sessions.Filter(pred), nil
+}
+
+// Filter returns the sessions for which pred is true. A nil pred matches
+// every session.
+func (s Sessions) Filter(pred Predicate) Sessions {
+	matched := make(Sessions, len(s))
+	for id, session := range s {
+		if pred == nil || pred(id, session) {
+			matched[id] = session
+		}
+	}
+	return matched
+}
+
+// IDs returns the session IDs in sorted order
+func (s Sessions) IDs() []string {
+	ids := make([]string, 0, len(s))
+	for id := range s {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// And matches sessions that satisfy every one of preds
+func And(preds ...Predicate) Predicate {
+	return func(id string, s Session) bool {
+		for _, pred := range preds {
+			if !pred(id, s) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// ByRuntime matches sessions running in the given runtime ("sandboxed" or "native")
+func ByRuntime(runtime string) Predicate {
+	return func(_ string, s Session) bool { return s.Runtime == runtime }
+}
+
+// ByTool matches sessions spawned with the given --tool. Sessions registered
+// before the tool was recorded have an empty Tool and match only "".
+func ByTool(tool string) Predicate {
+	return func(_ string, s Session) bool { return s.Tool == tool }
+}
+
+// ByLabel matches sessions that carry the label key, whatever its value
+func ByLabel(key string) Predicate {
+	return func(_ string, s Session) bool {
+		_, ok := s.Labels[key]
+		return ok
+	}
+}
+
+// Expired matches sessions whose TTL elapsed before now
+func Expired(now time.Time) Predicate {
+	return func(_ string, s Session) bool { return s.IsExpired(now) }
+}
@@ -0,0 +1,71 @@
+package sessions
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFilterCombinedPredicates(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	all := Sessions{
+		"api":    {Runtime: "sandboxed", Tool: "opencode", Labels: map[string]string{"team": "auth"}},
+		"web":    {Runtime: "sandboxed", Tool: "claude"},
+		"docs":   {Runtime: "native", Tool: "opencode", Labels: map[string]string{"team": ""}},
+		"old":    {Runtime: "sandboxed", Labels: map[string]string{"team": "auth"}, ExpiresAt: now.Add(-time.Hour)},
+		"legacy": {Runtime: "native"},
+	}
+
+	tests := []struct {
+		name string
+		pred Predicate
+		want []string
+	}{
+		{"nil matches all", nil, []string{"api", "docs", "legacy", "old", "web"}},
+		{"by runtime", ByRuntime("native"), []string{"docs", "legacy"}},
+		{"by tool", ByTool("opencode"), []string{"api", "docs"}},
+		{"empty label value still present", ByLabel("team"), []string{"api", "docs", "old"}},
+		{"sandboxed and labelled", And(ByRuntime("sandboxed"), ByLabel("team")), []string{"api", "old"}},
+		{"sandboxed, labelled and expired", And(ByRuntime("sandboxed"), ByLabel("team"), Expired(now)), []string{"old"}},
+		{"no match", And(ByRuntime("native"), ByTool("cursor")), []string{}},
+		{"and of nothing matches all", And(), []string{"api", "docs", "legacy", "old", "web"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := all.Filter(tt.pred).IDs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter().IDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	if err := Save(Sessions{
+		"api": {Worker: "Yakov", Runtime: "sandboxed", Labels: map[string]string{"team": "auth"}},
+		"web": {Worker: "Yakira", Runtime: "native"},
+	}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	found, err := Find(And(ByRuntime("sandboxed"), ByLabel("team")))
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if len(found) != 1 || found["api"].Worker != "Yakov" || found["api"].Labels["team"] != "auth" {
+		t.Errorf("Find() = %v, expected only api with its labels", found)
+	}
+}
@@ -0,0 +1,120 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// replyPollInterval is how often WaitForReply re-reads the session. A
+// variable so tests can avoid sleeping.
+var replyPollInterval = 2 * time.Second
+
+// ErrReplyTimeout is returned by WaitForReply when no new assistant message
+// arrives before the timeout.
+var ErrReplyTimeout = errors.New("timed out waiting for a reply")
+
+// AssistantMessage is a completed assistant message in an OpenCode session.
+type AssistantMessage struct {
+	ID   string
+	Text string
+}
+
+// openCodeSessionExport is the message list of `opencode export` output.
+type openCodeSessionExport struct {
+	Messages []struct {
+		Info struct {
+			ID   string `json:"id"`
+			Role string `json:"role"`
+			Time struct {
+				Completed int64 `json:"completed"`
+			} `json:"time"`
+		} `json:"info"`
+		Parts []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"parts"`
+	} `json:"messages"`
+}
+
+// LastAssistantMessage returns the most recent completed assistant message in
+// the worker's OpenCode session, read with `opencode export`, or nil if the
+// session has none yet.
+func LastAssistantMessage(ctx context.Context, runner CommandRunner, session *Session, openCodeSessionID string) (*AssistantMessage, error) {
+	var output []byte
+	var err error
+	if session.Runtime == "sandboxed" {
+		output, err = runner.Run(ctx, "docker", "exec", session.Container, "opencode", "export", openCodeSessionID)
+	} else {
+		output, err = runner.Run(ctx, "opencode", "export", openCodeSessionID)
+	}
+	if err != nil {
+		if classified := ClassifyOpenCodeError(err, output); errors.Is(classified, ErrOpenCodeNotFound) {
+			return nil, classified
+		}
+		return nil, fmt.Errorf("failed to export opencode session: %w\nOutput: %s", err, string(output))
+	}
+	return parseLastAssistantMessage(output)
+}
+
+// parseLastAssistantMessage finds the last completed assistant message in
+// `opencode export` output, joining its text parts.
+func parseLastAssistantMessage(data []byte) (*AssistantMessage, error) {
+	// Trim any non-JSON prefix, as in ParseOpenCodeSessions
+	trimmed := strings.TrimSpace(string(data))
+	startIdx := strings.Index(trimmed, "{")
+	if startIdx == -1 {
+		return nil, fmt.Errorf("no JSON object found in export output: %s", trimmed)
+	}
+
+	var export openCodeSessionExport
+	if err := json.Unmarshal([]byte(trimmed[startIdx:]), &export); err != nil {
+		return nil, fmt.Errorf("failed to parse opencode export: %w", err)
+	}
+
+	for i := len(export.Messages) - 1; i >= 0; i-- {
+		msg := export.Messages[i]
+		if msg.Info.Role != "assistant" || msg.Info.Time.Completed == 0 {
+			continue
+		}
+		var texts []string
+		for _, part := range msg.Parts {
+			if part.Type == "text" && part.Text != "" {
+				texts = append(texts, part.Text)
+			}
+		}
+		return &AssistantMessage{ID: msg.Info.ID, Text: strings.Join(texts, "\n")}, nil
+	}
+	return nil, nil
+}
+
+// WaitForReply polls the worker's OpenCode session until its last completed
+// assistant message is no longer the one with ID afterID, then returns it.
+// An empty afterID accepts any assistant message. It returns ErrReplyTimeout
+// once timeout passes, and the context error if ctx is cancelled.
+func WaitForReply(ctx context.Context, runner CommandRunner, session *Session, openCodeSessionID, afterID string, timeout time.Duration) (*AssistantMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		msg, err := LastAssistantMessage(ctx, runner, session, openCodeSessionID)
+		if ctx.Err() == nil && err != nil {
+			return nil, err
+		}
+		if msg != nil && msg.ID != afterID {
+			return msg, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%w after %s", ErrReplyTimeout, timeout)
+			}
+			return nil, ctx.Err()
+		case <-time.After(replyPollInterval):
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sequenceRunner returns outputs in order, repeating the last one.
+type sequenceRunner struct {
+	outputs []string
+	calls   []mockCall
+}
+
+func (s *sequenceRunner) Run(_ context.Context, name string, args ...string) ([]byte, error) {
+	s.calls = append(s.calls, mockCall{name: name, args: args})
+	i := min(len(s.calls), len(s.outputs)) - 1
+	return []byte(s.outputs[i]), nil
+}
+
+func exportWith(messages ...string) string {
+	out := `{"info": {"id": "ses_1"}, "messages": [`
+	for i, m := range messages {
+		if i > 0 {
+			out += ","
+		}
+		out += m
+	}
+	return out + "]}"
+}
+
+func assistantMessage(id, text string, completed int64) string {
+	return fmt.Sprintf(`{"info": {"id": %q, "role": "assistant", "time": {"created": 1, "completed": %d}}, "parts": [{"type": "step-start"}, {"type": "text", "text": %q}]}`, id, completed, text)
+}
+
+const userMessage = `{"info": {"id": "msg_user", "role": "user", "time": {"created": 1}}, "parts": [{"type": "text", "text": "hello"}]}`
+
+func withReplyPollInterval(t *testing.T, d time.Duration) {
+	t.Helper()
+	orig := replyPollInterval
+	replyPollInterval = d
+	t.Cleanup(func() { replyPollInterval = orig })
+}
+
+func TestLastAssistantMessage(t *testing.T) {
+	runner := &sequenceRunner{outputs: []string{"Exporting session: ses_1\n" + exportWith(
+		assistantMessage("msg_1", "first", 10),
+		userMessage,
+		assistantMessage("msg_2", "second", 20),
+		assistantMessage("msg_3", "still typing", 0),
+	)}}
+	session := &Session{Runtime: "sandboxed", Container: "yak-worker-api"}
+
+	msg, err := LastAssistantMessage(context.Background(), runner, session, "ses_1")
+	require.NoError(t, err)
+	assert.Equal(t, &AssistantMessage{ID: "msg_2", Text: "second"}, msg)
+	assert.Equal(t, "docker", runner.calls[0].name)
+	assert.Equal(t, []string{"exec", "yak-worker-api", "opencode", "export", "ses_1"}, runner.calls[0].args)
+
+	runner = &sequenceRunner{outputs: []string{exportWith(userMessage)}}
+	msg, err = LastAssistantMessage(context.Background(), runner, &Session{Runtime: "native"}, "ses_1")
+	require.NoError(t, err)
+	assert.Nil(t, msg)
+	assert.Equal(t, []string{"export", "ses_1"}, runner.calls[0].args)
+}
+
+func TestWaitForReply(t *testing.T) {
+	withReplyPollInterval(t, time.Millisecond)
+
+	old := assistantMessage("msg_1", "earlier answer", 10)
+	runner := &sequenceRunner{outputs: []string{
+		exportWith(old, userMessage),
+		exportWith(old, userMessage),
+		exportWith(old, userMessage, assistantMessage("msg_2", "the reply", 30)),
+	}}
+
+	msg, err := WaitForReply(context.Background(), runner, &Session{Runtime: "native"}, "ses_1", "msg_1", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, &AssistantMessage{ID: "msg_2", Text: "the reply"}, msg)
+	assert.Len(t, runner.calls, 3)
+}
+
+func TestWaitForReplyTimeout(t *testing.T) {
+	withReplyPollInterval(t, time.Millisecond)
+
+	runner := &sequenceRunner{outputs: []string{exportWith(assistantMessage("msg_1", "earlier answer", 10))}}
+
+	_, err := WaitForReply(context.Background(), runner, &Session{Runtime: "native"}, "ses_1", "msg_1", 20*time.Millisecond)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrReplyTimeout), "err = %v", err)
+	assert.Greater(t, len(runner.calls), 1)
+}
+
+func TestWaitForReplyExportFails(t *testing.T) {
+	runner := &mockRunner{output: []byte("Error: session not found"), err: errors.New("exit status 1")}
+
+	_, err := WaitForReply(context.Background(), runner, &Session{Runtime: "native"}, "ses_1", "", time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to export opencode session")
+}
@@ -6,61 +6,81 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
-	"strings"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/wellmaintained/yak-box/internal/workspace"
 )
 
 const (
-	yakBoxesDir  = ".yak-boxes"
-	sessionsFile = "sessions.json"
-	homeDir      = "@home"
+	yakBoxesDir    = ".yak-boxes"
+	sessionsFile   = "sessions.json"
+	homeDir        = "@home"
+	workerCostsDir = ".worker-costs"
 )
 
 var (
 	ErrSessionNotFound = fmt.Errorf("session not found")
 	sessionsMu         sync.RWMutex
+
+	// recoverCorrupt makes loading a corrupt sessions.json back it up and
+	// start from no sessions instead of failing. See SetRecoverCorrupt.
+	recoverCorrupt = true
 )
 
+// SetRecoverCorrupt controls what happens when sessions.json cannot be
+// parsed. When on (the default), the file is moved aside to
+// sessions.json.corrupt.<timestamp> with a warning on stderr and loading
+// continues with no sessions; when off, loading fails.
+func SetRecoverCorrupt(v bool) {
+	recoverCorrupt = v
+}
+
 // Session represents an active worker session
 type Session struct {
-	Worker        string    `json:"worker"`
-	Task          string    `json:"task,omitempty"`
-	Container     string    `json:"container,omitempty"`
-	SpawnedAt     time.Time `json:"spawned_at"`
-	Runtime       string    `json:"runtime"`
-	CWD           string    `json:"cwd"`
-	WorkerName    string    `json:"worker_name,omitempty"`
-	DisplayName   string    `json:"display_name"`
-	ZellijSession string    `json:"zellij_session,omitempty"`
-	PidFile       string    `json:"pid_file,omitempty"`
+	Worker        string            `json:"worker"`
+	Task          string            `json:"task,omitempty"`
+	Container     string            `json:"container,omitempty"`
+	SpawnedAt     time.Time         `json:"spawned_at"`
+	Runtime       string            `json:"runtime"`
+	CWD           string            `json:"cwd"`
+	WorkerName    string            `json:"worker_name,omitempty"`
+	DisplayName   string            `json:"display_name"`
+	ZellijSession string            `json:"zellij_session,omitempty"`
+	PidFile       string            `json:"pid_file,omitempty"`
+	ExpiresAt     time.Time         `json:"expires_at,omitzero"`
+	WorktreePath  string            `json:"worktree_path,omitempty"`
+	ProjectPath   string            `json:"project_path,omitempty"`
+	Tool          string            `json:"tool,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Detached      bool              `json:"detached,omitempty"`
+}
+
+// UsesOpenCode reports whether the worker runs OpenCode, the only tool whose
+// sessions, messages and stats yak-box can reach. Sessions registered before
+// the tool was recorded are assumed to.
+func (s Session) UsesOpenCode() bool {
+	return s.Tool == "" || s.Tool == "opencode"
+}
+
+// IsExpired reports whether the session has a TTL that elapsed before now
+func (s Session) IsExpired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
 }
 
 // Sessions is the map of active sessions keyed by session ID
 type Sessions map[string]Session
 
-func getRoot() (string, error) {
-	dir, err := os.Getwd()
-	if err != nil {
-		return "", err
-	}
-	for {
-		gitPath := filepath.Join(dir, ".git")
-		if _, err := os.Stat(gitPath); err == nil {
-			if runtime.GOOS == "darwin" && strings.HasPrefix(dir, "/private/var/") {
-				return strings.TrimPrefix(dir, "/private"), nil
-			}
-			return dir, nil
-		}
+// Expired returns the sorted IDs of sessions whose TTL elapsed before now
+func (s Sessions) Expired(now time.Time) []string {
+	return s.Filter(Expired(now)).IDs()
+}
 
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			break
-		}
-		dir = parent
-	}
-	return "", fmt.Errorf("not a git repository")
+// getRoot returns the workspace root that holds .yak-boxes. It uses the same
+// resolution as the sandboxed runtime so both agree on where the workspace is.
+func getRoot() (string, error) {
+	return workspace.FindRoot()
 }
 
 func ensureYakBoxesDir() error {
@@ -102,6 +122,16 @@ func GetYakBoxesDir() (string, error) {
 	return filepath.Join(root, yakBoxesDir), nil
 }
 
+// GetWorkerCostsDir returns the .worker-costs directory, where sandboxed
+// workers export their OpenCode sessions and stats on exit.
+func GetWorkerCostsDir() (string, error) {
+	root, err := getRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, workerCostsDir), nil
+}
+
 // Load loads sessions from sessions.json
 func Load() (Sessions, error) {
 	sessionsMu.RLock()
@@ -125,12 +155,27 @@ func loadUnlocked() (Sessions, error) {
 
 	var sessions Sessions
 	if err := json.Unmarshal(data, &sessions); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal sessions: %w", err)
+		if !recoverCorrupt {
+			return nil, fmt.Errorf("failed to unmarshal sessions: %w", err)
+		}
+		return recoverCorruptFile(path, err)
 	}
 
 	return sessions, nil
 }
 
+// recoverCorruptFile moves the unparseable sessions file at path aside and
+// returns an empty Sessions, so yak-box stays usable. Running workers can
+// still be found through Docker and Zellij.
+func recoverCorruptFile(path string, parseErr error) (Sessions, error) {
+	backup := fmt.Sprintf("%s.corrupt.%s", path, time.Now().UTC().Format("20060102T150405Z"))
+	if err := os.Rename(path, backup); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to unmarshal sessions: %w (and could not back it up: %v)", parseErr, err)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s is corrupt (%v); moved it to %s and continuing with no sessions. Use --no-recover to fail instead.\n", path, parseErr, backup)
+	return make(Sessions), nil
+}
+
 // Save saves sessions to sessions.json
 func Save(sessions Sessions) error {
 	sessionsMu.Lock()
@@ -167,30 +212,101 @@ func saveUnlocked(sessions Sessions) error {
 
 // Register adds a new session to sessions.json
 func Register(sessionID string, session Session) error {
-	sessionsMu.Lock()
-	defer sessionsMu.Unlock()
-
-	sessions, err := loadUnlocked()
-	if err != nil {
-		return err
-	}
+	return withSessionsLock(func() error {
+		sessions, err := loadUnlocked()
+		if err != nil {
+			return err
+		}
 
-	sessions[sessionID] = session
-	return saveUnlocked(sessions)
+		sessions[sessionID] = session
+		return saveUnlocked(sessions)
+	})
 }
 
 // Unregister removes a session from sessions.json
 func Unregister(sessionID string) error {
-	sessionsMu.Lock()
-	defer sessionsMu.Unlock()
+	return withSessionsLock(func() error {
+		sessions, err := loadUnlocked()
+		if err != nil {
+			return err
+		}
+
+		delete(sessions, sessionID)
+		return saveUnlocked(sessions)
+	})
+}
+
+// Update applies fn to the stored session with the given ID and saves the
+// result. If fn returns an error, nothing is written.
+func Update(sessionID string, fn func(*Session) error) error {
+	return withSessionsLock(func() error {
+		sessions, err := loadUnlocked()
+		if err != nil {
+			return err
+		}
+
+		session, ok := sessions[sessionID]
+		if !ok {
+			return ErrSessionNotFound
+		}
+		if err := fn(&session); err != nil {
+			return err
+		}
+
+		sessions[sessionID] = session
+		return saveUnlocked(sessions)
+	})
+}
 
-	sessions, err := loadUnlocked()
+// Prune removes every session for which alive returns false and returns the
+// removed session IDs in sorted order. sessions.json is only rewritten when
+// something was removed.
+func Prune(alive func(Session) bool) ([]string, error) {
+	var removed []string
+	err := withSessionsLock(func() error {
+		sessions, err := loadUnlocked()
+		if err != nil {
+			return err
+		}
+
+		for id, session := range sessions {
+			if !alive(session) {
+				removed = append(removed, id)
+			}
+		}
+		if len(removed) == 0 {
+			return nil
+		}
+
+		for _, id := range removed {
+			delete(sessions, id)
+		}
+		return saveUnlocked(sessions)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(removed)
+	return removed, nil
+}
+
+// withSessionsLock runs a load-modify-save of sessions.json under both the
+// in-process mutex and the sessions file lock, so concurrent changes from
+// other yak-box processes are not lost.
+func withSessionsLock(fn func() error) error {
+	if err := ensureYakBoxesDir(); err != nil {
+		return fmt.Errorf("failed to ensure yak-boxes dir: %w", err)
+	}
+	path, err := getSessionsPath()
 	if err != nil {
 		return err
 	}
 
-	delete(sessions, sessionID)
-	return saveUnlocked(sessions)
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	return withFileLock(path+".lock", fn)
 }
 
 // Get returns a session by ID
@@ -210,18 +326,34 @@ func Get(sessionID string) (*Session, error) {
 
 // GetByContainer returns a session by container name
 func GetByContainer(containerName string) (*Session, error) {
+	_, session, err := FindByContainer(containerName)
+	return session, err
+}
+
+// FindByContainer returns the ID and session that own a container
+func FindByContainer(containerName string) (string, *Session, error) {
+	return find(func(s Session) bool { return s.Container == containerName })
+}
+
+// FindByTab returns the ID and session whose Zellij tab has the given name
+func FindByTab(tabName string) (string, *Session, error) {
+	return find(func(s Session) bool { return s.DisplayName == tabName })
+}
+
+// find returns the first session, in ID order, for which match is true
+func find(match func(Session) bool) (string, *Session, error) {
 	sessions, err := Load()
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
-	for _, session := range sessions {
-		if session.Container == containerName {
-			return &session, nil
+	for _, id := range sessions.IDs() {
+		if session := sessions[id]; match(session) {
+			return id, &session, nil
 		}
 	}
 
-	return nil, ErrSessionNotFound
+	return "", nil, ErrSessionNotFound
 }
 
 // List returns all active sessions
@@ -244,13 +376,25 @@ func EnsureHomeDir(workerName string) (string, error) {
 		return "", err
 	}
 
-	// Pre-create .local directory structure with correct permissions
-	// to prevent Docker from creating it as root
 	homePath, err := GetHomeDir(workerName)
 	if err != nil {
 		return "", err
 	}
 
+	if err := PrepareHomeDir(homePath); err != nil {
+		return "", err
+	}
+	return homePath, nil
+}
+
+// PrepareHomeDir creates homePath if needed and pre-creates the .local directory
+// structure with correct permissions to prevent Docker from creating it as root.
+// It fails if homePath exists and is not a directory.
+func PrepareHomeDir(homePath string) error {
+	if info, err := os.Stat(homePath); err == nil && !info.IsDir() {
+		return fmt.Errorf("home path %s exists and is not a directory", homePath)
+	}
+
 	localDirs := []string{
 		filepath.Join(homePath, ".local"),
 		filepath.Join(homePath, ".local", "share"),
@@ -262,11 +406,11 @@ func EnsureHomeDir(workerName string) (string, error) {
 
 	for _, dir := range localDirs {
 		if err := os.MkdirAll(dir, 0755); err != nil {
-			return "", fmt.Errorf("failed to create %s: %w", dir, err)
+			return fmt.Errorf("failed to create %s: %w", dir, err)
 		}
 	}
 
-	return homePath, nil
+	return nil
 }
 
 // CleanHome removes a worker's persistent home directory
@@ -279,6 +423,32 @@ func CleanHome(workerName string) error {
 	return os.RemoveAll(dir)
 }
 
+// DirSize returns the total size in bytes of all regular files under path.
+// Unreadable entries are skipped; a missing path has size 0.
+func DirSize(path string) (int64, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info != nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// HomeDirSize returns the total size in bytes of a worker's home directory
+func HomeDirSize(workerName string) (int64, error) {
+	homePath, err := GetHomeDir(workerName)
+	if err != nil {
+		return 0, err
+	}
+	return DirSize(homePath)
+}
+
 // ListHomes returns all worker home directories
 func ListHomes() ([]string, error) {
 	root, err := getRoot()
@@ -6,9 +6,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/wellmaintained/yak-box/internal/workspace"
+	"github.com/wellmaintained/yak-box/pkg/types"
 )
 
 func initTestGitRepo(tmpDir string) error {
@@ -72,7 +78,7 @@ func TestLoad(t *testing.T) {
 			expectEmpty: false,
 		},
 		{
-			name: "load malformed JSON file",
+			name: "load malformed JSON file recovers with empty sessions",
 			setupFunc: func(t *testing.T, tmpDir string) {
 				if err := initTestGitRepo(tmpDir); err != nil {
 					t.Fatalf("failed to init test repo: %v", err)
@@ -82,8 +88,8 @@ func TestLoad(t *testing.T) {
 				sessionsPath := filepath.Join(yakBoxesPath, sessionsFile)
 				os.WriteFile(sessionsPath, []byte("invalid json {"), 0644)
 			},
-			expectError: true,
-			expectEmpty: false,
+			expectError: false,
+			expectEmpty: true,
 		},
 	}
 
@@ -1207,6 +1213,34 @@ func TestGetRootError(t *testing.T) {
 	}
 }
 
+func TestGetRootOverrideOutsideGit(t *testing.T) {
+	t.Chdir(t.TempDir())
+	root := t.TempDir()
+	t.Setenv(workspace.RootEnv, root)
+
+	if err := Register("api", Session{Worker: "Yakov", Runtime: "native"}); err != nil {
+		t.Fatalf("Register() with %s outside a git repo error = %v", workspace.RootEnv, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, yakBoxesDir, sessionsFile)); err != nil {
+		t.Errorf("sessions should be saved under the override root: %v", err)
+	}
+}
+
+func TestGetRootOverridePrecedence(t *testing.T) {
+	repo := t.TempDir()
+	if err := initTestGitRepo(repo); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+	t.Chdir(repo)
+	root := t.TempDir()
+	t.Setenv(workspace.RootEnv, root)
+
+	got, err := getRoot()
+	if err != nil || got != root {
+		t.Errorf("getRoot() = (%q, %v), expected the override %q over the git root", got, err, root)
+	}
+}
+
 func TestSaveErrorCreatingDirectory(t *testing.T) {
 	tmpDir := t.TempDir()
 	if err := initTestGitRepo(tmpDir); err != nil {
@@ -1592,12 +1626,60 @@ func TestLoadCorruptedSessionFile(t *testing.T) {
 	os.Chdir(tmpDir)
 	defer os.Chdir(originalWD)
 
+	SetRecoverCorrupt(false)
+	defer SetRecoverCorrupt(true)
+
 	_, err = Load()
 	if err == nil {
 		t.Error("Load() should fail with corrupted JSON")
 	}
 }
 
+func TestLoadRecoversCorruptedSessionFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	yakBoxesPath := filepath.Join(tmpDir, yakBoxesDir)
+	os.MkdirAll(yakBoxesPath, 0755)
+	sessionsPath := filepath.Join(yakBoxesPath, sessionsFile)
+
+	corruptedContent := `{"session1": {invalid json here}`
+	os.WriteFile(sessionsPath, []byte(corruptedContent), 0644)
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	sessions, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, expected recovery", err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("Load() returned %d sessions, expected none", len(sessions))
+	}
+
+	if _, err := os.Stat(sessionsPath); !os.IsNotExist(err) {
+		t.Error("corrupt sessions.json should have been moved aside")
+	}
+	backups, _ := filepath.Glob(sessionsPath + ".corrupt.*")
+	if len(backups) != 1 {
+		t.Fatalf("expected one backup, found %v", backups)
+	}
+	data, _ := os.ReadFile(backups[0])
+	if string(data) != corruptedContent {
+		t.Errorf("backup content = %q, expected the corrupt file", data)
+	}
+
+	if err := Register("s1", Session{Worker: "Yakov"}); err != nil {
+		t.Fatalf("Register() after recovery error = %v", err)
+	}
+}
+
 func TestConcurrentSaveLoadRaceCondition(t *testing.T) {
 	tmpDir := t.TempDir()
 	if err := initTestGitRepo(tmpDir); err != nil {
@@ -1720,3 +1802,720 @@ func TestSessionFilePermissionsIssue(t *testing.T) {
 		t.Logf("Load with restricted permissions succeeded (unexpected)")
 	}
 }
+
+func TestHomeDirSize(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	size, err := HomeDirSize("missing")
+	if err != nil {
+		t.Fatalf("HomeDirSize() error = %v", err)
+	}
+	if size != 0 {
+		t.Errorf("HomeDirSize() for missing home = %d, expected 0", size)
+	}
+
+	homePath, err := EnsureHomeDir("sized")
+	if err != nil {
+		t.Fatalf("EnsureHomeDir() error = %v", err)
+	}
+	os.WriteFile(filepath.Join(homePath, "a.txt"), make([]byte, 100), 0644)
+	os.WriteFile(filepath.Join(homePath, ".cache", "b.bin"), make([]byte, 250), 0644)
+
+	size, err = HomeDirSize("sized")
+	if err != nil {
+		t.Fatalf("HomeDirSize() error = %v", err)
+	}
+	if size != 350 {
+		t.Errorf("HomeDirSize() = %d, expected 350", size)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	initial := Sessions{
+		"alive":        Session{Worker: "Yakov", Runtime: "sandboxed", Container: "yak-worker-alive"},
+		"gone":         Session{Worker: "Yakira", Runtime: "sandboxed", Container: "yak-worker-gone"},
+		"native-stale": Session{Worker: "Yakriel", Runtime: "native", PidFile: "/nonexistent/worker.pid"},
+	}
+	if err := Save(initial); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	var checked []string
+	removed, err := Prune(func(s Session) bool {
+		checked = append(checked, s.Worker)
+		return s.Container == "yak-worker-alive"
+	})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(checked) != 3 {
+		t.Errorf("Prune() checked %d sessions, expected 3", len(checked))
+	}
+	if strings.Join(removed, ",") != "gone,native-stale" {
+		t.Errorf("Prune() removed = %v, expected [gone native-stale]", removed)
+	}
+
+	remaining, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(remaining) != 1 {
+		t.Fatalf("expected 1 remaining session, got %d", len(remaining))
+	}
+	if _, ok := remaining["alive"]; !ok {
+		t.Error("live session was pruned")
+	}
+
+	removed, err = Prune(func(Session) bool { return true })
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(removed) != 0 {
+		t.Errorf("Prune() with all alive removed %v", removed)
+	}
+}
+
+func TestBindings(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	persona, err := GetBinding("auth/api")
+	if err != nil {
+		t.Fatalf("GetBinding() error = %v", err)
+	}
+	if persona != "" {
+		t.Errorf("GetBinding() for unbound task = %q, expected empty", persona)
+	}
+
+	if err := Bind("auth/api/", "Yakov"); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if err := Bind("auth/web", "Yakira"); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+
+	persona, err = GetBinding("auth/api")
+	if err != nil {
+		t.Fatalf("GetBinding() error = %v", err)
+	}
+	if persona != "Yakov" {
+		t.Errorf("GetBinding() = %q, expected Yakov", persona)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, ".yak-boxes", "bindings.json")); err != nil {
+		t.Errorf("bindings.json not written: %v", err)
+	}
+
+	bindings, err := LoadBindings()
+	if err != nil {
+		t.Fatalf("LoadBindings() error = %v", err)
+	}
+	if len(bindings) != 2 {
+		t.Errorf("LoadBindings() returned %d bindings, expected 2", len(bindings))
+	}
+
+	if err := Bind("", "Yakov"); err == nil {
+		t.Error("Bind() with empty task should fail")
+	}
+}
+
+func TestPeekNextPersona(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	statePath := filepath.Join(tmpDir, ".yak-boxes", LastPersonaFile)
+	for i := 0; i < 6; i++ {
+		peeked, err := PeekNextPersona()
+		if err != nil {
+			t.Fatalf("PeekNextPersona() error = %v", err)
+		}
+		before, _ := os.ReadFile(statePath)
+
+		again, err := PeekNextPersona()
+		if err != nil {
+			t.Fatalf("PeekNextPersona() error = %v", err)
+		}
+		after, _ := os.ReadFile(statePath)
+		if again != peeked || string(before) != string(after) {
+			t.Fatalf("PeekNextPersona() advanced the round-robin state")
+		}
+
+		next, err := NextPersona()
+		if err != nil {
+			t.Fatalf("NextPersona() error = %v", err)
+		}
+		if next != peeked {
+			t.Errorf("iteration %d: NextPersona() = %q, PeekNextPersona() = %q", i, next, peeked)
+		}
+	}
+}
+
+func TestPeekNextPersonaInvalidState(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	os.MkdirAll(filepath.Join(tmpDir, ".yak-boxes"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".yak-boxes", LastPersonaFile), []byte("99"), 0644)
+
+	peeked, err := PeekNextPersona()
+	if err != nil {
+		t.Fatalf("PeekNextPersona() error = %v", err)
+	}
+	if peeked != types.WorkerNames[0] {
+		t.Errorf("PeekNextPersona() with out-of-range state = %q, expected %q", peeked, types.WorkerNames[0])
+	}
+}
+
+func TestSessionsExpired(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	all := Sessions{
+		"no-ttl":   Session{Worker: "Yakov"},
+		"past":     Session{Worker: "Yakira", ExpiresAt: now.Add(-time.Minute)},
+		"exact":    Session{Worker: "Yakriel", ExpiresAt: now},
+		"upcoming": Session{Worker: "Yakueline", ExpiresAt: now.Add(time.Hour)},
+	}
+
+	expired := all.Expired(now)
+	if strings.Join(expired, ",") != "exact,past" {
+		t.Errorf("Expired() = %v, expected [exact past]", expired)
+	}
+
+	if len(Sessions{}.Expired(now)) != 0 {
+		t.Error("Expired() on empty sessions should return nothing")
+	}
+}
+
+func TestSessionExpiresAtRoundtrip(t *testing.T) {
+	data, err := json.Marshal(Session{Worker: "Yakov"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if strings.Contains(string(data), "expires_at") {
+		t.Errorf("sessions without a TTL should omit expires_at: %s", data)
+	}
+
+	expires := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	data, _ = json.Marshal(Session{Worker: "Yakov", ExpiresAt: expires})
+	var decoded Session
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !decoded.ExpiresAt.Equal(expires) {
+		t.Errorf("ExpiresAt = %v, expected %v", decoded.ExpiresAt, expires)
+	}
+}
+
+func TestGetRootMatchesWorkspaceRoot(t *testing.T) {
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	defer os.Chdir(originalWD)
+
+	gitOnly := t.TempDir()
+	marked := t.TempDir()
+	nested := t.TempDir()
+	inner := filepath.Join(nested, "repos", "inner")
+	for _, dir := range []string{gitOnly, marked, nested, inner} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+		if err := initTestGitRepo(dir); err != nil {
+			t.Fatalf("failed to init test repo: %v", err)
+		}
+	}
+	for _, dir := range []string{filepath.Join(marked, yakBoxesDir), filepath.Join(nested, yakBoxesDir), filepath.Join(inner, "pkg")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		cwd  string
+		want string
+	}{
+		{name: "git only", cwd: gitOnly, want: gitOnly},
+		{name: "with .yak-boxes", cwd: marked, want: marked},
+		{name: "nested repo", cwd: filepath.Join(inner, "pkg"), want: inner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.Chdir(tt.cwd); err != nil {
+				t.Fatalf("failed to chdir: %v", err)
+			}
+			root, err := getRoot()
+			if err != nil {
+				t.Fatalf("getRoot() error = %v", err)
+			}
+			wsRoot, err := workspace.FindRoot()
+			if err != nil {
+				t.Fatalf("workspace.FindRoot() error = %v", err)
+			}
+			if root != wsRoot || root != tt.want {
+				t.Errorf("getRoot() = %s, workspace.FindRoot() = %s, expected %s", root, wsRoot, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadWorkerNames(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	personasPath := filepath.Join(tmpDir, ".yak-boxes", PersonasFile)
+
+	t.Run("file absent uses defaults", func(t *testing.T) {
+		names, err := LoadWorkerNames()
+		if err != nil {
+			t.Fatalf("LoadWorkerNames() error = %v", err)
+		}
+		if !reflect.DeepEqual(names, types.WorkerNames) {
+			t.Errorf("LoadWorkerNames() = %v, expected %v", names, types.WorkerNames)
+		}
+	})
+
+	t.Run("file present", func(t *testing.T) {
+		os.MkdirAll(filepath.Dir(personasPath), 0755)
+		os.WriteFile(personasPath, []byte("# team personas\nreviewer\n\n  architect  \nreviewer\ntester\n"), 0644)
+		defer os.Remove(personasPath)
+
+		names, err := LoadWorkerNames()
+		if err != nil {
+			t.Fatalf("LoadWorkerNames() error = %v", err)
+		}
+		expected := []string{"reviewer", "architect", "tester"}
+		if !reflect.DeepEqual(names, expected) {
+			t.Errorf("LoadWorkerNames() = %v, expected %v", names, expected)
+		}
+
+		for i, want := range []string{"reviewer", "architect", "tester", "reviewer"} {
+			got, err := NextPersona()
+			if err != nil {
+				t.Fatalf("NextPersona() error = %v", err)
+			}
+			if got != want {
+				t.Errorf("spawn %d: NextPersona() = %q, expected %q", i, got, want)
+			}
+		}
+	})
+
+	t.Run("invalid name rejected", func(t *testing.T) {
+		os.WriteFile(personasPath, []byte("ok\n../escape\n"), 0644)
+		defer os.Remove(personasPath)
+
+		if _, err := LoadWorkerNames(); err == nil || !strings.Contains(err.Error(), ":2: invalid persona name") {
+			t.Errorf("LoadWorkerNames() error = %v, expected invalid name on line 2", err)
+		}
+	})
+}
+
+func TestNextPersonaListShrunk(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	yakBoxes := filepath.Join(tmpDir, ".yak-boxes")
+	os.MkdirAll(yakBoxes, 0755)
+	os.WriteFile(filepath.Join(yakBoxes, PersonasFile), []byte("a\nb\nc\nd\ne\n"), 0644)
+	for i := 0; i < 4; i++ {
+		if _, err := NextPersona(); err != nil {
+			t.Fatalf("NextPersona() error = %v", err)
+		}
+	}
+
+	// The saved index (4) is out of range once the list shrinks to two names.
+	os.WriteFile(filepath.Join(yakBoxes, PersonasFile), []byte("a\nb\n"), 0644)
+	var got []string
+	for i := 0; i < 3; i++ {
+		name, err := NextPersona()
+		if err != nil {
+			t.Fatalf("NextPersona() error = %v", err)
+		}
+		got = append(got, name)
+	}
+	expected := []string{"a", "b", "a"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("NextPersona() after shrink = %v, expected %v", got, expected)
+	}
+}
+
+func TestWithFileLockSerialisesUpdates(t *testing.T) {
+	dir := t.TempDir()
+	counterPath := filepath.Join(dir, "counter")
+	lockPath := counterPath + ".lock"
+	os.WriteFile(counterPath, []byte("0"), 0644)
+
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- withFileLock(lockPath, func() error {
+				data, err := os.ReadFile(counterPath)
+				if err != nil {
+					return err
+				}
+				n, err := strconv.Atoi(string(data))
+				if err != nil {
+					return err
+				}
+				time.Sleep(time.Millisecond)
+				return os.WriteFile(counterPath, []byte(strconv.Itoa(n+1)), 0644)
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("withFileLock() error = %v", err)
+		}
+	}
+
+	data, _ := os.ReadFile(counterPath)
+	if string(data) != strconv.Itoa(workers) {
+		t.Errorf("counter = %s, expected %d (updates were lost)", data, workers)
+	}
+}
+
+func TestFindByContainerAndTab(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	Register("api-auth", Session{Worker: "Yakov", Container: "yak-worker-api-auth", DisplayName: "Yakov 🪒🦬 api-auth"})
+	Register("docs", Session{Worker: "Yakira", Container: "yak-worker-docs", DisplayName: "Yakira 🪒🦬 docs"})
+
+	id, session, err := FindByContainer("yak-worker-docs")
+	if err != nil || id != "docs" || session.Worker != "Yakira" {
+		t.Errorf("FindByContainer() = %q, %+v, %v; expected docs", id, session, err)
+	}
+
+	id, session, err = FindByTab("Yakov 🪒🦬 api-auth")
+	if err != nil || id != "api-auth" || session.Container != "yak-worker-api-auth" {
+		t.Errorf("FindByTab() = %q, %+v, %v; expected api-auth", id, session, err)
+	}
+
+	if _, _, err := FindByTab("missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("FindByTab() error = %v, expected ErrSessionNotFound", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	if err := Register("worker-1", Session{Worker: "Yakov", Task: "original", Runtime: "sandboxed"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	t.Run("mutates existing session", func(t *testing.T) {
+		err := Update("worker-1", func(s *Session) error {
+			s.DisplayName = "renamed"
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		got, err := Get("worker-1")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if got.DisplayName != "renamed" || got.Task != "original" {
+			t.Errorf("Update() session = %+v, expected only DisplayName changed", got)
+		}
+	})
+
+	t.Run("missing session", func(t *testing.T) {
+		called := false
+		err := Update("nope", func(s *Session) error {
+			called = true
+			return nil
+		})
+		if !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("Update() error = %v, expected ErrSessionNotFound", err)
+		}
+		if called {
+			t.Error("Update() called fn for a missing session")
+		}
+	})
+
+	t.Run("fn error skips save", func(t *testing.T) {
+		fnErr := errors.New("boom")
+		err := Update("worker-1", func(s *Session) error {
+			s.Task = "discarded"
+			return fnErr
+		})
+		if !errors.Is(err, fnErr) {
+			t.Errorf("Update() error = %v, expected %v", err, fnErr)
+		}
+		got, _ := Get("worker-1")
+		if got.Task != "original" {
+			t.Errorf("Task = %q, expected unchanged %q", got.Task, "original")
+		}
+	})
+}
+
+func TestUpdateConcurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	if err := Register("worker-1", Session{Worker: "Yakov", Runtime: "sandboxed"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- Update("worker-1", func(s *Session) error {
+				if s.Task != "" {
+					s.Task += ","
+				}
+				s.Task += strconv.Itoa(i)
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	got, err := Get("worker-1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if n := len(strings.Split(got.Task, ",")); n != workers {
+		t.Errorf("Task has %d entries, expected %d (updates were lost): %q", n, workers, got.Task)
+	}
+}
+
+func TestRegisterUnregisterConcurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	const workers = 20
+	for i := 0; i < workers; i++ {
+		if err := Register("old-"+strconv.Itoa(i), Session{Worker: "Yakov"}); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			errs <- Register("new-"+strconv.Itoa(i), Session{Worker: "Yakira"})
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			errs <- Unregister("old-" + strconv.Itoa(i))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Register()/Unregister() error = %v", err)
+		}
+	}
+
+	got, err := List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(got) != workers {
+		t.Errorf("got %d sessions, expected %d: %v", len(got), workers, got)
+	}
+	for i := 0; i < workers; i++ {
+		if _, ok := got["new-"+strconv.Itoa(i)]; !ok {
+			t.Errorf("session new-%d was lost", i)
+		}
+	}
+}
+
+func TestRegisterWaitsForFileLock(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := initTestGitRepo(tmpDir); err != nil {
+		t.Fatalf("failed to init test repo: %v", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWD)
+
+	if err := Register("first", Session{Worker: "Yakov"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	path, err := getSessionsPath()
+	if err != nil {
+		t.Fatalf("getSessionsPath() error = %v", err)
+	}
+
+	// Stand in for another yak-box process: it holds only the file lock
+	// while it adds a session, so Register must wait for it
+	locked := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- withFileLock(path+".lock", func() error {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var sessions Sessions
+			if err := json.Unmarshal(data, &sessions); err != nil {
+				return err
+			}
+			close(locked)
+			time.Sleep(50 * time.Millisecond)
+			sessions["other-process"] = Session{Worker: "Yakira"}
+			data, err = json.Marshal(sessions)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(path, data, 0644)
+		})
+	}()
+	<-locked
+
+	if err := Register("second", Session{Worker: "Yakob"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("other process error = %v", err)
+	}
+
+	got, err := List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	for _, id := range []string{"first", "second", "other-process"} {
+		if _, ok := got[id]; !ok {
+			t.Errorf("session %q was lost: %v", id, got)
+		}
+	}
+}
+
+func TestSessionUsesOpenCode(t *testing.T) {
+	for tool, want := range map[string]bool{"": true, "opencode": true, "claude": false, "cursor": false} {
+		if got := (Session{Tool: tool}).UsesOpenCode(); got != want {
+			t.Errorf("Session{Tool: %q}.UsesOpenCode() = %v, want %v", tool, got, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wellmaintained/yak-box/internal/pathutil"
+)
+
+// AssignedToFile names the worker a task is assigned to.
+const AssignedToFile = "assigned-to"
+
+// WorktreePathFile records the git worktree the assigned worker uses.
+const WorktreePathFile = "worktree-path"
+
+// Assign records worker as the assignee of the task at taskSlug, a path
+// relative to yakPath, and worktreePath as its worktree when non-empty.
+func Assign(yakPath, taskSlug, worker, worktreePath string) error {
+	taskDir, err := taskDirIn(yakPath, taskSlug)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(taskDir, AssignedToFile), []byte(worker), 0644); err != nil {
+		return err
+	}
+	if worktreePath != "" {
+		if err := os.WriteFile(filepath.Join(taskDir, WorktreePathFile), []byte(worktreePath), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unassign removes the task's assignee. A task that is not assigned is left
+// as it is.
+func Unassign(yakPath, taskSlug string) error {
+	taskDir, err := taskDirIn(yakPath, taskSlug)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(taskDir, AssignedToFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ReadAssignee returns the trimmed contents of the task's assigned-to file.
+// An unassigned task has an empty assignee.
+func ReadAssignee(taskDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(taskDir, AssignedToFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// taskDirIn joins taskSlug onto yakPath, rejecting slugs that name yakPath
+// itself or escape it.
+func taskDirIn(yakPath, taskSlug string) (string, error) {
+	taskDir := filepath.Join(yakPath, taskSlug)
+	if err := pathutil.ValidatePath(taskDir, yakPath); err != nil {
+		return "", fmt.Errorf("invalid task %q: %w", taskSlug, err)
+	}
+	if filepath.Clean(taskDir) == filepath.Clean(yakPath) {
+		return "", fmt.Errorf("invalid task %q: names the yak path itself", taskSlug)
+	}
+	return taskDir, nil
+}
@@ -0,0 +1,87 @@
+package tasks
+
+import (
+	goerrors "errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wellmaintained/yak-box/internal/pathutil"
+)
+
+func TestAssign(t *testing.T) {
+	yakPath := t.TempDir()
+	taskDir := filepath.Join(yakPath, "auth", "api")
+	if err := os.MkdirAll(taskDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Assign(yakPath, filepath.Join("auth", "api"), "Yakov", "/worktrees/auth-api"); err != nil {
+		t.Fatalf("Assign() error = %v", err)
+	}
+	if assignee, err := ReadAssignee(taskDir); err != nil || assignee != "Yakov" {
+		t.Errorf("ReadAssignee() = (%q, %v), expected Yakov", assignee, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(taskDir, WorktreePathFile)); err != nil || string(data) != "/worktrees/auth-api" {
+		t.Errorf("worktree-path = (%q, %v), expected the worktree", data, err)
+	}
+
+	other := filepath.Join(yakPath, "docs")
+	if err := os.Mkdir(other, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Assign(yakPath, "docs", "Yakira", ""); err != nil {
+		t.Fatalf("Assign() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(other, WorktreePathFile)); !os.IsNotExist(err) {
+		t.Error("worktree-path should not be written without a worktree")
+	}
+}
+
+func TestUnassign(t *testing.T) {
+	yakPath := t.TempDir()
+	taskDir := filepath.Join(yakPath, "api")
+	if err := os.Mkdir(taskDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Unassign(yakPath, "api"); err != nil {
+		t.Fatalf("Unassign() of an unassigned task error = %v", err)
+	}
+
+	if err := Assign(yakPath, "api", "Yakov", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := Unassign(yakPath, "api"); err != nil {
+		t.Fatalf("Unassign() error = %v", err)
+	}
+	if assignee, err := ReadAssignee(taskDir); err != nil || assignee != "" {
+		t.Errorf("ReadAssignee() after Unassign = (%q, %v), expected empty", assignee, err)
+	}
+}
+
+func TestAssignRejectsEscapingSlug(t *testing.T) {
+	root := t.TempDir()
+	yakPath := filepath.Join(root, ".yaks")
+	outside := filepath.Join(root, "outside")
+	for _, dir := range []string{yakPath, outside} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, slug := range []string{filepath.Join("..", "outside"), filepath.Join("api", "..", "..", "outside"), "."} {
+		if err := Assign(yakPath, slug, "Yakov", ""); err == nil {
+			t.Errorf("Assign(%q) expected an error", slug)
+		}
+		if err := Unassign(yakPath, slug); err == nil {
+			t.Errorf("Unassign(%q) expected an error", slug)
+		}
+	}
+	if err := Assign(yakPath, filepath.Join("..", "outside"), "Yakov", ""); !goerrors.Is(err, pathutil.ErrPathTraversal) {
+		t.Errorf("Assign() error = %v, expected ErrPathTraversal", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, AssignedToFile)); !os.IsNotExist(err) {
+		t.Error("assigned-to should not be written outside the yak path")
+	}
+}
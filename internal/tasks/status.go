@@ -0,0 +1,79 @@
+// Package tasks reads and writes the per-task state files kept under .yaks.
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// StatusFile is the file in a task directory that workers write their
+// progress to, e.g. "wip", "blocked: waiting on review" or "done".
+const StatusFile = "agent-status"
+
+// States are the task states WriteStatus accepts.
+var States = []string{"wip", "blocked", "done"}
+
+// ReadStatus returns the trimmed contents of the task's agent-status file.
+// A task without one has an empty status.
+func ReadStatus(taskDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(taskDir, StatusFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// State returns the state word a status starts with, lowercased, so that
+// "blocked: waiting on review" and "Blocked" both have state "blocked".
+func State(status string) string {
+	end := strings.IndexFunc(status, func(r rune) bool {
+		return r == ':' || r == ' ' || r == '\t' || r == '\n'
+	})
+	if end >= 0 {
+		status = status[:end]
+	}
+	return strings.ToLower(status)
+}
+
+// FormatStatus builds an agent-status line from a state and optional
+// message, e.g. "blocked: waiting on review".
+func FormatStatus(state, message string) string {
+	state = strings.ToLower(strings.TrimSpace(state))
+	message = strings.Join(strings.Fields(message), " ")
+	if message == "" {
+		return state
+	}
+	return state + ": " + message
+}
+
+// WriteStatus sets the agent-status of the task at taskSlug, a path relative
+// to yakPath. state must be one of States; message may be empty.
+func WriteStatus(yakPath, taskSlug, state, message string) error {
+	if !slices.Contains(States, strings.ToLower(strings.TrimSpace(state))) {
+		return fmt.Errorf("invalid state %q: must be one of %s", state, strings.Join(States, ", "))
+	}
+	taskDir, err := taskDirIn(yakPath, taskSlug)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(taskDir, StatusFile), []byte(FormatStatus(state, message)+"\n"), 0644)
+}
+
+// ClearStatus removes the agent-status of the task at taskSlug. A task
+// without a status is left as it is.
+func ClearStatus(yakPath, taskSlug string) error {
+	taskDir, err := taskDirIn(yakPath, taskSlug)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(taskDir, StatusFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
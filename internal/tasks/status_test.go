@@ -0,0 +1,108 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadStatus(t *testing.T) {
+	taskDir := t.TempDir()
+
+	status, err := ReadStatus(taskDir)
+	if err != nil || status != "" {
+		t.Fatalf("ReadStatus() without a file = (%q, %v), expected empty status", status, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(taskDir, StatusFile), []byte("  blocked: needs review\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	status, err = ReadStatus(taskDir)
+	if err != nil {
+		t.Fatalf("ReadStatus() error = %v", err)
+	}
+	if status != "blocked: needs review" {
+		t.Errorf("ReadStatus() = %q, expected trimmed contents", status)
+	}
+}
+
+func TestState(t *testing.T) {
+	tests := map[string]string{
+		"done":                  "done",
+		"Done":                  "done",
+		"blocked: needs review": "blocked",
+		"wip 3/5 steps":         "wip",
+		"":                      "",
+	}
+	for in, want := range tests {
+		if got := State(in); got != want {
+			t.Errorf("State(%q) = %q, expected %q", in, got, want)
+		}
+	}
+}
+
+func TestWriteStatus(t *testing.T) {
+	yakPath := t.TempDir()
+	taskDir := filepath.Join(yakPath, "auth", "api")
+	if err := os.MkdirAll(taskDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, state := range States {
+		if err := WriteStatus(yakPath, filepath.Join("auth", "api"), state, ""); err != nil {
+			t.Fatalf("WriteStatus(%q) error = %v", state, err)
+		}
+		if status, err := ReadStatus(taskDir); err != nil || status != state {
+			t.Errorf("ReadStatus() after WriteStatus(%q) = (%q, %v)", state, status, err)
+		}
+	}
+
+	if err := WriteStatus(yakPath, filepath.Join("auth", "api"), "Blocked", "  waiting on\nreview "); err != nil {
+		t.Fatalf("WriteStatus() error = %v", err)
+	}
+	if status, _ := ReadStatus(taskDir); status != "blocked: waiting on review" {
+		t.Errorf("ReadStatus() = %q, expected a normalised state and message", status)
+	}
+
+	if err := WriteStatus(yakPath, filepath.Join("auth", "api"), "finished", ""); err == nil {
+		t.Error("WriteStatus() expected an error for an unknown state")
+	}
+	if err := WriteStatus(yakPath, filepath.Join("..", "elsewhere"), "done", ""); err == nil {
+		t.Error("WriteStatus() expected an error for a task outside the yak path")
+	}
+}
+
+func TestFormatStatus(t *testing.T) {
+	tests := map[[2]string]string{
+		{"done", ""}:                  "done",
+		{"WIP", "3/5 steps"}:          "wip: 3/5 steps",
+		{" blocked ", "needs review"}: "blocked: needs review",
+		{"blocked", "   "}:            "blocked",
+	}
+	for in, want := range tests {
+		if got := FormatStatus(in[0], in[1]); got != want {
+			t.Errorf("FormatStatus(%q, %q) = %q, expected %q", in[0], in[1], got, want)
+		}
+	}
+}
+
+func TestClearStatus(t *testing.T) {
+	yakPath := t.TempDir()
+	taskDir := filepath.Join(yakPath, "api")
+	if err := os.Mkdir(taskDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ClearStatus(yakPath, "api"); err != nil {
+		t.Fatalf("ClearStatus() without a status error = %v", err)
+	}
+	if err := WriteStatus(yakPath, "api", "done", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := ClearStatus(yakPath, "api"); err != nil {
+		t.Fatalf("ClearStatus() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(taskDir, StatusFile)); !os.IsNotExist(err) {
+		t.Error("agent-status should be removed")
+	}
+}
@@ -3,31 +3,100 @@
 package ui
 
 import (
+	"io"
 	"os"
+	"sync/atomic"
 
 	"github.com/fatih/color"
 )
 
+// Level controls which messages the print functions emit.
+type Level int32
+
+const (
+	// Quiet suppresses Info and Success messages; warnings and errors are still printed.
+	Quiet Level = iota
+	// Normal prints every message. It is the default.
+	Normal
+)
+
+var (
+	level  atomic.Int32
+	stderr io.Writer = os.Stderr
+)
+
+func init() {
+	level.Store(int32(Normal))
+}
+
+// SetLevel sets the output level for all subsequent messages.
+func SetLevel(l Level) {
+	level.Store(int32(l))
+}
+
+// GetLevel returns the current output level.
+func GetLevel() Level {
+	return Level(level.Load())
+}
+
+// writer returns where a message that is shown at min and above should go.
+func writer(min Level) io.Writer {
+	if GetLevel() < min {
+		return io.Discard
+	}
+	return stderr
+}
+
 // Success prints a green-colored message to stderr.
-// Respects NO_COLOR environment variable and TTY detection.
+// Respects NO_COLOR environment variable and TTY detection. Suppressed in Quiet mode.
 func Success(format string, args ...interface{}) {
-	color.New(color.FgGreen).Fprintf(os.Stderr, format, args...)
+	color.New(color.FgGreen).Fprintf(writer(Normal), format, args...)
 }
 
 // Warning prints a yellow-colored message to stderr.
 // Respects NO_COLOR environment variable and TTY detection.
 func Warning(format string, args ...interface{}) {
-	color.New(color.FgYellow).Fprintf(os.Stderr, format, args...)
+	color.New(color.FgYellow).Fprintf(writer(Quiet), format, args...)
 }
 
 // Error prints a red-colored message to stderr.
 // Respects NO_COLOR environment variable and TTY detection.
 func Error(format string, args ...interface{}) {
-	color.New(color.FgRed).Fprintf(os.Stderr, format, args...)
+	color.New(color.FgRed).Fprintf(writer(Quiet), format, args...)
 }
 
 // Info prints a cyan-colored message to stderr.
-// Respects NO_COLOR environment variable and TTY detection.
+// Respects NO_COLOR environment variable and TTY detection. Suppressed in Quiet mode.
 func Info(format string, args ...interface{}) {
-	color.New(color.FgCyan).Fprintf(os.Stderr, format, args...)
+	color.New(color.FgCyan).Fprintf(writer(Normal), format, args...)
+}
+
+// Stream returns the writer for raw progress output, such as a docker build
+// log, that should follow the same rules as Info. Suppressed in Quiet mode.
+func Stream() io.Writer {
+	return writer(Normal)
+}
+
+// clearSequence moves the cursor to the top left and clears the screen.
+const clearSequence = "\033[H\033[2J"
+
+// isTerminal reports whether w is a terminal. A variable so tests can
+// pretend a buffer is one.
+var isTerminal = func(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ClearScreen clears the terminal behind w before a command redraws its
+// output. It does nothing when w is not a terminal or in Quiet mode, so
+// piped or logged output keeps every frame.
+func ClearScreen(w io.Writer) {
+	if GetLevel() < Normal || !isTerminal(w) {
+		return
+	}
+	_, _ = io.WriteString(w, clearSequence)
 }
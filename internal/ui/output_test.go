@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func captureOutput(t *testing.T, l Level) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	origWriter, origLevel := stderr, GetLevel()
+	stderr = &buf
+	SetLevel(l)
+	t.Cleanup(func() {
+		stderr = origWriter
+		SetLevel(origLevel)
+	})
+	return &buf
+}
+
+func TestNormalLevelPrintsEverything(t *testing.T) {
+	buf := captureOutput(t, Normal)
+
+	Info("info ")
+	Success("success ")
+	Warning("warning ")
+	Error("error")
+
+	if got := buf.String(); got != "info success warning error" {
+		t.Errorf("output = %q, expected every message", got)
+	}
+}
+
+func TestQuietLevelSuppressesDecorativeOutput(t *testing.T) {
+	buf := captureOutput(t, Quiet)
+
+	Info("⏳ Building container...\n")
+	Success("✅ Container ready\n")
+	if buf.Len() != 0 {
+		t.Errorf("Info/Success should be suppressed in quiet mode, got %q", buf.String())
+	}
+
+	Warning("careful\n")
+	Error("failed: %s\n", "boom")
+	if got := buf.String(); got != "careful\nfailed: boom\n" {
+		t.Errorf("output = %q, expected warnings and errors to be kept", got)
+	}
+}
+
+func TestDefaultLevelIsNormal(t *testing.T) {
+	if GetLevel() != Normal {
+		t.Errorf("GetLevel() = %d, expected Normal", GetLevel())
+	}
+}
+
+func TestClearScreen(t *testing.T) {
+	orig := isTerminal
+	t.Cleanup(func() { isTerminal = orig })
+
+	var buf bytes.Buffer
+	ClearScreen(&buf)
+	if buf.Len() != 0 {
+		t.Errorf("ClearScreen should do nothing on a non-terminal, wrote %q", buf.String())
+	}
+
+	isTerminal = func(io.Writer) bool { return true }
+	ClearScreen(&buf)
+	if buf.String() != clearSequence {
+		t.Errorf("ClearScreen wrote %q, expected the clear sequence", buf.String())
+	}
+
+	buf.Reset()
+	captureOutput(t, Quiet)
+	ClearScreen(&buf)
+	if buf.Len() != 0 {
+		t.Errorf("ClearScreen should do nothing in quiet mode, wrote %q", buf.String())
+	}
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrNoSelection is returned by SelectFromList when input ends, or an empty
+// line is entered, before a valid choice is made.
+var ErrNoSelection = errors.New("no selection made")
+
+// SelectFromList writes items to w as a numbered list and reads a choice from
+// r, re-prompting after invalid input. It returns the zero-based index of the
+// chosen item.
+func SelectFromList(w io.Writer, r io.Reader, items []string) (int, error) {
+	if len(items) == 0 {
+		return -1, fmt.Errorf("nothing to select from")
+	}
+
+	for i, item := range items {
+		fmt.Fprintf(w, "  %d) %s\n", i+1, item)
+	}
+
+	scanner := bufio.NewScanner(r)
+	for {
+		fmt.Fprintf(w, "Select [1-%d]: ", len(items))
+		if !scanner.Scan() {
+			fmt.Fprintln(w)
+			if err := scanner.Err(); err != nil {
+				return -1, err
+			}
+			return -1, ErrNoSelection
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			return -1, ErrNoSelection
+		}
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 1 || n > len(items) {
+			fmt.Fprintf(w, "Invalid selection %q\n", line)
+			continue
+		}
+		return n - 1, nil
+	}
+}
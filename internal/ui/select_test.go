@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSelectFromList(t *testing.T) {
+	items := []string{"api-auth", "docs", "frontend"}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    int
+		wantErr error
+	}{
+		{name: "valid choice", input: "2\n", want: 1},
+		{name: "surrounding whitespace", input: "  3 \n", want: 2},
+		{name: "re-prompts after invalid input", input: "0\nfour\n1\n", want: 0},
+		{name: "empty line", input: "\n", want: -1, wantErr: ErrNoSelection},
+		{name: "end of input", input: "", want: -1, wantErr: ErrNoSelection},
+		{name: "end of input after invalid", input: "9\n", want: -1, wantErr: ErrNoSelection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := SelectFromList(&out, strings.NewReader(tt.input), items)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SelectFromList() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SelectFromList() = %d, want %d", got, tt.want)
+			}
+			if !strings.Contains(out.String(), "  2) docs\n") || !strings.Contains(out.String(), "Select [1-3]: ") {
+				t.Errorf("SelectFromList() output missing list or prompt:\n%s", out.String())
+			}
+		})
+	}
+
+	t.Run("reports invalid input", func(t *testing.T) {
+		var out bytes.Buffer
+		SelectFromList(&out, strings.NewReader("7\n1\n"), items)
+		if !strings.Contains(out.String(), `Invalid selection "7"`) {
+			t.Errorf("SelectFromList() output missing invalid notice:\n%s", out.String())
+		}
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		if _, err := SelectFromList(&bytes.Buffer{}, strings.NewReader("1\n"), nil); err == nil {
+			t.Error("SelectFromList() with no items expected error")
+		}
+	})
+}
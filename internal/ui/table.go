@@ -2,37 +2,139 @@
 package ui
 
 import (
-	"fmt"
 	"io"
-	"text/tabwriter"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
 )
 
-// PrintTable prints a formatted table with headers and rows using text/tabwriter.
-// Headers and rows are printed with tab separators for automatic column alignment.
+// columnGap is the number of spaces between table columns.
+const columnGap = 2
+
+// Align is the horizontal alignment of a table column.
+type Align int
+
+const (
+	// AlignLeft pads cells on the right. It is the default.
+	AlignLeft Align = iota
+	// AlignRight pads cells on the left, for numbers and sizes.
+	AlignRight
+)
+
+// Truncate is where an over-long cell is cut to fit Column.MaxWidth.
+type Truncate int
+
+const (
+	// TruncateEnd keeps the start of the value: "Yakov 🪒🦬 api-a…". It is the default.
+	TruncateEnd Truncate = iota
+	// TruncateMiddle keeps both ends, which suits paths: "/home/…/api/.worktree".
+	TruncateMiddle
+)
+
+// Column configures one table column. The zero value is left-aligned with no
+// width limit.
+type Column struct {
+	// MaxWidth caps the cell width in runes, cutting longer values with an
+	// ellipsis. Zero means unlimited.
+	MaxWidth int
+	Align    Align
+	Truncate Truncate
+}
+
+// TableOptions configures PrintTableOpts.
+type TableOptions struct {
+	// Columns holds per-column settings by index; columns past its end use
+	// the zero Column.
+	Columns []Column
+	// Plain disables header styling even on a color terminal, for piping.
+	// Headers are also plain when color is off (NO_COLOR or no TTY).
+	Plain bool
+}
+
+// PrintTable prints a formatted table with headers and rows.
+// Columns are left-aligned, separated by two spaces and never truncated.
 func PrintTable(w io.Writer, headers []string, rows [][]string) error {
-	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	return PrintTableOpts(w, headers, rows, TableOptions{Plain: true})
+}
 
-	// Print headers
+// PrintTableOpts prints a table like PrintTable with per-column maximum
+// widths, truncation and alignment. Unless opts.Plain is set or color is
+// disabled, headers are printed in bold.
+func PrintTableOpts(w io.Writer, headers []string, rows [][]string, opts TableOptions) error {
+	column := func(i int) Column {
+		if i < len(opts.Columns) {
+			return opts.Columns[i]
+		}
+		return Column{}
+	}
+
+	lines := make([][]string, 0, len(rows)+1)
 	if len(headers) > 0 {
-		for i, h := range headers {
-			fmt.Fprint(tw, h)
-			if i < len(headers)-1 {
-				fmt.Fprint(tw, "\t")
+		lines = append(lines, headers)
+	}
+	lines = append(lines, rows...)
+
+	cells := make([][]string, len(lines))
+	var widths []int
+	for i, line := range lines {
+		cells[i] = make([]string, len(line))
+		for j, cell := range line {
+			col := column(j)
+			cell = truncateCell(cell, col.MaxWidth, col.Truncate)
+			cells[i][j] = cell
+			if j >= len(widths) {
+				widths = append(widths, 0)
 			}
+			widths[j] = max(widths[j], utf8.RuneCountInString(cell))
 		}
-		fmt.Fprintln(tw)
 	}
 
-	// Print rows
-	for _, row := range rows {
-		for i, cell := range row {
-			fmt.Fprint(tw, cell)
-			if i < len(row)-1 {
-				fmt.Fprint(tw, "\t")
+	bold := !opts.Plain && !color.NoColor
+	var sb strings.Builder
+	for i, line := range cells {
+		isHeader := i == 0 && len(headers) > 0
+		for j, cell := range line {
+			pad := strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell))
+			if isHeader && bold {
+				cell = color.New(color.Bold).Sprint(cell)
+			}
+			if column(j).Align == AlignRight {
+				sb.WriteString(pad + cell)
+			} else if j < len(line)-1 {
+				sb.WriteString(cell + pad)
+			} else {
+				// No trailing spaces after the last cell
+				sb.WriteString(cell)
+			}
+			if j < len(line)-1 {
+				sb.WriteString(strings.Repeat(" ", columnGap))
 			}
 		}
-		fmt.Fprintln(tw)
+		sb.WriteString("\n")
 	}
 
-	return tw.Flush()
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// truncateCell shortens s to at most maxWidth runes, replacing the removed
+// part with "…". A maxWidth of zero or less leaves s unchanged.
+func truncateCell(s string, maxWidth int, mode Truncate) string {
+	runes := []rune(s)
+	if maxWidth <= 0 || len(runes) <= maxWidth {
+		return s
+	}
+	if maxWidth == 1 {
+		return "…"
+	}
+
+	keep := maxWidth - 1
+	if mode == TruncateMiddle {
+		// Favour the tail: for paths it holds the file or task name
+		head := keep / 2
+		tail := keep - head
+		return string(runes[:head]) + "…" + string(runes[len(runes)-tail:])
+	}
+	return string(runes[:keep]) + "…"
 }
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintTable(t *testing.T) {
+	var buf bytes.Buffer
+	err := PrintTable(&buf, []string{"NAME", "STATUS"}, [][]string{
+		{"api-auth", "running"},
+		{"docs", "stopped"},
+	})
+	if err != nil {
+		t.Fatalf("PrintTable() error = %v", err)
+	}
+
+	want := "NAME      STATUS\n" +
+		"api-auth  running\n" +
+		"docs      stopped\n"
+	if got := buf.String(); got != want {
+		t.Errorf("PrintTable() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPrintTableOptsTruncationAndAlignment(t *testing.T) {
+	var buf bytes.Buffer
+	err := PrintTableOpts(&buf,
+		[]string{"WORKER", "SIZE", "CWD"},
+		[][]string{
+			{"Yakov 🪒🦬 api-authentication", "12.5 MB", "/home/yakov/projects/api/.worktree/auth-api"},
+			{"Yakira", "3 MB", "/srv/docs"},
+		},
+		TableOptions{
+			Plain: true,
+			Columns: []Column{
+				{MaxWidth: 12},
+				{Align: AlignRight},
+				{MaxWidth: 21, Truncate: TruncateMiddle},
+			},
+		})
+	if err != nil {
+		t.Fatalf("PrintTableOpts() error = %v", err)
+	}
+
+	want := "WORKER           SIZE  CWD\n" +
+		"Yakov 🪒🦬 ap…  12.5 MB  /home/yako…e/auth-api\n" +
+		"Yakira           3 MB  /srv/docs\n"
+	if got := buf.String(); got != want {
+		t.Errorf("PrintTableOpts() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTruncateCell(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		mode Truncate
+		want string
+	}{
+		{"short", 10, TruncateEnd, "short"},
+		{"exactly-10", 10, TruncateEnd, "exactly-10"},
+		{"unlimited value", 0, TruncateEnd, "unlimited value"},
+		{"display-name", 8, TruncateEnd, "display…"},
+		{"/a/b/c/d/e/file.go", 11, TruncateMiddle, "/a/b/…le.go"},
+		{"anything", 1, TruncateMiddle, "…"},
+	}
+	for _, tt := range tests {
+		if got := truncateCell(tt.in, tt.max, tt.mode); got != tt.want {
+			t.Errorf("truncateCell(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
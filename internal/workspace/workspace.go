@@ -2,17 +2,119 @@
 package workspace
 
 import (
-	"os/exec"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 )
 
-// FindRoot finds the git repository root directory by running `git rev-parse --show-toplevel`.
-// It returns the root directory path or an error if the command fails.
+// DefaultRootMarker is the directory whose presence marks a workspace root.
+// It is the directory yak-box keeps session state in.
+const DefaultRootMarker = ".yak-boxes"
+
+// RootMarkerEnv overrides DefaultRootMarker. Setting it to an empty string
+// is the same as leaving it unset.
+const RootMarkerEnv = "YAK_BOX_ROOT_MARKER"
+
+// RootMarker returns the configured workspace root marker.
+func RootMarker() string {
+	if marker := strings.TrimSpace(os.Getenv(RootMarkerEnv)); marker != "" {
+		return marker
+	}
+	return DefaultRootMarker
+}
+
+// RootEnv names the workspace root explicitly, for projects that are not
+// git repositories. SetRootOverride (the --root flag) takes precedence.
+const RootEnv = "YAK_BOX_ROOT"
+
+// rootOverride is the --root value; empty means unset.
+var rootOverride string
+
+// SetRootOverride makes FindRoot return dir instead of searching for the
+// root. An empty dir removes the override.
+func SetRootOverride(dir string) {
+	rootOverride = strings.TrimSpace(dir)
+}
+
+// FindRoot finds the workspace root for the current directory: the
+// SetRootOverride directory, else $YAK_BOX_ROOT, else the directory that
+// FindRootFrom finds above the working directory.
 func FindRoot() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	output, err := cmd.Output()
+	if root, ok, err := explicitRoot(); ok {
+		return root, err
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	root, err := FindRootFrom(dir, RootMarker())
+	if err != nil {
+		return "", fmt.Errorf("%w. Suggestion: Pass --root <path> or set %s to use a directory outside a git repository", err, RootEnv)
+	}
+	return root, nil
+}
+
+// explicitRoot returns the root named by SetRootOverride or RootEnv, and
+// whether one was named at all. The directory must exist.
+func explicitRoot() (string, bool, error) {
+	dir, source := rootOverride, "--root"
+	if dir == "" {
+		dir, source = strings.TrimSpace(os.Getenv(RootEnv)), RootEnv
+	}
+	if dir == "" {
+		return "", false, nil
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", true, fmt.Errorf("invalid %s %q: %w", source, dir, err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", true, fmt.Errorf("invalid %s %q: %w", source, dir, err)
+	}
+	if !info.IsDir() {
+		return "", true, fmt.Errorf("invalid %s %q: not a directory", source, dir)
+	}
+	return normalizeRoot(abs), true, nil
+}
+
+// FindRootFrom walks up from dir and returns the nearest directory that
+// contains either marker or .git. This is the git toplevel unless a
+// directory below it has been marked as a workspace root (or the workspace
+// is not a git repository at all). An empty marker only considers .git.
+func FindRootFrom(dir, marker string) (string, error) {
+	dir, err := filepath.Abs(dir)
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(string(output)), nil
+	start := dir
+	for {
+		if marker != "" {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return normalizeRoot(dir), nil
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return normalizeRoot(dir), nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", fmt.Errorf("not a git repository (or any parent up to /) and no %s directory found above %s", marker, start)
+}
+
+// normalizeRoot strips the /private prefix macOS adds to temporary
+// directories so roots compare equal to the paths callers were given.
+func normalizeRoot(dir string) string {
+	if runtime.GOOS == "darwin" && strings.HasPrefix(dir, "/private/var/") {
+		return strings.TrimPrefix(dir, "/private")
+	}
+	return dir
 }
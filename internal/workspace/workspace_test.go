@@ -1,6 +1,9 @@
 package workspace
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -17,3 +20,94 @@ func TestFindRoot(t *testing.T) {
 		t.Errorf("FindRoot() returned non-absolute path: %s", root)
 	}
 }
+
+func mkdirs(t *testing.T, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", p, err)
+		}
+	}
+}
+
+func TestFindRootFrom(t *testing.T) {
+	gitOnly := t.TempDir()
+	mkdirs(t, filepath.Join(gitOnly, ".git"), filepath.Join(gitOnly, "a", "b"))
+
+	marked := t.TempDir()
+	mkdirs(t, filepath.Join(marked, ".git"), filepath.Join(marked, "sub", ".yak-boxes"), filepath.Join(marked, "sub", "deep"))
+
+	nested := t.TempDir()
+	mkdirs(t, filepath.Join(nested, ".git"), filepath.Join(nested, ".yak-boxes"), filepath.Join(nested, "repos", "inner", ".git"), filepath.Join(nested, "repos", "inner", "pkg"))
+
+	noRepo := t.TempDir()
+	mkdirs(t, filepath.Join(noRepo, ".yak-box"), filepath.Join(noRepo, "work"))
+
+	tests := []struct {
+		name   string
+		dir    string
+		marker string
+		want   string
+	}{
+		{name: "git only", dir: filepath.Join(gitOnly, "a", "b"), marker: DefaultRootMarker, want: gitOnly},
+		{name: "marker below git toplevel", dir: filepath.Join(marked, "sub", "deep"), marker: DefaultRootMarker, want: filepath.Join(marked, "sub")},
+		{name: "outside marked subtree uses git", dir: marked, marker: DefaultRootMarker, want: marked},
+		{name: "nested repo uses nearest", dir: filepath.Join(nested, "repos", "inner", "pkg"), marker: DefaultRootMarker, want: filepath.Join(nested, "repos", "inner")},
+		{name: "custom marker without git", dir: filepath.Join(noRepo, "work"), marker: ".yak-box", want: noRepo},
+		{name: "empty marker ignores markers", dir: filepath.Join(marked, "sub", "deep"), marker: "", want: marked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindRootFrom(tt.dir, tt.marker)
+			if err != nil {
+				t.Fatalf("FindRootFrom() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FindRootFrom() = %s, expected %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := FindRootFrom(filepath.Join(noRepo, "work"), DefaultRootMarker); err == nil {
+		t.Error("FindRootFrom() expected error without .git or marker")
+	}
+}
+
+func TestRootMarker(t *testing.T) {
+	t.Setenv(RootMarkerEnv, "")
+	if got := RootMarker(); got != DefaultRootMarker {
+		t.Errorf("RootMarker() = %s, expected %s", got, DefaultRootMarker)
+	}
+	t.Setenv(RootMarkerEnv, ".yak-box")
+	if got := RootMarker(); got != ".yak-box" {
+		t.Errorf("RootMarker() = %s, expected .yak-box", got)
+	}
+}
+
+func TestFindRootOverride(t *testing.T) {
+	t.Cleanup(func() { SetRootOverride("") })
+	flagRoot, envRoot := t.TempDir(), t.TempDir()
+
+	noRepo := t.TempDir()
+	t.Chdir(noRepo)
+	t.Setenv(RootEnv, "")
+	if _, err := FindRoot(); err == nil || !strings.Contains(err.Error(), "--root") {
+		t.Errorf("FindRoot() outside a repository = %v, expected an error suggesting --root", err)
+	}
+
+	t.Setenv(RootEnv, envRoot)
+	if root, err := FindRoot(); err != nil || root != envRoot {
+		t.Errorf("FindRoot() with %s = (%q, %v), expected %q", RootEnv, root, err, envRoot)
+	}
+
+	SetRootOverride(flagRoot)
+	if root, err := FindRoot(); err != nil || root != flagRoot {
+		t.Errorf("FindRoot() with an override = (%q, %v), expected the override to win over %s", root, err, RootEnv)
+	}
+
+	SetRootOverride(filepath.Join(noRepo, "missing"))
+	if _, err := FindRoot(); err == nil || !strings.Contains(err.Error(), "invalid --root") {
+		t.Errorf("FindRoot() with a missing override = %v, expected an invalid --root error", err)
+	}
+}
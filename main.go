@@ -2,9 +2,10 @@
 package main
 
 import (
-	"log"
+	"os"
 
 	"github.com/wellmaintained/yak-box/cmd"
+	"github.com/wellmaintained/yak-box/internal/errors"
 )
 
 var version = "dev"
@@ -12,6 +13,6 @@ var version = "dev"
 func main() {
 	cmd.SetVersion(version)
 	if err := cmd.Execute(); err != nil {
-		log.Fatal(err)
+		os.Exit(errors.GetExitCode(err))
 	}
 }
@@ -3,8 +3,11 @@ package devcontainer
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+
+	"gopkg.in/yaml.v3"
 )
 
 // LockedFeature represents a pinned feature version in devcontainer-lock.json
@@ -199,12 +202,41 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// LoadConfig loads and parses .devcontainer/devcontainer.json if it exists
-func LoadConfig(projectPath string) (*Config, error) {
-	configPath := filepath.Join(projectPath, ".devcontainer", "devcontainer.json")
+// configFileNames are the files under .devcontainer that LoadConfig reads,
+// in order of precedence.
+var configFileNames = []string{"devcontainer.json", "devcontainer.yaml", "devcontainer.yml"}
+
+// findConfigFile returns the path of the highest-precedence devcontainer
+// config file in projectPath, or "" if there is none.
+func findConfigFile(projectPath string) string {
+	for _, name := range configFileNames {
+		configPath := filepath.Join(projectPath, ".devcontainer", name)
+		if _, err := os.Stat(configPath); err == nil {
+			return configPath
+		}
+	}
+	return ""
+}
 
-	// Check if file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+// yamlToJSON converts a YAML document to JSON so that it can be decoded with
+// the same rules (including custom unmarshalers) as devcontainer.json.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	return json.Marshal(doc)
+}
+
+// LoadConfig loads and parses the devcontainer config in .devcontainer if it
+// exists. devcontainer.json takes precedence over devcontainer.yaml and
+// devcontainer.yml, and may contain comments and trailing commas.
+func LoadConfig(projectPath string) (*Config, error) {
+	configPath := findConfigFile(projectPath)
+	if configPath == "" {
 		return nil, nil
 	}
 
@@ -213,6 +245,15 @@ func LoadConfig(projectPath string) (*Config, error) {
 		return nil, err
 	}
 
+	if filepath.Ext(configPath) != ".json" {
+		data, err = yamlToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(configPath), err)
+		}
+	} else {
+		data = stripJSONC(data)
+	}
+
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, err
@@ -353,3 +394,35 @@ func (c *Config) GetPortAttributes(port string) PortAttributes {
 	// Return otherPortsAttributes as default
 	return c.OtherPortsAttributes
 }
+
+// ForwardPortNumbers returns the forwardPorts entries that are plain port
+// numbers, in order. "host:port" entries refer to ports of other services
+// and are skipped, as are values Validate would reject.
+func (c *Config) ForwardPortNumbers() []int {
+	var ports []int
+	for _, port := range c.ForwardPorts {
+		var n int
+		switch p := port.(type) {
+		case float64:
+			if p != math.Trunc(p) {
+				continue
+			}
+			n = int(p)
+		case int:
+			n = p
+		default:
+			continue
+		}
+		if checkPortRange(n) == nil {
+			ports = append(ports, n)
+		}
+	}
+	return ports
+}
+
+// ShouldForward reports whether a port with the given attributes is forwarded.
+// Only onAutoForward "ignore" opts a port out; "silent" ports are still
+// forwarded, just not announced.
+func ShouldForward(attrs PortAttributes) bool {
+	return attrs.OnAutoForward != "ignore"
+}
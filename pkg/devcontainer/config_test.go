@@ -3,6 +3,7 @@ package devcontainer
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -221,3 +222,203 @@ func TestLoadConfigNullValues(t *testing.T) {
 		t.Logf("Config loaded with null values: image=%q", config.Image)
 	}
 }
+
+func writeDevcontainerFile(t *testing.T, projectPath, name, content string) {
+	t.Helper()
+	dir := filepath.Join(projectPath, ".devcontainer")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadConfigYAMLMatchesJSON(t *testing.T) {
+	jsonDir := t.TempDir()
+	writeDevcontainerFile(t, jsonDir, "devcontainer.json", `{
+		"image": "mcr.microsoft.com/devcontainers/go:1.21",
+		"remoteUser": "vscode",
+		"containerEnv": {"TEST_VAR": "test_value"},
+		"mounts": ["source=/tmp,target=/tmp,type=bind"],
+		"forwardPorts": [8080, "db:5432"],
+		"capAdd": ["SYS_PTRACE"],
+		"entrypoint": "/usr/local/bin/start.sh",
+		"postCreateCommand": ["npm", "install"],
+		"overrideCommand": false
+	}`)
+
+	yamlDir := t.TempDir()
+	writeDevcontainerFile(t, yamlDir, "devcontainer.yaml", `
+image: mcr.microsoft.com/devcontainers/go:1.21
+remoteUser: vscode
+containerEnv:
+  TEST_VAR: test_value
+mounts:
+  - source=/tmp,target=/tmp,type=bind
+forwardPorts:
+  - 8080
+  - db:5432
+capAdd: [SYS_PTRACE]
+entrypoint: /usr/local/bin/start.sh
+postCreateCommand: [npm, install]
+overrideCommand: false
+`)
+
+	fromJSON, err := LoadConfig(jsonDir)
+	if err != nil {
+		t.Fatalf("LoadConfig(json) failed: %v", err)
+	}
+	fromYAML, err := LoadConfig(yamlDir)
+	if err != nil {
+		t.Fatalf("LoadConfig(yaml) failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Errorf("YAML config differs from JSON equivalent:\njson: %+v\nyaml: %+v", fromJSON, fromYAML)
+	}
+	if len(fromYAML.Entrypoint) != 1 || fromYAML.Entrypoint[0] != "/usr/local/bin/start.sh" {
+		t.Errorf("Expected string entrypoint to become a single-element array, got %v", fromYAML.Entrypoint)
+	}
+	if fromYAML.ShouldOverrideCommand() {
+		t.Error("Expected overrideCommand: false to be respected")
+	}
+}
+
+func TestLoadConfigYMLExtension(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeDevcontainerFile(t, tmpDir, "devcontainer.yml", "image: alpine:3\n")
+
+	config, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config == nil || config.Image != "alpine:3" {
+		t.Fatalf("Expected image alpine:3, got %+v", config)
+	}
+	if config.RemoteUser != "root" {
+		t.Errorf("Expected default remoteUser root, got %s", config.RemoteUser)
+	}
+}
+
+func TestLoadConfigJSONTakesPrecedence(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeDevcontainerFile(t, tmpDir, "devcontainer.json", `{"image": "from-json"}`)
+	writeDevcontainerFile(t, tmpDir, "devcontainer.yaml", "image: from-yaml\n")
+
+	config, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.Image != "from-json" {
+		t.Errorf("Expected devcontainer.json to win, got image %s", config.Image)
+	}
+}
+
+func TestLoadConfigCorruptYAML(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeDevcontainerFile(t, tmpDir, "devcontainer.yaml", "image: [unclosed\n")
+
+	if _, err := LoadConfig(tmpDir); err == nil {
+		t.Error("Expected error for corrupt YAML")
+	}
+}
+
+func TestLoadConfigJSONCComments(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeDevcontainerFile(t, tmpDir, "devcontainer.json", `{
+	// Base image for the worker
+	"image": "ubuntu:22.04", // trailing line comment
+	/* Block comment
+	   spanning lines */
+	"remoteUser": /* inline */ "vscode",
+	"entrypoint": "/bin/sh"
+}`)
+
+	config, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.Image != "ubuntu:22.04" {
+		t.Errorf("Image = %q, expected ubuntu:22.04", config.Image)
+	}
+	if config.RemoteUser != "vscode" {
+		t.Errorf("RemoteUser = %q, expected vscode", config.RemoteUser)
+	}
+	if len(config.Entrypoint) != 1 || config.Entrypoint[0] != "/bin/sh" {
+		t.Errorf("Entrypoint = %v, expected [/bin/sh]", config.Entrypoint)
+	}
+}
+
+func TestLoadConfigJSONCTrailingCommas(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeDevcontainerFile(t, tmpDir, "devcontainer.json", `{
+	"image": "ubuntu:22.04",
+	"runArgs": ["--init", "--rm",],
+	"containerEnv": {
+		"FOO": "bar",
+	},
+}`)
+
+	config, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if len(config.RunArgs) != 2 || config.RunArgs[1] != "--rm" {
+		t.Errorf("RunArgs = %v, expected [--init --rm]", config.RunArgs)
+	}
+	if config.ContainerEnv["FOO"] != "bar" {
+		t.Errorf("ContainerEnv = %v, expected FOO=bar", config.ContainerEnv)
+	}
+}
+
+func TestLoadConfigJSONCPreservesStrings(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeDevcontainerFile(t, tmpDir, "devcontainer.json", `{
+	"image": "registry.example.com//team/image:1", // comment
+	"containerEnv": {
+		"URL": "https://example.com/*not a comment*/",
+		"QUOTED": "say \"hi\" // still a string,}",
+	}
+}`)
+
+	config, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.Image != "registry.example.com//team/image:1" {
+		t.Errorf("Image = %q, expected string contents preserved", config.Image)
+	}
+	if got := config.ContainerEnv["URL"]; got != "https://example.com/*not a comment*/" {
+		t.Errorf("URL = %q, expected string contents preserved", got)
+	}
+	if got := config.ContainerEnv["QUOTED"]; got != `say "hi" // still a string,}` {
+		t.Errorf("QUOTED = %q, expected string contents preserved", got)
+	}
+}
+
+func TestForwardPortNumbers(t *testing.T) {
+	config := &Config{ForwardPorts: []interface{}{float64(3000), "db:5432", float64(1.5), float64(70000), 8080}}
+	if got, want := config.ForwardPortNumbers(), []int{3000, 8080}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ForwardPortNumbers() = %v, want %v", got, want)
+	}
+}
+
+func TestShouldForward(t *testing.T) {
+	for action, want := range map[string]bool{
+		"":            true,
+		"notify":      true,
+		"openBrowser": true,
+		"silent":      true,
+		"ignore":      false,
+	} {
+		if got := ShouldForward(PortAttributes{OnAutoForward: action}); got != want {
+			t.Errorf("ShouldForward(%q) = %v, want %v", action, got, want)
+		}
+	}
+
+	config := &Config{OtherPortsAttributes: PortAttributes{OnAutoForward: "ignore"}}
+	if ShouldForward(config.GetPortAttributes("9000")) {
+		t.Error("otherPortsAttributes ignore should apply to ports without their own attributes")
+	}
+}
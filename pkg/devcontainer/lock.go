@@ -0,0 +1,43 @@
+package devcontainer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VerifyLock checks that every feature referenced by cfg is pinned in lock
+// to a content digest, so that rebuilding the worker image reproduces the
+// same features. Local features ("./..." or "../...") are not locked and are
+// skipped. It returns one error per problem, ordered by feature ID.
+func VerifyLock(cfg *Config, lock *LockFile) []error {
+	if cfg == nil || len(cfg.Features) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(cfg.Features))
+	for id := range cfg.Features {
+		if strings.HasPrefix(id, "./") || strings.HasPrefix(id, "../") {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	if len(ids) > 0 && lock == nil {
+		return []error{fmt.Errorf("devcontainer-lock.json not found but %d feature(s) are referenced", len(ids))}
+	}
+
+	var errs []error
+	for _, id := range ids {
+		locked, ok := lock.Features[id]
+		if !ok {
+			errs = append(errs, fmt.Errorf("feature %s has no entry in devcontainer-lock.json", id))
+			continue
+		}
+		if !strings.Contains(locked.Resolved, "@sha256:") {
+			errs = append(errs, fmt.Errorf("feature %s is locked to %q, which is not pinned by digest (@sha256:)", id, locked.Resolved))
+		}
+	}
+	return errs
+}
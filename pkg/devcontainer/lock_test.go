@@ -0,0 +1,76 @@
+package devcontainer
+
+import (
+	"strings"
+	"testing"
+)
+
+const goFeatureDigest = "ghcr.io/devcontainers/features/go@sha256:6c1c6b8b4bd8e0a6f3f5e8f97e7d1f8e8b0d9ad7b1c3d4e5f60718293a4b5c6d"
+
+func TestVerifyLockFullyPinned(t *testing.T) {
+	cfg := &Config{Features: map[string]interface{}{
+		"ghcr.io/devcontainers/features/go:1": map[string]interface{}{"version": "1.22"},
+		"./local-feature":                     map[string]interface{}{},
+	}}
+	lock := &LockFile{Features: map[string]LockedFeature{
+		"ghcr.io/devcontainers/features/go:1": {Version: "1.2.3", Resolved: goFeatureDigest},
+	}}
+
+	if errs := VerifyLock(cfg, lock); len(errs) != 0 {
+		t.Errorf("VerifyLock() = %v, expected no errors", errs)
+	}
+}
+
+func TestVerifyLockMissingEntries(t *testing.T) {
+	cfg := &Config{Features: map[string]interface{}{
+		"ghcr.io/devcontainers/features/go:1":   map[string]interface{}{},
+		"ghcr.io/devcontainers/features/node:1": map[string]interface{}{},
+	}}
+	lock := &LockFile{Features: map[string]LockedFeature{
+		"ghcr.io/devcontainers/features/go:1": {Version: "1.2.3", Resolved: goFeatureDigest},
+	}}
+
+	errs := VerifyLock(cfg, lock)
+	if len(errs) != 1 {
+		t.Fatalf("VerifyLock() = %v, expected one error", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "features/node:1 has no entry") {
+		t.Errorf("unexpected error: %v", errs[0])
+	}
+
+	errs = VerifyLock(cfg, nil)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "devcontainer-lock.json not found") {
+		t.Errorf("VerifyLock() without a lock file = %v", errs)
+	}
+}
+
+func TestVerifyLockNonDigestRefs(t *testing.T) {
+	cfg := &Config{Features: map[string]interface{}{
+		"ghcr.io/devcontainers/features/go:1":   map[string]interface{}{},
+		"ghcr.io/devcontainers/features/node:1": map[string]interface{}{},
+	}}
+	lock := &LockFile{Features: map[string]LockedFeature{
+		"ghcr.io/devcontainers/features/go:1":   {Version: "1.2.3", Resolved: "ghcr.io/devcontainers/features/go:1.2.3"},
+		"ghcr.io/devcontainers/features/node:1": {Version: "1.0.0", Resolved: ""},
+	}}
+
+	errs := VerifyLock(cfg, lock)
+	if len(errs) != 2 {
+		t.Fatalf("VerifyLock() = %v, expected two errors", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "features/go:1 is locked to") || !strings.Contains(errs[0].Error(), "not pinned by digest") {
+		t.Errorf("unexpected first error: %v", errs[0])
+	}
+	if !strings.Contains(errs[1].Error(), "features/node:1") {
+		t.Errorf("unexpected second error: %v", errs[1])
+	}
+}
+
+func TestVerifyLockNoFeatures(t *testing.T) {
+	if errs := VerifyLock(nil, nil); len(errs) != 0 {
+		t.Errorf("VerifyLock(nil, nil) = %v", errs)
+	}
+	if errs := VerifyLock(&Config{Image: "ubuntu:22.04"}, nil); len(errs) != 0 {
+		t.Errorf("VerifyLock() without features = %v", errs)
+	}
+}
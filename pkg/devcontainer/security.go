@@ -7,10 +7,16 @@ import (
 	"github.com/wellmaintained/yak-box/internal/pathutil"
 )
 
+// Severity levels reported in SecurityWarning.Severity
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
 // SecurityWarning represents a security-related warning about devcontainer configuration
 type SecurityWarning struct {
-	Severity string // "warning" or "critical"
-	Message  string
+	Severity string `json:"severity"` // SeverityWarning or SeverityCritical
+	Message  string `json:"message"`
 }
 
 // Dangerous Linux capabilities that could bypass container isolation
@@ -43,7 +49,7 @@ func ValidateSecurityConfig(cfg *Config) []SecurityWarning {
 
 	if cfg.Privileged != nil && *cfg.Privileged {
 		warnings = append(warnings, SecurityWarning{
-			Severity: "critical",
+			Severity: SeverityCritical,
 			Message:  "Container is running in privileged mode, which disables most security isolation",
 		})
 	}
@@ -51,7 +57,7 @@ func ValidateSecurityConfig(cfg *Config) []SecurityWarning {
 	for _, cap := range cfg.CapAdd {
 		if dangerousCapabilities[cap] {
 			warnings = append(warnings, SecurityWarning{
-				Severity: "critical",
+				Severity: SeverityCritical,
 				Message:  "Dangerous capability requested: " + cap + " - this can bypass container isolation",
 			})
 		}
@@ -61,7 +67,7 @@ func ValidateSecurityConfig(cfg *Config) []SecurityWarning {
 		for _, dangerous := range dangerousSecurityOpts {
 			if opt == dangerous {
 				warnings = append(warnings, SecurityWarning{
-					Severity: "critical",
+					Severity: SeverityCritical,
 					Message:  "Dangerous security option: " + opt + " - this weakens container isolation",
 				})
 				break
@@ -74,6 +80,17 @@ func ValidateSecurityConfig(cfg *Config) []SecurityWarning {
 	return warnings
 }
 
+// FilterCritical returns only the critical-severity warnings, preserving order
+func FilterCritical(warnings []SecurityWarning) []SecurityWarning {
+	var critical []SecurityWarning
+	for _, warning := range warnings {
+		if warning.Severity == SeverityCritical {
+			critical = append(critical, warning)
+		}
+	}
+	return critical
+}
+
 func validateMountPaths(cfg *Config, warnings *[]SecurityWarning) {
 	if len(cfg.Mounts) == 0 {
 		return
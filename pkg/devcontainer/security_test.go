@@ -222,3 +222,23 @@ func TestValidateSecurityConfigEmptyConfig(t *testing.T) {
 		t.Errorf("Expected no warnings for empty config, got %d warnings", len(warnings))
 	}
 }
+
+func TestFilterCritical(t *testing.T) {
+	warnings := []SecurityWarning{
+		{Severity: SeverityWarning, Message: "minor"},
+		{Severity: SeverityCritical, Message: "first"},
+		{Severity: SeverityCritical, Message: "second"},
+	}
+
+	critical := FilterCritical(warnings)
+	if len(critical) != 2 {
+		t.Fatalf("Expected 2 critical warnings, got %d", len(critical))
+	}
+	if critical[0].Message != "first" || critical[1].Message != "second" {
+		t.Errorf("FilterCritical should preserve order, got %v", critical)
+	}
+
+	if got := FilterCritical(nil); len(got) != 0 {
+		t.Errorf("Expected no warnings from nil input, got %v", got)
+	}
+}
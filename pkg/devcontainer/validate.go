@@ -0,0 +1,172 @@
+package devcontainer
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// waitForCommands are the lifecycle commands that waitFor may name.
+var waitForCommands = []string{
+	"initializeCommand",
+	"onCreateCommand",
+	"updateContentCommand",
+	"postCreateCommand",
+	"postStartCommand",
+}
+
+// shutdownActions are the allowed shutdownAction values.
+var shutdownActions = []string{"none", "stopContainer", "stopCompose"}
+
+// Validate checks cfg for structural mistakes that parse as valid JSON but
+// break the container later. Each error is prefixed with the path of the
+// offending field, e.g. "forwardPorts[1]".
+func Validate(cfg *Config) []error {
+	if cfg == nil {
+		return nil
+	}
+
+	var errs []error
+	for i, port := range cfg.ForwardPorts {
+		if err := validateForwardPort(port); err != nil {
+			errs = append(errs, fmt.Errorf("forwardPorts[%d]: %w", i, err))
+		}
+	}
+	for i, mount := range cfg.Mounts {
+		if err := validateMount(mount); err != nil {
+			errs = append(errs, fmt.Errorf("mounts[%d]: %w", i, err))
+		}
+	}
+	if cfg.WaitFor != "" && !slices.Contains(waitForCommands, cfg.WaitFor) {
+		errs = append(errs, fmt.Errorf("waitFor: %q is not a lifecycle command (use one of %s)", cfg.WaitFor, strings.Join(waitForCommands, ", ")))
+	}
+	if cfg.ShutdownAction != "" && !slices.Contains(shutdownActions, cfg.ShutdownAction) {
+		errs = append(errs, fmt.Errorf("shutdownAction: %q is not allowed (use one of %s)", cfg.ShutdownAction, strings.Join(shutdownActions, ", ")))
+	}
+	errs = append(errs, ValidateFeatureOrder(cfg)...)
+	return errs
+}
+
+// ValidateFeatureOrder checks overrideFeatureInstallOrder for entries listed
+// more than once and for ids that name no configured feature. An entry may
+// omit the version of the feature it names, so "ghcr.io/x/node" matches a
+// "ghcr.io/x/node:1" feature.
+func ValidateFeatureOrder(cfg *Config) []error {
+	if cfg == nil {
+		return nil
+	}
+
+	known := make(map[string]bool, len(cfg.Features)*2)
+	for id := range cfg.Features {
+		known[id] = true
+		known[featureIDWithoutVersion(id)] = true
+	}
+
+	var errs []error
+	seen := make(map[string]int, len(cfg.OverrideFeatureInstallOrder))
+	for i, id := range cfg.OverrideFeatureInstallOrder {
+		if first, ok := seen[id]; ok {
+			errs = append(errs, fmt.Errorf("overrideFeatureInstallOrder[%d]: %q is already listed at index %d", i, id, first))
+			continue
+		}
+		seen[id] = i
+		if !known[id] {
+			errs = append(errs, fmt.Errorf("overrideFeatureInstallOrder[%d]: %q is not in features", i, id))
+		}
+	}
+	return errs
+}
+
+// featureIDWithoutVersion strips a ":tag" or "@digest" suffix from a feature
+// id, leaving any registry port in place.
+func featureIDWithoutVersion(id string) string {
+	if i := strings.LastIndex(id, "@"); i > 0 {
+		return id[:i]
+	}
+	if i := strings.LastIndex(id, ":"); i > strings.LastIndex(id, "/") {
+		return id[:i]
+	}
+	return id
+}
+
+// validateForwardPort accepts a port number or a "host:port" string.
+func validateForwardPort(port interface{}) error {
+	switch p := port.(type) {
+	case float64:
+		if p != math.Trunc(p) {
+			return fmt.Errorf("port %v must be an integer", p)
+		}
+		return checkPortRange(int(p))
+	case int:
+		return checkPortRange(p)
+	case string:
+		host, portStr, ok := strings.Cut(p, ":")
+		if !ok || host == "" {
+			return fmt.Errorf("%q must be a port number or a \"host:port\" string", p)
+		}
+		n, err := strconv.Atoi(portStr)
+		if err != nil {
+			return fmt.Errorf("%q has a non-numeric port", p)
+		}
+		return checkPortRange(n)
+	default:
+		return fmt.Errorf("%v must be a port number or a \"host:port\" string", p)
+	}
+}
+
+func checkPortRange(port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("port %d is outside 1-65535", port)
+	}
+	return nil
+}
+
+// variableRef matches a ${...} variable reference, whose colons are not
+// mount separators.
+var variableRef = regexp.MustCompile(`\$\{[^}]+\}`)
+
+// validateMount checks a mount in either of the forms the runtime accepts: a
+// Docker --mount string, which must name its type, target and (except for
+// tmpfs) source, or a "source:target[:options]" volume string.
+func validateMount(mount string) error {
+	if !strings.Contains(mount, "=") {
+		parts := strings.Split(variableRef.ReplaceAllString(mount, "var"), ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("%q must be \"source:target[:options]\" or \"type=...,source=...,target=...\"", mount)
+		}
+		return nil
+	}
+
+	keys := make(map[string]bool)
+	mountType := ""
+	for _, part := range strings.Split(mount, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "src":
+			key = "source"
+		case "dst", "destination":
+			key = "target"
+		case "type":
+			mountType = value
+		}
+		keys[key] = true
+	}
+
+	var missing []string
+	if !keys["type"] {
+		missing = append(missing, "type")
+	}
+	if !keys["source"] && mountType != "tmpfs" {
+		missing = append(missing, "source")
+	}
+	if !keys["target"] {
+		missing = append(missing, "target")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%q is missing %s", mount, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package devcontainer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateValidConfig(t *testing.T) {
+	cfg := &Config{
+		ForwardPorts: []interface{}{float64(3000), "db:5432"},
+		Mounts: []string{
+			"source=${localWorkspaceFolder}/data,target=/data,type=bind",
+			"src=cache,dst=/cache,type=volume",
+			"type=tmpfs,target=/tmp/scratch",
+			"${localEnv:HOME}/.npmrc:/home/node/.npmrc:ro",
+			"/var/cache/apt:/var/cache/apt",
+		},
+		WaitFor:        "postCreateCommand",
+		ShutdownAction: "stopContainer",
+	}
+	if errs := Validate(cfg); len(errs) != 0 {
+		t.Errorf("Validate() = %v, expected no errors", errs)
+	}
+	if errs := Validate(nil); len(errs) != 0 {
+		t.Errorf("Validate(nil) = %v, expected no errors", errs)
+	}
+}
+
+func TestValidateInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *Config
+		want string
+	}{
+		{name: "float port", cfg: &Config{ForwardPorts: []interface{}{float64(3000), 8080.5}}, want: "forwardPorts[1]: port 8080.5 must be an integer"},
+		{name: "port out of range", cfg: &Config{ForwardPorts: []interface{}{float64(70000)}}, want: "forwardPorts[0]: port 70000 is outside 1-65535"},
+		{name: "port string without host", cfg: &Config{ForwardPorts: []interface{}{"3000"}}, want: `forwardPorts[0]: "3000" must be a port number or a "host:port" string`},
+		{name: "port string non-numeric", cfg: &Config{ForwardPorts: []interface{}{"db:http"}}, want: `forwardPorts[0]: "db:http" has a non-numeric port`},
+		{name: "port wrong type", cfg: &Config{ForwardPorts: []interface{}{true}}, want: "forwardPorts[0]: true must be a port number"},
+		{name: "mount missing target", cfg: &Config{Mounts: []string{"source=/tmp,type=bind"}}, want: `mounts[0]: "source=/tmp,type=bind" is missing target`},
+		{name: "mount missing type and source", cfg: &Config{Mounts: []string{"source=/a,target=/a,type=bind", "target=/b"}}, want: `mounts[1]: "target=/b" is missing type, source`},
+		{name: "short mount missing target", cfg: &Config{Mounts: []string{"/data"}}, want: `mounts[0]: "/data" must be "source:target[:options]"`},
+		{name: "short mount with empty source", cfg: &Config{Mounts: []string{":/data:ro"}}, want: `mounts[0]: ":/data:ro" must be "source:target[:options]"`},
+		{name: "unknown waitFor", cfg: &Config{WaitFor: "postAttachCommand"}, want: `waitFor: "postAttachCommand" is not a lifecycle command`},
+		{name: "unknown shutdownAction", cfg: &Config{ShutdownAction: "kill"}, want: `shutdownAction: "kill" is not allowed`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Validate(tt.cfg)
+			if len(errs) != 1 {
+				t.Fatalf("Validate() = %v, expected exactly one error", errs)
+			}
+			if !strings.Contains(errs[0].Error(), tt.want) {
+				t.Errorf("Validate() error = %q, expected it to contain %q", errs[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFeatureOrder(t *testing.T) {
+	features := map[string]interface{}{
+		"ghcr.io/devcontainers/features/node:1":  map[string]interface{}{},
+		"ghcr.io/devcontainers/features/go:1":    map[string]interface{}{},
+		"localhost:5000/features/tool@sha256:ab": map[string]interface{}{},
+	}
+
+	t.Run("valid ordering", func(t *testing.T) {
+		cfg := &Config{Features: features, OverrideFeatureInstallOrder: []string{
+			"ghcr.io/devcontainers/features/go",
+			"ghcr.io/devcontainers/features/node:1",
+			"localhost:5000/features/tool",
+		}}
+		if errs := ValidateFeatureOrder(cfg); len(errs) != 0 {
+			t.Errorf("ValidateFeatureOrder() = %v, expected no errors", errs)
+		}
+	})
+
+	t.Run("duplicate entry", func(t *testing.T) {
+		cfg := &Config{Features: features, OverrideFeatureInstallOrder: []string{
+			"ghcr.io/devcontainers/features/node",
+			"ghcr.io/devcontainers/features/go",
+			"ghcr.io/devcontainers/features/node",
+		}}
+		errs := ValidateFeatureOrder(cfg)
+		if len(errs) != 1 {
+			t.Fatalf("ValidateFeatureOrder() = %v, expected exactly one error", errs)
+		}
+		want := `overrideFeatureInstallOrder[2]: "ghcr.io/devcontainers/features/node" is already listed at index 0`
+		if errs[0].Error() != want {
+			t.Errorf("ValidateFeatureOrder() error = %q, expected %q", errs[0], want)
+		}
+	})
+
+	t.Run("unknown feature", func(t *testing.T) {
+		cfg := &Config{Features: features, OverrideFeatureInstallOrder: []string{"ghcr.io/devcontainers/features/python"}}
+		errs := Validate(cfg)
+		if len(errs) != 1 {
+			t.Fatalf("Validate() = %v, expected exactly one error", errs)
+		}
+		want := `overrideFeatureInstallOrder[0]: "ghcr.io/devcontainers/features/python" is not in features`
+		if errs[0].Error() != want {
+			t.Errorf("Validate() error = %q, expected %q", errs[0], want)
+		}
+	})
+}
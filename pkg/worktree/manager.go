@@ -9,34 +9,60 @@ import (
 	"strings"
 )
 
-// DetermineWorktreePath calculates the path for a worktree
-// Uses XDG-compliant location: ~/.local/share/yak-box/worktrees/<project>/<task-path>
-func DetermineWorktreePath(projectPath, taskPath string) string {
-	projectName := filepath.Base(projectPath)
-	sanitizedName := sanitizeTaskPath(taskPath)
+// WorktreeRootEnv overrides the base directory under which worktrees are created.
+const WorktreeRootEnv = "YAK_BOX_WORKTREE_ROOT"
+
+// WorktreeRoot returns the base directory for worktrees.
+// YAK_BOX_WORKTREE_ROOT takes precedence; otherwise uses the XDG-compliant
+// location ~/.local/share/yak-box/worktrees. Returns an error only when no
+// override is set and the user's home directory cannot be determined.
+func WorktreeRoot() (string, error) {
+	if root := strings.TrimSpace(os.Getenv(WorktreeRootEnv)); root != "" {
+		return filepath.Abs(root)
+	}
 
 	// Get user's home directory
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		// Fallback to old behavior if can't get home
-		parentDir := filepath.Dir(projectPath)
-		return filepath.Join(parentDir, fmt.Sprintf("%s-%s", projectName, sanitizedName))
+		return "", err
 	}
 
-	// XDG-compliant path: ~/.local/share/yak-box/worktrees/<project>/<task-path>
 	xdgDataHome := os.Getenv("XDG_DATA_HOME")
 	if xdgDataHome == "" {
 		xdgDataHome = filepath.Join(homeDir, ".local", "share")
 	}
 
-	worktreePath := filepath.Join(xdgDataHome, "yak-box", "worktrees", projectName, sanitizedName)
-
-	// Ensure parent directory exists
-	_ = os.MkdirAll(filepath.Dir(worktreePath), 0755)
+	return filepath.Join(xdgDataHome, "yak-box", "worktrees"), nil
+}
 
+// DetermineWorktreePath calculates the path for a worktree
+// Uses <root>/<project>/<task-path>, where root comes from WorktreeRoot
+func DetermineWorktreePath(projectPath, taskPath string) string {
+	worktreePath, underRoot := worktreePathFor(projectPath, taskPath)
+	if underRoot {
+		// Ensure parent directory exists
+		_ = os.MkdirAll(filepath.Dir(worktreePath), 0755)
+	}
 	return worktreePath
 }
 
+// worktreePathFor computes DetermineWorktreePath's result without touching
+// the filesystem. underRoot is false when it fell back to a sibling of
+// projectPath because WorktreeRoot failed.
+func worktreePathFor(projectPath, taskPath string) (path string, underRoot bool) {
+	projectName := filepath.Base(projectPath)
+	sanitizedName := sanitizeTaskPath(taskPath)
+
+	root, err := WorktreeRoot()
+	if err != nil {
+		// Fallback to old behavior if can't get home
+		parentDir := filepath.Dir(projectPath)
+		return filepath.Join(parentDir, fmt.Sprintf("%s-%s", projectName, sanitizedName)), false
+	}
+
+	return filepath.Join(root, projectName, sanitizedName), true
+}
+
 // sanitizeTaskPath converts task path to filesystem-safe name
 func sanitizeTaskPath(taskPath string) string {
 	// Replace slashes with dashes
@@ -47,6 +73,12 @@ func sanitizeTaskPath(taskPath string) string {
 	return name
 }
 
+// BranchForTask returns the branch name used for a task's worktree
+// (the task path with / replaced by -).
+func BranchForTask(taskPath string) string {
+	return strings.ReplaceAll(taskPath, "/", "-")
+}
+
 // IsGitRepo checks if a directory is a git repository
 func IsGitRepo(path string) bool {
 	cmd := exec.Command("git", "-C", path, "rev-parse", "--git-dir")
@@ -63,6 +95,17 @@ func GetCurrentBranch(path string) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// CommonGitDir returns the absolute path of the git directory shared by the
+// repository that path belongs to. For a linked worktree this is the main
+// repository's .git, which the worktree's .git file refers to by path.
+func CommonGitDir(path string) (string, error) {
+	output, err := exec.Command("git", "-C", path, "rev-parse", "--path-format=absolute", "--git-common-dir").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the git directory of %s: %w", path, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // WorktreeExists checks if a worktree with the given name exists
 // Checks in the context of the projectPath git repository
 func WorktreeExists(projectPath, worktreeName string) (bool, error) {
@@ -116,28 +159,25 @@ func GetWorktreePath(projectPath, worktreeName string) (string, error) {
 }
 
 // CreateWorktree creates a new worktree
-// Creates it in the context of the projectPath git repository
-func CreateWorktree(projectPath, worktreePath, branchName string, verbose bool) error {
-	// Check if branch already exists
-	checkCmd := exec.Command("git", "-C", projectPath, "show-ref", "--verify", "--quiet", fmt.Sprintf("refs/heads/%s", branchName))
-	branchExists := checkCmd.Run() == nil
-
-	var cmd *exec.Cmd
-	if branchExists {
+// Creates it in the context of the projectPath git repository. A new branch
+// starts at baseBranch, or at HEAD when baseBranch is empty; an existing
+// branch is checked out as it is.
+func CreateWorktree(projectPath, worktreePath, branchName, baseBranch string, verbose bool) error {
+	var args []string
+	if branchExists(projectPath, branchName) {
 		// Branch exists, check it out in the worktree
-		cmd = exec.Command("git", "-C", projectPath, "worktree", "add", worktreePath, branchName)
-		if verbose {
-			fmt.Fprintf(os.Stderr, "+ git -C %s worktree add %s %s\n", projectPath, worktreePath, branchName)
-		}
+		args = []string{"-C", projectPath, "worktree", "add", worktreePath, branchName}
 	} else {
 		// Branch doesn't exist, create it
-		cmd = exec.Command("git", "-C", projectPath, "worktree", "add", worktreePath, "-b", branchName)
-		if verbose {
-			fmt.Fprintf(os.Stderr, "+ git -C %s worktree add %s -b %s\n", projectPath, worktreePath, branchName)
+		args = []string{"-C", projectPath, "worktree", "add", worktreePath, "-b", branchName}
+		if baseBranch != "" {
+			args = append(args, baseBranch)
 		}
 	}
 
+	cmd := exec.Command("git", args...)
 	if verbose {
+		fmt.Fprintf(os.Stderr, "+ git %s\n", strings.Join(args, " "))
 		cmd.Stdout = os.Stderr
 		cmd.Stderr = os.Stderr
 	}
@@ -145,40 +185,114 @@ func CreateWorktree(projectPath, worktreePath, branchName string, verbose bool)
 	return cmd.Run()
 }
 
-// EnsureWorktree ensures a worktree exists, creating it if necessary
-// Returns the path to the worktree
-func EnsureWorktree(projectPath, taskPath string, verbose bool) (string, error) {
+// commitExists reports whether ref resolves to a commit in projectPath
+func commitExists(projectPath, ref string) bool {
+	cmd := exec.Command("git", "-C", projectPath, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	return cmd.Run() == nil
+}
+
+// branchExists reports whether projectPath has a local branch named branchName
+func branchExists(projectPath, branchName string) bool {
+	cmd := exec.Command("git", "-C", projectPath, "show-ref", "--verify", "--quiet", fmt.Sprintf("refs/heads/%s", branchName))
+	return cmd.Run() == nil
+}
+
+// PlanAction is what EnsureWorktree would do for a task.
+type PlanAction string
+
+const (
+	// PlanReuse means a worktree for the task's branch already exists.
+	PlanReuse PlanAction = "reuse"
+	// PlanCheckoutBranch means a new worktree would check out an existing branch.
+	PlanCheckoutBranch PlanAction = "checkout-branch"
+	// PlanCreateBranch means a new worktree would be created on a new branch.
+	PlanCreateBranch PlanAction = "create-branch"
+)
+
+// Plan describes the worktree EnsureWorktree would use for a task.
+type Plan struct {
+	Action PlanAction
+	Path   string
+	Branch string
+}
+
+// String renders the plan as a one-line summary for dry-run output
+func (p Plan) String() string {
+	switch p.Action {
+	case PlanReuse:
+		return fmt.Sprintf("reuse existing worktree %s (branch %s)", p.Path, p.Branch)
+	case PlanCheckoutBranch:
+		return fmt.Sprintf("create worktree %s checking out existing branch %s", p.Path, p.Branch)
+	default:
+		return fmt.Sprintf("create worktree %s on new branch %s", p.Path, p.Branch)
+	}
+}
+
+// PlanWorktree reports what EnsureWorktree would do for taskPath without
+// changing the repository or creating any directories.
+func PlanWorktree(projectPath, taskPath string) (*Plan, error) {
 	// Verify projectPath is a git repo
 	if !IsGitRepo(projectPath) {
-		return "", fmt.Errorf("not a git repository: %s", projectPath)
+		return nil, fmt.Errorf("not a git repository: %s", projectPath)
 	}
 
-	// Convert task path to branch name (replace / with -)
-	branchName := strings.ReplaceAll(taskPath, "/", "-")
+	branchName := BranchForTask(taskPath)
 
 	// Check if worktree already exists
 	exists, err := WorktreeExists(projectPath, branchName)
 	if err != nil {
-		return "", fmt.Errorf("failed to check worktree existence: %w", err)
+		return nil, fmt.Errorf("failed to check worktree existence: %w", err)
 	}
 
 	if exists {
 		// Get existing worktree path
 		path, err := GetWorktreePath(projectPath, branchName)
 		if err != nil {
-			return "", fmt.Errorf("failed to get worktree path: %w", err)
+			return nil, fmt.Errorf("failed to get worktree path: %w", err)
 		}
+		return &Plan{Action: PlanReuse, Path: path, Branch: branchName}, nil
+	}
+
+	path, _ := worktreePathFor(projectPath, taskPath)
+	action := PlanCreateBranch
+	if branchExists(projectPath, branchName) {
+		action = PlanCheckoutBranch
+	}
+	return &Plan{Action: action, Path: path, Branch: branchName}, nil
+}
+
+// EnsureWorktree ensures a worktree exists, creating it if necessary
+// Returns the path to the worktree. A new task branch starts at baseBranch,
+// e.g. the repository's default branch, or at HEAD when baseBranch is empty.
+func EnsureWorktree(projectPath, taskPath, baseBranch string, verbose bool) (string, error) {
+	plan, err := PlanWorktree(projectPath, taskPath)
+	if err != nil {
+		return "", err
+	}
+
+	if plan.Action == PlanReuse {
 		if verbose {
-			fmt.Fprintf(os.Stderr, "Using existing worktree: %s\n", path)
+			fmt.Fprintf(os.Stderr, "Using existing worktree: %s\n", plan.Path)
+		}
+		return plan.Path, nil
+	}
+
+	if plan.Action == PlanCreateBranch {
+		if baseBranch != "" && !commitExists(projectPath, baseBranch) {
+			return "", fmt.Errorf("base branch %q not found in %s", baseBranch, projectPath)
+		}
+		if baseBranch == "" && verbose {
+			if current, err := GetCurrentBranch(projectPath); err == nil && current != "" {
+				fmt.Fprintf(os.Stderr, "Branching %s from current branch %s\n", plan.Branch, current)
+			}
 		}
-		return path, nil
 	}
 
 	// Determine where to create the worktree
 	worktreePath := DetermineWorktreePath(projectPath, taskPath)
 
 	// Create the worktree
-	if err := CreateWorktree(projectPath, worktreePath, branchName, verbose); err != nil {
+	if err := CreateWorktree(projectPath, worktreePath, plan.Branch, baseBranch, verbose); err != nil {
 		return "", fmt.Errorf("failed to create worktree: %w", err)
 	}
 
@@ -191,11 +305,15 @@ func EnsureWorktree(projectPath, taskPath string, verbose bool) (string, error)
 
 // EnsureWorktreeAtPath ensures a worktree exists at the requested destination.
 // If the destination already contains a git worktree, it checks out (or creates)
-// the target branch in place.
-func EnsureWorktreeAtPath(projectPath, destinationPath, branchName string, verbose bool) (string, error) {
+// the target branch in place. A new branch starts at baseBranch, or at HEAD
+// when baseBranch is empty.
+func EnsureWorktreeAtPath(projectPath, destinationPath, branchName, baseBranch string, verbose bool) (string, error) {
 	if !IsGitRepo(projectPath) {
 		return "", fmt.Errorf("not a git repository: %s", projectPath)
 	}
+	if baseBranch != "" && !branchExists(projectPath, branchName) && !commitExists(projectPath, baseBranch) {
+		return "", fmt.Errorf("base branch %q not found in %s", baseBranch, projectPath)
+	}
 
 	if info, err := os.Stat(destinationPath); err == nil {
 		if !info.IsDir() {
@@ -220,9 +338,13 @@ func EnsureWorktreeAtPath(projectPath, destinationPath, branchName string, verbo
 			return destinationPath, nil
 		}
 
-		createCmd := exec.Command("git", "-C", destinationPath, "checkout", "-b", branchName)
+		createArgs := []string{"-C", destinationPath, "checkout", "-b", branchName}
+		if baseBranch != "" {
+			createArgs = append(createArgs, baseBranch)
+		}
+		createCmd := exec.Command("git", createArgs...)
 		if verbose {
-			fmt.Fprintf(os.Stderr, "+ git -C %s checkout -b %s\n", destinationPath, branchName)
+			fmt.Fprintf(os.Stderr, "+ git %s\n", strings.Join(createArgs, " "))
 			createCmd.Stdout = os.Stderr
 			createCmd.Stderr = os.Stderr
 		}
@@ -235,8 +357,75 @@ func EnsureWorktreeAtPath(projectPath, destinationPath, branchName string, verbo
 	if err := os.MkdirAll(filepath.Dir(destinationPath), 0755); err != nil {
 		return "", fmt.Errorf("failed to create parent directory for worktree: %w", err)
 	}
-	if err := CreateWorktree(projectPath, destinationPath, branchName, verbose); err != nil {
+	if err := CreateWorktree(projectPath, destinationPath, branchName, baseBranch, verbose); err != nil {
 		return "", fmt.Errorf("failed to create worktree: %w", err)
 	}
 	return destinationPath, nil
 }
+
+// Remove removes the worktree of projectPath that has branchName checked out.
+// With force, the worktree is removed even if it has local modifications.
+func Remove(projectPath, branchName string, force bool) error {
+	worktreePath, err := GetWorktreePath(projectPath, branchName)
+	if err != nil {
+		return err
+	}
+	return removeWorktree(projectPath, worktreePath, force)
+}
+
+// RemoveAtPath removes the worktree at worktreePath from projectPath. Without
+// force, a worktree with uncommitted changes is kept and the returned error
+// lists the changes that would be lost.
+func RemoveAtPath(projectPath, worktreePath string, force bool) error {
+	if !force {
+		changes, err := UncommittedChanges(worktreePath)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			return fmt.Errorf("worktree %s has uncommitted changes that would be lost:\n  %s", worktreePath, strings.Join(changes, "\n  "))
+		}
+	}
+	return removeWorktree(projectPath, worktreePath, force)
+}
+
+// UncommittedChanges returns the git status --porcelain lines for path,
+// including untracked files. It is empty for a clean worktree.
+func UncommittedChanges(path string) ([]string, error) {
+	output, err := exec.Command("git", "-C", path, "status", "--porcelain").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check status of %s: %w", path, err)
+	}
+	var changes []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.TrimSpace(line) != "" {
+			changes = append(changes, line)
+		}
+	}
+	return changes, nil
+}
+
+func removeWorktree(projectPath, worktreePath string, force bool) error {
+	args := []string{"-C", projectPath, "worktree", "remove"}
+	if force {
+		args = append(args, "--force")
+	}
+	args = append(args, worktreePath)
+	if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("git worktree remove %s failed: %w: %s", worktreePath, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// DeleteBranch deletes branchName from projectPath. Without force, git
+// refuses to delete a branch that is not fully merged.
+func DeleteBranch(projectPath, branchName string, force bool) error {
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+	if output, err := exec.Command("git", "-C", projectPath, "branch", flag, branchName).CombinedOutput(); err != nil {
+		return fmt.Errorf("git branch %s %s failed: %w: %s", flag, branchName, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -41,7 +42,7 @@ func TestEnsureWorktreeAtPath(t *testing.T) {
 
 	initRepoWithCommit(t, repoPath)
 
-	wtPath, err := EnsureWorktreeAtPath(repoPath, destPath, "sc-12345", false)
+	wtPath, err := EnsureWorktreeAtPath(repoPath, destPath, "sc-12345", "", false)
 	assert.NoError(t, err)
 	assert.Equal(t, destPath, wtPath)
 	assert.True(t, IsGitRepo(destPath))
@@ -50,7 +51,7 @@ func TestEnsureWorktreeAtPath(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "sc-12345", branch)
 
-	wtPath, err = EnsureWorktreeAtPath(repoPath, destPath, "sc-12345", false)
+	wtPath, err = EnsureWorktreeAtPath(repoPath, destPath, "sc-12345", "", false)
 	assert.NoError(t, err)
 	assert.Equal(t, destPath, wtPath)
 }
@@ -95,6 +96,30 @@ func TestDetermineWorktreePath(t *testing.T) {
 	}
 }
 
+func TestDetermineWorktreePathRootOverride(t *testing.T) {
+	t.Run("override takes precedence over XDG", func(t *testing.T) {
+		root := t.TempDir()
+		t.Setenv(WorktreeRootEnv, root)
+		t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+		got := DetermineWorktreePath("/home/user/myproject", "auth/api")
+		assert.Equal(t, filepath.Join(root, "myproject", "auth-api"), got)
+
+		info, err := os.Stat(filepath.Join(root, "myproject"))
+		assert.NoError(t, err)
+		assert.True(t, info.IsDir())
+	})
+
+	t.Run("defaults to XDG data home when unset", func(t *testing.T) {
+		xdg := t.TempDir()
+		t.Setenv(WorktreeRootEnv, "")
+		t.Setenv("XDG_DATA_HOME", xdg)
+
+		got := DetermineWorktreePath("/home/user/myproject", "bugfix")
+		assert.Equal(t, filepath.Join(xdg, "yak-box", "worktrees", "myproject", "bugfix"), got)
+	})
+}
+
 func TestSanitizeTaskPath(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -153,3 +178,208 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestRemove(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoPath := filepath.Join(tmpDir, "repo")
+	initRepoWithCommit(t, repoPath)
+	t.Setenv(WorktreeRootEnv, filepath.Join(tmpDir, "worktrees"))
+
+	wtPath, err := EnsureWorktree(repoPath, "release/docs", "", false)
+	assert.NoError(t, err)
+	exists, err := WorktreeExists(repoPath, "release-docs")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	t.Run("refuses dirty worktree without force", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(filepath.Join(wtPath, "scratch.txt"), []byte("wip\n"), 0644))
+		assert.Error(t, Remove(repoPath, "release-docs", false))
+	})
+
+	t.Run("removes with force", func(t *testing.T) {
+		assert.NoError(t, Remove(repoPath, "release-docs", true))
+		exists, err := WorktreeExists(repoPath, "release-docs")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+		_, err = os.Stat(wtPath)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("unknown branch", func(t *testing.T) {
+		assert.Error(t, Remove(repoPath, "no-such-branch", false))
+	})
+
+	t.Run("deletes branch", func(t *testing.T) {
+		assert.NoError(t, DeleteBranch(repoPath, "release-docs", false))
+		err := exec.Command("git", "-C", repoPath, "show-ref", "--verify", "--quiet", "refs/heads/release-docs").Run()
+		assert.Error(t, err)
+	})
+}
+
+func TestRemoveAtPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoPath := filepath.Join(tmpDir, "repo")
+	initRepoWithCommit(t, repoPath)
+	t.Setenv(WorktreeRootEnv, filepath.Join(tmpDir, "worktrees"))
+
+	wtPath, err := EnsureWorktree(repoPath, "auth/api", "", false)
+	assert.NoError(t, err)
+
+	t.Run("refuses dirty worktree and lists changes", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(filepath.Join(wtPath, "scratch.txt"), []byte("wip\n"), 0644))
+		err := RemoveAtPath(repoPath, wtPath, false)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "scratch.txt")
+		_, statErr := os.Stat(wtPath)
+		assert.NoError(t, statErr)
+	})
+
+	t.Run("removes clean worktree", func(t *testing.T) {
+		assert.NoError(t, os.Remove(filepath.Join(wtPath, "scratch.txt")))
+		assert.NoError(t, RemoveAtPath(repoPath, wtPath, false))
+		_, statErr := os.Stat(wtPath)
+		assert.True(t, os.IsNotExist(statErr))
+	})
+
+	t.Run("force removes dirty worktree", func(t *testing.T) {
+		wtPath, err := EnsureWorktree(repoPath, "auth/web", "", false)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(wtPath, "scratch.txt"), []byte("wip\n"), 0644))
+		assert.NoError(t, RemoveAtPath(repoPath, wtPath, true))
+		_, statErr := os.Stat(wtPath)
+		assert.True(t, os.IsNotExist(statErr))
+	})
+}
+
+func TestBranchForTask(t *testing.T) {
+	assert.Equal(t, "release-yak-box-docs", BranchForTask("release/yak-box/docs"))
+	assert.Equal(t, "sc-12345", BranchForTask("sc-12345"))
+}
+
+func TestPlanWorktree(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoPath := filepath.Join(tmpDir, "repo")
+	initRepoWithCommit(t, repoPath)
+	root := filepath.Join(tmpDir, "worktrees")
+	t.Setenv(WorktreeRootEnv, root)
+
+	t.Run("create new branch without touching the repo", func(t *testing.T) {
+		plan, err := PlanWorktree(repoPath, "auth/api")
+		assert.NoError(t, err)
+		assert.Equal(t, PlanCreateBranch, plan.Action)
+		assert.Equal(t, "auth-api", plan.Branch)
+		assert.Equal(t, filepath.Join(root, "repo", "auth-api"), plan.Path)
+
+		assert.False(t, branchExists(repoPath, "auth-api"))
+		_, err = os.Stat(root)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("check out existing branch", func(t *testing.T) {
+		assert.NoError(t, exec.Command("git", "-C", repoPath, "branch", "auth-web").Run())
+		plan, err := PlanWorktree(repoPath, "auth/web")
+		assert.NoError(t, err)
+		assert.Equal(t, PlanCheckoutBranch, plan.Action)
+		assert.Contains(t, plan.String(), "existing branch auth-web")
+	})
+
+	t.Run("reuse existing worktree", func(t *testing.T) {
+		wtPath, err := EnsureWorktree(repoPath, "auth/api", "", false)
+		assert.NoError(t, err)
+
+		plan, err := PlanWorktree(repoPath, "auth/api")
+		assert.NoError(t, err)
+		assert.Equal(t, PlanReuse, plan.Action)
+		assert.Equal(t, wtPath, plan.Path)
+	})
+
+	t.Run("not a git repo", func(t *testing.T) {
+		_, err := PlanWorktree(t.TempDir(), "auth/api")
+		assert.Error(t, err)
+	})
+}
+
+func gitOutput(t *testing.T, repoPath string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", repoPath}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=yak-box-test",
+		"GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=yak-box-test",
+		"GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestEnsureWorktreeFromBaseBranch(t *testing.T) {
+	t.Setenv(WorktreeRootEnv, t.TempDir())
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	initRepoWithCommit(t, repoPath)
+
+	base := gitOutput(t, repoPath, "branch", "--show-current")
+	baseCommit := gitOutput(t, repoPath, "rev-parse", "HEAD")
+	gitOutput(t, repoPath, "checkout", "-b", "feature")
+	gitOutput(t, repoPath, "commit", "--allow-empty", "-m", "feature work")
+	featureCommit := gitOutput(t, repoPath, "rev-parse", "HEAD")
+
+	wtPath, err := EnsureWorktree(repoPath, "auth/api", base, false)
+	assert.NoError(t, err)
+	assert.Equal(t, baseCommit, gitOutput(t, wtPath, "rev-parse", "HEAD"), "the new branch should start at the base")
+	assert.Equal(t, baseCommit, gitOutput(t, repoPath, "merge-base", BranchForTask("auth/api"), "feature"))
+
+	wtPath, err = EnsureWorktree(repoPath, "auth/web", "", false)
+	assert.NoError(t, err)
+	assert.Equal(t, featureCommit, gitOutput(t, wtPath, "rev-parse", "HEAD"), "an empty base should branch from HEAD")
+
+	_, err = EnsureWorktree(repoPath, "auth/cli", "no-such-branch", false)
+	assert.ErrorContains(t, err, `base branch "no-such-branch" not found`)
+}
+
+func TestEnsureWorktreeAtPathFromBaseBranch(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoPath := filepath.Join(tmpDir, "repo")
+	initRepoWithCommit(t, repoPath)
+
+	base := gitOutput(t, repoPath, "branch", "--show-current")
+	baseCommit := gitOutput(t, repoPath, "rev-parse", "HEAD")
+	gitOutput(t, repoPath, "checkout", "-b", "feature")
+	gitOutput(t, repoPath, "commit", "--allow-empty", "-m", "feature work")
+
+	destPath := filepath.Join(tmpDir, "worker-home", "repo")
+	wtPath, err := EnsureWorktreeAtPath(repoPath, destPath, "sc-12345", base, false)
+	assert.NoError(t, err)
+	assert.Equal(t, baseCommit, gitOutput(t, wtPath, "rev-parse", "HEAD"), "the new branch should start at the base")
+
+	// A new branch in an existing destination starts at the base, not at
+	// the destination's current commit
+	gitOutput(t, destPath, "commit", "--allow-empty", "-m", "worker work")
+	wtPath, err = EnsureWorktreeAtPath(repoPath, destPath, "sc-54321", base, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "sc-54321", gitOutput(t, wtPath, "branch", "--show-current"))
+	assert.Equal(t, baseCommit, gitOutput(t, wtPath, "rev-parse", "HEAD"))
+
+	_, err = EnsureWorktreeAtPath(repoPath, filepath.Join(tmpDir, "other", "repo"), "sc-99999", "no-such-branch", false)
+	assert.ErrorContains(t, err, `base branch "no-such-branch" not found`)
+}
+
+func TestCommonGitDir(t *testing.T) {
+	t.Setenv(WorktreeRootEnv, t.TempDir())
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	initRepoWithCommit(t, repoPath)
+	repoPath, err := filepath.EvalSymlinks(repoPath)
+	assert.NoError(t, err)
+
+	wtPath, err := EnsureWorktree(repoPath, "auth/api", "", false)
+	assert.NoError(t, err)
+
+	gitDir, err := CommonGitDir(wtPath)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(repoPath, ".git"), gitDir)
+
+	_, err = CommonGitDir(t.TempDir())
+	assert.Error(t, err)
+}